@@ -22,6 +22,11 @@ type LogEntry struct {
 	QueueTimeMs int64     `json:"queue_time_ms"`
 	EntryTime   time.Time `json:"entry_time"`
 	ExitTime    time.Time `json:"exit_time"`
+	Qty         float64   `json:"qty"`
+	Notional    float64   `json:"notional"`
+	Risk        float64   `json:"risk"`
+	CappedBy    string    `json:"capped_by"`
+	SkipReason  string    `json:"skip_reason"`
 }
 
 type ExitStat struct {
@@ -45,6 +50,12 @@ type QueueStat struct {
 	TimeSum, Min, Max int64
 }
 
+// SizingStat は新規建ての数量の決定（POSITION_SIZED）の集計です
+type SizingStat struct {
+	Count, Skipped, Capped       int
+	QtySum, NotionalSum, RiskSum float64
+}
+
 func main() {
 	logFile := flag.String("file", "", "解析する単一のログファイルパス")
 	logDir := flag.String("dir", "", "解析するログファイルが含まれるディレクトリパス")
@@ -78,9 +89,10 @@ func main() {
 	queueStats := make(map[string]*QueueStat)
 	symbolExitStats := make(map[string]map[string]*ExitStat)
 	symbolHourlyStats := make(map[string]map[int]*HourlyStat)
+	sizingStats := make(map[string]*SizingStat)
 
 	for _, path := range filesToProcess {
-		processFile(path, symbolStats, queueStats, symbolExitStats, symbolHourlyStats)
+		processFile(path, symbolStats, queueStats, symbolExitStats, symbolHourlyStats, sizingStats)
 	}
 
 	syms := make([]string, 0, len(symbolStats))
@@ -135,6 +147,7 @@ func main() {
 			"BreakEven":         "(建値決済)",
 			"StopLoss_VWAP":     "(VWAP下抜け損切)",
 			"StopLoss_ORB_Low":  "(ORB安値損切)",
+			"StopLoss_ORB_High": "(ORB高値損切)",
 			"StopLoss_Ratio":    "(比率損切)",
 			"IFD:TakeProfit":    "(利確)",
 			"IFD:ProfitLock":    "(利益ロック)",
@@ -149,6 +162,14 @@ func main() {
 			"FLAT:ProfitLock":   "(利益ロック)",
 			"FLAT:StopLoss":     "(損切)",
 			"FLAT:BreakEven":    "(建値決済)",
+			"BEAR:TakeProfit":   "(利確)",
+			"BEAR:EarlyExit":    "(早期撤退)",
+			"BEAR:ProfitLock":   "(利益ロック)",
+			"BEAR:StopLoss":     "(損切)",
+			"BEAR:BreakEven":    "(建値決済)",
+			"BULL:RegimeSwitch": "(相場環境の切替)",
+			"BEAR:RegimeSwitch": "(相場環境の切替)",
+			"FLAT:RegimeSwitch": "(相場環境の切替)",
 		}
 
 		for _, r := range reasons {
@@ -167,6 +188,16 @@ func main() {
 				qs.TimeSum/int64(qs.Count), qs.Min, qs.Max)
 		}
 
+		// サイジング（見送った回は平均に含めない）
+		if ss, ok := sizingStats[sym]; ok && ss.Count > 0 {
+			fmt.Printf("  [サイジング] 決定: %d回 (見送り: %d回, 上限適用: %d回)", ss.Count, ss.Skipped, ss.Capped)
+			if sized := ss.Count - ss.Skipped; sized > 0 {
+				fmt.Printf(", 平均数量: %.0f株, 平均建玉金額: %.0f円, 平均想定損失: %.0f円",
+					ss.QtySum/float64(sized), ss.NotionalSum/float64(sized), ss.RiskSum/float64(sized))
+			}
+			fmt.Println()
+		}
+
 		// PnL分布
 		fmt.Print("  [損益分布] ")
 		pnlValues := make([]float64, 0, len(stat.PnlDist))
@@ -181,7 +212,7 @@ func main() {
 	}
 }
 
-func processFile(path string, symbolStats map[string]*SymbolStat, queueStats map[string]*QueueStat, symbolExitStats map[string]map[string]*ExitStat, symbolHourlyStats map[string]map[int]*HourlyStat) {
+func processFile(path string, symbolStats map[string]*SymbolStat, queueStats map[string]*QueueStat, symbolExitStats map[string]map[string]*ExitStat, symbolHourlyStats map[string]map[int]*HourlyStat, sizingStats map[string]*SizingStat) {
 	file, err := os.Open(path)
 	if err != nil {
 		fmt.Printf("警告: ファイル %s の読み込みに失敗: %v\n", path, err)
@@ -269,6 +300,24 @@ func processFile(path string, symbolStats map[string]*SymbolStat, queueStats map
 			if entry.QueueTimeMs > q.Max {
 				q.Max = entry.QueueTimeMs
 			}
+
+		case "POSITION_SIZED":
+			stratKey := entry.Symbol + " [" + entry.Sniper + "]"
+			if _, ok := sizingStats[stratKey]; !ok {
+				sizingStats[stratKey] = &SizingStat{}
+			}
+			ss := sizingStats[stratKey]
+			ss.Count++
+			if entry.SkipReason != "" {
+				ss.Skipped++
+				continue
+			}
+			if entry.CappedBy != "" {
+				ss.Capped++
+			}
+			ss.QtySum += entry.Qty
+			ss.NotionalSum += entry.Notional
+			ss.RiskSum += entry.Risk
 		}
 	}
 }
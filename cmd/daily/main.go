@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/r-umemoto/trading-bot/pkg/infra/daily"
+)
+
+func main() {
+	dir := flag.String("dir", daily.DefaultDir, "日足ストアのディレクトリ")
+	importPath := flag.String("import", "", "取り込む日足CSVのパス（Date,Open,High,Low,Close[,Volume][,Symbol]）")
+	symbol := flag.String("symbol", "", "-import のCSVに銘柄列が無い場合の銘柄コード")
+	appendPath := flag.String("append", "", "日足として追記するTick記録CSVのパス（例: ./data/20260610/all_20260610.csv）")
+	flag.Parse()
+
+	if *importPath == "" && *appendPath == "" {
+		fmt.Println("使用方法:")
+		fmt.Println("  日足CSVの取り込み: go run cmd/daily/main.go -import <path_to_csv> [-symbol 7203]")
+		fmt.Println("  当日記録の追記:   go run cmd/daily/main.go -append <path_to_all_YYYYMMDD.csv>")
+		return
+	}
+
+	store := daily.NewStore(*dir)
+
+	if *importPath != "" {
+		n, err := daily.ImportCSV(store, *importPath, *symbol)
+		if err != nil {
+			log.Fatalf("❌ 日足CSVの取り込みに失敗: %v", err)
+		}
+		fmt.Printf("✅ %d 本の日足を取り込みました (%s)\n", n, *dir)
+	}
+
+	if *appendPath != "" {
+		n, partial, err := daily.AppendSession(store, *appendPath)
+		if err != nil {
+			log.Fatalf("❌ Tick記録からの日足追記に失敗: %v", err)
+		}
+		fmt.Printf("✅ %d 銘柄の日足を追記しました (%s)\n", n, *dir)
+		if partial > 0 {
+			fmt.Printf("⚠️ %d 銘柄は大引けまで記録されていないため、終値未確定（Partial）として保存しました\n", partial)
+		}
+	}
+}
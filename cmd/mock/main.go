@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -35,6 +36,7 @@ func main() {
 	http.HandleFunc("/kabusapi/cancelorder", handleCancelOrder)
 	http.HandleFunc("/kabusapi/register", handleRegister)
 	http.HandleFunc("/kabusapi/unregister/all", handleUnregisterAll)
+	http.HandleFunc("/kabusapi/margin/marginpremium/", handleMarginPremium)
 
 	port := ":18082"
 	fmt.Printf("[Mock] サーバー起動: ポート%sで待機中...\n", port)
@@ -116,6 +118,7 @@ func streamCSV(conn *websocket.Conn) {
 		lastPricesMu.Lock()
 		lastPrices[record[1]] = price
 		lastPricesMu.Unlock()
+		triggerStops(record[1], price)
 
 		volume, _ := strconv.ParseFloat(record[3], 64)
 		vwap, _ := strconv.ParseFloat(record[4], 64)
@@ -217,6 +220,8 @@ func streamDummy(conn *websocket.Conn) {
 		lastPrices["7201"] = currentPrice
 		lastPrices["9434"] = currentPrice
 		lastPricesMu.Unlock()
+		triggerStops("7201", currentPrice)
+		triggerStops("9434", currentPrice)
 
 		// ランダムな出来高（今回は簡単のために価格変動時に100〜500株の約定があったことにする擬似ロジック）
 		// ここではモックなので固定の擬似乱数的な変動として、インデックスを利用しつつ多少ばらけさせます
@@ -309,24 +314,46 @@ var mockOrders = []map[string]interface{}{}
 func handlePositions(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("[Mock] 📦 建玉照会リクエストを受信しました")
 
+	mockMu.Lock()
+	defer mockMu.Unlock()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(mockPositions)
 }
 
+// sendOrderRequest はボットから送られてくる注文データです
+type sendOrderRequest struct {
+	Symbol            string  `json:"Symbol"`
+	Side              string  `json:"Side"` // "1": 売, "2": 買
+	Qty               float64 `json:"Qty"`
+	Price             float64 `json:"Price"`
+	FrontOrderType    int     `json:"FrontOrderType"` // 10: 成行, 20: 指値, 30: 逆指値
+	AccountType       int32   `json:"AccountType"`
+	CashMargin        int     `json:"CashMargin"` // 信用区分 (2: 信用新規, 3: 信用返済)
+	ReverseLimitOrder *struct {
+		TriggerPrice      float64 `json:"TriggerPrice"`
+		UnderOver         int     `json:"UnderOver"`         // 1: 以下, 2: 以上
+		AfterHitOrderType int     `json:"AfterHitOrderType"` // 1: 成行, 2: 指値
+		AfterHitPrice     float64 `json:"AfterHitPrice"`
+	} `json:"ReverseLimitOrder"`
+}
+
+// pendingStop は発動待ちの逆指値注文です
+type pendingStop struct {
+	orderID string
+	req     sendOrderRequest
+}
+
+var (
+	mockMu       sync.Mutex // mockPositions・mockOrders・pendingStops を保護する
+	pendingStops []pendingStop
+)
+
 // cmd/mock/main.go の handleSendOrder 関数を修正
 func handleSendOrder(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("\n[Mock] 🔫 注文(SendOrder)リクエストを受信しました！")
 
 	// 1. ボットから送られてきた注文データ（JSON）を読み解く
-	var req struct {
-		Symbol         string  `json:"Symbol"`
-		Side           string  `json:"Side"` // "1": 売, "2": 買
-		Qty            float64 `json:"Qty"`
-		Price          float64 `json:"Price"`
-		FrontOrderType int     `json:"FrontOrderType"`
-		AccountType    int32   `json:"AccountType"`
-		CashMargin     int     `json:"CashMargin"` // 信用区分 (2: 信用新規, 3: 信用返済)
-	}
+	var req sendOrderRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err == nil {
 		actionStr := "不明"
@@ -338,21 +365,119 @@ func handleSendOrder(w http.ResponseWriter, r *http.Request) {
 		}
 		fmt.Printf("[Mock] 注文内容: 【%s】 信用区分(CashMargin): %d, 銘柄: %s, 数量: %.0f株, 価格%.0f\n", actionStr, req.CashMargin, req.Symbol, req.Qty, req.Price)
 
-		// 成行注文 (Price == 0) の場合、最新価格を適用する
-		orderPrice := req.Price
-		if orderPrice == 0 {
-			lastPricesMu.RLock()
-			orderPrice = lastPrices[req.Symbol]
-			lastPricesMu.RUnlock()
+		uniqueID := fmt.Sprintf("mock_order_%d", time.Now().UnixNano())
+		response := map[string]interface{}{
+			"Result":  0,
+			"OrderId": uniqueID,
+		}
+
+		mockMu.Lock()
+		if req.FrontOrderType == 30 && req.ReverseLimitOrder != nil {
+			// 逆指値はトリガー価格に到達するまで約定させず、未約定の注文として登録しておく
+			mockOrders = append(mockOrders, map[string]interface{}{
+				"ID":          uniqueID,
+				"Symbol":      req.Symbol,
+				"State":       3,
+				"Side":        req.Side,
+				"CumQty":      0.0,
+				"OrderQty":    req.Qty,
+				"AccountType": req.AccountType,
+				"Details":     []map[string]interface{}{},
+			})
+			pendingStops = append(pendingStops, pendingStop{orderID: uniqueID, req: req})
+			fmt.Printf("[Mock] ⏳ 逆指値を受け付けました（トリガー: %.1f）。発動するまで約定しません\n", req.ReverseLimitOrder.TriggerPrice)
+		} else {
+			// 成行注文 (Price == 0) の場合、最新価格を適用する
+			orderPrice := req.Price
 			if orderPrice == 0 {
-				orderPrice = 1000.0 // デフォルトのフォールバック価格
+				orderPrice = lastPriceOf(req.Symbol)
 			}
+			mockOrders = append(mockOrders, map[string]interface{}{
+				"ID":          uniqueID,
+				"Symbol":      req.Symbol,
+				"State":       3,
+				"Side":        req.Side,
+				"CumQty":      req.Qty,
+				"OrderQty":    req.Qty,
+				"AccountType": req.AccountType,
+				"Details":     []map[string]interface{}{},
+			})
+			fillOrder(uniqueID, req, orderPrice)
 		}
+		mockMu.Unlock()
 
-		// 2. 建玉の管理
-		// 信用区分 CashMargin: 2=信用新規(Entry), 3=信用返済(Exit)
-		if req.CashMargin == 2 {
-			// 新規建て（ロング/ショート）
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	} else {
+		fmt.Printf("[Mock] ⚠️ リクエストの解析に失敗しました: %v\n", err)
+	}
+}
+
+// lastPriceOf は銘柄の最新価格を返します（配信前はデフォルトのフォールバック価格）
+func lastPriceOf(symbol string) float64 {
+	lastPricesMu.RLock()
+	price := lastPrices[symbol]
+	lastPricesMu.RUnlock()
+	if price == 0 {
+		price = 1000.0 // デフォルトのフォールバック価格
+	}
+	return price
+}
+
+// fillOrder は注文を全数約定させ、建玉と注文履歴に反映します（mockMu を保持して呼び出すこと）
+func fillOrder(orderID string, req sendOrderRequest, orderPrice float64) {
+	// 建玉の管理
+	// 信用区分 CashMargin: 2=信用新規(Entry), 3=信用返済(Exit)
+	if req.CashMargin == 2 {
+		// 新規建て（ロング/ショート）
+		mockPositions = append(mockPositions, map[string]interface{}{
+			"ExecutionID":     fmt.Sprintf("exec_%d", time.Now().UnixNano()),
+			"Symbol":          req.Symbol,
+			"SymbolName":      "シミュレーション銘柄",
+			"LeavesQty":       req.Qty,
+			"HoldQty":         req.Qty,
+			"Price":           orderPrice,
+			"Side":            req.Side, // "2"なら買い建玉(ロング), "1"なら売り建玉(ショート)
+			"AccountType":     req.AccountType,
+			"MarginTradeType": 3,
+		})
+		fmt.Printf("[Mock] 📈 %s の新規建玉（Side: %s）が %.0f株 追加されました (価格: %.1f)\n", req.Symbol, req.Side, req.Qty, orderPrice)
+	} else if req.CashMargin == 3 {
+		// 返済（決済）
+		// 買い戻し(Side: "2")なら対象はショート建玉(Side: "1")
+		// 転売返済(Side: "1")なら対象はロング建玉(Side: "2")
+		targetSide := "2"
+		if req.Side == "2" {
+			targetSide = "1"
+		}
+
+		var newPositions []map[string]interface{}
+		qtyToReduce := req.Qty
+
+		for _, pos := range mockPositions {
+			posSymbol := pos["Symbol"].(string)
+			posSide := pos["Side"].(string)
+
+			if posSymbol == req.Symbol && posSide == targetSide && qtyToReduce > 0 {
+				currentQty := pos["LeavesQty"].(float64)
+				if currentQty > qtyToReduce {
+					pos["LeavesQty"] = currentQty - qtyToReduce
+					pos["HoldQty"] = currentQty - qtyToReduce
+					qtyToReduce = 0
+					newPositions = append(newPositions, pos)
+					fmt.Printf("[Mock] 📉 %s の建玉（Side: %s）が %.0f株 に減りました（一部決済）。\n", req.Symbol, targetSide, pos["LeavesQty"])
+				} else {
+					qtyToReduce -= currentQty
+					fmt.Printf("[Mock] 🗑️ %s の建玉（Side: %s）がゼロになったため削除しました（完全決済）。\n", req.Symbol, targetSide)
+				}
+			} else {
+				newPositions = append(newPositions, pos)
+			}
+		}
+		mockPositions = newPositions
+	} else {
+		// 現物等のフォールバック
+		if req.Side == "2" {
 			mockPositions = append(mockPositions, map[string]interface{}{
 				"ExecutionID":     fmt.Sprintf("exec_%d", time.Now().UnixNano()),
 				"Symbol":          req.Symbol,
@@ -360,92 +485,50 @@ func handleSendOrder(w http.ResponseWriter, r *http.Request) {
 				"LeavesQty":       req.Qty,
 				"HoldQty":         req.Qty,
 				"Price":           orderPrice,
-				"Side":            req.Side, // "2"なら買い建玉(ロング), "1"なら売り建玉(ショート)
+				"Side":            "2",
 				"AccountType":     req.AccountType,
 				"MarginTradeType": 3,
 			})
-			fmt.Printf("[Mock] 📈 %s の新規建玉（Side: %s）が %.0f株 追加されました (価格: %.1f)\n", req.Symbol, req.Side, req.Qty, orderPrice)
-		} else if req.CashMargin == 3 {
-			// 返済（決済）
-			// 買い戻し(Side: "2")なら対象はショート建玉(Side: "1")
-			// 転売返済(Side: "1")なら対象はロング建玉(Side: "2")
-			targetSide := "2"
-			if req.Side == "2" {
-				targetSide = "1"
-			}
-
-			var newPositions []map[string]interface{}
-			qtyToReduce := req.Qty
-
-			for _, pos := range mockPositions {
-				posSymbol := pos["Symbol"].(string)
-				posSide := pos["Side"].(string)
-
-				if posSymbol == req.Symbol && posSide == targetSide && qtyToReduce > 0 {
-					currentQty := pos["LeavesQty"].(float64)
-					if currentQty > qtyToReduce {
-						pos["LeavesQty"] = currentQty - qtyToReduce
-						pos["HoldQty"] = currentQty - qtyToReduce
-						qtyToReduce = 0
-						newPositions = append(newPositions, pos)
-						fmt.Printf("[Mock] 📉 %s の建玉（Side: %s）が %.0f株 に減りました（一部決済）。\n", req.Symbol, targetSide, pos["LeavesQty"])
-					} else {
-						qtyToReduce -= currentQty
-						fmt.Printf("[Mock] 🗑️ %s の建玉（Side: %s）がゼロになったため削除しました（完全決済）。\n", req.Symbol, targetSide)
-					}
-				} else {
-					newPositions = append(newPositions, pos)
-				}
-			}
-			mockPositions = newPositions
-		} else {
-			// 現物等のフォールバック
-			if req.Side == "2" {
-				mockPositions = append(mockPositions, map[string]interface{}{
-					"ExecutionID":     fmt.Sprintf("exec_%d", time.Now().UnixNano()),
-					"Symbol":          req.Symbol,
-					"SymbolName":      "シミュレーション銘柄",
-					"LeavesQty":       req.Qty,
-					"HoldQty":         req.Qty,
-					"Price":           orderPrice,
-					"Side":            "2",
-					"AccountType":     req.AccountType,
-					"MarginTradeType": 3,
-				})
-			}
 		}
+	}
 
-		// 3. 注文履歴の追加
-		uniqueID := fmt.Sprintf("mock_order_%d", time.Now().UnixNano())
-		response := map[string]interface{}{
-			"Result":  0,
-			"OrderId": uniqueID,
+	// 注文履歴に約定明細を追加
+	for _, o := range mockOrders {
+		if o["ID"] != orderID {
+			continue
 		}
+		o["CumQty"] = req.Qty
+		o["Details"] = []map[string]interface{}{{
+			"Price":        orderPrice,
+			"Qty":          req.Qty,
+			"ExecutionID":  fmt.Sprintf("mock_order_ex_%d", time.Now().UnixNano()),
+			"ExecutionDay": time.Now().Format(time.RFC3339),
+			"RecType":      8,
+		}}
+	}
+}
 
-		uniqueExID := fmt.Sprintf("mock_order_ex_%d", time.Now().UnixNano())
-
-		mockOrders = append(mockOrders, map[string]interface{}{
-			"ID":          uniqueID,
-			"Symbol":      req.Symbol,
-			"State":       3,
-			"Side":        req.Side,
-			"CumQty":      req.Qty,
-			"OrderQty":    req.Qty,
-			"AccountType": req.AccountType,
-			"Details": []map[string]interface{}{{
-				"Price":        orderPrice,
-				"Qty":          req.Qty,
-				"ExecutionID":  uniqueExID,
-				"ExecutionDay": time.Now().Format(time.RFC3339),
-				"RecType":      8,
-			}},
-		})
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	} else {
-		fmt.Printf("[Mock] ⚠️ リクエストの解析に失敗しました: %v\n", err)
+// triggerStops は最新価格がトリガー価格に到達した逆指値を発動し、成行（または指値）で約定させます
+func triggerStops(symbol string, price float64) {
+	mockMu.Lock()
+	defer mockMu.Unlock()
+
+	remaining := pendingStops[:0]
+	for _, stop := range pendingStops {
+		rl := stop.req.ReverseLimitOrder
+		hit := (rl.UnderOver == 1 && price <= rl.TriggerPrice) || (rl.UnderOver == 2 && price >= rl.TriggerPrice)
+		if stop.req.Symbol != symbol || !hit {
+			remaining = append(remaining, stop)
+			continue
+		}
+		fillPrice := price
+		if rl.AfterHitOrderType == 2 {
+			fillPrice = rl.AfterHitPrice
+		}
+		fmt.Printf("[Mock] ⚡ 逆指値が発動しました: %s (トリガー: %.1f, 現在値: %.1f)\n", stop.orderID, rl.TriggerPrice, price)
+		fillOrder(stop.orderID, stop.req, fillPrice)
 	}
+	pendingStops = remaining
 }
 
 // mock_server/main.go に追記
@@ -454,6 +537,8 @@ func handleSendOrder(w http.ResponseWriter, r *http.Request) {
 func handleOrders(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("[Mock] 📋 注文照会(Orders)リクエストを受信しました")
 
+	mockMu.Lock()
+	defer mockMu.Unlock()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(mockOrders)
 }
@@ -475,6 +560,7 @@ func handleCancelOrder(w http.ResponseWriter, r *http.Request) {
 			"Result":  0,
 			"OrderId": req.OrderID,
 		}
+		cancelPendingStop(req.OrderID)
 	} else {
 		fmt.Println("[Mock] ⚠️ 注文取消(Cancel)失敗")
 	}
@@ -483,6 +569,26 @@ func handleCancelOrder(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// cancelPendingStop は発動前の逆指値を取り消し、注文を終了状態にします
+func cancelPendingStop(orderID string) {
+	mockMu.Lock()
+	defer mockMu.Unlock()
+
+	for i, stop := range pendingStops {
+		if stop.orderID != orderID {
+			continue
+		}
+		pendingStops = append(pendingStops[:i], pendingStops[i+1:]...)
+		for _, o := range mockOrders {
+			if o["ID"] == orderID {
+				o["State"] = 5
+			}
+		}
+		fmt.Printf("[Mock] 🛑 発動前の逆指値を取り消しました: %s\n", orderID)
+		return
+	}
+}
+
 func handleRegister(w http.ResponseWriter, r *http.Request) {
 
 	// API仕様通りのJSONを返す
@@ -495,6 +601,22 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleMarginPremium はプレミアム料情報を返します。どの銘柄も一般信用（長期・デイトレ）の対象（プレミアム料なし）として扱います
+func handleMarginPremium(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.TrimPrefix(r.URL.Path, "/kabusapi/margin/marginpremium/")
+	fmt.Printf("[Mock] 💴 プレミアム料照会リクエストを受信しました: %s\n", symbol)
+
+	noPremium := map[string]interface{}{"MarginPremiumType": 0, "MarginPremium": 0.0}
+	response := map[string]interface{}{
+		"Symbol":        symbol,
+		"GeneralMargin": noPremium,
+		"DayTrade":      noPremium,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func handleUnregisterAll(w http.ResponseWriter, r *http.Request) {
 
 	// API仕様通りのJSONを返す
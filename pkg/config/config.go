@@ -2,15 +2,42 @@
 package config
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
 	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/api"
 )
 
 // AppConfig はシステム全体の設定です
 type AppConfig struct {
-	BrokerType string     `envconfig:"BROKER_TYPE" default:"kabu"`
-	Kabu       api.Config // ネストされた構造体も、タグに従って自動で読み込まれます
+	BrokerType     string     `envconfig:"BROKER_TYPE" default:"kabu"`
+	Kabu           api.Config // ネストされた構造体も、タグに従って自動で読み込まれます
+	TickValidation TickValidationConfig
+	CalendarPath   string `envconfig:"MARKET_CALENDAR_PATH" default:"configs/jpx_calendar.csv"` // JPX 休場日カレンダー
+
+	// 作戦設定ファイルと、稼働中に変更を反映するための確認間隔（0 の場合は再読み込みしない）
+	OperationsPath           string        `envconfig:"OPERATIONS_PATH" default:"configs/operations.json"`
+	OperationsReloadInterval time.Duration `envconfig:"OPERATIONS_RELOAD_INTERVAL" default:"5s"`
+}
+
+// TickValidationConfig は Tick 品質検証の問題ごとの扱い（ACCEPT / REPAIR / QUARANTINE）です
+type TickValidationConfig struct {
+	OutOfOrder       tick.ValidationAction `envconfig:"TICK_OUT_OF_ORDER" default:"QUARANTINE"`
+	VolumeRegression tick.ValidationAction `envconfig:"TICK_VOLUME_REGRESSION" default:"REPAIR"`
+	ZeroPrice        tick.ValidationAction `envconfig:"TICK_ZERO_PRICE" default:"QUARANTINE"`
+	Duplicate        tick.ValidationAction `envconfig:"TICK_DUPLICATE" default:"QUARANTINE"`
+}
+
+// Rules はドメインの検証ルールに変換します
+func (c TickValidationConfig) Rules() tick.ValidationRules {
+	return tick.ValidationRules{
+		OutOfOrder:       c.OutOfOrder,
+		VolumeRegression: c.VolumeRegression,
+		ZeroPrice:        c.ZeroPrice,
+		Duplicate:        c.Duplicate,
+	}
 }
 
 // Load は環境変数から設定を自動でマッピングして返します
@@ -24,6 +51,24 @@ func Load() (*AppConfig, error) {
 	if err := envconfig.Process("", &cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.TickValidation.Rules().Validate(); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
+
+// LoadTickValidation は環境変数から Tick 品質検証の設定だけを読み込みます。
+// 証券会社の認証情報を必要としないバックテストから利用します。
+func LoadTickValidation() (TickValidationConfig, error) {
+	_ = godotenv.Load()
+
+	var cfg TickValidationConfig
+	if err := envconfig.Process("", &cfg); err != nil {
+		return TickValidationConfig{}, err
+	}
+	if err := cfg.Rules().Validate(); err != nil {
+		return TickValidationConfig{}, err
+	}
+	return cfg, nil
+}
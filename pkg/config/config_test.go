@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/r-umemoto/trading-bot/pkg/config"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
 )
 
 func TestLoad(t *testing.T) {
@@ -43,4 +44,23 @@ func TestLoad(t *testing.T) {
 			t.Errorf("期待値: 'mock', 実際の値: '%s'", cfg.BrokerType)
 		}
 	})
+	t.Run("Tick検証ルールの既定値と不正な値の検出", func(t *testing.T) {
+		t.Setenv("KABU_PASSWORD", "dummy_pass")
+
+		cfg, err := config.Load()
+		if err != nil {
+			t.Fatalf("Load() で想定外のエラーが発生しました: %v", err)
+		}
+		if cfg.TickValidation.Rules() != tick.DefaultValidationRules() {
+			t.Errorf("期待値: 既定ルール, 実際の値: %+v", cfg.TickValidation)
+		}
+
+		t.Setenv("TICK_ZERO_PRICE", "DROP")
+		if _, err := config.Load(); err == nil {
+			t.Error("不正な検証ルールでエラーが返されませんでした")
+		}
+		if _, err := config.LoadTickValidation(); err == nil {
+			t.Error("LoadTickValidation で不正な検証ルールのエラーが返されませんでした")
+		}
+	})
 }
@@ -2,6 +2,7 @@ package order
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -63,6 +64,7 @@ type Order struct {
 	Action             Action
 	Type               OrderType // 🌟 注文種別 (指値・成行)
 	OrderPrice         float64   // 発注時の指値（成行の場合は0など）
+	TriggerPrice       float64   // 逆指値のトリガー価格（逆指値以外は0）
 	OrderQty           float64   // 発注した総数量
 	CashMargin         CashMarginType
 
@@ -73,6 +75,8 @@ type Order struct {
 	CumQty float64     // 🌟 APIが報告してきた累計約定数量
 
 	IfDone         *Order          // 🌟 この注文が約定した後に有効になる注文
+	OCO            *Order          // 🌟 この注文と同時に発注する OCO の相手方（どちらかが約定したら他方を取り消す）
+	OCOGroupID     string          // 🌟 OCO の組の識別子（ゲートウェイが発注時に採番し、両方の注文に付与する）
 
 	CancelSentAt time.Time // 🌟 キャンセル送信時刻（ゾンビ防止用）
 
@@ -105,6 +109,13 @@ func WithType(t OrderType) OrderOption {
 	}
 }
 
+// WithTriggerPrice は逆指値のトリガー価格を指定します
+func WithTriggerPrice(price float64) OrderOption {
+	return func(o *Order) {
+		o.TriggerPrice = price
+	}
+}
+
 func WithCashMargin(cm CashMarginType) OrderOption {
 	return func(o *Order) {
 		o.CashMargin = cm
@@ -117,6 +128,13 @@ func WithRequest(req *OrderRequest) OrderOption {
 	}
 }
 
+// WithOCO は OCO の相手方となる注文を指定します
+func WithOCO(sibling *Order) OrderOption {
+	return func(o *Order) {
+		o.OCO = sibling
+	}
+}
+
 func WithReason(reason string) OrderOption {
 	return func(o *Order) {
 		o.Reason = reason
@@ -144,6 +162,18 @@ func NewOrder(id string, symbol string, action Action, price float64, qty float6
 
 
 
+// IsTriggeredBy は逆指値が価格 price で発動するかを判定します。
+// 買いはトリガー価格以上、売りはトリガー価格以下で発動します（逆指値以外は常に false）。
+func (o *Order) IsTriggeredBy(price float64) bool {
+	if !o.Type.IsStop() || o.TriggerPrice <= 0 || price <= 0 {
+		return false
+	}
+	if o.Action == ACTION_BUY {
+		return price >= o.TriggerPrice
+	}
+	return price <= o.TriggerPrice
+}
+
 // FilledQty は現在までに約定した合計数量を返します
 func (o *Order) FilledQty() float64 {
 	var sum float64
@@ -515,3 +545,30 @@ func (aos ActiveOrders) LockedHoldIDs() map[string]bool {
 	return locked
 }
 
+// OCOLegs returns the orders that belong to the given OCO group.
+func (aos ActiveOrders) OCOLegs(groupID string) []*Order {
+	if groupID == "" {
+		return nil
+	}
+	var legs []*Order
+	for _, o := range aos {
+		if o != nil && o.OCOGroupID == groupID {
+			legs = append(legs, o)
+		}
+	}
+	return legs
+}
+
+// OCORemainingQty returns the quantity an OCO group can still execute.
+// Both legs close the same quantity and only one of them is meant to fill, so the remaining quantity is
+// the largest leg size minus the fills of all legs (a leg re-sent after a partial fill of its sibling is smaller).
+func OCORemainingQty(legs []*Order) float64 {
+	var size, filled float64
+	for _, o := range legs {
+		if o.OrderQty > size {
+			size = o.OrderQty
+		}
+		filled += o.FilledQty()
+	}
+	return math.Max(size-filled, 0)
+}
@@ -694,3 +694,28 @@ func TestOrder_Transitions_ExtraPanics(t *testing.T) {
 	})
 }
 
+func TestOrder_IsTriggeredBy(t *testing.T) {
+	sellStop := order.NewOrder("1", "7203", order.ACTION_SELL, 0, 100, order.WithType(order.ORDER_TYPE_STOP), order.WithTriggerPrice(990))
+	buyStop := order.NewOrder("2", "7203", order.ACTION_BUY, 1015, 100, order.WithType(order.ORDER_TYPE_STOP_LIMIT), order.WithTriggerPrice(1010))
+	limit := order.NewOrder("3", "7203", order.ACTION_SELL, 990, 100)
+
+	tests := []struct {
+		name  string
+		ord   *order.Order
+		price float64
+		want  bool
+	}{
+		{"sell stop above trigger", sellStop, 991, false},
+		{"sell stop at trigger", sellStop, 990, true},
+		{"sell stop below trigger", sellStop, 980, true},
+		{"buy stop below trigger", buyStop, 1009, false},
+		{"buy stop at trigger", buyStop, 1010, true},
+		{"no price", sellStop, 0, false},
+		{"limit order is never triggered", limit, 980, false},
+	}
+	for _, tt := range tests {
+		if got := tt.ord.IsTriggeredBy(tt.price); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -41,10 +41,17 @@ const (
 type OrderType uint32
 
 const (
-	ORDER_TYPE_MARKET OrderType = 10
-	ORDER_TYPE_LIMIT  OrderType = 20
+	ORDER_TYPE_MARKET     OrderType = 10
+	ORDER_TYPE_LIMIT      OrderType = 20
+	ORDER_TYPE_STOP       OrderType = 30 // 逆指値（トリガー価格に到達したら成行）
+	ORDER_TYPE_STOP_LIMIT OrderType = 40 // 逆指値（トリガー価格に到達したら指値）
 )
 
+// IsStop は逆指値（トリガー価格に到達するまで発動しない注文）かを返します
+func (t OrderType) IsStop() bool {
+	return t == ORDER_TYPE_STOP || t == ORDER_TYPE_STOP_LIMIT
+}
+
 type AccountType uint32
 
 const (
@@ -44,6 +44,9 @@ func (m *mockDataPool) GetState(symbol string) tick.MarketState {
 func (m *mockDataPool) GetOrCreateIndicator(symbol, id string, factory func() tick.Indicator) tick.Indicator {
 	return nil
 }
+func (m *mockDataPool) GetOrCreateComposite(id string, factory func() tick.CompositeIndicator) tick.CompositeIndicator {
+	return nil
+}
 
 func TestGeneratePerformanceReport(t *testing.T) {
 	// Setup mock data
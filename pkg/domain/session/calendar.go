@@ -0,0 +1,167 @@
+// Package session は東京証券取引所（JPX）の営業日カレンダーと立会時間（セッション）を表すドメインモデルです。
+// 休場日・半日立会の判定、前場・後場の板寄せ／ザラバ／クロージング・オークションのフェーズ判定を提供し、
+// エンジンのキルスイッチ、作戦のエントリー時間帯、時間足のバー境界から共通に参照されます。
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultCalendarPath は JPX 休場日カレンダーファイルの既定の配置場所です
+const DefaultCalendarPath = "configs/jpx_calendar.csv"
+
+// DayKind は営業日の種類です
+type DayKind int
+
+const (
+	DAY_KIND_TRADING  DayKind = iota // 通常の営業日（前場・後場）
+	DAY_KIND_HOLIDAY                 // 休場日（土日・祝日・年末年始）
+	DAY_KIND_HALF_DAY                // 半日立会（前場のみ）
+)
+
+func (k DayKind) String() string {
+	switch k {
+	case DAY_KIND_HOLIDAY:
+		return "HOLIDAY"
+	case DAY_KIND_HALF_DAY:
+		return "HALF_DAY"
+	default:
+		return "TRADING"
+	}
+}
+
+// dateLayout はカレンダーファイルの日付の書式です
+const dateLayout = "2006-01-02"
+
+// Calendar は JPX の営業日カレンダーです。土日は常に休場とし、それ以外の休場日・半日立会を日付ごとに保持します。
+type Calendar struct {
+	days map[int]calendarDay // キーは dateKey（YYYYMMDD）
+}
+
+type calendarDay struct {
+	kind DayKind
+	name string
+}
+
+// NewCalendar は土日のみを休場とする空のカレンダーを作成します
+func NewCalendar() *Calendar {
+	return &Calendar{days: make(map[int]calendarDay)}
+}
+
+// LoadCalendar はカレンダーファイルを読み込みます。
+// 1行1日で「日付,種類,名称」を記述します（種類は holiday / half_day、名称は省略可）。
+// 空行と # で始まる行は無視します。
+//
+//	# date,kind,name
+//	2026-01-01,holiday,元日
+func LoadCalendar(path string) (*Calendar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cal := NewCalendar()
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, ",")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected date,kind[,name]: %q", path, line, text)
+		}
+		date, err := time.ParseInLocation(dateLayout, strings.TrimSpace(fields[0]), Location())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid date: %w", path, line, err)
+		}
+		name := ""
+		if len(fields) >= 3 {
+			name = strings.TrimSpace(fields[2])
+		}
+		switch strings.ToLower(strings.TrimSpace(fields[1])) {
+		case "holiday":
+			cal.AddHoliday(date, name)
+		case "half_day":
+			cal.AddHalfDay(date, name)
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown day kind: %q", path, line, fields[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// AddHoliday は休場日を追加します
+func (c *Calendar) AddHoliday(date time.Time, name string) {
+	c.days[dateKey(date)] = calendarDay{kind: DAY_KIND_HOLIDAY, name: name}
+}
+
+// AddHalfDay は半日立会（前場のみ）の日を追加します
+func (c *Calendar) AddHalfDay(date time.Time, name string) {
+	c.days[dateKey(date)] = calendarDay{kind: DAY_KIND_HALF_DAY, name: name}
+}
+
+// Kind は日付（日本時間）の営業日の種類を返します
+func (c *Calendar) Kind(date time.Time) DayKind {
+	if d, ok := c.days[dateKey(date)]; ok {
+		return d.kind
+	}
+	switch date.In(Location()).Weekday() {
+	case time.Saturday, time.Sunday:
+		return DAY_KIND_HOLIDAY
+	}
+	return DAY_KIND_TRADING
+}
+
+// Name は休場日・半日立会の名称を返します（登録が無い場合は空文字）
+func (c *Calendar) Name(date time.Time) string {
+	return c.days[dateKey(date)].name
+}
+
+// IsTradingDay は日付が営業日（半日立会を含む）かを返します
+func (c *Calendar) IsTradingDay(date time.Time) bool {
+	return c.Kind(date) != DAY_KIND_HOLIDAY
+}
+
+// NextTradingDay は date の翌日以降で最初の営業日（日本時間の0時）を返します
+func (c *Calendar) NextTradingDay(date time.Time) time.Time {
+	d := startOfDay(date)
+	for {
+		d = d.AddDate(0, 0, 1)
+		if c.IsTradingDay(d) {
+			return d
+		}
+	}
+}
+
+// PreviousTradingDay は date の前日以前で最後の営業日（日本時間の0時）を返します
+func (c *Calendar) PreviousTradingDay(date time.Time) time.Time {
+	d := startOfDay(date)
+	for {
+		d = d.AddDate(0, 0, -1)
+		if c.IsTradingDay(d) {
+			return d
+		}
+	}
+}
+
+// dateKey は日付（日本時間）を YYYYMMDD の整数にします。
+// 時間足のバー境界の判定で Tick ごとに呼ばれるため、文字列への変換によるアロケーションを避けています
+func dateKey(t time.Time) int {
+	y, m, d := t.In(Location()).Date()
+	return y*10000 + int(m)*100 + d
+}
+
+// startOfDay は t の日付（日本時間）の0時を返します
+func startOfDay(t time.Time) time.Time {
+	local := t.In(Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, Location())
+}
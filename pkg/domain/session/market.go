@@ -0,0 +1,264 @@
+package session
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Phase は立会中の時間帯（フェーズ）です
+type Phase int
+
+const (
+	PHASE_CLOSED          Phase = iota // 立会時間外・休場日
+	PHASE_PRE_OPEN                     // 寄付前の板寄せ（注文受付のみ）
+	PHASE_CONTINUOUS                   // ザラバ（連続約定）
+	PHASE_CLOSING_AUCTION              // 大引け前のクロージング・オークション
+	PHASE_LUNCH_BREAK                  // 昼休み（前場引け〜後場の注文受付開始）
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PHASE_PRE_OPEN:
+		return "PRE_OPEN"
+	case PHASE_CONTINUOUS:
+		return "CONTINUOUS"
+	case PHASE_CLOSING_AUCTION:
+		return "CLOSING_AUCTION"
+	case PHASE_LUNCH_BREAK:
+		return "LUNCH_BREAK"
+	default:
+		return "CLOSED"
+	}
+}
+
+// Session は前場・後場の区分です
+type Session int
+
+const (
+	SESSION_NONE      Session = iota // 立会時間外
+	SESSION_MORNING                  // 前場
+	SESSION_AFTERNOON                // 後場
+)
+
+func (s Session) String() string {
+	switch s {
+	case SESSION_MORNING:
+		return "MORNING"
+	case SESSION_AFTERNOON:
+		return "AFTERNOON"
+	default:
+		return "NONE"
+	}
+}
+
+// Schedule は1日の立会スケジュールです。各時刻は日本時間の0時からの経過時間で表します。
+type Schedule struct {
+	MorningPreOpen time.Duration // 前場の注文受付開始（板寄せ）
+	MorningOpen    time.Duration // 前場の寄付
+	MorningClose   time.Duration // 前場の引け
+
+	AfternoonPreOpen        time.Duration // 後場の注文受付開始（板寄せ）
+	AfternoonOpen           time.Duration // 後場の寄付
+	AfternoonClosingAuction time.Duration // クロージング・オークションの開始（ザラバの終了）
+	AfternoonClose          time.Duration // 大引け
+}
+
+// DefaultSchedule は東証の現行の立会時間（前場 9:00〜11:30、後場 12:30〜15:30、15:25〜クロージング・オークション）です
+func DefaultSchedule() Schedule {
+	return Schedule{
+		MorningPreOpen: hm(8, 0),
+		MorningOpen:    hm(9, 0),
+		MorningClose:   hm(11, 30),
+
+		AfternoonPreOpen:        hm(12, 5),
+		AfternoonOpen:           hm(12, 30),
+		AfternoonClosingAuction: hm(15, 25),
+		AfternoonClose:          hm(15, 30),
+	}
+}
+
+func hm(hour, minute int) time.Duration {
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+}
+
+// Market はカレンダーと立会スケジュールを組み合わせ、任意の時刻のフェーズやセッションを判定します
+type Market struct {
+	calendar *Calendar
+	schedule Schedule
+}
+
+// NewMarket はカレンダーとスケジュールから Market を作成します。calendar が nil の場合は土日のみを休場とします。
+func NewMarket(calendar *Calendar, schedule Schedule) *Market {
+	if calendar == nil {
+		calendar = NewCalendar()
+	}
+	return &Market{calendar: calendar, schedule: schedule}
+}
+
+// Calendar は営業日カレンダーを返します
+func (m *Market) Calendar() *Calendar {
+	return m.calendar
+}
+
+// Schedule は立会スケジュールを返します
+func (m *Market) Schedule() Schedule {
+	return m.schedule
+}
+
+// at は t の日付（日本時間）における指定時刻を返します
+func (m *Market) at(t time.Time, offset time.Duration) time.Time {
+	return startOfDay(t).Add(offset)
+}
+
+// Phase は時刻 t のフェーズを返します
+func (m *Market) Phase(t time.Time) Phase {
+	kind := m.calendar.Kind(t)
+	if kind == DAY_KIND_HOLIDAY {
+		return PHASE_CLOSED
+	}
+	s := m.schedule
+	tod := t.Sub(startOfDay(t))
+	switch {
+	case tod < s.MorningPreOpen:
+		return PHASE_CLOSED
+	case tod < s.MorningOpen:
+		return PHASE_PRE_OPEN
+	case tod < s.MorningClose:
+		return PHASE_CONTINUOUS
+	case kind == DAY_KIND_HALF_DAY:
+		return PHASE_CLOSED
+	case tod < s.AfternoonPreOpen:
+		return PHASE_LUNCH_BREAK
+	case tod < s.AfternoonOpen:
+		return PHASE_PRE_OPEN
+	case tod < s.AfternoonClosingAuction:
+		return PHASE_CONTINUOUS
+	case tod < s.AfternoonClose:
+		return PHASE_CLOSING_AUCTION
+	default:
+		return PHASE_CLOSED
+	}
+}
+
+// Session は時刻 t が属するセッションを返します（板寄せ・クロージング・オークションを含む）
+func (m *Market) Session(t time.Time) Session {
+	switch m.Phase(t) {
+	case PHASE_CLOSED, PHASE_LUNCH_BREAK:
+		return SESSION_NONE
+	}
+	if t.Sub(startOfDay(t)) < m.schedule.MorningClose {
+		return SESSION_MORNING
+	}
+	return SESSION_AFTERNOON
+}
+
+// ContinuousWindow は t がザラバ中であれば、そのセッションと寄付からの経過時間、ザラバ終了までの残り時間を返します。
+// 後場の残り時間は大引けではなくクロージング・オークションの開始までです。
+func (m *Market) ContinuousWindow(t time.Time) (sess Session, elapsed, remaining time.Duration, ok bool) {
+	if m.Phase(t) != PHASE_CONTINUOUS {
+		return SESSION_NONE, 0, 0, false
+	}
+	s := m.schedule
+	tod := t.Sub(startOfDay(t))
+	if tod < s.MorningClose {
+		return SESSION_MORNING, tod - s.MorningOpen, s.MorningClose - tod, true
+	}
+	return SESSION_AFTERNOON, tod - s.AfternoonOpen, s.AfternoonClosingAuction - tod, true
+}
+
+// Close は day の取引終了時刻（通常は大引け、半日立会は前場の引け）を返します
+func (m *Market) Close(day time.Time) time.Time {
+	if m.calendar.Kind(day) == DAY_KIND_HALF_DAY {
+		return m.at(day, m.schedule.MorningClose)
+	}
+	return m.at(day, m.schedule.AfternoonClose)
+}
+
+// IsTradingDay は day が営業日かを返します
+func (m *Market) IsTradingDay(day time.Time) bool {
+	return m.calendar.IsTradingDay(day)
+}
+
+// BarStart は時刻 t を含む frame 幅のバーの開始時刻を返します。
+// 立会中はセッションの寄付を起点に区切るため、後場（12:30〜）の時間足も寄付から揃い、
+// 引けちょうどの Tick（大引けの約定など）は最後のバーに含めます。立会時間外は t.Truncate(frame) と同じです。
+func (m *Market) BarStart(t time.Time, frame time.Duration) time.Time {
+	if frame <= 0 {
+		return t
+	}
+	var openAt, closeAt time.Duration
+	switch m.sessionBounds(t) {
+	case SESSION_MORNING:
+		openAt, closeAt = m.schedule.MorningOpen, m.schedule.MorningClose
+	case SESSION_AFTERNOON:
+		openAt, closeAt = m.schedule.AfternoonOpen, m.schedule.AfternoonClose
+	default:
+		return t.Truncate(frame)
+	}
+	day := startOfDay(t)
+	tod := t.Sub(day)
+	if tod == closeAt && tod > openAt {
+		tod--
+	}
+	return day.Add(openAt + (tod-openAt)/frame*frame)
+}
+
+// sessionBounds は寄付〜引け（引けちょうどを含む）の範囲にある t のセッションを返します
+func (m *Market) sessionBounds(t time.Time) Session {
+	kind := m.calendar.Kind(t)
+	if kind == DAY_KIND_HOLIDAY {
+		return SESSION_NONE
+	}
+	s := m.schedule
+	tod := t.Sub(startOfDay(t))
+	switch {
+	case tod >= s.MorningOpen && tod <= s.MorningClose:
+		return SESSION_MORNING
+	case kind != DAY_KIND_HALF_DAY && tod >= s.AfternoonOpen && tod <= s.AfternoonClose:
+		return SESSION_AFTERNOON
+	}
+	return SESSION_NONE
+}
+
+var defaultMarket atomic.Pointer[Market]
+
+func init() {
+	defaultMarket.Store(NewMarket(nil, DefaultSchedule()))
+}
+
+// Default はプロセス全体で共有する Market を返します。
+// 起動時に SetDefault でカレンダーを読み込んだ Market を設定するまでは、土日のみを休場とする既定値です。
+func Default() *Market {
+	return defaultMarket.Load()
+}
+
+// SetDefault はプロセス全体で共有する Market を差し替えます
+func SetDefault(m *Market) {
+	if m == nil {
+		m = NewMarket(nil, DefaultSchedule())
+	}
+	defaultMarket.Store(m)
+}
+
+var jst = loadLocation()
+
+func loadLocation() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Tokyo"); err == nil {
+		return loc
+	}
+	return time.FixedZone("JST", 9*60*60)
+}
+
+// Location は立会時間の判定に使うタイムゾーン（日本時間）です
+func Location() *time.Location {
+	return jst
+}
+
+// LoadMarket はカレンダーファイルを読み込み、東証の既定スケジュールと組み合わせた Market を作成します
+func LoadMarket(calendarPath string) (*Market, error) {
+	cal, err := LoadCalendar(calendarPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewMarket(cal, DefaultSchedule()), nil
+}
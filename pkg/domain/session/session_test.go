@@ -0,0 +1,177 @@
+package session_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+)
+
+func jst(month time.Month, day, hour, minute, sec int) time.Time {
+	return time.Date(2026, month, day, hour, minute, sec, 0, session.Location())
+}
+
+func writeCalendar(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "calendar.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCalendar(t *testing.T) {
+	path := writeCalendar(t, "# date,kind,name\n\n2026-07-20,holiday,海の日\n2026-12-30,half_day\n")
+	cal, err := session.LoadCalendar(path)
+	if err != nil {
+		t.Fatalf("LoadCalendar failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want session.DayKind
+	}{
+		{"weekday", jst(7, 17, 0, 0, 0), session.DAY_KIND_TRADING},
+		{"holiday", jst(7, 20, 0, 0, 0), session.DAY_KIND_HOLIDAY},
+		{"saturday", jst(7, 18, 0, 0, 0), session.DAY_KIND_HOLIDAY},
+		{"half day", jst(12, 30, 0, 0, 0), session.DAY_KIND_HALF_DAY},
+		// UTC では前日でも、日本時間の日付で判定する
+		{"judged in JST", time.Date(2026, 7, 19, 16, 0, 0, 0, time.UTC), session.DAY_KIND_HOLIDAY},
+	}
+	for _, tt := range tests {
+		if got := cal.Kind(tt.date); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+	if cal.Name(jst(7, 20, 0, 0, 0)) != "海の日" {
+		t.Errorf("unexpected name: %q", cal.Name(jst(7, 20, 0, 0, 0)))
+	}
+
+	// 金曜の翌営業日は土日・祝日を飛ばした火曜
+	if got := cal.NextTradingDay(jst(7, 17, 15, 0, 0)); !got.Equal(jst(7, 21, 0, 0, 0)) {
+		t.Errorf("unexpected next trading day: %v", got)
+	}
+	if got := cal.PreviousTradingDay(jst(7, 21, 9, 0, 0)); !got.Equal(jst(7, 17, 0, 0, 0)) {
+		t.Errorf("unexpected previous trading day: %v", got)
+	}
+}
+
+func TestLoadCalendar_Errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"missing kind": "2026-07-20\n",
+		"bad date":     "2026/07/20,holiday\n",
+		"unknown kind": "2026-07-20,closed\n",
+	} {
+		if _, err := session.LoadCalendar(writeCalendar(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := session.LoadCalendar(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestShippedCalendarLoads(t *testing.T) {
+	cal, err := session.LoadCalendar(filepath.Join("..", "..", "..", session.DefaultCalendarPath))
+	if err != nil {
+		t.Fatalf("failed to load the shipped calendar: %v", err)
+	}
+	if cal.IsTradingDay(jst(1, 1, 0, 0, 0)) || !cal.IsTradingDay(jst(1, 5, 0, 0, 0)) {
+		t.Errorf("unexpected new year holidays")
+	}
+}
+
+func newMarket() *session.Market {
+	cal := session.NewCalendar()
+	cal.AddHoliday(jst(7, 20, 0, 0, 0), "海の日")
+	cal.AddHalfDay(jst(12, 30, 0, 0, 0), "")
+	return session.NewMarket(cal, session.DefaultSchedule())
+}
+
+func TestMarket_PhaseAndSession(t *testing.T) {
+	m := newMarket()
+
+	tests := []struct {
+		at          time.Time
+		wantPhase   session.Phase
+		wantSession session.Session
+	}{
+		{jst(6, 10, 7, 59, 59), session.PHASE_CLOSED, session.SESSION_NONE},
+		{jst(6, 10, 8, 30, 0), session.PHASE_PRE_OPEN, session.SESSION_MORNING},
+		{jst(6, 10, 9, 0, 0), session.PHASE_CONTINUOUS, session.SESSION_MORNING},
+		{jst(6, 10, 11, 30, 0), session.PHASE_LUNCH_BREAK, session.SESSION_NONE},
+		{jst(6, 10, 12, 10, 0), session.PHASE_PRE_OPEN, session.SESSION_AFTERNOON},
+		{jst(6, 10, 12, 30, 0), session.PHASE_CONTINUOUS, session.SESSION_AFTERNOON},
+		{jst(6, 10, 15, 25, 0), session.PHASE_CLOSING_AUCTION, session.SESSION_AFTERNOON},
+		{jst(6, 10, 15, 30, 0), session.PHASE_CLOSED, session.SESSION_NONE},
+		{jst(7, 20, 10, 0, 0), session.PHASE_CLOSED, session.SESSION_NONE},
+		{jst(12, 30, 10, 0, 0), session.PHASE_CONTINUOUS, session.SESSION_MORNING},
+		{jst(12, 30, 13, 0, 0), session.PHASE_CLOSED, session.SESSION_NONE},
+	}
+	for _, tt := range tests {
+		if got := m.Phase(tt.at); got != tt.wantPhase {
+			t.Errorf("%v: expected phase %s, got %s", tt.at, tt.wantPhase, got)
+		}
+		if got := m.Session(tt.at); got != tt.wantSession {
+			t.Errorf("%v: expected session %s, got %s", tt.at, tt.wantSession, got)
+		}
+	}
+}
+
+func TestMarket_ContinuousWindowAndClose(t *testing.T) {
+	m := newMarket()
+
+	sess, elapsed, remaining, ok := m.ContinuousWindow(jst(6, 10, 14, 45, 0))
+	if !ok || sess != session.SESSION_AFTERNOON || elapsed != 135*time.Minute || remaining != 40*time.Minute {
+		t.Errorf("unexpected afternoon window: %s %v %v %v", sess, elapsed, remaining, ok)
+	}
+	if _, _, _, ok := m.ContinuousWindow(jst(6, 10, 15, 26, 0)); ok {
+		t.Errorf("expected the closing auction to be outside the continuous window")
+	}
+
+	if got := m.Close(jst(6, 10, 10, 0, 0)); !got.Equal(jst(6, 10, 15, 30, 0)) {
+		t.Errorf("unexpected close: %v", got)
+	}
+	if got := m.Close(jst(12, 30, 10, 0, 0)); !got.Equal(jst(12, 30, 11, 30, 0)) {
+		t.Errorf("unexpected half day close: %v", got)
+	}
+}
+
+func TestMarket_BarStart(t *testing.T) {
+	m := newMarket()
+
+	tests := []struct {
+		name  string
+		at    time.Time
+		frame time.Duration
+		want  time.Time
+	}{
+		{"morning minute", jst(6, 10, 9, 0, 59), time.Minute, jst(6, 10, 9, 0, 0)},
+		{"afternoon hour is anchored at 12:30", jst(6, 10, 13, 10, 0), time.Hour, jst(6, 10, 12, 30, 0)},
+		{"morning hour does not cross lunch", jst(6, 10, 11, 15, 0), time.Hour, jst(6, 10, 11, 0, 0)},
+		{"closing print joins the last bar", jst(6, 10, 15, 30, 0), time.Minute, jst(6, 10, 15, 29, 0)},
+		{"outside sessions truncates", jst(6, 10, 11, 45, 30), time.Minute, jst(6, 10, 11, 45, 0)},
+	}
+	for _, tt := range tests {
+		if got := m.BarStart(tt.at, tt.frame); !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	m := newMarket()
+	session.SetDefault(m)
+	defer session.SetDefault(nil)
+
+	if session.Default() != m {
+		t.Fatalf("expected the configured market")
+	}
+	session.SetDefault(nil)
+	if session.Default().IsTradingDay(jst(7, 20, 0, 0, 0)) != true {
+		t.Errorf("expected the fallback market to treat only weekends as holidays")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
 	"github.com/r-umemoto/trading-bot/pkg/domain/position"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/brain"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/sizing"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
@@ -54,7 +55,7 @@ type FireAction struct {
 }
 
 // SniperNest は特定の銘柄（Symbol）におけるスナイパーたちを束ねるドメイン集約（Aggregate Root）です。
-// 子コンポーネント（OrderTracker, PositionTracker, PerformanceTracker, CooldownTracker）をオーケストレートし、銘柄ごとの取引状態を保護します。
+// 子コンポーネント（OrderTracker, PositionTracker, PerformanceTracker, CooldownTracker, TrailingTracker）をオーケストレートし、銘柄ごとの取引状態を保護します。
 type SniperNest struct {
 	SymbolCode   string
 	Detail       symbol.Symbol // 銘柄情報
@@ -63,9 +64,13 @@ type SniperNest struct {
 	positions    *PositionTracker
 	performance  *PerformanceTracker
 	cooldowns    *CooldownTracker
+	trailing     *TrailingTracker
 	Logger       *slog.Logger
 	mu           sync.Mutex
 	lastTickTime time.Time // 🌟 最新のシミュレーション時刻を保存（エラー発生時の時間軸統一用）
+
+	restrictedWarned map[string]bool      // 取引規制で新規建てを見送った旨を警告済みのスナイパー・売買方向
+	entrySizes       map[string]entrySize // スナイパーごとの新規建てのサイジング結果（建て終わるまで維持する）
 }
 
 func NewSniperNest(code string, detail symbol.Symbol, snipers []*Sniper, logger *slog.Logger) *SniperNest {
@@ -80,10 +85,30 @@ func NewSniperNest(code string, detail symbol.Symbol, snipers []*Sniper, logger
 		positions:   NewPositionTracker(logger),
 		performance: NewPerformanceTracker(),
 		cooldowns:   NewCooldownTracker(),
+		trailing:    NewTrailingTracker(),
 		Logger:      logger,
+		entrySizes:  make(map[string]entrySize),
 	}
 }
 
+// UseSizing は配下のスナイパーすべてに、新規建ての数量を決めるサイジングを設定します
+func (n *SniperNest) UseSizing(params sizing.Params, pool tick.DataPool) {
+	_ = n.UpdateSizing(&params, pool)
+}
+
+// UpdateSizing は配下のスナイパーすべてのサイジングを差し替えます（params が nil ならサイジングを外します）。
+// 建玉や注文には触れず、建てている途中の数量は建て終わるまで維持し、次の新規建てから新しい設定で決めます。
+func (n *SniperNest) UpdateSizing(params *sizing.Params, pool tick.DataPool) error {
+	for _, s := range n.snipers {
+		var sizer *sizing.Sizer
+		if params != nil {
+			sizer = sizing.New(*params, s.Detail, pool)
+		}
+		s.UpdateSizer(sizer)
+	}
+	return nil
+}
+
 // GetSymbolCodes は対象の全銘柄コードのリストを返します。
 func (n *SniperNest) GetSymbolCodes() []string {
 	return []string{n.SymbolCode}
@@ -97,7 +122,8 @@ func (n *SniperNest) HandleTick(t tick.Tick) []FireAction {
 		if s.GetLifecycle() == LifecycleStopped {
 			continue
 		}
-		obs := n.PrepareObservation(s.ID, t, s.ExecutionPolicy)
+		policy, sizer := s.execution()
+		obs := n.PrepareObservation(s.ID, t, policy)
 
 		virtualPos := obs.CalculateVirtualPosition()
 
@@ -107,7 +133,12 @@ func (n *SniperNest) HandleTick(t tick.Tick) []FireAction {
 		}
 
 		target := s.Evaluate(input)
-		bullet := n.ReconcileTarget(s.ID, obs.Tick, virtualPos, target, s.Exchange, s.MarginTradeType, s.AccountType, s.ExecutionPolicy)
+		target = n.sizeTarget(s, sizer, obs, virtualPos, target)
+		// トレーリングストップに掛かった建玉の返済は、戦略の目標より優先する
+		bullet := n.reconcileTrailingStop(s, obs, target)
+		if bullet == nil {
+			bullet = n.ReconcileTarget(s.ID, obs.Tick, virtualPos, target, s.Exchange, s.MarginTradeType, s.AccountType, policy)
+		}
 
 		if bullet != nil {
 			// 🌟 不公正取引（自己対当クロス）の自動調停ロジック
@@ -182,11 +213,51 @@ func (n *SniperNest) ForceExit() {
 	}
 }
 
+// OrderlyExit は配下の全スナイパーに撤収（保有建玉の手仕舞い後に新規エントリーを止める）を命じます。
+func (n *SniperNest) OrderlyExit() {
+	for _, s := range n.snipers {
+		s.OrderlyExit()
+	}
+}
+
+// Close は配下の全スナイパーの戦略（strategy.Closer を実装したもの）を閉じます。
+func (n *SniperNest) Close() error {
+	var errs []error
+	for _, s := range n.snipers {
+		if err := s.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("スナイパー %s: %w", s.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reconfigure は指定したスナイパーの戦略にパラメータの差し替えを適用し、執行ポリシー（nil なら維持）を入れ替えます。
+func (n *SniperNest) Reconfigure(sniperID string, params interface{}, policy strategy.ExecutionPolicy) error {
+	for _, s := range n.snipers {
+		if s.ID == sniperID {
+			return s.Reconfigure(params, policy)
+		}
+	}
+	return fmt.Errorf("スナイパー %s が見つかりません", sniperID)
+}
+
 // GetSymbolCode は対象の銘柄コードを返します。
 func (n *SniperNest) GetSymbolCode() string {
 	return n.SymbolCode
 }
 
+// IsFlat は配下の全スナイパーに保有建玉も未完了の注文も無いかを返します（撤収の完了判定に使います）。
+func (n *SniperNest) IsFlat() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, s := range n.snipers {
+		if len(n.positions.positions[s.ID]) > 0 {
+			return false
+		}
+	}
+	return len(n.orders.GetAllActive()) == 0
+}
+
 // GetActiveOrders は配下の全スナイパーが追跡中の未完了注文を集約して返します。
 func (n *SniperNest) GetActiveOrders() []*order.Order {
 	n.mu.Lock()
@@ -393,6 +464,22 @@ func (n *SniperNest) ReconcileTarget(
 	if target.HasIfDone && target.ExitPrice > 0 {
 		target.ExitPrice = n.Detail.RoundPrice(target.ExitPrice)
 	}
+	if target.StopPrice > 0 {
+		target.StopPrice = n.Detail.RoundPrice(target.StopPrice)
+	}
+	if target.HasIfDone && target.ExitStopPrice > 0 {
+		target.ExitStopPrice = n.Detail.RoundPrice(target.ExitStopPrice)
+	}
+	if target.HasOCO && target.OCOPrice > 0 {
+		target.OCOPrice = n.Detail.RoundPrice(target.OCOPrice)
+	}
+	if target.HasOCO && target.OCOStopPrice > 0 {
+		target.OCOStopPrice = n.Detail.RoundPrice(target.OCOStopPrice)
+	}
+	// 銘柄マスタから売買単位が分かっている場合は、目標数量を売買単位に切り捨てる（単元未満の注文は取引所で受け付けられない）
+	if n.Detail.TradingUnit > 0 {
+		target.Qty = n.Detail.RoundQty(target.Qty)
+	}
 
 	// --- 1. インフライト注文の分類と集計 ---
 	stats := n.orders.GetInflightStats(sniperID)
@@ -491,6 +578,12 @@ func (n *SniperNest) ReconcileTarget(
 		}
 	}
 
+	// 信用区分で新規建てできない銘柄（貸借不可・デイトレ対象外など）は新規注文を出さない
+	if cashMargin == order.CASH_MARGIN_MARGIN_ENTRY && gap >= 1.0 && !n.Detail.CanOpen(action, marginType) {
+		n.warnRestrictedOnce(sniperID, action, marginType)
+		return nil
+	}
+
 	absGap := math.Abs(gap)
 
 	// 同方向かつ同口座区分の進行中注文があるか確認
@@ -512,6 +605,7 @@ func (n *SniperNest) ReconcileTarget(
 	var desiredQty float64
 	var desiredPrice float64
 	var desiredOrderType order.OrderType
+	var desiredTrigger float64
 	var desiredReason string
 
 	if cashMargin == order.CASH_MARGIN_MARGIN_EXIT {
@@ -519,22 +613,33 @@ func (n *SniperNest) ReconcileTarget(
 		if target.HasIfDone {
 			desiredPrice = target.ExitPrice
 			desiredOrderType = target.ExitOrderType
+			desiredTrigger = target.ExitStopPrice
 			desiredReason = target.ExitReason
 		} else {
 			desiredPrice = target.Price
 			desiredOrderType = target.OrderType
+			desiredTrigger = target.StopPrice
 			desiredReason = target.Reason
 		}
 	} else {
 		desiredQty = math.Abs(effectiveTargetQty)
 		desiredPrice = target.Price
 		desiredOrderType = target.OrderType
+		desiredTrigger = target.StopPrice
 		desiredReason = target.Reason
 		// 既存のエントリー注文があり、かつターゲット価格が 0 (HOLDなど) の場合は、
 		// 既存注文の価格とタイプを引き継ぐことで、不要なキャンセルを防ぐ。
-		if matchingOrder != nil && matchingOrder.CashMargin == order.CASH_MARGIN_MARGIN_ENTRY && target.Price == 0 {
+		if matchingOrder != nil && matchingOrder.CashMargin == order.CASH_MARGIN_MARGIN_ENTRY && target.Price == 0 && target.StopPrice == 0 {
 			desiredPrice = matchingOrder.OrderPrice
 			desiredOrderType = matchingOrder.Type
+			desiredTrigger = matchingOrder.TriggerPrice
+		}
+	}
+
+	// 値幅制限に寄せた後の価格で既存注文と比較する（寄せた価格で出した注文を毎 Tick 出し直さないため）
+	if desiredOrderType != order.ORDER_TYPE_MARKET {
+		if fitted, err := n.Detail.PriceLimits.FitLimitPrice(action, desiredPrice); err == nil {
+			desiredPrice = fitted
 		}
 	}
 
@@ -554,18 +659,45 @@ func (n *SniperNest) ReconcileTarget(
 		Reason:    desiredReason,
 	}
 
+	// OCO の組（既存の注文・目標のどちらか一方でも）は、相方を含めた組全体で目標と比較する
+	wantOCO := target.HasOCO && cashMargin == order.CASH_MARGIN_MARGIN_EXIT
+	var bracketLegs []*order.Order
+	if matchingOrder != nil {
+		bracketLegs = ocoLegsOf(matchingOrder, stats.ActiveOrders)
+	}
+	isBracket := wantOCO || len(bracketLegs) > 0
+	var sameBracket bool
+	if isBracket && matchingOrder != nil {
+		desiredLegs := []order.Order{{Action: action, Type: desiredOrderType, OrderPrice: desiredPrice, TriggerPrice: desiredTrigger}}
+		if wantOCO {
+			ocoPrice := target.OCOPrice
+			if target.OCOOrderType != order.ORDER_TYPE_MARKET {
+				if fitted, err := n.Detail.PriceLimits.FitLimitPrice(action, ocoPrice); err == nil {
+					ocoPrice = fitted
+				}
+			}
+			desiredLegs = append(desiredLegs, order.Order{Action: action, Type: target.OCOOrderType, OrderPrice: ocoPrice, TriggerPrice: target.OCOStopPrice})
+		}
+		sameBracket = matchesBracket(bracketLegs, desiredLegs, desiredQty)
+	}
+
 	// ギャップが極小で、かつ既存注文の更新も必要ない場合は早期リターン
 	if absGap < 1.0 {
 		if matchingOrder == nil {
 			return nil
 		}
+		if isBracket && sameBracket {
+			return nil
+		}
 
 		isIdentical := matchingOrder.Action == action &&
 			matchingOrder.OrderQty == desiredSignal.Quantity &&
 			(matchingOrder.OrderPrice == desiredSignal.Price || (math.IsNaN(matchingOrder.OrderPrice) && math.IsNaN(desiredSignal.Price))) &&
 			matchingOrder.CashMargin == cashMargin
 
-		if isIdentical || policy.IsOrderDesired(matchingOrder, desiredSignal, n.Detail) {
+		// 逆指値のトリガー価格が変わった場合は、指値の許容幅に関わらず出し直す
+		sameTrigger := matchingOrder.TriggerPrice == desiredTrigger
+		if !isBracket && sameTrigger && (isIdentical || policy.IsOrderDesired(matchingOrder, desiredSignal, n.Detail)) {
 			return nil
 		}
 	}
@@ -585,18 +717,23 @@ func (n *SniperNest) ReconcileTarget(
 	}
 
 	if matchingOrder != nil {
+		if isBracket && sameBracket {
+			return nil
+		}
 		isIdentical := matchingOrder.Action == action &&
 			matchingOrder.OrderQty == desiredSignal.Quantity &&
 			(matchingOrder.OrderPrice == desiredSignal.Price || (math.IsNaN(matchingOrder.OrderPrice) && math.IsNaN(desiredSignal.Price))) &&
 			matchingOrder.CashMargin == cashMargin
 
-		if isIdentical || policy.IsOrderDesired(matchingOrder, desiredSignal, n.Detail) {
+		// 逆指値のトリガー価格が変わった場合は、指値の許容幅に関わらず出し直す
+		sameTrigger := matchingOrder.TriggerPrice == desiredTrigger
+		if !isBracket && sameTrigger && (isIdentical || policy.IsOrderDesired(matchingOrder, desiredSignal, n.Detail)) {
 			return nil
 		}
 
-		fmt.Printf("🔄 [%s] 目標値変更により、既存注文(%s)を上書きします [Status:%v, OldQty:%f, NewQty:%f, OldPrice:%f, NewPrice:%f]\n",
+		fmt.Printf("🔄 [%s] 目標値変更により、既存注文(%s)を上書きします [Status:%v, OldQty:%f, NewQty:%f, OldPrice:%f, NewPrice:%f, OldTrigger:%f, NewTrigger:%f]\n",
 			n.Detail.Code, matchingOrder.ID, matchingOrder.Status(),
-			matchingOrder.OrderQty, desiredQty, matchingOrder.OrderPrice, desiredPrice)
+			matchingOrder.OrderQty, desiredQty, matchingOrder.OrderPrice, desiredPrice, matchingOrder.TriggerPrice, desiredTrigger)
 
 		if !matchingOrder.CanCancel() {
 			// API送信中やキャンセル送信中のため、安全のため完了するまで上書きを保留する
@@ -611,6 +748,9 @@ func (n *SniperNest) ReconcileTarget(
 	lockedHoldIDs := order.ActiveOrders(activeOrders).LockedHoldIDs()
 
 	entry, exit := n.buildOrderPairFromTarget(sniperID, target, action, absGap, cashMargin, exchange, marginType, accountType, lockedHoldIDs)
+	if entry == nil {
+		return nil
+	}
 	if exit != nil {
 		entry.IfDone = exit
 	}
@@ -631,6 +771,24 @@ func (n *SniperNest) buildOrderPairFromTarget(
 	accountType order.AccountType,
 	lockedHoldIDs map[string]bool,
 ) (*order.Order, *order.Order) {
+	// 取引所に拒否される指値は送らない（約定条件が変わらない方向の超過は制限値に寄せる）
+	target, err := n.fitTargetToPriceLimits(target, action)
+	if err != nil {
+		n.Logger.Warn("🚧 指値が値幅制限の範囲外のため発注を見送ります",
+			slog.String("symbol", n.Detail.Code),
+			slog.String("sniper_id", sniperID),
+			slog.Float64("price", target.Price),
+			slog.Float64("exit_price", target.ExitPrice),
+			slog.Float64("stop_price", target.StopPrice),
+			slog.Float64("exit_stop_price", target.ExitStopPrice),
+			slog.Float64("oco_price", target.OCOPrice),
+			slog.Float64("oco_stop_price", target.OCOStopPrice),
+			slog.Float64("upper", n.Detail.PriceLimits.Upper),
+			slog.Float64("lower", n.Detail.PriceLimits.Lower),
+		)
+		return nil, nil
+	}
+
 	isExit := (cashMargin == order.CASH_MARGIN_MARGIN_EXIT)
 
 	var closePositions []order.ClosePosition
@@ -658,21 +816,22 @@ func (n *SniperNest) buildOrderPairFromTarget(
 		target.Price,
 		qty,
 		order.WithType(target.OrderType),
+		order.WithTriggerPrice(target.StopPrice),
 		order.WithCashMargin(cashMargin),
 		order.WithRequest(entryReq),
 		order.WithReason(target.Reason),
 	)
 	entry.ToPending()
 
+	var exitAction order.Action
+	if action == order.ACTION_BUY {
+		exitAction = order.ACTION_SELL
+	} else {
+		exitAction = order.ACTION_BUY
+	}
+
 	var exit *order.Order
 	if target.HasIfDone {
-		var exitAction order.Action
-		if action == order.ACTION_BUY {
-			exitAction = order.ACTION_SELL
-		} else {
-			exitAction = order.ACTION_BUY
-		}
-
 		exitReq := &order.OrderRequest{
 			Exchange:           exchange,
 			SecurityType:       order.SECURITY_TYPE_STOCK,
@@ -688,15 +847,375 @@ func (n *SniperNest) buildOrderPairFromTarget(
 			target.ExitPrice,
 			qty,
 			order.WithType(target.ExitOrderType),
+			order.WithTriggerPrice(target.ExitStopPrice),
 			order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT),
 			order.WithRequest(exitReq),
 			order.WithReason(target.ExitReason),
 		)
 	}
 
+	// OCO の相手方は IFD の決済注文、無ければ返済注文そのものと対にする（新規建ての注文とは組まない）
+	if target.HasOCO {
+		switch {
+		case exit != nil:
+			exit.OCO = n.buildOCOLeg(target, exitAction, qty, exit.Request)
+		case isExit:
+			entry.OCO = n.buildOCOLeg(target, action, qty, entry.Request)
+		}
+	}
+
 	return entry, exit
 }
 
+// buildOCOLeg は目標の OCO の相手方の注文を作ります。返済する建玉の指定は対になる注文と同じにします
+func (n *SniperNest) buildOCOLeg(target strategy.TargetPosition, action order.Action, qty float64, req *order.OrderRequest) *order.Order {
+	legReq := *req
+	legReq.ClosePositions = append([]order.ClosePosition(nil), req.ClosePositions...)
+	return order.NewOrder(
+		order.GenerateLocalID(),
+		n.Detail.Code,
+		action,
+		target.OCOPrice,
+		qty,
+		order.WithType(target.OCOOrderType),
+		order.WithTriggerPrice(target.OCOStopPrice),
+		order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT),
+		order.WithRequest(&legReq),
+		order.WithReason(target.OCOReason),
+	)
+}
+
+// ocoLegsOf は注文が属する OCO の組の、進行中の注文を返します。発注前の注文は OCO の相手方と組にします
+func ocoLegsOf(o *order.Order, active []*order.Order) []*order.Order {
+	if o.OCOGroupID != "" {
+		return order.ActiveOrders(active).OCOLegs(o.OCOGroupID)
+	}
+	if o.OCO != nil {
+		return []*order.Order{o, o.OCO}
+	}
+	return nil
+}
+
+// matchesBracket は OCO の組が目標と一致しているかを判定します。
+// 組の残数量が目標数量と等しく、進行中のすべての注文が目標のいずれかの注文と一致すれば維持します。
+// ゲートウェイが相方の一部約定に合わせて出し直している間は注文が欠けるため、目標側の注文が余っていても一致とみなします。
+func matchesBracket(legs []*order.Order, desired []order.Order, qty float64) bool {
+	if len(legs) == 0 || order.OCORemainingQty(legs) != qty {
+		return false
+	}
+	for _, leg := range legs {
+		matched := false
+		for _, d := range desired {
+			if leg.Action == d.Action && leg.Type == d.Type && leg.OrderPrice == d.OrderPrice && leg.TriggerPrice == d.TriggerPrice {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// entrySize は新規建てのサイジング結果と、その時に戦略が返した数量（サイジング前）です
+type entrySize struct {
+	decision    sizing.Decision
+	strategyQty float64
+}
+
+// sizeTarget はスナイパーにサイジングが設定されていれば、新規建ての目標数量をサイジングで決めた数量に置き換えます。
+// 戦略の数量は売買方向としてだけ扱い、建て終わるまでは最初に決めた数量を維持します（価格の変化で注文を出し直さないため）。
+// 保有中に戦略が新規建ての時と同じ数量を返す間は、サイジングした数量を保有し続ける目標とみなします。
+// それ以外の保有分の維持・手仕舞い・反転の目標はそのまま返します。
+func (n *SniperNest) sizeTarget(s *Sniper, sizer *sizing.Sizer, obs Observation, virtualPos strategy.Position, target strategy.TargetPosition) strategy.TargetPosition {
+	if sizer == nil {
+		return target
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	stats := n.orders.GetInflightStats(s.ID)
+	building := !virtualPos.IsFlat() || stats.InflightBuyEntry+stats.InflightSellEntry > 0
+	if target.IsFlat() {
+		if !building {
+			delete(n.entrySizes, s.ID)
+		}
+		return target
+	}
+	entry, sized := n.entrySizes[s.ID]
+	if !virtualPos.IsFlat() {
+		if virtualPos.IsLong() != target.IsLong() {
+			return target
+		}
+		// 売買単位など一定の数量を返す戦略が、サイジングした建玉を1単位まで減らしてしまわないようにする
+		holding := sized && entry.decision.Qty > 0 && target.AbsQty() == entry.strategyQty
+		if !holding && target.AbsQty() <= virtualPos.AbsQty() {
+			return target
+		}
+	}
+
+	d := entry.decision
+	if !sized || !building {
+		price := sizing.EntryPrice(target, obs.Tick.Price)
+		next := sizer.Size(price, sizing.StopDistance(target, price, n.Detail))
+		if !sized || next.Qty != d.Qty || next.SkipReason != d.SkipReason {
+			action := order.ACTION_BUY
+			if target.IsShort() {
+				action = order.ACTION_SELL
+			}
+			s.Logger.Info("POSITION_SIZED",
+				slog.String("sniper", s.ID),
+				slog.String("symbol", n.Detail.Code),
+				slog.String("model", string(next.Model)),
+				slog.String("action", string(action)),
+				slog.String("reason", target.Reason),
+				slog.Float64("price", next.Price),
+				slog.Float64("stop_distance", next.StopDistance),
+				slog.Float64("atr", next.ATR),
+				slog.Float64("raw_qty", next.RawQty),
+				slog.Float64("qty", next.Qty),
+				slog.Float64("notional", next.Notional()),
+				slog.Float64("risk", next.Risk()),
+				slog.String("capped_by", next.CappedBy),
+				slog.String("skip_reason", next.SkipReason),
+			)
+		}
+		d = next
+		n.entrySizes[s.ID] = entrySize{decision: d, strategyQty: target.AbsQty()}
+	}
+	if d.Qty <= 0 {
+		target.Qty = 0
+		return target
+	}
+	target.Qty = math.Copysign(d.Qty, target.Qty)
+	return target
+}
+
+// reconcileTrailingStop は目標のトレーリングストップの指定を反映し、逆行幅を超えた建玉があれば、その建玉だけを成行で返済する注文を返します。
+// 同じ建玉を返済する他の注文（利確の指値など）が残っている場合は、先にそれを取り消します。
+func (n *SniperNest) reconcileTrailingStop(s *Sniper, obs Observation, target strategy.TargetPosition) Bullet {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.trailing.Arm(s.ID, target)
+	if len(obs.Positions) == 0 {
+		// 建玉も進行中の注文も無くなったら、次の新規建てで指定し直されるまで解除する
+		if len(obs.ActiveOrders) == 0 && !target.HasTrail() {
+			n.trailing.Disarm(s.ID)
+		}
+		return nil
+	}
+	t := obs.Tick
+	if !n.trailing.IsArmed(s.ID) || !t.IsExecution() || t.Price <= 0 {
+		return nil
+	}
+	hits := n.trailing.Update(s.ID, obs.Positions, t.Price, n.Detail)
+	if len(hits) == 0 {
+		return nil
+	}
+
+	now := t.CurrentPriceTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	stats := n.orders.GetInflightStats(s.ID)
+	if len(stats.CancelingOrders) > 0 {
+		return nil
+	}
+	reason := n.trailing.Reason(s.ID)
+
+	var action order.Action
+	var closePositions []order.ClosePosition
+	var qty float64
+	var exits []TrailHit
+	for _, hit := range hits {
+		p := hit.Position
+		exitAction := order.ACTION_SELL
+		if p.Action == order.ACTION_SELL {
+			exitAction = order.ACTION_BUY
+		}
+		if action != "" && exitAction != action {
+			continue
+		}
+
+		// 建玉を返済する他の注文が残っていれば取り消す（返済指定の無い返済注文は、どの建玉を返済するか分からないため同様に扱う）
+		waiting := false
+		for _, o := range stats.ActiveOrders {
+			if o == nil || o.CashMargin != order.CASH_MARGIN_MARGIN_EXIT || o.Action != exitAction {
+				continue
+			}
+			if !locksPosition(o, p.ExecutionID) {
+				continue
+			}
+			if o.Reason == reason && o.Type == order.ORDER_TYPE_MARKET {
+				// トレーリングストップの返済注文を出し済み
+				waiting = true
+				break
+			}
+			if !o.CanCancel() {
+				waiting = true
+				break
+			}
+			n.Logger.Info("📉 [TrailingStop] 建玉を返済するため、同じ建玉の返済注文を取り消します",
+				slog.String("symbol", n.Detail.Code),
+				slog.String("sniper_id", s.ID),
+				slog.String("hold_id", p.ExecutionID),
+				slog.String("canceling_order_id", o.ID),
+			)
+			if o.InternalState() != order.STATE_PREPARING {
+				o.ToCancelSent()
+				o.CancelSentAt = now
+			}
+			return CancelBullet{OrderID: o.ID}
+		}
+		// 約定直後で IFD の返済注文がまだ届いていない建玉は、届いてから取り消す
+		for _, o := range stats.ActiveOrders {
+			if o != nil && o.IfDone != nil && o.HasExecution(p.ExecutionID) {
+				waiting = true
+			}
+		}
+		if waiting {
+			continue
+		}
+
+		action = exitAction
+		closePositions = append(closePositions, order.ClosePosition{HoldID: p.ExecutionID, Qty: p.LeavesQty})
+		qty += p.LeavesQty
+		exits = append(exits, hit)
+	}
+	if qty <= 0 {
+		return nil
+	}
+	if n.cooldowns.IsCoolingDown(s.ID, now) {
+		return nil
+	}
+
+	ord := order.NewOrder(
+		order.GenerateLocalID(),
+		n.Detail.Code,
+		action,
+		0,
+		qty,
+		order.WithType(order.ORDER_TYPE_MARKET),
+		order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT),
+		order.WithRequest(&order.OrderRequest{
+			Exchange:        s.Exchange,
+			SecurityType:    order.SECURITY_TYPE_STOCK,
+			MarginTradeType: s.MarginTradeType,
+			AccountType:     s.AccountType,
+			ClosePositions:  closePositions,
+		}),
+		order.WithReason(reason),
+	)
+	ord.ToPending()
+	ord.CreatedAt = now
+
+	// クールダウン中などで返済注文を出さない Tick では記録せず、注文を出す建玉だけを記録する
+	for _, hit := range exits {
+		p := hit.Position
+		n.Logger.Info("TRAILING_STOP",
+			slog.String("sniper", s.ID),
+			slog.String("symbol", n.Detail.Code),
+			slog.String("hold_id", p.ExecutionID),
+			slog.Float64("qty", p.LeavesQty),
+			slog.Float64("entry_price", p.Price),
+			slog.Float64("mark", hit.Mark),
+			slog.Float64("stop_price", hit.StopPrice),
+			slog.Float64("price", t.Price),
+		)
+	}
+	return OrderBullet{Order: ord}
+}
+
+// locksPosition は返済注文が建玉を返済する（または返済指定が無く、どの建玉でも返済し得る）かを判定します
+func locksPosition(o *order.Order, holdID string) bool {
+	if o.Request == nil || len(o.Request.ClosePositions) == 0 {
+		return true
+	}
+	for _, cp := range o.Request.ClosePositions {
+		if cp.HoldID == holdID {
+			return true
+		}
+	}
+	return false
+}
+
+// warnRestrictedOnce は新規建てできない銘柄への発注見送りを、スナイパー・売買方向ごとに1度だけ警告します
+func (n *SniperNest) warnRestrictedOnce(sniperID string, action order.Action, marginType order.MarginTradeType) {
+	key := fmt.Sprintf("%s/%v", sniperID, action)
+	if n.restrictedWarned[key] {
+		return
+	}
+	if n.restrictedWarned == nil {
+		n.restrictedWarned = make(map[string]bool)
+	}
+	n.restrictedWarned[key] = true
+	n.Logger.Warn("🚫 信用区分の取引規制により新規建てを見送ります",
+		slog.String("symbol", n.Detail.Code),
+		slog.String("sniper_id", sniperID),
+		slog.Any("action", action),
+		slog.Any("margin_trade_type", marginType),
+	)
+}
+
+// fitTargetToPriceLimits は目標の指値（IFD の返済指値・OCO の相手方の指値を含む）を銘柄の値幅制限に合わせます。
+// 成行や値幅制限が未取得の場合はそのまま返します。逆指値のトリガー価格が値幅制限の範囲外の場合は発動し得ないためエラーを返します。
+func (n *SniperNest) fitTargetToPriceLimits(target strategy.TargetPosition, action order.Action) (strategy.TargetPosition, error) {
+	limits := n.Detail.PriceLimits
+	if !limits.Known() {
+		return target, nil
+	}
+
+	if target.OrderType != order.ORDER_TYPE_MARKET {
+		price, err := limits.FitLimitPrice(action, target.Price)
+		if err != nil {
+			return target, err
+		}
+		target.Price = price
+	}
+	if target.OrderType.IsStop() && !limits.Contains(target.StopPrice) {
+		return target, symbol.ErrOutsidePriceLimits
+	}
+
+	if target.HasIfDone && target.ExitOrderType != order.ORDER_TYPE_MARKET {
+		exitAction := order.ACTION_BUY
+		if action == order.ACTION_BUY {
+			exitAction = order.ACTION_SELL
+		}
+		price, err := limits.FitLimitPrice(exitAction, target.ExitPrice)
+		if err != nil {
+			return target, err
+		}
+		target.ExitPrice = price
+	}
+	if target.HasIfDone && target.ExitOrderType.IsStop() && !limits.Contains(target.ExitStopPrice) {
+		return target, symbol.ErrOutsidePriceLimits
+	}
+
+	if target.HasOCO {
+		// OCO の相手方は IFD の決済注文、または返済注文と同じ売買方向
+		ocoAction := action
+		if target.HasIfDone {
+			ocoAction = order.ACTION_BUY
+			if action == order.ACTION_BUY {
+				ocoAction = order.ACTION_SELL
+			}
+		}
+		if target.OCOOrderType != order.ORDER_TYPE_MARKET {
+			price, err := limits.FitLimitPrice(ocoAction, target.OCOPrice)
+			if err != nil {
+				return target, err
+			}
+			target.OCOPrice = price
+		}
+		if target.OCOOrderType.IsStop() && !limits.Contains(target.OCOStopPrice) {
+			return target, symbol.ErrOutsidePriceLimits
+		}
+	}
+	return target, nil
+}
+
 // CalculateVirtualPosition は Observation の状態から約定予定分を含んだ仮想ポジションを計算します
 func (obs Observation) CalculateVirtualPosition() strategy.Position {
 	var totalQty float64
@@ -1,12 +1,15 @@
 package sniper
 
 import (
+	"bytes"
 	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
 	"github.com/r-umemoto/trading-bot/pkg/domain/position"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/sizing"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
@@ -975,3 +978,409 @@ func TestSniperNest_ReconcileTarget_FlatTargetWithNonZeroPrice(t *testing.T) {
 		t.Errorf("expected CancelBullet for buy-entry-ord, got %+v", bullet)
 	}
 }
+
+func TestSniperNest_ReconcileTarget_PriceLimits(t *testing.T) {
+	sym := symbol.Symbol{Code: "7203", PriceLimits: symbol.NewPriceLimits(2500)} // 2000〜3000
+	sniperID := "sniper-1"
+	reconcile := func(nest *SniperNest, target strategy.TargetPosition) Bullet {
+		return nest.ReconcileTarget(sniperID, tick.Tick{Price: 2990}, strategy.Position{Qty: 0}, target, order.EXCHANGE_TOSHO, order.TRADE_TYPE_SYSTEM, order.ACCOUNT_SPECIAL, &strategy.NoopPolicy{})
+	}
+
+	// 1. ストップ高を超える買い指値はストップ高に寄せ、IFD の返済指値も範囲内に寄せる
+	nest := NewSniperNest("7203", sym, nil, nil)
+	bullet := reconcile(nest, strategy.TargetPosition{
+		Qty: 100, Price: 3050, OrderType: order.ORDER_TYPE_LIMIT,
+		HasIfDone: true, ExitPrice: 1950, ExitOrderType: order.ORDER_TYPE_LIMIT,
+	})
+	ob, ok := bullet.(OrderBullet)
+	if !ok {
+		t.Fatalf("expected OrderBullet, got %+v", bullet)
+	}
+	if ob.Order.OrderPrice != 3000 || ob.Order.IfDone == nil || ob.Order.IfDone.OrderPrice != 2000 {
+		t.Errorf("expected prices clamped to the limits, got entry %.0f", ob.Order.OrderPrice)
+	}
+
+	// 2. 寄せた価格の既存注文があれば、同じ目標で出し直さない
+	ob.Order.BypassTransition(order.ORDER_STATUS_IN_PROGRESS, order.STATE_ACTIVE)
+	nest.AddOrder(sniperID, ob.Order)
+	if again := reconcile(nest, strategy.TargetPosition{Qty: 100, Price: 3050, OrderType: order.ORDER_TYPE_LIMIT}); again != nil {
+		t.Errorf("expected no re-order for the clamped price, got %+v", again)
+	}
+
+	// 3. ストップ高を超える売り指値は取引所に拒否されるため発注しない
+	if rejected := reconcile(NewSniperNest("7203", sym, nil, nil), strategy.TargetPosition{Qty: -100, Price: 3050, OrderType: order.ORDER_TYPE_LIMIT}); rejected != nil {
+		t.Errorf("expected no order outside the limits, got %+v", rejected)
+	}
+
+	// 4. 成行は値幅制限の影響を受けない
+	if market := reconcile(NewSniperNest("7203", sym, nil, nil), strategy.TargetPosition{Qty: -100, OrderType: order.ORDER_TYPE_MARKET}); market == nil {
+		t.Errorf("expected a market order to be sent")
+	}
+}
+
+func TestSniperNest_ReconcileTarget_SymbolMaster(t *testing.T) {
+	sym := symbol.Symbol{
+		Code:        "7203",
+		TradingUnit: 100,
+		Margin:      symbol.MarginEligibility{Known: true, SystemBuy: true, GeneralBuy: true, GeneralSell: true},
+	}
+	sniperID := "sniper-1"
+	reconcile := func(nest *SniperNest, target strategy.TargetPosition, marginType order.MarginTradeType) Bullet {
+		return nest.ReconcileTarget(sniperID, tick.Tick{Price: 2500}, strategy.Position{Qty: 0}, target, order.EXCHANGE_TOSHO, marginType, order.ACCOUNT_SPECIAL, &strategy.NoopPolicy{})
+	}
+
+	// 1. 目標数量は売買単位に揃える
+	bullet := reconcile(NewSniperNest("7203", sym, nil, nil), strategy.TargetPosition{Qty: 130, Price: 2500, OrderType: order.ORDER_TYPE_LIMIT}, order.TRADE_TYPE_SYSTEM)
+	ob, ok := bullet.(OrderBullet)
+	if !ok || ob.Order.OrderQty != 100 {
+		t.Fatalf("expected an order for one trading unit, got %+v", bullet)
+	}
+
+	// 2. 貸借銘柄でなければ制度信用の新規売りは出さない
+	if rejected := reconcile(NewSniperNest("7203", sym, nil, nil), strategy.TargetPosition{Qty: -100, Price: 2500, OrderType: order.ORDER_TYPE_LIMIT}, order.TRADE_TYPE_SYSTEM); rejected != nil {
+		t.Errorf("expected no short entry for a non-lendable symbol, got %+v", rejected)
+	}
+
+	// 3. 一般信用なら新規売りできる
+	if allowed := reconcile(NewSniperNest("7203", sym, nil, nil), strategy.TargetPosition{Qty: -100, Price: 2500, OrderType: order.ORDER_TYPE_LIMIT}, order.TRADE_TYPE_GENERAL); allowed == nil {
+		t.Errorf("expected a general margin short entry to be sent")
+	}
+
+	// 4. 規制されていても保有中の建玉の返済は妨げない
+	nest := NewSniperNest("7203", sym, nil, nil)
+	exit := nest.ReconcileTarget(sniperID, tick.Tick{Price: 2500}, strategy.Position{Qty: -100}, strategy.TargetPosition{Qty: 0, OrderType: order.ORDER_TYPE_MARKET}, order.EXCHANGE_TOSHO, order.TRADE_TYPE_SYSTEM, order.ACCOUNT_SPECIAL, &strategy.NoopPolicy{})
+	if _, ok := exit.(OrderBullet); !ok {
+		t.Errorf("expected the exit order to be sent, got %+v", exit)
+	}
+}
+
+func TestSniperNest_ReconcileTarget_StopOrders(t *testing.T) {
+	sym := symbol.Symbol{Code: "7203", PriceLimits: symbol.NewPriceLimits(2500)} // 2000〜3000
+	sniperID := "sniper-1"
+	reconcile := func(nest *SniperNest, pos strategy.Position, target strategy.TargetPosition) Bullet {
+		return nest.ReconcileTarget(sniperID, tick.Tick{Price: 2500}, pos, target, order.EXCHANGE_TOSHO, order.TRADE_TYPE_SYSTEM, order.ACCOUNT_SPECIAL, &strategy.NoopPolicy{})
+	}
+
+	// 1. IFD の決済注文を逆指値にできる
+	bullet := reconcile(NewSniperNest("7203", sym, nil, nil), strategy.Position{}, strategy.TargetPosition{
+		Qty: 100, OrderType: order.ORDER_TYPE_MARKET,
+		HasIfDone: true, ExitOrderType: order.ORDER_TYPE_STOP, ExitStopPrice: 2400, ExitReason: "StopLoss",
+	})
+	ob, ok := bullet.(OrderBullet)
+	if !ok || ob.Order.IfDone == nil {
+		t.Fatalf("expected OrderBullet with an if-done exit, got %+v", bullet)
+	}
+	if exit := ob.Order.IfDone; exit.Type != order.ORDER_TYPE_STOP || exit.TriggerPrice != 2400 || exit.OrderPrice != 0 {
+		t.Errorf("unexpected stop exit: type=%v trigger=%v price=%v", exit.Type, exit.TriggerPrice, exit.OrderPrice)
+	}
+
+	// 2. 建玉の返済を逆指値（指値）で出す
+	nest := NewSniperNest("7203", sym, nil, nil)
+	long := strategy.Position{Qty: 100, AveragePrice: 2500}
+	stop := strategy.TargetPosition{Qty: 0, Price: 2390, OrderType: order.ORDER_TYPE_STOP_LIMIT, StopPrice: 2400, Reason: "StopLoss"}
+	ob, ok = reconcile(nest, long, stop).(OrderBullet)
+	if !ok || ob.Order.Action != order.ACTION_SELL || ob.Order.Type != order.ORDER_TYPE_STOP_LIMIT || ob.Order.TriggerPrice != 2400 || ob.Order.OrderPrice != 2390 {
+		t.Fatalf("expected a stop-limit sell exit, got %+v", ob.Order)
+	}
+	ob.Order.BypassTransition(order.ORDER_STATUS_IN_PROGRESS, order.STATE_ACTIVE)
+	nest.AddOrder(sniperID, ob.Order)
+
+	// 3. 同じトリガー価格なら出し直さず、トリガー価格が変わったら取り消して出し直す
+	if again := reconcile(nest, long, stop); again != nil {
+		t.Errorf("expected no re-order for the same stop, got %+v", again)
+	}
+	stop.StopPrice = 2450
+	if cb, ok := reconcile(nest, long, stop).(CancelBullet); !ok || cb.OrderID != ob.Order.ID {
+		t.Errorf("expected the stop to be cancelled for the new trigger price")
+	}
+
+	// 4. トリガー価格が値幅制限の範囲外の逆指値は発動し得ないため発注しない
+	outside := strategy.TargetPosition{Qty: 0, OrderType: order.ORDER_TYPE_STOP, StopPrice: 1900}
+	if rejected := reconcile(NewSniperNest("7203", sym, nil, nil), long, outside); rejected != nil {
+		t.Errorf("expected no stop order outside the limits, got %+v", rejected)
+	}
+}
+
+func TestSniperNest_ReconcileTarget_OCOBracket(t *testing.T) {
+	sym := symbol.Symbol{Code: "7203", PriceLimits: symbol.NewPriceLimits(2500)} // 2000〜3000
+	sniperID := "sniper-1"
+	reconcile := func(nest *SniperNest, pos strategy.Position, target strategy.TargetPosition) Bullet {
+		return nest.ReconcileTarget(sniperID, tick.Tick{Price: 2500}, pos, target, order.EXCHANGE_TOSHO, order.TRADE_TYPE_SYSTEM, order.ACCOUNT_SPECIAL, &strategy.NoopPolicy{})
+	}
+
+	// 1. IFD の利確の指値に、損切りの逆指値を OCO で組み合わせる
+	bullet := reconcile(NewSniperNest("7203", sym, nil, nil), strategy.Position{}, strategy.TargetPosition{
+		Qty: 100, OrderType: order.ORDER_TYPE_MARKET,
+		HasIfDone: true, ExitPrice: 2600, ExitOrderType: order.ORDER_TYPE_LIMIT, ExitReason: "TakeProfit",
+		HasOCO: true, OCOOrderType: order.ORDER_TYPE_STOP, OCOStopPrice: 2400, OCOReason: "StopLoss",
+	})
+	ob, ok := bullet.(OrderBullet)
+	if !ok || ob.Order.IfDone == nil || ob.Order.IfDone.OCO == nil {
+		t.Fatalf("expected an if-done exit with an OCO sibling, got %+v", bullet)
+	}
+	if sl := ob.Order.IfDone.OCO; sl.Action != order.ACTION_SELL || sl.Type != order.ORDER_TYPE_STOP || sl.TriggerPrice != 2400 ||
+		sl.OrderQty != 100 || sl.CashMargin != order.CASH_MARGIN_MARGIN_EXIT || sl.Reason != "StopLoss" {
+		t.Errorf("unexpected OCO stop leg: %+v", sl)
+	}
+
+	// 2. 建玉の返済を利確と損切りの OCO で出す
+	nest := NewSniperNest("7203", sym, nil, nil)
+	long := strategy.Position{Qty: 100, AveragePrice: 2500}
+	bracket := strategy.TargetPosition{
+		Qty: 0, Price: 2600, OrderType: order.ORDER_TYPE_LIMIT, Reason: "TakeProfit",
+		HasOCO: true, OCOOrderType: order.ORDER_TYPE_STOP, OCOStopPrice: 2400, OCOReason: "StopLoss",
+	}
+	ob, ok = reconcile(nest, long, bracket).(OrderBullet)
+	if !ok || ob.Order.OCO == nil || ob.Order.Action != order.ACTION_SELL || ob.Order.OCO.Action != order.ACTION_SELL {
+		t.Fatalf("expected a take-profit exit with an OCO stop, got %+v", ob.Order)
+	}
+
+	// ゲートウェイが組の識別子を付けて両方を発注し、相方も取り込まれた状態
+	tp, sl := ob.Order, ob.Order.OCO
+	tp.OCOGroupID, sl.OCOGroupID, sl.ID = "oco-1", "oco-1", "api-sl"
+	tp.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_ACTIVE)
+	sl.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_ACTIVE)
+	nest.AddOrder(sniperID, tp)
+	nest.AddOrder(sniperID, sl)
+
+	// 3. 同じ組なら出し直さない（返済予定は組で1回だけ数える）
+	if again := reconcile(nest, long, bracket); again != nil {
+		t.Errorf("expected no re-order for the same bracket, got %+v", again)
+	}
+
+	// 4. 損切りのトリガー価格が変わった場合や、OCO をやめた場合は組を取り消す
+	moved := bracket
+	moved.OCOStopPrice = 2450
+	if _, ok := reconcile(nest, long, moved).(CancelBullet); !ok {
+		t.Errorf("expected the bracket to be cancelled for the new stop price")
+	}
+	single := bracket
+	single.HasOCO = false
+	tp.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_ACTIVE)
+	sl.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_ACTIVE)
+	if _, ok := reconcile(nest, long, single).(CancelBullet); !ok {
+		t.Errorf("expected the bracket to be cancelled when the target drops the OCO leg")
+	}
+}
+
+func TestSniperNest_HandleTick_TrailingStop(t *testing.T) {
+	sym := symbol.Symbol{Code: "7203"}
+	sniperID := "sniper-1"
+	strat := &mockNestStrategy{
+		evaluateFn: func(input strategy.StrategyInput) strategy.TargetPosition {
+			// 保有中は建玉を維持し、1% のトレーリングストップを指定し続ける
+			return strategy.TargetPosition{Qty: input.HoldQty(), TrailType: strategy.TRAIL_TYPE_PERCENT, TrailWidth: 1}
+		},
+	}
+	newNest := func() *SniperNest {
+		s := NewSniper(sniperID, sym, strat, &strategy.NoopPolicy{}, order.EXCHANGE_TOSHO, nil)
+		return NewSniperNest("7203", sym, []*Sniper{s}, nil)
+	}
+	volume := 0.0
+	at := func(price float64) tick.Tick {
+		volume += 100
+		return tick.Tick{Symbol: "7203", Price: price, TradingVolume: volume, CurrentPriceTime: time.Now(), CurrentPriceStatus: tick.PRICE_STATUS_CURRENT}
+	}
+
+	// 1. 最高値は建玉ごとに追跡し、逆行幅を超えた建玉だけを成行で返済する
+	nest := newNest()
+	nest.positions.positions[sniperID] = []position.Position{
+		{ExecutionID: "exec-1", Symbol: "7203", Price: 2000, LeavesQty: 100, Action: order.ACTION_BUY},
+	}
+	if actions := nest.HandleTick(at(2100)); len(actions) != 0 {
+		t.Fatalf("expected no action while trailing, got %+v", actions)
+	}
+	// 後から建て増した建玉は取得価格から追跡を始める（最高値 2090、水準 2069.1）
+	nest.positions.positions[sniperID] = append(nest.positions.positions[sniperID],
+		position.Position{ExecutionID: "exec-2", Symbol: "7203", Price: 2090, LeavesQty: 100, Action: order.ACTION_BUY})
+
+	actions := nest.HandleTick(at(2078)) // exec-1 は最高値 2100 から 1% (2079) を割り込む
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got %+v", actions)
+	}
+	ob, ok := actions[0].Bullet.(OrderBullet)
+	if !ok {
+		t.Fatalf("expected an order bullet, got %+v", actions[0].Bullet)
+	}
+	if o := ob.Order; o.Action != order.ACTION_SELL || o.Type != order.ORDER_TYPE_MARKET || o.OrderQty != 100 ||
+		o.CashMargin != order.CASH_MARGIN_MARGIN_EXIT || o.Reason != "TrailingStop" ||
+		len(o.Request.ClosePositions) != 1 || o.Request.ClosePositions[0].HoldID != "exec-1" {
+		t.Errorf("unexpected trailing stop order: %+v", o)
+	}
+
+	// 返済注文が約定するまでは出し直さない
+	if actions := nest.HandleTick(at(2077)); len(actions) != 0 {
+		t.Errorf("expected no duplicate trailing stop, got %+v", actions)
+	}
+
+	// 2. 同じ建玉の利確の指値が残っている場合は、先に取り消す
+	nest = newNest()
+	nest.positions.positions[sniperID] = []position.Position{
+		{ExecutionID: "exec-1", Symbol: "7203", Price: 2000, LeavesQty: 100, Action: order.ACTION_BUY},
+	}
+	tp := order.NewOrder("tp-1", "7203", order.ACTION_SELL, 2200, 100,
+		order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT),
+		order.WithRequest(&order.OrderRequest{ClosePositions: []order.ClosePosition{{HoldID: "exec-1", Qty: 100}}}),
+		order.WithReason("TakeProfit"))
+	tp.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_ACTIVE)
+	nest.AddOrder(sniperID, tp)
+	nest.HandleTick(at(2100))
+	actions = nest.HandleTick(at(2070))
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got %+v", actions)
+	}
+	if cb, ok := actions[0].Bullet.(CancelBullet); !ok || cb.OrderID != "tp-1" {
+		t.Errorf("expected the take profit to be cancelled first, got %+v", actions[0].Bullet)
+	}
+
+	// 3. 売り建ては最安値から逆行したら買い戻す
+	nest = newNest()
+	nest.positions.positions[sniperID] = []position.Position{
+		{ExecutionID: "exec-s", Symbol: "7203", Price: 2000, LeavesQty: 100, Action: order.ACTION_SELL},
+	}
+	nest.HandleTick(at(1900))
+	actions = nest.HandleTick(at(1920)) // 最安値 1900 から 1% (1919) を超える
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got %+v", actions)
+	}
+	if ob, ok := actions[0].Bullet.(OrderBullet); !ok || ob.Order.Action != order.ACTION_BUY || ob.Order.Request.ClosePositions[0].HoldID != "exec-s" {
+		t.Errorf("expected a buy-to-cover trailing stop, got %+v", actions[0].Bullet)
+	}
+
+	// 4. 建玉も注文も無くなったら解除する
+	nest = newNest()
+	nest.trailing.Arm(sniperID, strategy.TargetPosition{TrailType: strategy.TRAIL_TYPE_PERCENT, TrailWidth: 1})
+	strat.evaluateFn = func(input strategy.StrategyInput) strategy.TargetPosition { return strategy.TargetPosition{} }
+	nest.HandleTick(at(2000))
+	if nest.trailing.IsArmed(sniperID) {
+		t.Error("expected the trailing stop to be disarmed when flat")
+	}
+
+	// 5. クールダウン中は返済注文を出さず、TRAILING_STOP も記録しない
+	strat.evaluateFn = func(input strategy.StrategyInput) strategy.TargetPosition {
+		return strategy.TargetPosition{Qty: input.HoldQty(), TrailType: strategy.TRAIL_TYPE_PERCENT, TrailWidth: 1}
+	}
+	var logs bytes.Buffer
+	s := NewSniper(sniperID, sym, strat, &strategy.NoopPolicy{}, order.EXCHANGE_TOSHO, nil)
+	nest = NewSniperNest("7203", sym, []*Sniper{s}, slog.New(slog.NewJSONHandler(&logs, nil)))
+	nest.positions.positions[sniperID] = []position.Position{
+		{ExecutionID: "exec-1", Symbol: "7203", Price: 2000, LeavesQty: 100, Action: order.ACTION_BUY},
+	}
+	nest.HandleTick(at(2100))
+	nest.cooldowns.Trigger(sniperID)
+	for _, price := range []float64{2078, 2077} {
+		if actions := nest.HandleTick(at(price)); len(actions) != 0 {
+			t.Errorf("expected no trailing stop while cooling down, got %+v", actions)
+		}
+	}
+	if strings.Contains(logs.String(), "TRAILING_STOP") {
+		t.Errorf("expected no TRAILING_STOP log while cooling down, got %s", logs.String())
+	}
+}
+
+func TestSniperNest_HandleTick_Sizing(t *testing.T) {
+	sym := symbol.Symbol{Code: "7203", TradingUnit: 100}
+	sniperID := "sniper-1"
+	stopDistance := 20.0
+	strat := &mockNestStrategy{
+		evaluateFn: func(input strategy.StrategyInput) strategy.TargetPosition {
+			if input.HoldQty() != 0 {
+				return strategy.TargetPosition{Qty: input.HoldQty()}
+			}
+			return strategy.TargetPosition{Qty: 100, Price: 2000, OrderType: order.ORDER_TYPE_LIMIT, StopDistance: stopDistance, Reason: "Entry"}
+		},
+	}
+	var logs bytes.Buffer
+	newNest := func() *SniperNest {
+		s := NewSniper(sniperID, sym, strat, &strategy.NoopPolicy{}, order.EXCHANGE_TOSHO, slog.New(slog.NewJSONHandler(&logs, nil)))
+		nest := NewSniperNest("7203", sym, []*Sniper{s}, nil)
+		nest.UseSizing(sizing.Params{Model: sizing.MODEL_FIXED_RISK, Risk: 10000}, nil)
+		return nest
+	}
+	at := func(price float64) tick.Tick {
+		return tick.Tick{Symbol: "7203", Price: price, CurrentPriceTime: time.Now()}
+	}
+
+	// 1. 新規建ての数量は、許容損失 10000円 / 損切り幅 20円 = 500株
+	nest := newNest()
+	actions := nest.HandleTick(at(2005))
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got %+v", actions)
+	}
+	if ob, ok := actions[0].Bullet.(OrderBullet); !ok || ob.Order.Action != order.ACTION_BUY || ob.Order.OrderQty != 500 {
+		t.Fatalf("expected a sized entry of 500 shares, got %+v", actions[0].Bullet)
+	}
+	if !strings.Contains(logs.String(), `"msg":"POSITION_SIZED"`) || !strings.Contains(logs.String(), `"risk":10000`) {
+		t.Errorf("expected the sizing decision to be logged, got %s", logs.String())
+	}
+
+	// 建て終わるまでは、損切り幅が変わっても数量を決め直さない
+	stopDistance = 10
+	if actions := nest.HandleTick(at(2004)); len(actions) != 0 {
+		t.Errorf("expected the sized entry to be kept, got %+v", actions)
+	}
+	if n := strings.Count(logs.String(), "POSITION_SIZED"); n != 1 {
+		t.Errorf("expected a single sizing log, got %d", n)
+	}
+
+	// 2. 損切り幅が分からなければ新規建てを見送る
+	logs.Reset()
+	stopDistance = 0
+	nest = newNest()
+	for _, price := range []float64{2005, 2004} {
+		if actions := nest.HandleTick(at(price)); len(actions) != 0 {
+			t.Errorf("expected the entry to be skipped, got %+v", actions)
+		}
+	}
+	if n := strings.Count(logs.String(), sizing.SKIP_NO_STOP_DISTANCE); n != 1 {
+		t.Errorf("expected the skip to be logged once, got %d: %s", n, logs.String())
+	}
+
+	// 3. 保有中の目標はサイジングしない
+	logs.Reset()
+	nest = newNest()
+	nest.positions.positions[sniperID] = []position.Position{
+		{ExecutionID: "exec-1", Symbol: "7203", Price: 2000, LeavesQty: 500, Action: order.ACTION_BUY},
+	}
+	if actions := nest.HandleTick(at(2010)); len(actions) != 0 {
+		t.Errorf("expected no action while holding, got %+v", actions)
+	}
+	if strings.Contains(logs.String(), "POSITION_SIZED") {
+		t.Errorf("expected no sizing while holding, got %s", logs.String())
+	}
+
+	// 4. 保有中も売買単位を返し続ける戦略は、サイジングした数量を保有し続ける
+	stopDistance = 20
+	strat.evaluateFn = func(input strategy.StrategyInput) strategy.TargetPosition {
+		return strategy.TargetPosition{Qty: 100, Price: 2000, OrderType: order.ORDER_TYPE_LIMIT, StopDistance: stopDistance, Reason: "Entry"}
+	}
+	nest = newNest()
+	actions = nest.HandleTick(at(2005))
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got %+v", actions)
+	}
+	if ob, ok := actions[0].Bullet.(OrderBullet); !ok || ob.Order.OrderQty != 500 {
+		t.Fatalf("expected a sized entry of 500 shares, got %+v", actions[0].Bullet)
+	}
+	nest.orders.activeOrders[sniperID] = nil
+	nest.positions.positions[sniperID] = []position.Position{
+		{ExecutionID: "exec-1", Symbol: "7203", Price: 2000, LeavesQty: 500, Action: order.ACTION_BUY},
+	}
+	for _, price := range []float64{2010, 1990} {
+		if actions := nest.HandleTick(at(price)); len(actions) != 0 {
+			t.Errorf("expected the sized position to be held, got %+v", actions)
+		}
+	}
+
+	// 手仕舞いの目標はそのまま返す
+	strat.evaluateFn = func(input strategy.StrategyInput) strategy.TargetPosition {
+		return strategy.TargetPosition{Reason: "Exit"}
+	}
+	actions = nest.HandleTick(at(2010))
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got %+v", actions)
+	}
+	if ob, ok := actions[0].Bullet.(OrderBullet); !ok || ob.Order.Action != order.ACTION_SELL || ob.Order.CashMargin != order.CASH_MARGIN_MARGIN_EXIT || ob.Order.OrderQty != 500 {
+		t.Errorf("expected an exit of 500 shares, got %+v", actions[0].Bullet)
+	}
+}
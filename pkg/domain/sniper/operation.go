@@ -2,6 +2,8 @@ package sniper
 
 import (
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/sizing"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
 )
 
@@ -15,6 +17,11 @@ type Operation interface {
 	HandleTick(t tick.Tick) []FireAction
 	UpdateOrders(report order.Orders)
 	ForceExit()
+	OrderlyExit()
+	IsFlat() bool
+	Close() error
+	Reconfigure(sniperID string, params interface{}, policy strategy.ExecutionPolicy) error
+	UpdateSizing(params *sizing.Params, pool tick.DataPool) error
 	GetActiveOrders() []*order.Order
 	GetReportableTargets() []ReportableTarget
 
@@ -167,10 +167,12 @@ func (ot *OrderTracker) Update(report order.Orders, detail symbol.Symbol, now ti
 								o.IfDone.OrderPrice,
 								ext.OrderQty,
 								order.WithType(o.IfDone.Type),
+								order.WithTriggerPrice(o.IfDone.TriggerPrice),
 								order.WithCashMargin(o.IfDone.CashMargin),
 								order.WithRequest(ext.Request),
 								order.WithReason(o.IfDone.Reason),
 							)
+							matchedChild.OCOGroupID = ext.OCOGroupID
 							matchedChild.BypassTransition(ext.Status(), order.STATE_ACTIVE)
 							ot.activeOrders[sniperID] = append(ot.activeOrders[sniperID], matchedChild)
 							orders = ot.activeOrders[sniperID]
@@ -189,6 +191,52 @@ func (ot *OrderTracker) Update(report order.Orders, detail symbol.Symbol, now ti
 			}
 		}
 
+		// OCO の相方（ゲートウェイが対で発注した注文や、相方の一部約定に合わせて出し直した注文）を取り込む。
+		// IFD 子注文の相方は親の約定と同時に発注されるため、未昇格の IfDone の組も対象にする
+		ocoGroups := make(map[string]bool)
+		for _, o := range orders {
+			if o.OCOGroupID != "" {
+				ocoGroups[o.OCOGroupID] = true
+			}
+			if o.IfDone != nil && o.IfDone.OCOGroupID != "" {
+				ocoGroups[o.IfDone.OCOGroupID] = true
+			}
+		}
+		for i, ext := range untrackedAPIOrders {
+			// IFD 子注文そのもの（ParentOrderID 付き）は上の IFD の照合と昇格で扱う
+			if ext == nil || !ocoGroups[ext.OCOGroupID] || ext.ParentOrderID != "" {
+				continue
+			}
+			if ot.logger != nil {
+				ot.logger.Info("🎯 [ID_RESOLVED] OCO の相方の発注を検知しました",
+					slog.String("sniper", sniperID),
+					slog.String("group", ext.OCOGroupID),
+					slog.Float64("qty", ext.OrderQty),
+					slog.String("serverID", ext.ID),
+				)
+			}
+			leg := order.NewOrder(
+				ext.ID,
+				ext.Symbol,
+				ext.Action,
+				ext.OrderPrice,
+				ext.OrderQty,
+				order.WithType(ext.Type),
+				order.WithTriggerPrice(ext.TriggerPrice),
+				order.WithCashMargin(ext.CashMargin),
+				order.WithRequest(ext.Request),
+				order.WithReason(ext.Reason),
+			)
+			leg.OCOGroupID = ext.OCOGroupID
+			leg.CreatedAt = ext.CreatedAt
+			leg.BypassTransition(ext.Status(), order.STATE_ACTIVE)
+			ot.activeOrders[sniperID] = append(ot.activeOrders[sniperID], leg)
+			orders = ot.activeOrders[sniperID]
+
+			untrackedAPIOrders[i] = nil
+			allTrackedIDs[ext.ID] = true
+		}
+
 		// 1. Identify which tombstone orders should be resurrected (those that actually exist in the API report)
 		var resurrected []*order.Order
 		resurrectedMap := make(map[string]bool)
@@ -483,6 +531,8 @@ func (ot *OrderTracker) GetInflightStats(sniperID string) InflightStats {
 		}
 	}
 
+	ocoLegs := make(map[string][]*order.Order)
+	ocoFillExpected := make(map[string]bool)
 	for _, o := range orders {
 		if o == nil {
 			continue
@@ -521,7 +571,13 @@ func (ot *OrderTracker) GetInflightStats(sniperID string) InflightStats {
 		}
 
 		// Sum up inflight quantities (excluding orders expected to fill synthetically as they are already accounted for)
-		if !o.IsFillExpected() {
+		if o.OCOGroupID != "" && o.CashMargin == order.CASH_MARGIN_MARGIN_EXIT {
+			// Only one leg of an OCO group fills, so the group is counted once after the loop
+			ocoLegs[o.OCOGroupID] = append(ocoLegs[o.OCOGroupID], o)
+			if o.IsFillExpected() {
+				ocoFillExpected[o.OCOGroupID] = true
+			}
+		} else if !o.IsFillExpected() {
 			if o.CashMargin == order.CASH_MARGIN_MARGIN_ENTRY {
 				if o.Action == order.ACTION_BUY {
 					stats.InflightBuyEntry += o.OrderQty
@@ -548,6 +604,18 @@ func (ot *OrderTracker) GetInflightStats(sniperID string) InflightStats {
 			}
 		}
 	}
+
+	for groupID, legs := range ocoLegs {
+		if ocoFillExpected[groupID] {
+			continue
+		}
+		remaining := order.OCORemainingQty(legs)
+		if legs[0].Action == order.ACTION_BUY {
+			stats.InflightBuyExit += remaining
+		} else if legs[0].Action == order.ACTION_SELL {
+			stats.InflightSellExit += remaining
+		}
+	}
 	return stats
 }
 
@@ -527,3 +527,72 @@ func TestOrderTracker_Update_PreservesChildRequest(t *testing.T) {
 	}
 }
 
+
+func TestOrderTracker_Update_OCOBracket(t *testing.T) {
+	ot := sniper.NewOrderTracker(nil)
+	sniperID := "test-sniper"
+	sym := symbol.Symbol{Code: "7203"}
+
+	// 新規建てが約定し、利確の指値と損切りの逆指値が OCO の組で IFD 発注された状態
+	parent := order.NewOrder("parent-1", "7203", order.ACTION_BUY, 2000, 100, order.WithCashMargin(order.CASH_MARGIN_MARGIN_ENTRY))
+	parent.BypassTransition(order.ORDER_STATUS_IN_PROGRESS, order.STATE_ACTIVE)
+	takeProfit := order.NewOrder("tp-temp", "7203", order.ACTION_SELL, 2050, 100, order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT), order.WithReason("TakeProfit"))
+	takeProfit.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_PENDING)
+	takeProfit.OCO = order.NewOrder("sl-temp", "7203", order.ACTION_SELL, 0, 100, order.WithType(order.ORDER_TYPE_STOP), order.WithTriggerPrice(1980))
+	parent.IfDone = takeProfit
+	ot.Add(sniperID, parent)
+
+	apiParent := *parent
+	apiParent.IfDone = nil
+	apiParent.AddExecution(order.Execution{ID: "exec-1", Price: 2000, Qty: 100})
+	apiParent.BypassTransition(order.ORDER_STATUS_FILLED, order.STATE_CLOSED)
+
+	closeAll := &order.OrderRequest{ClosePositions: []order.ClosePosition{{HoldID: "exec-1", Qty: 100}}}
+	apiTP := order.NewOrder("api-tp", "7203", order.ACTION_SELL, 2050, 100, order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT), order.WithRequest(closeAll))
+	apiTP.ParentOrderID = "parent-1"
+	apiTP.OCOGroupID = "oco-1"
+	apiTP.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_ACTIVE)
+	apiSL := order.NewOrder("api-sl", "7203", order.ACTION_SELL, 0, 100, order.WithType(order.ORDER_TYPE_STOP), order.WithTriggerPrice(1980),
+		order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT), order.WithRequest(closeAll), order.WithReason("StopLoss"))
+	apiSL.OCOGroupID = "oco-1"
+	apiSL.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_ACTIVE)
+
+	noop := func(string, order.Execution, order.Action, time.Time, *order.Order) {}
+	ot.Update(order.Orders{Orders: []order.Order{apiParent, *apiTP, *apiSL}}, sym, time.Now(), noop)
+
+	// 利確は IFD 子注文として、損切りは組の識別子で取り込まれる
+	active := ot.GetActive(sniperID)
+	legs := order.ActiveOrders(active).OCOLegs("oco-1")
+	if len(legs) != 2 {
+		t.Fatalf("expected both OCO legs to be tracked, got %d (active: %d)", len(legs), len(active))
+	}
+	for _, leg := range legs {
+		if leg.ID == "api-sl" && (leg.Type != order.ORDER_TYPE_STOP || leg.TriggerPrice != 1980 || leg.Reason != "StopLoss") {
+			t.Errorf("unexpected stop leg: %+v", leg)
+		}
+	}
+
+	// 2本の返済注文があっても、返済予定の数量は組で1回だけ数える
+	if stats := ot.GetInflightStats(sniperID); stats.InflightSellExit != 100 {
+		t.Errorf("expected inflight sell exit 100, got %v", stats.InflightSellExit)
+	}
+
+	// 利確が40株約定し、ゲートウェイが損切りを残りの60株で出し直した
+	apiTP.AddExecution(order.Execution{ID: "exec-tp-1", Price: 2050, Qty: 40})
+	apiTP.BypassTransition(order.ORDER_STATUS_IN_PROGRESS, order.STATE_ACTIVE)
+	apiSL.BypassTransition(order.ORDER_STATUS_CANCELED, order.STATE_CLOSED)
+	apiSL2 := *apiSL
+	apiSL2.ID = "api-sl-2"
+	apiSL2.OrderQty = 60
+	apiSL2.Request = &order.OrderRequest{ClosePositions: []order.ClosePosition{{HoldID: "exec-1", Qty: 60}}}
+	apiSL2.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_ACTIVE)
+	ot.Update(order.Orders{Orders: []order.Order{apiParent, *apiTP, *apiSL, apiSL2}}, sym, time.Now(), noop)
+	ot.PrepareActiveOrders(sniperID, tick.Tick{}, nil)
+
+	if legs := order.ActiveOrders(ot.GetActive(sniperID)).OCOLegs("oco-1"); len(legs) != 2 {
+		t.Fatalf("expected the partially filled leg and the resized leg, got %d", len(legs))
+	}
+	if stats := ot.GetInflightStats(sniperID); stats.InflightSellExit != 60 {
+		t.Errorf("expected inflight sell exit 60 after the partial fill, got %v", stats.InflightSellExit)
+	}
+}
@@ -1,15 +1,19 @@
 package sniper
 
 import (
+	"errors"
 	"log/slog"
 	"math"
 	"sync"
 	"time"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/sizing"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/composite"
 )
 
 // InstructionStrategy は、司令官 (Commander) からの指示をそのまま実行する Sniper 用の戦略実装です。
@@ -53,11 +57,19 @@ type PairTradingOperation struct {
 	strategyA          *InstructionStrategy
 	strategyB          *InstructionStrategy
 	dataPool           tick.DataPool
-	thresholdPriceDiff float64 // スプレッドの閾値
-	tradeQty           float64 // 取引数量
+	spread             *composite.Spread // 始値基準の正規化スプレッド（A − B）
+	paramsMu           sync.Mutex        // 閾値・数量の稼働中の差し替えを保護する
+	thresholdPriceDiff float64           // スプレッドの閾値
+	tradeQty           float64           // 取引数量
 	logger             *slog.Logger
 }
 
+// PairParams はペアトレードで稼働中に差し替えられるパラメータです（operations.json の threshold・qty）
+type PairParams struct {
+	Threshold float64
+	Qty       float64
+}
+
 func NewPairTradingOperation(
 	id string,
 	nestA *SniperNest,
@@ -79,6 +91,7 @@ func NewPairTradingOperation(
 		strategyA:          strategyA,
 		strategyB:          strategyB,
 		dataPool:           dataPool,
+		spread:             composite.GetOrCreateSpread(dataPool, nestA.SymbolCode, nestB.SymbolCode),
 		thresholdPriceDiff: threshold,
 		tradeQty:           qty,
 		logger:             logger,
@@ -114,48 +127,39 @@ func (o *PairTradingOperation) GetExchanges() []order.ExchangeMarket {
 	return list
 }
 
+// isAllowedTimeForEntry は、寄付直後と引け前の値の荒れやすい時間帯を避けて新規エントリーを許可します。
+// 前場は寄付から30分経過後、後場は寄付から15分経過後かつクロージング・オークションまで40分以上ある間のみ許可します
+// （通常日は 09:30〜11:30 / 12:45〜14:45、休場日・半日立会の後場は不可）。
 func (o *PairTradingOperation) isAllowedTimeForEntry(t time.Time) bool {
-	// 日本時間 (Asia/Tokyo) に統一して時間判定する
-	loc, err := time.LoadLocation("Asia/Tokyo")
-	if err == nil {
-		t = t.In(loc)
+	sess, elapsed, remaining, ok := session.Default().ContinuousWindow(t)
+	if !ok {
+		return false
 	}
-
-	hm := t.Hour()*100 + t.Minute()
-
-	// 前場安定期: 09:30 〜 11:30
-	// 後場安定期: 12:45 〜 14:45
-	if (hm >= 930 && hm < 1130) || (hm >= 1245 && hm < 1445) {
-		return true
+	switch sess {
+	case session.SESSION_MORNING:
+		return elapsed >= 30*time.Minute
+	case session.SESSION_AFTERNOON:
+		return elapsed >= 15*time.Minute && remaining > 40*time.Minute
 	}
 	return false
 }
 
 func (o *PairTradingOperation) HandleTick(t tick.Tick) []FireAction {
-	// 1. 最新価格の取得
-	stateA := o.dataPool.GetState(o.nestA.SymbolCode)
-	stateB := o.dataPool.GetState(o.nestB.SymbolCode)
-
-	if stateA.LatestTick.CurrentPriceTime.IsZero() || stateB.LatestTick.CurrentPriceTime.IsZero() {
+	// 1. 最新価格とスプレッドの取得（スプレッドは DataPool 上の複数銘柄指標が両銘柄の Tick から更新している）
+	if !o.spread.Ready() {
 		return nil
 	}
+	stateA := o.dataPool.GetState(o.nestA.SymbolCode)
+	stateB := o.dataPool.GetState(o.nestB.SymbolCode)
 
-	priceA := stateA.LatestTick.Price
-	priceB := stateB.LatestTick.Price
-
+	priceA, priceB := o.spread.PriceA(), o.spread.PriceB()
 	// 始値（OpeningPrice）を基準価格とする。未設定の場合は最新価格でフォールバック。
-	openA := stateA.LatestTick.OpeningPrice
-	if openA == 0 {
-		openA = priceA
-	}
-	openB := stateB.LatestTick.OpeningPrice
-	if openB == 0 {
-		openB = priceB
-	}
+	openA, openB := o.spread.BaseA(), o.spread.BaseB()
+	priceDiff := o.spread.Value()
 
-	normA := priceA / openA
-	normB := priceB / openB
-	priceDiff := normA - normB
+	o.paramsMu.Lock()
+	threshold, tradeQty := o.thresholdPriceDiff, o.tradeQty
+	o.paramsMu.Unlock()
 
 	o.logger.Info("PAIR_SPREAD_MONITOR",
 		slog.String("operation", o.ID),
@@ -170,42 +174,44 @@ func (o *PairTradingOperation) HandleTick(t tick.Tick) []FireAction {
 
 	var actions []FireAction
 
-	// 3. 金額等価になるように数量をスケーリング (100株単位に丸める)
-	qtyA_scaled := o.tradeQty
-	qtyB_scaled := o.tradeQty
+	// 3. 金額等価になるように数量をスケーリングし、各銘柄の売買単位に切り捨てる
+	qtyA_scaled := tradeQty
+	qtyB_scaled := tradeQty
 
 	if openA < openB {
-		ratio := openB / openA
-		qtyA_scaled = math.Round(o.tradeQty*ratio/100.0) * 100.0
-		if qtyA_scaled < 100.0 {
-			qtyA_scaled = 100.0
-		}
+		qtyA_scaled = tradeQty * openB / openA
 	} else {
-		ratio := openA / openB
-		qtyB_scaled = math.Round(o.tradeQty*ratio/100.0) * 100.0
-		if qtyB_scaled < 100.0 {
-			qtyB_scaled = 100.0
-		}
+		qtyB_scaled = tradeQty * openA / openB
 	}
+	qtyA_scaled = o.nestA.Detail.RoundQty(qtyA_scaled)
+	qtyB_scaled = o.nestB.Detail.RoundQty(qtyB_scaled)
 
 	// 4. 取引シグナルの生成
 	if qtyA == 0 && qtyB == 0 {
 		// ノーポジションのとき、スプレッド乖離を判定してエントリー
 		// 新規エントリー時のみ時間帯フィルターを適用する
-		if o.isAllowedTimeForEntry(stateA.LatestTick.CurrentPriceTime) {
-			if priceDiff > o.thresholdPriceDiff {
+		if qtyA_scaled == 0 || qtyB_scaled == 0 {
+			// 片方の銘柄が売買単位に満たない場合は、片側だけ建たないようにエントリーを見送る
+			if math.Abs(priceDiff) > threshold {
+				o.logger.Warn("PAIR_ENTRY_SKIPPED_BELOW_UNIT",
+					slog.Float64("qty_a", qtyA_scaled),
+					slog.Float64("qty_b", qtyB_scaled),
+				)
+			}
+		} else if o.isAllowedTimeForEntry(stateA.LatestTick.CurrentPriceTime) {
+			if priceDiff > threshold {
 				o.logger.Warn("PAIR_ENTRY_SIGNAL_DETECTED", slog.String("reason", "spread_exceeded_positive_threshold"))
 				// 銘柄Aを売り、銘柄Bを買う
 				o.strategyA.SetTarget(strategy.TargetPosition{Qty: -qtyA_scaled, Price: 0.0, OrderType: order.ORDER_TYPE_MARKET, Reason: "PairEntry_SellA"})
 				o.strategyB.SetTarget(strategy.TargetPosition{Qty: qtyB_scaled, Price: 0.0, OrderType: order.ORDER_TYPE_MARKET, Reason: "PairEntry_BuyB"})
-			} else if priceDiff < -o.thresholdPriceDiff {
+			} else if priceDiff < -threshold {
 				o.logger.Warn("PAIR_ENTRY_SIGNAL_DETECTED", slog.String("reason", "spread_exceeded_negative_threshold"))
 				// 銘柄Aを買い、銘柄Bを売る
 				o.strategyA.SetTarget(strategy.TargetPosition{Qty: qtyA_scaled, Price: 0.0, OrderType: order.ORDER_TYPE_MARKET, Reason: "PairEntry_BuyA"})
 				o.strategyB.SetTarget(strategy.TargetPosition{Qty: -qtyB_scaled, Price: 0.0, OrderType: order.ORDER_TYPE_MARKET, Reason: "PairEntry_SellB"})
 			}
 		} else {
-			if math.Abs(priceDiff) > o.thresholdPriceDiff {
+			if math.Abs(priceDiff) > threshold {
 				o.logger.Info("PAIR_ENTRY_SKIPPED_BY_TIME_FILTER",
 					slog.String("reason", "outside_golden_time_windows"),
 					slog.Time("tick_time", stateA.LatestTick.CurrentPriceTime),
@@ -215,7 +221,7 @@ func (o *PairTradingOperation) HandleTick(t tick.Tick) []FireAction {
 	} else {
 		// ポジションを保有している場合、平均回帰したら手仕舞い（利確/損切）
 		// スプレッドの絶対値が元の閾値の10%未満に収束したら決済
-		if math.Abs(priceDiff) < o.thresholdPriceDiff*0.1 {
+		if math.Abs(priceDiff) < threshold*0.1 {
 			o.logger.Warn("PAIR_EXIT_SIGNAL_DETECTED", slog.String("reason", "spread_reverted_to_mean"))
 			o.strategyA.SetTarget(strategy.TargetPosition{Qty: 0.0, Price: 0.0, OrderType: order.ORDER_TYPE_MARKET, Reason: "PairExit_FlatA"})
 			o.strategyB.SetTarget(strategy.TargetPosition{Qty: 0.0, Price: 0.0, OrderType: order.ORDER_TYPE_MARKET, Reason: "PairExit_FlatB"})
@@ -242,6 +248,41 @@ func (o *PairTradingOperation) ForceExit() {
 	o.nestB.ForceExit()
 }
 
+// OrderlyExit は両銘柄のスナイパーに撤収を命じます（両建ての建玉は成行で手仕舞われます）
+func (o *PairTradingOperation) OrderlyExit() {
+	o.nestA.OrderlyExit()
+	o.nestB.OrderlyExit()
+}
+
+// IsFlat は両銘柄とも保有建玉も未完了の注文も無いかを返します
+func (o *PairTradingOperation) IsFlat() bool {
+	return o.nestA.IsFlat() && o.nestB.IsFlat()
+}
+
+// Close は両銘柄のスナイパーの戦略を閉じます
+func (o *PairTradingOperation) Close() error {
+	return errors.Join(o.nestA.Close(), o.nestB.Close())
+}
+
+// Reconfigure はペアトレードの閾値・数量（PairParams）を差し替えます。作戦全体のパラメータのため sniperID・policy は使いません。
+// 建玉には触れず、新しい閾値は次の Tick の判定から、数量は次の新規エントリーから使います。
+func (o *PairTradingOperation) Reconfigure(sniperID string, params interface{}, policy strategy.ExecutionPolicy) error {
+	p, ok := params.(PairParams)
+	if !ok {
+		return ErrNotReconfigurable
+	}
+	o.paramsMu.Lock()
+	defer o.paramsMu.Unlock()
+	o.thresholdPriceDiff = p.Threshold
+	o.tradeQty = p.Qty
+	return nil
+}
+
+// UpdateSizing はペアトレードでは未対応です（数量は作戦の qty で決まります）
+func (o *PairTradingOperation) UpdateSizing(params *sizing.Params, pool tick.DataPool) error {
+	return ErrNotReconfigurable
+}
+
 func (o *PairTradingOperation) GetActiveOrders() []*order.Order {
 	var all []*order.Order
 	all = append(all, o.nestA.GetActiveOrders()...)
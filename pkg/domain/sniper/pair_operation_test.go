@@ -1,12 +1,14 @@
 package sniper
 
 import (
+	"errors"
 	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
 	"github.com/r-umemoto/trading-bot/pkg/domain/position"
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
@@ -299,6 +301,9 @@ func TestPairTradingOperation_InterfaceMethods(t *testing.T) {
 	if len(o.GetActiveOrders()) != 1 {
 		t.Error("expected 1 active order")
 	}
+	if o.IsFlat() {
+		t.Error("expected IsFlat to be false while an order is active")
+	}
 
 	o.UpdateOrders(order.Orders{
 		Orders: []order.Order{*ord},
@@ -347,6 +352,22 @@ func TestPairTradingOperation_InterfaceMethods(t *testing.T) {
 		t.Error("expected zero pnl")
 	}
 
+	// IsFlat
+	if !o.IsFlat() {
+		t.Error("expected IsFlat to be true without positions and orders")
+	}
+
+	// Reconfigure
+	if err := o.Reconfigure("", PairParams{Threshold: 0.02, Qty: 200}, nil); err != nil {
+		t.Errorf("expected pair params to be reconfigured, got %v", err)
+	}
+	if o.thresholdPriceDiff != 0.02 || o.tradeQty != 200 {
+		t.Errorf("unexpected params after Reconfigure: threshold=%v qty=%v", o.thresholdPriceDiff, o.tradeQty)
+	}
+	if err := o.Reconfigure("sniper-a", map[string]interface{}{"threshold": 0.03}, nil); !errors.Is(err, ErrNotReconfigurable) {
+		t.Errorf("expected ErrNotReconfigurable for non pair params, got %v", err)
+	}
+
 	// ForceExit
 	o.ForceExit()
 	if sniperA.GetLifecycle() != LifecycleStopped || sniperB.GetLifecycle() != LifecycleStopped {
@@ -478,14 +499,14 @@ func TestPairTradingOperation_HandleTick_ScalingAndRounding(t *testing.T) {
 		}
 	})
 
-	t.Run("openA < openB with low qty boundary correction", func(t *testing.T) {
+	t.Run("openA < openB below one unit skips the entry", func(t *testing.T) {
 		// tradeQty = 10.0
 		o := NewPairTradingOperation("test-pair", nestA, nestB, stratA, stratB, dataPool, 0.01, 10.0, nil)
 
 		timeAllowed, _ := time.ParseInLocation(time.RFC3339, "2026-06-01T10:00:00+09:00", loc)
 		// openA (900) < openB (1000)
 		// ratio = 1000 / 900 = 1.111
-		// qtyA_scaled = round(10 * 1.111 / 100) * 100 = 0 -> corrected to 100.
+		// qtyA_scaled = floor(10 * 1.111 / 100) * 100 = 0 -> 売買単位に満たないため両方とも建てない
 		tickA := tick.Tick{Symbol: "7203", Price: 918.0, CurrentPriceTime: timeAllowed, OpeningPrice: 900.0} // normA = 918 / 900 = 1.02
 		tickB := tick.Tick{Symbol: "7267", Price: 1000.0, CurrentPriceTime: timeAllowed, OpeningPrice: 1000.0} // normB = 1000 / 1000 = 1.0
 		dataPool.PushTick(tickA)
@@ -498,30 +519,19 @@ func TestPairTradingOperation_HandleTick_ScalingAndRounding(t *testing.T) {
 		nestA.orders.activeOrders["sniper-a"] = nil
 		nestB.orders.activeOrders["sniper-b"] = nil
 
-		actions := o.HandleTick(tickA)
-		if len(actions) != 2 {
-			t.Fatalf("expected 2 actions, got %d", len(actions))
-		}
-
-		for _, act := range actions {
-			if b, ok := act.Bullet.(OrderBullet); ok {
-				if act.SniperID == "sniper-a" {
-					if b.Order.OrderQty != 100.0 {
-						t.Errorf("expected corrected qtyA to be 100.0, got %f", b.Order.OrderQty)
-					}
-				}
-			}
+		if actions := o.HandleTick(tickA); len(actions) != 0 {
+			t.Fatalf("expected the entry to be skipped, got %d actions", len(actions))
 		}
 	})
 
-	t.Run("openA >= openB with low qty boundary correction", func(t *testing.T) {
-		// tradeQty = 10.0
-		o := NewPairTradingOperation("test-pair", nestA, nestB, stratA, stratB, dataPool, 0.01, 10.0, nil)
+	t.Run("openA >= openB floors the scaled leg to the unit", func(t *testing.T) {
+		// tradeQty = 100.0
+		o := NewPairTradingOperation("test-pair", nestA, nestB, stratA, stratB, dataPool, 0.01, 100.0, nil)
 
 		timeAllowed, _ := time.ParseInLocation(time.RFC3339, "2026-06-01T10:00:00+09:00", loc)
 		// openA (1000) >= openB (900)
 		// ratio = 1000 / 900 = 1.111
-		// qtyB_scaled = round(10 * 1.111 / 100) * 100 = 0 -> corrected to 100.
+		// qtyB_scaled = floor(100 * 1.111 / 100) * 100 = 100（切り上げない）
 		tickA := tick.Tick{Symbol: "7203", Price: 1020.0, CurrentPriceTime: timeAllowed, OpeningPrice: 1000.0} // normA = 1.02
 		tickB := tick.Tick{Symbol: "7267", Price: 900.0, CurrentPriceTime: timeAllowed, OpeningPrice: 900.0} // normB = 1.0
 		dataPool.PushTick(tickA)
@@ -542,7 +552,7 @@ func TestPairTradingOperation_HandleTick_ScalingAndRounding(t *testing.T) {
 			if b, ok := act.Bullet.(OrderBullet); ok {
 				if act.SniperID == "sniper-b" {
 					if b.Order.OrderQty != 100.0 {
-						t.Errorf("expected corrected qtyB to be 100.0, got %f", b.Order.OrderQty)
+						t.Errorf("expected qtyB to be floored to 100.0, got %f", b.Order.OrderQty)
 					}
 				}
 			}
@@ -550,3 +560,29 @@ func TestPairTradingOperation_HandleTick_ScalingAndRounding(t *testing.T) {
 	})
 }
 
+
+func TestPairTradingOperation_IsAllowedTimeForEntry_FollowsCalendar(t *testing.T) {
+	cal := session.NewCalendar()
+	cal.AddHoliday(time.Date(2026, 7, 20, 0, 0, 0, 0, session.Location()), "海の日")
+	cal.AddHalfDay(time.Date(2026, 12, 30, 0, 0, 0, 0, session.Location()), "")
+	session.SetDefault(session.NewMarket(cal, session.DefaultSchedule()))
+	defer session.SetDefault(nil)
+
+	o := &PairTradingOperation{}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, session.Location())
+	}
+
+	if o.isAllowedTimeForEntry(at(7, 20, 10, 0)) {
+		t.Errorf("expected no entry on a holiday")
+	}
+	if o.isAllowedTimeForEntry(at(6, 6, 10, 0)) {
+		t.Errorf("expected no entry on a Saturday")
+	}
+	if !o.isAllowedTimeForEntry(at(12, 30, 10, 0)) {
+		t.Errorf("expected entry in the morning of a half day")
+	}
+	if o.isAllowedTimeForEntry(at(12, 30, 13, 0)) {
+		t.Errorf("expected no entry in the afternoon of a half day")
+	}
+}
@@ -0,0 +1,256 @@
+// Package sizing は戦略の新規建ての意図（売買方向と損切りまでの値幅）から、建てる数量を決めるサイジングを提供します。
+// 作戦ごとに operations.json の params.sizing で設定し、SniperNest が Sniper.Evaluate の目標を発注前に置き換えます。
+// 数量は売買単位の倍数に切り捨て、許容する損失や建玉金額を超えないようにします。
+package sizing
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/technical"
+)
+
+// Model は数量の決め方です
+type Model string
+
+const (
+	MODEL_FIXED_NOTIONAL   Model = "fixed_notional"   // 1回の建玉金額を一定にする
+	MODEL_FIXED_RISK       Model = "fixed_risk"       // 損切りまでの損失額を一定にする（損切り幅は戦略の目標から求める）
+	MODEL_ATR              Model = "atr"              // ATR×倍率を損切り幅とみなし、損失額を一定にする（値動きが大きいほど小さく建てる）
+	MODEL_CAPITAL_FRACTION Model = "capital_fraction" // 作戦に割り当てた資金の一定割合で建てる
+)
+
+// 数量を決められずに新規建てを見送った理由です
+const (
+	SKIP_NO_PRICE         = "NO_PRICE"         // 建てる価格の目安が無い
+	SKIP_NO_STOP_DISTANCE = "NO_STOP_DISTANCE" // 戦略の目標から損切り幅が分からない（fixed_risk）
+	SKIP_ATR_NOT_READY    = "ATR_NOT_READY"    // ATR の計算に必要な足が揃っていない（atr）
+	SKIP_BELOW_UNIT       = "BELOW_UNIT"       // 計算した数量が売買単位に満たない
+)
+
+// Params はサイジングの設定です（operations.json の params.sizing）
+type Params struct {
+	Model       Model         `json:"model"`
+	Notional    float64       `json:"notional"`     // fixed_notional: 1回の建玉金額（円）
+	Risk        float64       `json:"risk"`         // fixed_risk / atr: 1回の取引で許容する損失（円）
+	ATRMultiple float64       `json:"atr_multiple"` // atr: 損切り幅とみなす ATR の倍率
+	ATRPeriod   int           `json:"atr_period"`   // atr: ATR の期間（足の本数）
+	ATRBar      time.Duration `json:"atr_bar"`      // atr: ATR の計算に使う時間足
+	Capital     float64       `json:"capital"`      // capital_fraction: 作戦に割り当てた資金（円）
+	Fraction    float64       `json:"fraction"`     // capital_fraction: 1回に使う資金の割合
+	MaxQty      float64       `json:"max_qty"`      // 数量の上限（株）。0 なら制限しない
+	MaxNotional float64       `json:"max_notional"` // 建玉金額の上限（円）。0 なら制限しない
+}
+
+var paramSchema = strategy.ParamSchema{
+	Fields: []strategy.ParamField{
+		{Name: "model", Type: strategy.PARAM_TYPE_STRING, Required: true, Choices: []string{string(MODEL_FIXED_NOTIONAL), string(MODEL_FIXED_RISK), string(MODEL_ATR), string(MODEL_CAPITAL_FRACTION)}, Description: "数量の決め方"},
+		{Name: "notional", Type: strategy.PARAM_TYPE_FLOAT, Default: 0.0, Min: strategy.Bound(0), Description: "1回の建玉金額（円）"},
+		{Name: "risk", Type: strategy.PARAM_TYPE_FLOAT, Default: 0.0, Min: strategy.Bound(0), Description: "1回の取引で許容する損失（円）"},
+		{Name: "atr_multiple", Type: strategy.PARAM_TYPE_FLOAT, Default: 2.0, Min: strategy.Bound(0.1), Max: strategy.Bound(20), Description: "損切り幅とみなす ATR の倍率"},
+		{Name: "atr_period", Type: strategy.PARAM_TYPE_INT, Default: 14, Min: strategy.Bound(1), Max: strategy.Bound(500), Description: "ATR の期間"},
+		{Name: "atr_bar", Type: strategy.PARAM_TYPE_DURATION, Default: "1m", Min: strategy.Bound(1), Description: "ATR の計算に使う時間足"},
+		{Name: "capital", Type: strategy.PARAM_TYPE_FLOAT, Default: 0.0, Min: strategy.Bound(0), Description: "作戦に割り当てた資金（円）"},
+		{Name: "fraction", Type: strategy.PARAM_TYPE_FLOAT, Default: 0.0, Min: strategy.Bound(0), Max: strategy.Bound(1), Description: "1回に使う資金の割合"},
+		{Name: "max_qty", Type: strategy.PARAM_TYPE_FLOAT, Default: 0.0, Min: strategy.Bound(0), Description: "数量の上限（株）"},
+		{Name: "max_notional", Type: strategy.PARAM_TYPE_FLOAT, Default: 0.0, Min: strategy.Bound(0), Description: "建玉金額の上限（円）"},
+	},
+	New: func() interface{} { return &Params{} },
+}
+
+// Resolve は default 作戦の params から sizing の設定を検証して取り出します。指定が無い場合は nil を返します。
+// path は作戦の params の位置（例: operations[3].params）です。
+func Resolve(opParams map[string]interface{}, path string) (*Params, error) {
+	raw, ok := opParams["sizing"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	path = joinPath(path, "sizing")
+	decoded, err := paramSchema.Decode(raw, path)
+	if err != nil {
+		return nil, err
+	}
+	p := decoded.(*Params)
+
+	// モデルが使う項目は省略を許さない
+	var required []string
+	switch p.Model {
+	case MODEL_FIXED_NOTIONAL:
+		required = []string{"notional"}
+	case MODEL_FIXED_RISK, MODEL_ATR:
+		required = []string{"risk"}
+	case MODEL_CAPITAL_FRACTION:
+		required = []string{"capital", "fraction"}
+	}
+	values := map[string]float64{"notional": p.Notional, "risk": p.Risk, "capital": p.Capital, "fraction": p.Fraction}
+	for _, name := range required {
+		if values[name] <= 0 {
+			return nil, &strategy.ParamError{Path: joinPath(path, name), Err: fmt.Errorf("%w: model が %s の場合は 0 より大きい値を指定してください", strategy.ErrParamRequired, p.Model)}
+		}
+	}
+	return p, nil
+}
+
+// Forbid は sizing に対応していない作戦（pair_trading など）の params に sizing が指定されていればエラーを返します。
+// ペアの両建ては2銘柄を合わせた数量で決めるため、銘柄ごとのサイジングでは脚の数量の釣り合いが崩れます。
+func Forbid(opType string, opParams map[string]interface{}, path string) error {
+	if raw, ok := opParams["sizing"]; !ok || raw == nil {
+		return nil
+	}
+	return &strategy.ParamError{Path: joinPath(path, "sizing"), Err: fmt.Errorf("%w: %s 作戦ではサイジングを使えません", strategy.ErrUnknownParam, opType)}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Decision はサイジングの結果です（分析ログ POSITION_SIZED に記録します）
+type Decision struct {
+	Model        Model
+	Price        float64 // 数量の計算に使った価格
+	StopDistance float64 // 1株あたりの損切り幅（atr の場合は ATR×倍率）
+	ATR          float64 // atr の場合の ATR
+	RawQty       float64 // 売買単位に丸める前の数量
+	Qty          float64 // 売買単位に切り捨て、上限を適用した数量（0 なら新規建てを見送る）
+	CappedBy     string  // 適用した上限（"max_qty" / "max_notional"）
+	SkipReason   string  // 新規建てを見送った理由（SKIP_*）
+}
+
+// Notional は建玉金額の目安を返します
+func (d Decision) Notional() float64 {
+	return d.Qty * d.Price
+}
+
+// Risk は損切りに掛かった場合の損失の目安を返します（損切り幅が分からない場合は 0）
+func (d Decision) Risk() float64 {
+	return d.Qty * d.StopDistance
+}
+
+// Sizer はスナイパー1体の新規建ての数量を決めます
+type Sizer struct {
+	params Params
+	detail symbol.Symbol
+	atr    *technical.ATR
+}
+
+// New は銘柄のサイジングを作成します。model が atr の場合は DataPool 上の ATR を共有します
+func New(params Params, detail symbol.Symbol, pool tick.DataPool) *Sizer {
+	s := &Sizer{params: params, detail: detail}
+	if params.Model == MODEL_ATR && pool != nil {
+		bars := tick.GetOrCreateBarIndicator(pool, detail.Code, tick.TimeBar(params.ATRBar))
+		s.atr = technical.GetOrCreateATR(pool, detail.Code, bars, params.ATRPeriod)
+	}
+	return s
+}
+
+// Model はサイジングのモデルを返します
+func (s *Sizer) Model() Model {
+	return s.params.Model
+}
+
+// Size は建てる価格の目安と1株あたりの損切り幅（分からなければ 0）から、新規建ての数量を決めます
+func (s *Sizer) Size(price, stopDistance float64) Decision {
+	d := Decision{Model: s.params.Model, Price: price}
+	if price <= 0 {
+		d.SkipReason = SKIP_NO_PRICE
+		return d
+	}
+	if stopDistance > 0 {
+		d.StopDistance = stopDistance
+	}
+
+	switch s.params.Model {
+	case MODEL_FIXED_NOTIONAL:
+		d.RawQty = s.params.Notional / price
+	case MODEL_FIXED_RISK:
+		if stopDistance <= 0 {
+			d.SkipReason = SKIP_NO_STOP_DISTANCE
+			return d
+		}
+		d.RawQty = s.params.Risk / stopDistance
+	case MODEL_ATR:
+		if s.atr != nil {
+			d.ATR = s.atr.Value()
+		}
+		if d.ATR <= 0 {
+			d.SkipReason = SKIP_ATR_NOT_READY
+			return d
+		}
+		d.StopDistance = d.ATR * s.params.ATRMultiple
+		d.RawQty = s.params.Risk / d.StopDistance
+	case MODEL_CAPITAL_FRACTION:
+		d.RawQty = s.params.Capital * s.params.Fraction / price
+	}
+
+	d.Qty = s.floorUnit(d.RawQty)
+	if s.params.MaxQty > 0 && d.Qty > s.params.MaxQty {
+		d.Qty = s.floorUnit(s.params.MaxQty)
+		d.CappedBy = "max_qty"
+	}
+	if s.params.MaxNotional > 0 && d.Qty*price > s.params.MaxNotional {
+		d.Qty = s.floorUnit(s.params.MaxNotional / price)
+		d.CappedBy = "max_notional"
+	}
+	if d.Qty <= 0 {
+		d.Qty = 0
+		d.SkipReason = SKIP_BELOW_UNIT
+	}
+	return d
+}
+
+// floorUnit は数量を売買単位の倍数に切り捨てます（切り上げると許容する損失や金額を超えるため）
+func (s *Sizer) floorUnit(qty float64) float64 {
+	unit := s.detail.Unit()
+	return math.Floor(qty/unit+1e-9) * unit
+}
+
+// EntryPrice は新規建ての目標から、数量の計算に使う価格の目安（指値・逆指値のトリガー価格・現在値の順）を返します
+func EntryPrice(target strategy.TargetPosition, currentPrice float64) float64 {
+	if target.Price > 0 {
+		return target.Price
+	}
+	if target.StopPrice > 0 {
+		return target.StopPrice
+	}
+	return currentPrice
+}
+
+// StopDistance は新規建ての目標から1株あたりの損切り幅を求めます。
+// 戦略が StopDistance を指定していればそれを使い、無ければ IFD の返済・OCO の逆指値、トレーリングストップの幅の順に求めます。
+// 分からない場合は 0 を返します。
+func StopDistance(target strategy.TargetPosition, price float64, detail symbol.Symbol) float64 {
+	if target.StopDistance > 0 {
+		return target.StopDistance
+	}
+	if target.HasIfDone {
+		if isStop(target.ExitOrderType) && target.ExitStopPrice > 0 {
+			return math.Abs(price - target.ExitStopPrice)
+		}
+		if target.HasOCO && isStop(target.OCOOrderType) && target.OCOStopPrice > 0 {
+			return math.Abs(price - target.OCOStopPrice)
+		}
+	}
+	if target.HasTrail() {
+		switch target.TrailType {
+		case strategy.TRAIL_TYPE_TICKS:
+			return target.TrailWidth * detail.CalcTickSize(price)
+		case strategy.TRAIL_TYPE_PERCENT:
+			return price * target.TrailWidth / 100
+		case strategy.TRAIL_TYPE_ATR:
+			return target.TrailATR * target.TrailWidth
+		}
+	}
+	return 0
+}
+
+func isStop(t order.OrderType) bool {
+	return t == order.ORDER_TYPE_STOP || t == order.ORDER_TYPE_STOP_LIMIT
+}
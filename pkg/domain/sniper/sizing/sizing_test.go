@@ -0,0 +1,148 @@
+package sizing_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/sizing"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+var detail = symbol.Symbol{Code: "7203", TradingUnit: 100, PriceRangeGroup: symbol.PRICE_RANGE_GROUP_TSE_STANDARD}
+
+func mustResolve(t *testing.T, raw map[string]interface{}) sizing.Params {
+	t.Helper()
+	p, err := sizing.Resolve(map[string]interface{}{"sizing": raw}, "operations[0].params")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	return *p
+}
+
+func TestResolve(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		p, err := sizing.Resolve(map[string]interface{}{"symbol": "7203"}, "operations[0].params")
+		if p != nil || err != nil {
+			t.Errorf("expected no sizing, got %+v / %v", p, err)
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		p := mustResolve(t, map[string]interface{}{"model": "atr", "risk": 10000.0})
+		if p.Model != sizing.MODEL_ATR || p.ATRMultiple != 2 || p.ATRPeriod != 14 || p.ATRBar != time.Minute {
+			t.Errorf("unexpected params: %+v", p)
+		}
+	})
+
+	tests := []struct {
+		name string
+		raw  map[string]interface{}
+		path string
+		want error
+	}{
+		{"model is required", map[string]interface{}{"risk": 10000.0}, "operations[0].params.sizing.model", strategy.ErrParamRequired},
+		{"unknown model", map[string]interface{}{"model": "kelly"}, "operations[0].params.sizing.model", strategy.ErrParamRange},
+		{"unknown key", map[string]interface{}{"model": "fixed_risk", "risk": 10000.0, "risk_yen": 1.0}, "operations[0].params.sizing.risk_yen", strategy.ErrUnknownParam},
+		{"fixed_risk without risk", map[string]interface{}{"model": "fixed_risk"}, "operations[0].params.sizing.risk", strategy.ErrParamRequired},
+		{"capital_fraction without fraction", map[string]interface{}{"model": "capital_fraction", "capital": 1e6}, "operations[0].params.sizing.fraction", strategy.ErrParamRequired},
+		{"fraction above 1", map[string]interface{}{"model": "capital_fraction", "capital": 1e6, "fraction": 1.5}, "operations[0].params.sizing.fraction", strategy.ErrParamRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := sizing.Resolve(map[string]interface{}{"sizing": tt.raw}, "operations[0].params")
+			var pe *strategy.ParamError
+			if !errors.As(err, &pe) || pe.Path != tt.path || !errors.Is(err, tt.want) {
+				t.Errorf("expected %v at %s, got %v", tt.want, tt.path, err)
+			}
+		})
+	}
+}
+
+func TestForbid(t *testing.T) {
+	if err := sizing.Forbid("pair_trading", map[string]interface{}{"symbol_a": "7203", "symbol_b": "7267"}, "operations[1].params"); err != nil {
+		t.Errorf("expected no error without sizing, got %v", err)
+	}
+	err := sizing.Forbid("pair_trading", map[string]interface{}{"sizing": map[string]interface{}{"model": "fixed_risk", "risk": 10000.0}}, "operations[1].params")
+	var pe *strategy.ParamError
+	if !errors.As(err, &pe) || pe.Path != "operations[1].params.sizing" || !errors.Is(err, strategy.ErrUnknownParam) {
+		t.Errorf("expected sizing to be rejected on a pair operation, got %v", err)
+	}
+}
+
+func TestSizer_Size(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      map[string]interface{}
+		price    float64
+		stop     float64
+		qty      float64
+		cappedBy string
+		skip     string
+	}{
+		{"fixed notional rounds down to the unit", map[string]interface{}{"model": "fixed_notional", "notional": 1e6}, 2100, 0, 400, "", ""},
+		{"fixed risk", map[string]interface{}{"model": "fixed_risk", "risk": 10000.0}, 2000, 30, 300, "", ""},
+		{"fixed risk without stop distance", map[string]interface{}{"model": "fixed_risk", "risk": 10000.0}, 2000, 0, 0, "", sizing.SKIP_NO_STOP_DISTANCE},
+		{"capital fraction", map[string]interface{}{"model": "capital_fraction", "capital": 3e6, "fraction": 0.5}, 1000, 0, 1500, "", ""},
+		{"max qty", map[string]interface{}{"model": "fixed_risk", "risk": 10000.0, "max_qty": 250.0}, 2000, 10, 200, "max_qty", ""},
+		{"max notional", map[string]interface{}{"model": "fixed_risk", "risk": 10000.0, "max_notional": 700000.0}, 2000, 10, 300, "max_notional", ""},
+		{"below one unit", map[string]interface{}{"model": "fixed_risk", "risk": 1000.0}, 2000, 20, 0, "", sizing.SKIP_BELOW_UNIT},
+		{"no price", map[string]interface{}{"model": "fixed_notional", "notional": 1e6}, 0, 0, 0, "", sizing.SKIP_NO_PRICE},
+		{"atr not ready", map[string]interface{}{"model": "atr", "risk": 10000.0}, 2000, 0, 0, "", sizing.SKIP_ATR_NOT_READY},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := sizing.New(mustResolve(t, tt.raw), detail, tick.NewDefaultDataPool(nil)).Size(tt.price, tt.stop)
+			if d.Qty != tt.qty || d.CappedBy != tt.cappedBy || d.SkipReason != tt.skip {
+				t.Errorf("expected qty %v (capped: %q, skip: %q), got %+v", tt.qty, tt.cappedBy, tt.skip, d)
+			}
+		})
+	}
+
+	t.Run("atr volatility targeting", func(t *testing.T) {
+		pool := tick.NewDefaultDataPool(nil)
+		sizer := sizing.New(mustResolve(t, map[string]interface{}{"model": "atr", "risk": 10000.0, "atr_period": 2.0}), detail, pool)
+
+		// 1分足の値幅が 10円ずつ続き、ATR は 10円
+		start := time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+		volume := 0.0
+		for i, price := range []float64{2000, 2010, 2000, 2010, 2000, 2010, 2005} {
+			volume += 100
+			pool.PushTick(tick.Tick{Symbol: "7203", Price: price, TradingVolume: volume,
+				CurrentPriceTime: start.Add(time.Duration(i) * 30 * time.Second), CurrentPriceStatus: tick.PRICE_STATUS_CURRENT})
+		}
+
+		// 損切り幅は ATR×2 = 20円、10000円 / 20円 = 500株
+		d := sizer.Size(2005, 0)
+		if d.ATR != 10 || d.StopDistance != 20 || d.Qty != 500 || d.Risk() != 10000 {
+			t.Errorf("unexpected atr sizing: %+v", d)
+		}
+	})
+}
+
+func TestStopDistance(t *testing.T) {
+	tests := []struct {
+		name   string
+		target strategy.TargetPosition
+		want   float64
+	}{
+		{"explicit", strategy.TargetPosition{Qty: 100, StopDistance: 12, HasIfDone: true, ExitOrderType: order.ORDER_TYPE_STOP, ExitStopPrice: 1980}, 12},
+		{"ifd stop exit", strategy.TargetPosition{Qty: 100, HasIfDone: true, ExitOrderType: order.ORDER_TYPE_STOP, ExitStopPrice: 1980}, 20},
+		{"oco stop leg", strategy.TargetPosition{Qty: 100, HasIfDone: true, ExitOrderType: order.ORDER_TYPE_LIMIT, ExitPrice: 2050,
+			HasOCO: true, OCOOrderType: order.ORDER_TYPE_STOP, OCOStopPrice: 1985}, 15},
+		{"short ifd stop exit", strategy.TargetPosition{Qty: -100, HasIfDone: true, ExitOrderType: order.ORDER_TYPE_STOP_LIMIT, ExitStopPrice: 2025}, 25},
+		{"trailing ticks", strategy.TargetPosition{Qty: 100, TrailType: strategy.TRAIL_TYPE_TICKS, TrailWidth: 5}, 5},
+		{"trailing percent", strategy.TargetPosition{Qty: 100, TrailType: strategy.TRAIL_TYPE_PERCENT, TrailWidth: 1}, 20},
+		{"take profit only", strategy.TargetPosition{Qty: 100, HasIfDone: true, ExitOrderType: order.ORDER_TYPE_LIMIT, ExitPrice: 2050}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sizing.StopDistance(tt.target, 2000, detail); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
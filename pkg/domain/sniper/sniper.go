@@ -1,16 +1,26 @@
 package sniper
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/sizing"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 )
 
+// ErrNotReconfigurable は戦略が稼働中のパラメータ変更（strategy.Reconfigurable）に対応していないことを表します
+var ErrNotReconfigurable = errors.New("戦略が稼働中のパラメータ変更に対応していません")
+
+// SniperID は戦略名と銘柄コードからスナイパーIDを組み立てます
+func SniperID(strategyName, symbolCode string) string {
+	return fmt.Sprintf("%s_%s", strategyName, symbolCode)
+}
+
 type Strategy interface {
 	Name() string
 	Evaluate(input strategy.StrategyInput) strategy.TargetPosition
@@ -66,6 +76,8 @@ type Sniper struct {
 	AccountType       order.AccountType
 	Exchange          order.ExchangeMarket
 	MarginTradeType   order.MarginTradeType
+	Sizer             *sizing.Sizer // 新規建ての数量を決めるサイジング（nil なら戦略の数量のまま発注する）
+	closed            bool          // 戦略を strategy.Closer で閉じた（以降は戦略に判断させず建玉を維持する）
 
 	lastSignalReason string
 	lastStatusLogAt  time.Time
@@ -95,7 +107,10 @@ func (s *Sniper) Evaluate(input strategy.StrategyInput) strategy.TargetPosition
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	target := s.Strategy.Evaluate(input)
+	target := strategy.TargetPosition{Qty: input.HoldQty()}
+	if !s.closed {
+		target = s.Strategy.Evaluate(input)
+	}
 
 	if target.Price > 0 {
 		target.Price = s.Detail.RoundPrice(target.Price)
@@ -140,6 +155,60 @@ func (s *Sniper) OrderlyExit() {
 	defer s.mu.Unlock()
 	s.lifecycle = LifecycleExiting
 	s.Logger.Warn("LIFECYCLE_EXIT_TRIGGERED", slog.String("symbol", s.Detail.Code))
+	// 手仕舞いは成行で行うため戦略の判断は不要になる。子プロセスなどの資源はここで解放する
+	if err := s.closeLocked(); err != nil {
+		s.Logger.Warn("STRATEGY_CLOSE_FAILED", slog.String("symbol", s.Detail.Code), slog.Any("error", err))
+	}
+}
+
+// Close は戦略が strategy.Closer を実装していれば閉じます（2回目以降は何もしません）。
+// 閉じた後の Evaluate は戦略を呼ばずに建玉を維持する目標を返します（撤収中なら従来どおり手仕舞います）。
+func (s *Sniper) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeLocked()
+}
+
+func (s *Sniper) closeLocked() error {
+	closer, ok := s.Strategy.(strategy.Closer)
+	if !ok || s.closed {
+		return nil
+	}
+	s.closed = true
+	return closer.Close()
+}
+
+// Reconfigure は稼働中の戦略にパラメータの差し替えを適用し、同じパラメータから作り直した執行ポリシー（nil なら維持）に入れ替えます。
+// 建玉や注文には触れません。戦略が strategy.Reconfigurable を実装していない場合は ErrNotReconfigurable を返し、執行ポリシーも維持します。
+func (s *Sniper) Reconfigure(params interface{}, policy strategy.ExecutionPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.Strategy.(strategy.Reconfigurable)
+	if !ok {
+		return ErrNotReconfigurable
+	}
+	if err := r.Reconfigure(params); err != nil {
+		return err
+	}
+	if policy != nil {
+		s.ExecutionPolicy = policy
+	}
+	s.Logger.Info("STRATEGY_RECONFIGURED", slog.String("symbol", s.Detail.Code), slog.String("sniper", s.ID))
+	return nil
+}
+
+// UpdateSizer は新規建ての数量を決めるサイジングを差し替えます（nil でサイジングを外します）。建玉や注文には触れません。
+func (s *Sniper) UpdateSizer(sizer *sizing.Sizer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Sizer = sizer
+}
+
+// execution は執行ポリシーとサイジングを返します（稼働中の差し替えと競合しないようロックして読み出します）
+func (s *Sniper) execution() (strategy.ExecutionPolicy, *sizing.Sizer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ExecutionPolicy, s.Sizer
 }
 
 func (s *Sniper) ForceStop() {
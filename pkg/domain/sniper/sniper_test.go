@@ -1,6 +1,7 @@
 package sniper
 
 import (
+	"errors"
 	"log/slog"
 	"testing"
 	"time"
@@ -430,3 +431,68 @@ func TestSniperNest_NoSyntheticFillOnCancelSent(t *testing.T) {
 		t.Errorf("expected status to remain CANCEL_SENT, but got %v", ord.Status())
 	}
 }
+
+// closableStrategy は子プロセスなどの資源を持つ戦略の代わりに、Close と Evaluate の呼び出し回数を数えます
+type closableStrategy struct {
+	ControllableStrategy
+	evaluated int
+	closed    int
+}
+
+func (c *closableStrategy) Evaluate(input strategy.StrategyInput) strategy.TargetPosition {
+	c.evaluated++
+	return strategy.TargetPosition{Qty: 100, OrderType: order.ORDER_TYPE_MARKET, Reason: "Entry"}
+}
+
+func (c *closableStrategy) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestSniper_CloseOnOrderlyExit(t *testing.T) {
+	detail := symbol.Symbol{Code: "7203"}
+	strat := &closableStrategy{}
+	s := NewSniper("closable_7203", detail, strat, &strategy.NoopPolicy{}, order.EXCHANGE_TOSHO, nil)
+	op := NewDefaultOperation("Op_7203", NewSniperNest("7203", detail, []*Sniper{s}, nil))
+
+	// 撤収で戦略を閉じ、以降は戦略に判断させずに手仕舞う
+	op.OrderlyExit()
+	if strat.closed != 1 {
+		t.Fatalf("expected the strategy to be closed on orderly exit, got %d", strat.closed)
+	}
+	holding := strategy.StrategyInput{Position: strategy.Position{Qty: 100}, LatestTick: tick.Tick{Price: 2000}}
+	if got := s.Evaluate(holding); got.Qty != 0 || got.Reason != "LIFECYCLE_FORCE_EXIT" || strat.evaluated != 0 {
+		t.Errorf("expected a force exit without evaluating the closed strategy, got %+v (evaluated %d)", got, strat.evaluated)
+	}
+
+	// 終了時にもう一度閉じても戦略の Close は1度だけ
+	if err := op.Close(); err != nil || strat.closed != 1 {
+		t.Errorf("expected Close to be idempotent, got %v (closed %d)", err, strat.closed)
+	}
+}
+
+func TestSniper_Reconfigure(t *testing.T) {
+	detail := symbol.Symbol{Code: "7203"}
+	factory, _ := strategy.GetFactory("sample")
+	sample := NewSniper(SniperID("sample", "7203"), detail, factory.NewStrategy(detail, tick.NewDefaultDataPool(nil), nil), &strategy.NoopPolicy{}, order.EXCHANGE_TOSHO, nil)
+	nest := NewSniperNest("7203", detail, []*Sniper{sample}, nil)
+	op := NewDefaultOperation("Op_7203", nest)
+
+	if err := op.Reconfigure("sample_7203", &strategy.SampleParams{RisingBars: 5}, nil); err != nil {
+		t.Errorf("expected the sample strategy to accept new params, got %v", err)
+	}
+	if err := op.Reconfigure("missing_7203", nil, nil); err == nil {
+		t.Errorf("expected an error for an unknown sniper")
+	}
+
+	instruction := NewSniper("pair_7203", detail, NewInstructionStrategy(), &strategy.NoopPolicy{}, order.EXCHANGE_TOSHO, nil)
+	if err := instruction.Reconfigure(nil, nil); !errors.Is(err, ErrNotReconfigurable) {
+		t.Errorf("expected ErrNotReconfigurable, got %v", err)
+	}
+
+	// 撤収は建玉の手仕舞いを促すだけで、スナイパーは停止しない
+	op.OrderlyExit()
+	if sample.GetLifecycle() != LifecycleExiting {
+		t.Errorf("expected the sniper to be exiting, got %v", sample.GetLifecycle())
+	}
+}
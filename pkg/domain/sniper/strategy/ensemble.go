@@ -0,0 +1,402 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// EnsembleRule は子戦略のターゲットを1つにまとめる規則です
+type EnsembleRule string
+
+const (
+	ENSEMBLE_RULE_MAJORITY       EnsembleRule = "majority"       // 過半数の子戦略が一致した方向（ロング・ショート・ノーポジ）に従う
+	ENSEMBLE_RULE_WEIGHTED_SUM   EnsembleRule = "weighted_sum"   // 子戦略の Qty を weight で加重平均する
+	ENSEMBLE_RULE_UNANIMOUS      EnsembleRule = "unanimous"      // 全員一致の時だけ従い、割れたらノーポジにする
+	ENSEMBLE_RULE_FIRST_NON_FLAT EnsembleRule = "first_non_flat" // children の並び順で最初にポジションを求めた子戦略に従う
+)
+
+// EnsembleParams は ensemble 戦略のパラメータです（operations.json の strategy_params.ensemble）
+type EnsembleParams struct {
+	Rule     EnsembleRule
+	Children []EnsembleChild
+}
+
+// EnsembleChild は ensemble に組み込む子戦略1つ分の設定です
+type EnsembleChild struct {
+	Strategy string      // 登録済みの戦略名
+	Weight   float64     // weighted_sum での重み
+	Params   interface{} // 子戦略のファクトリで検証済みのパラメータ
+}
+
+var ensembleParamSchema = ParamSchema{
+	Fields: []ParamField{
+		{Name: "rule", Type: PARAM_TYPE_STRING, Default: string(ENSEMBLE_RULE_MAJORITY), Choices: []string{string(ENSEMBLE_RULE_MAJORITY), string(ENSEMBLE_RULE_WEIGHTED_SUM), string(ENSEMBLE_RULE_UNANIMOUS), string(ENSEMBLE_RULE_FIRST_NON_FLAT)}, Description: "子戦略のターゲットをまとめる規則"},
+		{Name: "children", Type: PARAM_TYPE_LIST, Required: true, Description: "子戦略の一覧"},
+	},
+}
+
+var ensembleChildSchema = ParamSchema{
+	Fields: []ParamField{
+		{Name: "strategy", Type: PARAM_TYPE_STRING, Required: true, Description: "登録済みの戦略名"},
+		{Name: "weight", Type: PARAM_TYPE_FLOAT, Default: 1.0, Min: Bound(0), Description: "weighted_sum での重み"},
+		{Name: "params", Type: PARAM_TYPE_OBJECT, Description: "子戦略のパラメータ"},
+	},
+}
+
+type ensembleMember struct {
+	name     string // 登録済みの戦略名
+	label    string // Reason に記録する名前（同じ戦略を複数組み込んだ場合は #番号 を付ける）
+	weight   float64
+	params   interface{}
+	strategy Strategy
+}
+
+// EnsembleStrategy は複数の子戦略のターゲットを1つにまとめてネストに渡すメタ戦略です。
+// 子戦略は毎 Tick すべて評価されるため、子戦略の内部状態（高値の追跡など）は単独で動かした場合と同じように更新されます。
+type EnsembleStrategy struct {
+	detail   symbol.Symbol
+	rule     EnsembleRule
+	children []ensembleMember
+}
+
+func (e *EnsembleStrategy) Name() string {
+	return "ensemble"
+}
+
+func (e *EnsembleStrategy) AnalysisLogger() *slog.Logger {
+	return nil
+}
+
+// Evaluate は子戦略を順に評価し、rule に従って1つのターゲットにまとめます。
+// 子戦略ごとのターゲットと理由は Reason に残します（例: "ensemble(majority) +100 [sample: +100 3 consecutive bars rise | orb: 0]"）。
+func (e *EnsembleStrategy) Evaluate(input StrategyInput) TargetPosition {
+	holdQty := input.HoldQty()
+	if len(e.children) == 0 {
+		return TargetPosition{Qty: holdQty}
+	}
+
+	targets := make([]TargetPosition, len(e.children))
+	for i, c := range e.children {
+		targets[i] = c.strategy.Evaluate(input)
+	}
+
+	var out TargetPosition
+	switch e.rule {
+	case ENSEMBLE_RULE_WEIGHTED_SUM:
+		out = e.weightedSum(targets)
+	case ENSEMBLE_RULE_UNANIMOUS:
+		out = e.vote(targets, len(targets), TargetPosition{Qty: 0, OrderType: order.ORDER_TYPE_MARKET})
+	case ENSEMBLE_RULE_FIRST_NON_FLAT:
+		out = e.firstNonFlat(targets)
+	default:
+		out = e.vote(targets, len(targets)/2+1, TargetPosition{Qty: holdQty})
+	}
+
+	// 注文が動かない Tick では理由の文字列を組み立てない
+	if out.Qty == holdQty {
+		return out
+	}
+	if out.OrderType == 0 && out.Price == 0 {
+		out.OrderType = order.ORDER_TYPE_MARKET
+	}
+	out.Reason = e.reason(out, targets)
+	return out
+}
+
+// vote は quorum 以上の子戦略が同じ方向を向いていればその方向に、そうでなければ fallback に従います。
+// 数量は一致した子戦略の平均を売買単位に切り捨てたものです。
+func (e *EnsembleStrategy) vote(targets []TargetPosition, quorum int, fallback TargetPosition) TargetPosition {
+	var groups [3][]int // 0: ノーポジ, 1: ロング, 2: ショート
+	for i, t := range targets {
+		groups[direction(t.Qty)] = append(groups[direction(t.Qty)], i)
+	}
+	for _, idx := range groups {
+		if len(idx) < quorum {
+			continue
+		}
+		sum := 0.0
+		for _, i := range idx {
+			sum += targets[i].Qty
+		}
+		out := targets[representative(targets, idx)]
+		out.Qty = e.detail.RoundQty(sum / float64(len(idx)))
+		return out
+	}
+	return fallback
+}
+
+// weightedSum は子戦略の Qty を weight で加重平均します。
+// 重みを正規化するため、全員が現在の保有数量を返している（ホールド）間は数量が膨らみません。
+func (e *EnsembleStrategy) weightedSum(targets []TargetPosition) TargetPosition {
+	sum, totalWeight := 0.0, 0.0
+	for i, t := range targets {
+		sum += e.children[i].weight * t.Qty
+		totalWeight += e.children[i].weight
+	}
+	if totalWeight == 0 {
+		return TargetPosition{Qty: 0, OrderType: order.ORDER_TYPE_MARKET}
+	}
+	qty := e.detail.RoundQty(sum / totalWeight)
+	if qty == 0 {
+		return TargetPosition{Qty: 0, OrderType: order.ORDER_TYPE_MARKET}
+	}
+
+	// 注文価格・注文タイプは、合計と同じ方向で最も寄与の大きい子戦略のものを使う
+	best := -1
+	for i, t := range targets {
+		if direction(t.Qty) != direction(qty) {
+			continue
+		}
+		if best < 0 || math.Abs(e.children[i].weight*t.Qty) > math.Abs(e.children[best].weight*targets[best].Qty) {
+			best = i
+		}
+	}
+	out := targets[best]
+	out.Qty = qty
+	return out
+}
+
+func (e *EnsembleStrategy) firstNonFlat(targets []TargetPosition) TargetPosition {
+	for _, t := range targets {
+		if !t.IsFlat() {
+			return t
+		}
+	}
+	all := make([]int, len(targets))
+	for i := range targets {
+		all[i] = i
+	}
+	out := targets[representative(targets, all)]
+	out.Qty = 0
+	return out
+}
+
+func (e *EnsembleStrategy) reason(out TargetPosition, targets []TargetPosition) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ensemble(%s) %+g [", e.rule, out.Qty)
+	for i, t := range targets {
+		if i > 0 {
+			b.WriteString(" | ")
+		}
+		fmt.Fprintf(&b, "%s: %+g", e.children[i].label, t.Qty)
+		if t.Reason != "" {
+			b.WriteString(" " + t.Reason)
+		}
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// ShouldCancel は、CancelChecker を実装した子戦略のいずれかがキャンセルを求めた注文をキャンセルします
+func (e *EnsembleStrategy) ShouldCancel(input StrategyInput, ord *order.Order) bool {
+	for _, c := range e.children {
+		if checker, ok := c.strategy.(CancelChecker); ok && checker.ShouldCancel(input, ord) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reconfigure は稼働中に rule・weight と子戦略のパラメータを差し替えます。
+// 子戦略の構成（戦略名と並び順）の変更はスナイパーの入れ替えが必要なためエラーにします。
+// パラメータが変わった子戦略が Reconfigurable を実装していない場合や、いずれかの子戦略が差し替えを拒否した場合も、何も変更せずにエラーを返します。
+func (e *EnsembleStrategy) Reconfigure(params interface{}) error {
+	p, err := ensembleParamsOf(params)
+	if err != nil {
+		return err
+	}
+	if len(p.Children) != len(e.children) {
+		return fmt.Errorf("ensemble の子戦略の数は稼働中に変更できません (%d -> %d)", len(e.children), len(p.Children))
+	}
+	for i, c := range p.Children {
+		current := e.children[i]
+		if c.Strategy != current.name {
+			return fmt.Errorf("ensemble の children[%d] の戦略は稼働中に変更できません (%s -> %s)", i, current.name, c.Strategy)
+		}
+		if _, ok := current.strategy.(Reconfigurable); !ok && !reflect.DeepEqual(c.Params, current.params) {
+			return fmt.Errorf("ensemble の children[%d] (%s) は稼働中のパラメータ変更に対応していません", i, c.Strategy)
+		}
+	}
+
+	// 子戦略のパラメータは ensembleParamsOf で全て検証済み。差し替え中に失敗した場合は、差し替え済みの子戦略を元のパラメータに戻す
+	for i, c := range p.Children {
+		r, ok := e.children[i].strategy.(Reconfigurable)
+		if !ok {
+			continue
+		}
+		if err := r.Reconfigure(c.Params); err != nil {
+			err = fmt.Errorf("ensemble の children[%d] (%s): %w", i, c.Strategy, err)
+			return errors.Join(err, e.restoreChildren(i))
+		}
+	}
+	for i, c := range p.Children {
+		e.children[i].weight = c.Weight
+		e.children[i].params = c.Params
+	}
+	e.rule = p.Rule
+	return nil
+}
+
+// restoreChildren は Reconfigure の途中で失敗した場合に、先頭から n 個の子戦略を稼働中のパラメータに戻します
+func (e *EnsembleStrategy) restoreChildren(n int) error {
+	var errs []error
+	for i, c := range e.children[:n] {
+		if r, ok := c.strategy.(Reconfigurable); ok {
+			if err := r.Reconfigure(c.params); err != nil {
+				errs = append(errs, fmt.Errorf("ensemble の children[%d] (%s) を元のパラメータに戻せませんでした: %w", i, c.name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close は Closer を実装した子戦略をすべて閉じます
+func (e *EnsembleStrategy) Close() error {
+	var errs []error
+	for i, c := range e.children {
+		if closer, ok := c.strategy.(Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("ensemble の children[%d] (%s): %w", i, c.name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// direction は数量の向きを 0: ノーポジ, 1: ロング, 2: ショート で返します
+func direction(qty float64) int {
+	switch {
+	case qty > 0:
+		return 1
+	case qty < 0:
+		return 2
+	}
+	return 0
+}
+
+// representative は idx の子戦略のうち、注文価格・注文タイプを採用する子戦略を選びます。
+// 理由付きのターゲット（実際にシグナルを出した子戦略）を優先し、無ければ先頭を使います。
+func representative(targets []TargetPosition, idx []int) int {
+	for _, i := range idx {
+		if targets[i].Reason != "" {
+			return i
+		}
+	}
+	return idx[0]
+}
+
+// ----------------------------------------------------------------------------
+// Factory & Registration
+// ----------------------------------------------------------------------------
+
+type EnsembleStrategyFactory struct{}
+
+func (f *EnsembleStrategyFactory) NewStrategy(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) Strategy {
+	e := &EnsembleStrategy{detail: detail, rule: ENSEMBLE_RULE_MAJORITY}
+	p, err := ensembleParamsOf(params)
+	if err != nil {
+		slog.Error("❌ ensemble 戦略のパラメータが不正なため、子戦略なしで起動します", slog.String("symbol", detail.Code), slog.Any("error", err))
+		return e
+	}
+
+	e.rule = p.Rule
+	counts := make(map[string]int, len(p.Children))
+	for _, c := range p.Children {
+		// 検証済みのため GetFactory は失敗しない
+		factory, _ := GetFactory(c.Strategy)
+		counts[c.Strategy]++
+		label := c.Strategy
+		if counts[c.Strategy] > 1 {
+			label = fmt.Sprintf("%s#%d", c.Strategy, counts[c.Strategy])
+		}
+		e.children = append(e.children, ensembleMember{
+			name:     c.Strategy,
+			label:    label,
+			weight:   c.Weight,
+			params:   c.Params,
+			strategy: factory.NewStrategy(detail, dataPool, c.Params),
+		})
+	}
+	return e
+}
+
+// CreateExecutionPolicy は先頭の子戦略の執行ポリシーを使います
+func (f *EnsembleStrategyFactory) CreateExecutionPolicy(params interface{}) ExecutionPolicy {
+	p, err := ensembleParamsOf(params)
+	if err != nil || len(p.Children) == 0 {
+		return &NoopPolicy{}
+	}
+	factory, err := GetFactory(p.Children[0].Strategy)
+	if err != nil {
+		return &NoopPolicy{}
+	}
+	return factory.CreateExecutionPolicy(p.Children[0].Params)
+}
+
+// DecodeParams は ensemble 自身のパラメータに加え、子戦略ごとのパラメータも各ファクトリのスキーマで検証します。
+// エラーの位置は path.children[1].params.rising_bars のように子戦略の中まで示します。
+func (f *EnsembleStrategyFactory) DecodeParams(params interface{}, path string) (interface{}, error) {
+	decoded, err := ensembleParamSchema.Decode(params, path)
+	if err != nil {
+		return nil, err
+	}
+	raw := decoded.(map[string]interface{})
+
+	childrenPath := joinParamPath(path, "children")
+	list := raw["children"].([]interface{})
+	if len(list) == 0 {
+		return nil, &ParamError{Path: childrenPath, Err: fmt.Errorf("%w: 子戦略を1つ以上指定してください", ErrParamRequired)}
+	}
+
+	p := &EnsembleParams{Rule: EnsembleRule(raw["rule"].(string))}
+	for i, elem := range list {
+		childPath := fmt.Sprintf("%s[%d]", childrenPath, i)
+		decodedChild, err := ensembleChildSchema.Decode(elem, childPath)
+		if err != nil {
+			return nil, err
+		}
+		child := decodedChild.(map[string]interface{})
+
+		name := child["strategy"].(string)
+		childParams, err := prepareChildParams(name, child["params"], childPath)
+		if err != nil {
+			return nil, err
+		}
+		p.Children = append(p.Children, EnsembleChild{Strategy: name, Weight: child["weight"].(float64), Params: childParams})
+	}
+	return p, nil
+}
+
+// prepareChildParams はメタ戦略に組み込む子戦略 name のパラメータを、子戦略のファクトリで検証します。
+// childPath は子戦略の設定（strategy / params を持つオブジェクト）の位置です。
+func prepareChildParams(name string, params interface{}, childPath string) (interface{}, error) {
+	factory, err := GetFactory(name)
+	if err != nil {
+		return nil, &ParamError{Path: joinParamPath(childPath, "strategy"), Err: err}
+	}
+	return PrepareParams(factory, params, joinParamPath(childPath, "params"))
+}
+
+// ensembleParamsOf は NewStrategy などに渡された params を *EnsembleParams として取り出します（生の値はその場で検証します）
+func ensembleParamsOf(params interface{}) (*EnsembleParams, error) {
+	if p, ok := params.(*EnsembleParams); ok && p != nil {
+		return p, nil
+	}
+	decoded, err := (&EnsembleStrategyFactory{}).DecodeParams(params, "")
+	if err != nil {
+		return nil, err
+	}
+	return decoded.(*EnsembleParams), nil
+}
+
+func init() {
+	Register("ensemble", &EnsembleStrategyFactory{})
+}
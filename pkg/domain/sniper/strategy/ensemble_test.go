@@ -0,0 +1,268 @@
+package strategy_test
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// fixedStrategy は params の qty / reason をそのまま返すテスト用の子戦略です
+type fixedStrategy struct {
+	qty    float64
+	reason string
+}
+
+func (s *fixedStrategy) Name() string                 { return "fixed" }
+func (s *fixedStrategy) AnalysisLogger() *slog.Logger { return nil }
+func (s *fixedStrategy) Evaluate(input strategy.StrategyInput) strategy.TargetPosition {
+	t := strategy.TargetPosition{Qty: s.qty, Reason: s.reason}
+	if s.reason != "" {
+		t.OrderType = order.ORDER_TYPE_MARKET
+	}
+	return t
+}
+
+type fixedStrategyFactory struct{}
+
+func (f *fixedStrategyFactory) NewStrategy(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) strategy.Strategy {
+	p, _ := params.(map[string]interface{})
+	qty, _ := p["qty"].(float64)
+	reason, _ := p["reason"].(string)
+	return &fixedStrategy{qty: qty, reason: reason}
+}
+
+func (f *fixedStrategyFactory) CreateExecutionPolicy(params interface{}) strategy.ExecutionPolicy {
+	return &strategy.StrictPiercePolicy{}
+}
+
+// tunableStrategy は稼働中に qty を差し替えられるテスト用の子戦略です。params の reject が true なら差し替えを拒否します
+type tunableStrategy struct {
+	fixedStrategy
+}
+
+func (s *tunableStrategy) Reconfigure(params interface{}) error {
+	p, _ := params.(map[string]interface{})
+	if reject, _ := p["reject"].(bool); reject {
+		return errors.New("rejected")
+	}
+	s.qty, _ = p["qty"].(float64)
+	return nil
+}
+
+type tunableStrategyFactory struct{ fixedStrategyFactory }
+
+func (f *tunableStrategyFactory) NewStrategy(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) strategy.Strategy {
+	s := &tunableStrategy{}
+	s.Reconfigure(params)
+	return s
+}
+
+func init() {
+	strategy.Register("fixed", &fixedStrategyFactory{})
+	strategy.Register("tunable", &tunableStrategyFactory{})
+}
+
+func tunableChild(qty float64, reject bool) map[string]interface{} {
+	return map[string]interface{}{"strategy": "tunable", "params": map[string]interface{}{"qty": qty, "reject": reject}}
+}
+
+func fixedChild(qty float64, reason string) map[string]interface{} {
+	return map[string]interface{}{"strategy": "fixed", "params": map[string]interface{}{"qty": qty, "reason": reason}}
+}
+
+func newEnsemble(t *testing.T, rule string, children ...map[string]interface{}) strategy.Strategy {
+	t.Helper()
+	list := make([]interface{}, len(children))
+	for i, c := range children {
+		list[i] = c
+	}
+	factory, err := strategy.GetFactory("ensemble")
+	if err != nil {
+		t.Fatalf("failed to get ensemble factory: %v", err)
+	}
+	params, err := strategy.PrepareParams(factory, map[string]interface{}{"rule": rule, "children": list}, "ensemble")
+	if err != nil {
+		t.Fatalf("PrepareParams failed: %v", err)
+	}
+	return factory.NewStrategy(symbol.Symbol{Code: "7203", TradingUnit: 100}, &dummyDataPool{}, params)
+}
+
+func TestEnsembleStrategy_Evaluate(t *testing.T) {
+	weighted := func(qty, weight float64) map[string]interface{} {
+		c := fixedChild(qty, "w")
+		c["weight"] = weight
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		holdQty  float64
+		children []map[string]interface{}
+		wantQty  float64
+	}{
+		{"majority long", "majority", 0, []map[string]interface{}{fixedChild(100, "a"), fixedChild(300, "b"), fixedChild(0, "")}, 200},
+		{"majority split holds", "majority", 100, []map[string]interface{}{fixedChild(100, "a"), fixedChild(-100, "b"), fixedChild(0, "")}, 100},
+		{"majority flat exits", "majority", 100, []map[string]interface{}{fixedChild(0, "cut"), fixedChild(0, ""), fixedChild(100, "")}, 0},
+		{"unanimous agree", "unanimous", 0, []map[string]interface{}{fixedChild(-100, "a"), fixedChild(-100, "b")}, -100},
+		{"unanimous split goes flat", "unanimous", 100, []map[string]interface{}{fixedChild(100, ""), fixedChild(0, "cut")}, 0},
+		{"weighted sum", "weighted_sum", 0, []map[string]interface{}{weighted(300, 3), weighted(-100, 1)}, 200},
+		{"weighted sum rounds to unit", "weighted_sum", 0, []map[string]interface{}{weighted(100, 1), weighted(0, 3)}, 0},
+		{"weighted sum floors to unit", "weighted_sum", 0, []map[string]interface{}{weighted(200, 1), weighted(100, 1)}, 100},
+		{"first non flat", "first_non_flat", 0, []map[string]interface{}{fixedChild(0, ""), fixedChild(-200, "b"), fixedChild(100, "c")}, -200},
+		{"first non flat all flat", "first_non_flat", 100, []map[string]interface{}{fixedChild(0, ""), fixedChild(0, "")}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newEnsemble(t, tt.rule, tt.children...)
+			got := s.Evaluate(strategy.StrategyInput{Position: strategy.Position{Qty: tt.holdQty}})
+			if got.Qty != tt.wantQty {
+				t.Errorf("expected qty %v, got %v", tt.wantQty, got.Qty)
+			}
+			if got.Qty != tt.holdQty && got.OrderType != order.ORDER_TYPE_MARKET {
+				t.Errorf("expected market order when the target moves, got %v", got.OrderType)
+			}
+		})
+	}
+}
+
+func TestEnsembleStrategy_Reason(t *testing.T) {
+	s := newEnsemble(t, "majority", fixedChild(100, "breakout"), fixedChild(100, "trend"), fixedChild(0, ""))
+	got := s.Evaluate(strategy.StrategyInput{})
+	want := "ensemble(majority) +100 [fixed: +100 breakout | fixed#2: +100 trend | fixed#3: +0]"
+	if got.Reason != want {
+		t.Errorf("expected reason %q, got %q", want, got.Reason)
+	}
+
+	// 目標が保有数量と変わらない Tick では ensemble の理由を組み立てない
+	got = s.Evaluate(strategy.StrategyInput{Position: strategy.Position{Qty: 100}})
+	if got.Qty != 100 || strings.HasPrefix(got.Reason, "ensemble") {
+		t.Errorf("expected hold, got %+v", got)
+	}
+}
+
+func TestEnsembleStrategyFactory_DecodeParams(t *testing.T) {
+	factory, _ := strategy.GetFactory("ensemble")
+
+	params, err := strategy.PrepareParams(factory, map[string]interface{}{
+		"children": []interface{}{
+			map[string]interface{}{"strategy": "sample", "params": map[string]interface{}{"rising_bars": 4.0}},
+			map[string]interface{}{"strategy": "sample", "weight": 2.0},
+		},
+	}, "ensemble")
+	if err != nil {
+		t.Fatalf("PrepareParams failed: %v", err)
+	}
+	p := params.(*strategy.EnsembleParams)
+	if p.Rule != strategy.ENSEMBLE_RULE_MAJORITY || len(p.Children) != 2 {
+		t.Fatalf("unexpected params: %+v", p)
+	}
+	if sp := p.Children[0].Params.(*strategy.SampleParams); sp.RisingBars != 4 || p.Children[0].Weight != 1 {
+		t.Errorf("unexpected first child: %+v %+v", p.Children[0], sp)
+	}
+	if sp := p.Children[1].Params.(*strategy.SampleParams); sp.RisingBars != 3 || p.Children[1].Weight != 2 {
+		t.Errorf("expected defaults for the second child: %+v %+v", p.Children[1], sp)
+	}
+
+	// 執行ポリシーは先頭の子戦略のものを使う
+	if _, ok := factory.CreateExecutionPolicy(params).(*strategy.NoopPolicy); !ok {
+		t.Errorf("expected the first child's policy")
+	}
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		wantPath string
+		wantErr  error
+	}{
+		{"unknown rule", map[string]interface{}{"rule": "random", "children": []interface{}{fixedChild(0, "")}}, "ensemble.rule", strategy.ErrParamRange},
+		{"missing children", map[string]interface{}{}, "ensemble.children", strategy.ErrParamRequired},
+		{"empty children", map[string]interface{}{"children": []interface{}{}}, "ensemble.children", strategy.ErrParamRequired},
+		{"unknown child key", map[string]interface{}{"children": []interface{}{map[string]interface{}{"strategy": "fixed", "wieght": 1.0}}}, "ensemble.children[0].wieght", strategy.ErrUnknownParam},
+		{"negative weight", map[string]interface{}{"children": []interface{}{map[string]interface{}{"strategy": "fixed", "weight": -1.0}}}, "ensemble.children[0].weight", strategy.ErrParamRange},
+		{"unknown child strategy", map[string]interface{}{"children": []interface{}{fixedChild(0, ""), map[string]interface{}{"strategy": "nope"}}}, "ensemble.children[1].strategy", nil},
+		{"invalid child params", map[string]interface{}{"children": []interface{}{map[string]interface{}{"strategy": "sample", "params": map[string]interface{}{"rising_bars": 1.0}}}}, "ensemble.children[0].params.rising_bars", strategy.ErrParamRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := strategy.PrepareParams(factory, tt.params, "ensemble")
+			var pe *strategy.ParamError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected ParamError, got %v", err)
+			}
+			if pe.Path != tt.wantPath {
+				t.Errorf("expected path %q, got %q", tt.wantPath, pe.Path)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEnsembleStrategy_Reconfigure(t *testing.T) {
+	factory, _ := strategy.GetFactory("ensemble")
+	s := newEnsemble(t, "majority", fixedChild(100, "a"), fixedChild(0, ""))
+	r, ok := s.(strategy.Reconfigurable)
+	if !ok {
+		t.Fatalf("ensemble should be reconfigurable")
+	}
+
+	// 子戦略のパラメータが変わらなければ rule の変更は反映される（fixed は Reconfigurable ではない）
+	next, err := strategy.PrepareParams(factory, map[string]interface{}{"rule": "first_non_flat", "children": []interface{}{fixedChild(100, "a"), fixedChild(0, "")}}, "")
+	if err != nil {
+		t.Fatalf("PrepareParams failed: %v", err)
+	}
+	if err := r.Reconfigure(next); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if got := s.Evaluate(strategy.StrategyInput{}); got.Qty != 100 {
+		t.Errorf("expected first_non_flat to follow the first child, got %v", got.Qty)
+	}
+
+	changed, _ := strategy.PrepareParams(factory, map[string]interface{}{"rule": "majority", "children": []interface{}{fixedChild(200, "a"), fixedChild(0, "")}}, "")
+	if err := r.Reconfigure(changed); err == nil || !strings.Contains(err.Error(), "children[0]") {
+		t.Errorf("expected error for a non-reconfigurable child, got %v", err)
+	}
+	reshaped, _ := strategy.PrepareParams(factory, map[string]interface{}{"children": []interface{}{fixedChild(100, "a")}}, "")
+	if err := r.Reconfigure(reshaped); err == nil {
+		t.Errorf("expected error when the children change")
+	}
+	if got := s.Evaluate(strategy.StrategyInput{}); got.Qty != 100 {
+		t.Errorf("failed reconfiguration should keep the previous settings, got %v", got.Qty)
+	}
+}
+
+func TestEnsembleStrategy_Reconfigure_RejectedChildKeepsOthers(t *testing.T) {
+	factory, _ := strategy.GetFactory("ensemble")
+	s := newEnsemble(t, "first_non_flat", tunableChild(100, false), tunableChild(0, false))
+	r := s.(strategy.Reconfigurable)
+
+	// 後ろの子戦略が差し替えを拒否した場合、先に差し替えた子戦略も元のパラメータに戻す
+	next, err := strategy.PrepareParams(factory, map[string]interface{}{"rule": "first_non_flat", "children": []interface{}{tunableChild(200, false), tunableChild(0, true)}}, "")
+	if err != nil {
+		t.Fatalf("PrepareParams failed: %v", err)
+	}
+	if err := r.Reconfigure(next); err == nil || !strings.Contains(err.Error(), "children[1]") {
+		t.Fatalf("expected the rejection of children[1], got %v", err)
+	}
+	if got := s.Evaluate(strategy.StrategyInput{}); got.Qty != 100 {
+		t.Errorf("expected children[0] to keep the previous params, got %v", got.Qty)
+	}
+
+	// 全ての子戦略が受け付ければ差し替わる
+	next, _ = strategy.PrepareParams(factory, map[string]interface{}{"rule": "first_non_flat", "children": []interface{}{tunableChild(200, false), tunableChild(0, false)}}, "")
+	if err := r.Reconfigure(next); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if got := s.Evaluate(strategy.StrategyInput{}); got.Qty != 200 {
+		t.Errorf("expected children[0] to be reconfigured, got %v", got.Qty)
+	}
+}
@@ -0,0 +1,153 @@
+// Package expr は operations.json に書かれた売買条件の式（例: "close > sma(20) && rsi(14) < 30"）を
+// 起動時に構文解析・型検査し、Tick 毎に呼び出せるクロージャにコンパイルします。
+// 識別子や指標関数の解決は Env に任せ、コンパイル後の評価ではマップ引きやリフレクションを行いません。
+package expr
+
+import (
+	"fmt"
+	"math"
+)
+
+// Type は式の型です
+type Type int
+
+const (
+	TYPE_NUMBER Type = iota + 1 // 数値
+	TYPE_BOOL                   // 真偽値
+)
+
+func (t Type) String() string {
+	switch t {
+	case TYPE_NUMBER:
+		return "数値"
+	case TYPE_BOOL:
+		return "真偽値"
+	}
+	return "不明"
+}
+
+// Binding は識別子や指標関数を解決した結果です
+type Binding struct {
+	Value func() float64
+	// Ready は値が使える状態か（指標のウォームアップが済んだか）を返します。nil の場合は常に使えるものとします
+	Ready func() bool
+}
+
+// Env は式に現れる識別子と指標関数を解決します。解決は Compile の時点で1度だけ行われます。
+type Env interface {
+	// Var は識別子 name を解決します。未定義の場合は false を返します
+	Var(name string) (Binding, bool)
+	// Func は指標関数 name を解決します。引数は定数に限られ、指標の生成・取得はこの時点で行います
+	Func(name string, args []float64) (Binding, error)
+}
+
+// Error は式の構文・型のエラーです
+type Error struct {
+	Pos int // 式の先頭からのバイト位置
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d文字目: %s", e.Pos+1, e.Msg)
+}
+
+// Program はコンパイル済みの式です
+type Program struct {
+	src   string
+	node  node
+	ready []func() bool
+}
+
+// Compile は式を構文解析・型検査してコンパイルします
+func Compile(src string, env Env) (*Program, error) {
+	p := &parser{lexer: lexer{src: src}, env: env}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("式の終わりに余分な %q があります", p.tok.text)
+	}
+	return &Program{src: src, node: n, ready: p.ready}, nil
+}
+
+// CompileBool は真偽値の式（売買条件）をコンパイルします
+func CompileBool(src string, env Env) (*Program, error) {
+	return compileTyped(src, env, TYPE_BOOL)
+}
+
+// CompileNumber は数値の式（価格など）をコンパイルします
+func CompileNumber(src string, env Env) (*Program, error) {
+	return compileTyped(src, env, TYPE_NUMBER)
+}
+
+func compileTyped(src string, env Env, want Type) (*Program, error) {
+	prog, err := Compile(src, env)
+	if err != nil {
+		return nil, err
+	}
+	if prog.Type() != want {
+		return nil, &Error{Pos: 0, Msg: fmt.Sprintf("%sの式を指定してください（%sの式です）", want, prog.Type())}
+	}
+	return prog, nil
+}
+
+// String は元の式を返します
+func (p *Program) String() string {
+	return p.src
+}
+
+// Type は式の型を返します
+func (p *Program) Type() Type {
+	return p.node.typ
+}
+
+// Ready は式が参照するすべての識別子・指標が使える状態かを返します
+func (p *Program) Ready() bool {
+	for _, ready := range p.ready {
+		if !ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// Bool は真偽値の式を評価します。参照する指標が使えない間は false を返します
+func (p *Program) Bool() bool {
+	return p.Ready() && p.node.boolean()
+}
+
+// Number は数値の式を評価します。参照する指標が使えない間や計算できない場合は NaN を返します
+func (p *Program) Number() float64 {
+	if !p.Ready() {
+		return math.NaN()
+	}
+	return p.node.number()
+}
+
+// node はコンパイル済みの部分式です（型に応じて number / boolean のどちらかを持ちます）
+type node struct {
+	typ      Type
+	number   func() float64
+	boolean  func() bool
+	constant bool // 識別子・指標を含まない（コンパイル時に値が決まる）
+}
+
+func numberNode(fn func() float64, constant bool) node {
+	if constant {
+		v := fn()
+		fn = func() float64 { return v }
+	}
+	return node{typ: TYPE_NUMBER, number: fn, constant: constant}
+}
+
+func boolNode(fn func() bool, constant bool) node {
+	if constant {
+		v := fn()
+		fn = func() bool { return v }
+	}
+	return node{typ: TYPE_BOOL, boolean: fn, constant: constant}
+}
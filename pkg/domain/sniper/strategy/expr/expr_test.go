@@ -0,0 +1,150 @@
+package expr_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy/expr"
+)
+
+// testEnv は変数を map で、指標関数 sma(n) を「n * 10」で解決するテスト用の Env です
+type testEnv struct {
+	vars  map[string]float64
+	ready bool
+	calls []string
+}
+
+func (e *testEnv) Var(name string) (expr.Binding, bool) {
+	if _, ok := e.vars[name]; !ok {
+		return expr.Binding{}, false
+	}
+	return expr.Binding{Value: func() float64 { return e.vars[name] }}, true
+}
+
+func (e *testEnv) Func(name string, args []float64) (expr.Binding, error) {
+	e.calls = append(e.calls, fmt.Sprintf("%s%v", name, args))
+	if name != "sma" {
+		return expr.Binding{}, fmt.Errorf("未定義の関数 %s です", name)
+	}
+	v := args[0] * 10
+	return expr.Binding{Value: func() float64 { return v }, Ready: func() bool { return e.ready }}, nil
+}
+
+func TestCompile_Evaluate(t *testing.T) {
+	env := &testEnv{vars: map[string]float64{"close": 250, "rsi": 25, "position": 0}, ready: true}
+
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{"close > sma(20) && rsi < 30", true},
+		{"close > sma(30) || rsi >= 30", false},
+		{"!(close < sma(20))", true},
+		{"close - sma(20) * 2 / 4", 150.0},
+		{"-close + 1", -249.0},
+		{"abs(position - 100) == 100", true},
+		{"max(close, sma(30)) - min(close, sma(30))", 50.0},
+		{"sma(2 * 10) == 200", true},
+		{"(rsi < 30) == true", true},
+		{"close / position > 0", false}, // 0除算は NaN になり比較は偽
+		{".5 + 1.25", 1.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			prog, err := expr.Compile(tt.src, env)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			switch want := tt.want.(type) {
+			case bool:
+				if prog.Type() != expr.TYPE_BOOL || prog.Bool() != want {
+					t.Errorf("expected %v, got %v (%v)", want, prog.Bool(), prog.Type())
+				}
+			case float64:
+				if prog.Type() != expr.TYPE_NUMBER || prog.Number() != want {
+					t.Errorf("expected %v, got %v (%v)", want, prog.Number(), prog.Type())
+				}
+			}
+		})
+	}
+
+	// 変数は評価のたびに読み直される
+	prog, _ := expr.CompileBool("close > sma(20)", env)
+	env.vars["close"] = 150
+	if prog.Bool() {
+		t.Errorf("expected re-evaluation with the latest value")
+	}
+}
+
+func TestProgram_Ready(t *testing.T) {
+	env := &testEnv{vars: map[string]float64{"close": 250}}
+	cond, _ := expr.CompileBool("!(close < sma(20))", env)
+	num, _ := expr.CompileNumber("close - sma(20)", env)
+
+	// 指標のウォームアップ中は条件を偽、数値を NaN とする（! で反転しても真にならない）
+	if cond.Ready() || cond.Bool() {
+		t.Errorf("expected false while the indicator is warming up")
+	}
+	if !math.IsNaN(num.Number()) {
+		t.Errorf("expected NaN while the indicator is warming up, got %v", num.Number())
+	}
+
+	env.ready = true
+	if !cond.Bool() || num.Number() != 50 {
+		t.Errorf("expected values after warm-up, got %v / %v", cond.Bool(), num.Number())
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	env := &testEnv{vars: map[string]float64{"close": 0, "rsi": 0}}
+
+	tests := []struct {
+		name    string
+		src     string
+		compile func(string, expr.Env) (*expr.Program, error)
+		wantPos int
+		wantMsg string
+	}{
+		{"unknown var", "close > smaa", expr.Compile, 9, "未定義の識別子 smaa"},
+		{"unknown func", "close > ema(20)", expr.Compile, 9, "未定義の関数 ema"},
+		{"non constant arg", "close > sma(rsi)", expr.Compile, 13, "定数の数値"},
+		{"bool arithmetic", "close + (rsi < 30)", expr.Compile, 7, "数値が必要"},
+		{"number logic", "close && rsi < 30", expr.Compile, 7, "真偽値が必要"},
+		{"chained compare", "1 < close < 3", expr.Compile, 11, "連続して"},
+		{"unclosed paren", "(close > 1", expr.Compile, 11, "\")\" が必要"},
+		{"trailing token", "close > 1 )", expr.Compile, 11, "余分な"},
+		{"bad char", "close > 1 & rsi", expr.Compile, 11, "使用できない文字"},
+		{"incomplete", "close >", expr.Compile, 8, "途中で終わって"},
+		{"builtin arity", "abs(close, rsi) > 0", expr.Compile, 1, "引数は 1 個"},
+		{"bad number", "close > 1.2.3", expr.Compile, 9, "解釈できません"},
+		{"want bool", "close * 2", expr.CompileBool, 1, "真偽値の式を指定してください"},
+		{"want number", "close > 2", expr.CompileNumber, 1, "数値の式を指定してください"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.compile(tt.src, env)
+			var e *expr.Error
+			if !errors.As(err, &e) {
+				t.Fatalf("expected *expr.Error, got %v", err)
+			}
+			if e.Pos+1 != tt.wantPos || !strings.Contains(e.Msg, tt.wantMsg) {
+				t.Errorf("expected %q at %d, got %q at %d", tt.wantMsg, tt.wantPos, e.Msg, e.Pos+1)
+			}
+		})
+	}
+}
+
+func BenchmarkProgram_Bool(b *testing.B) {
+	env := &testEnv{vars: map[string]float64{"close": 250, "rsi": 25}, ready: true}
+	prog, err := expr.CompileBool("close > sma(20) && rsi < 30 || close < sma(5) * 0.98", env)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		prog.Bool()
+	}
+}
@@ -0,0 +1,463 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lexer は式を字句に分割します
+type lexer struct {
+	src string
+	pos int
+}
+
+// twoCharOps は2文字の演算子です（1文字の演算子より先に照合する）
+var twoCharOps = []string{"&&", "||", "<=", ">=", "==", "!="}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && isSpace(l.src[l.pos]) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		return token{kind: tokenNumber, text: l.src[start:l.pos], pos: start}, nil
+	case isIdentStart(c):
+		for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenIdent, text: l.src[start:l.pos], pos: start}, nil
+	}
+
+	for _, op := range twoCharOps {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += 2
+			return token{kind: tokenOp, text: op, pos: start}, nil
+		}
+	}
+	if strings.IndexByte("+-*/<>!(),", c) >= 0 {
+		l.pos++
+		return token{kind: tokenOp, text: string(c), pos: start}, nil
+	}
+	return token{}, &Error{Pos: start, Msg: fmt.Sprintf("使用できない文字 %q があります", c)}
+}
+
+func isSpace(c byte) bool      { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
+func isDigit(c byte) bool      { return c >= '0' && c <= '9' }
+func isIdentStart(c byte) bool { return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+// parser は再帰下降で構文解析しながら、型検査とクロージャへのコンパイルを同時に行います。
+//
+//	or      = and { "||" and }
+//	and     = compare { "&&" compare }
+//	compare = sum [ ( "<" | "<=" | ">" | ">=" | "==" | "!=" ) sum ]
+//	sum     = product { ( "+" | "-" ) product }
+//	product = unary { ( "*" | "/" ) unary }
+//	unary   = ( "!" | "-" ) unary | primary
+//	primary = number | "true" | "false" | ident | ident "(" [ or { "," or } ] ")" | "(" or ")"
+type parser struct {
+	lexer lexer
+	env   Env
+	tok   token
+	ready []func() bool
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &Error{Pos: p.tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	if p.tok.kind != tokenOp {
+		return false
+	}
+	for _, op := range ops {
+		if p.tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		if p.tok.kind == tokenEOF {
+			return p.errorf("%q が必要です（式が途中で終わっています）", op)
+		}
+		return p.errorf("%q が必要です（%q があります）", op, p.tok.text)
+	}
+	return p.advance()
+}
+
+func typeError(pos int, op string, want Type, got node) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf("演算子 %s には%sが必要です（%sが指定されています）", op, want, got.typ)}
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return node{}, err
+	}
+	for p.isOp("||") {
+		pos := p.tok.pos
+		if err := p.advance(); err != nil {
+			return node{}, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return node{}, err
+		}
+		if left.typ != TYPE_BOOL {
+			return node{}, typeError(pos, "||", TYPE_BOOL, left)
+		}
+		if right.typ != TYPE_BOOL {
+			return node{}, typeError(pos, "||", TYPE_BOOL, right)
+		}
+		l, r := left.boolean, right.boolean
+		left = boolNode(func() bool { return l() || r() }, left.constant && right.constant)
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return node{}, err
+	}
+	for p.isOp("&&") {
+		pos := p.tok.pos
+		if err := p.advance(); err != nil {
+			return node{}, err
+		}
+		right, err := p.parseCompare()
+		if err != nil {
+			return node{}, err
+		}
+		if left.typ != TYPE_BOOL {
+			return node{}, typeError(pos, "&&", TYPE_BOOL, left)
+		}
+		if right.typ != TYPE_BOOL {
+			return node{}, typeError(pos, "&&", TYPE_BOOL, right)
+		}
+		l, r := left.boolean, right.boolean
+		left = boolNode(func() bool { return l() && r() }, left.constant && right.constant)
+	}
+	return left, nil
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return node{}, err
+	}
+	if !p.isOp("<", "<=", ">", ">=", "==", "!=") {
+		return left, nil
+	}
+	op, pos := p.tok.text, p.tok.pos
+	if err := p.advance(); err != nil {
+		return node{}, err
+	}
+	right, err := p.parseSum()
+	if err != nil {
+		return node{}, err
+	}
+	if p.isOp("<", "<=", ">", ">=", "==", "!=") {
+		return node{}, p.errorf("比較演算子は連続して使えません。&& で区切ってください")
+	}
+
+	constant := left.constant && right.constant
+	if left.typ == TYPE_BOOL && right.typ == TYPE_BOOL && (op == "==" || op == "!=") {
+		l, r := left.boolean, right.boolean
+		if op == "==" {
+			return boolNode(func() bool { return l() == r() }, constant), nil
+		}
+		return boolNode(func() bool { return l() != r() }, constant), nil
+	}
+	if left.typ != TYPE_NUMBER {
+		return node{}, typeError(pos, op, TYPE_NUMBER, left)
+	}
+	if right.typ != TYPE_NUMBER {
+		return node{}, typeError(pos, op, TYPE_NUMBER, right)
+	}
+	l, r := left.number, right.number
+	var fn func() bool
+	switch op {
+	case "<":
+		fn = func() bool { return l() < r() }
+	case "<=":
+		fn = func() bool { return l() <= r() }
+	case ">":
+		fn = func() bool { return l() > r() }
+	case ">=":
+		fn = func() bool { return l() >= r() }
+	case "==":
+		fn = func() bool { return l() == r() }
+	default:
+		fn = func() bool { return l() != r() }
+	}
+	return boolNode(fn, constant), nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return node{}, err
+	}
+	for p.isOp("+", "-") {
+		op, pos := p.tok.text, p.tok.pos
+		if err := p.advance(); err != nil {
+			return node{}, err
+		}
+		right, err := p.parseProduct()
+		if err != nil {
+			return node{}, err
+		}
+		if left, err = arithmetic(op, pos, left, right); err != nil {
+			return node{}, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseProduct() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return node{}, err
+	}
+	for p.isOp("*", "/") {
+		op, pos := p.tok.text, p.tok.pos
+		if err := p.advance(); err != nil {
+			return node{}, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return node{}, err
+		}
+		if left, err = arithmetic(op, pos, left, right); err != nil {
+			return node{}, err
+		}
+	}
+	return left, nil
+}
+
+func arithmetic(op string, pos int, left, right node) (node, error) {
+	if left.typ != TYPE_NUMBER {
+		return node{}, typeError(pos, op, TYPE_NUMBER, left)
+	}
+	if right.typ != TYPE_NUMBER {
+		return node{}, typeError(pos, op, TYPE_NUMBER, right)
+	}
+	l, r := left.number, right.number
+	var fn func() float64
+	switch op {
+	case "+":
+		fn = func() float64 { return l() + r() }
+	case "-":
+		fn = func() float64 { return l() - r() }
+	case "*":
+		fn = func() float64 { return l() * r() }
+	default:
+		// 0 除算は NaN とし、比較はすべて false になる
+		fn = func() float64 {
+			d := r()
+			if d == 0 {
+				return math.NaN()
+			}
+			return l() / d
+		}
+	}
+	return numberNode(fn, left.constant && right.constant), nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if !p.isOp("!", "-") {
+		return p.parsePrimary()
+	}
+	op, pos := p.tok.text, p.tok.pos
+	if err := p.advance(); err != nil {
+		return node{}, err
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return node{}, err
+	}
+	if op == "!" {
+		if operand.typ != TYPE_BOOL {
+			return node{}, typeError(pos, op, TYPE_BOOL, operand)
+		}
+		b := operand.boolean
+		return boolNode(func() bool { return !b() }, operand.constant), nil
+	}
+	if operand.typ != TYPE_NUMBER {
+		return node{}, typeError(pos, op, TYPE_NUMBER, operand)
+	}
+	n := operand.number
+	return numberNode(func() float64 { return -n() }, operand.constant), nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return node{}, p.errorf("数値 %q を解釈できません", tok.text)
+		}
+		if err := p.advance(); err != nil {
+			return node{}, err
+		}
+		return numberNode(func() float64 { return v }, true), nil
+
+	case tokenIdent:
+		if err := p.advance(); err != nil {
+			return node{}, err
+		}
+		switch tok.text {
+		case "true":
+			return boolNode(func() bool { return true }, true), nil
+		case "false":
+			return boolNode(func() bool { return false }, true), nil
+		}
+		if p.isOp("(") {
+			return p.parseCall(tok)
+		}
+		b, ok := p.env.Var(tok.text)
+		if !ok {
+			return node{}, &Error{Pos: tok.pos, Msg: fmt.Sprintf("未定義の識別子 %s です", tok.text)}
+		}
+		return p.bind(b), nil
+
+	case tokenOp:
+		if tok.text == "(" {
+			if err := p.advance(); err != nil {
+				return node{}, err
+			}
+			n, err := p.parseOr()
+			if err != nil {
+				return node{}, err
+			}
+			if err := p.expect(")"); err != nil {
+				return node{}, err
+			}
+			return n, nil
+		}
+	case tokenEOF:
+		return node{}, p.errorf("式が途中で終わっています")
+	}
+	return node{}, p.errorf("%q は使えません", tok.text)
+}
+
+// parseCall は関数呼び出しを解析します。abs / min / max は式を引数に取り、それ以外は Env の指標関数として定数の引数で解決します
+func (p *parser) parseCall(name token) (node, error) {
+	if err := p.advance(); err != nil { // "("
+		return node{}, err
+	}
+	var args []node
+	var argPos []int
+	for !p.isOp(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return node{}, err
+			}
+		}
+		argPos = append(argPos, p.tok.pos)
+		arg, err := p.parseOr()
+		if err != nil {
+			return node{}, err
+		}
+		args = append(args, arg)
+	}
+	if err := p.advance(); err != nil { // ")"
+		return node{}, err
+	}
+
+	if builtin, ok := builtins[name.text]; ok {
+		if len(args) != builtin.arity {
+			return node{}, &Error{Pos: name.pos, Msg: fmt.Sprintf("関数 %s の引数は %d 個です（%d 個指定されています）", name.text, builtin.arity, len(args))}
+		}
+		constant := true
+		fns := make([]func() float64, len(args))
+		for i, a := range args {
+			if a.typ != TYPE_NUMBER {
+				return node{}, &Error{Pos: argPos[i], Msg: fmt.Sprintf("関数 %s の引数には数値が必要です", name.text)}
+			}
+			fns[i] = a.number
+			constant = constant && a.constant
+		}
+		return numberNode(builtin.compile(fns), constant), nil
+	}
+
+	values := make([]float64, len(args))
+	for i, a := range args {
+		if a.typ != TYPE_NUMBER || !a.constant {
+			return node{}, &Error{Pos: argPos[i], Msg: fmt.Sprintf("関数 %s の引数には定数の数値を指定してください", name.text)}
+		}
+		values[i] = a.number()
+	}
+	b, err := p.env.Func(name.text, values)
+	if err != nil {
+		return node{}, &Error{Pos: name.pos, Msg: err.Error()}
+	}
+	return p.bind(b), nil
+}
+
+// bind は Env が解決した値を式のノードにし、ウォームアップの判定を Program に登録します
+func (p *parser) bind(b Binding) node {
+	if b.Ready != nil {
+		p.ready = append(p.ready, b.Ready)
+	}
+	return numberNode(b.Value, false)
+}
+
+type builtin struct {
+	arity   int
+	compile func(args []func() float64) func() float64
+}
+
+var builtins = map[string]builtin{
+	"abs": {1, func(a []func() float64) func() float64 {
+		x := a[0]
+		return func() float64 { return math.Abs(x()) }
+	}},
+	"min": {2, func(a []func() float64) func() float64 {
+		x, y := a[0], a[1]
+		return func() float64 { return math.Min(x(), y()) }
+	}},
+	"max": {2, func(a []func() float64) func() float64 {
+		x, y := a[0], a[1]
+		return func() float64 { return math.Max(x(), y()) }
+	}},
+}
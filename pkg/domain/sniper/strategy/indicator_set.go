@@ -0,0 +1,56 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy/expr"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// IndicatorSet は名前付きの数値の式（例: {"sma20": "sma(20)", "rsi": "rsi(14)"}）をまとめて評価します。
+// 式で使える値・指標関数は rules 戦略と同じです。Go 以外で書かれた戦略（external）に指標の値を渡す用途を想定しています。
+type IndicatorSet struct {
+	env      *rulesEnv
+	names    []string
+	programs []*expr.Program
+}
+
+// CompileIndicators は指標の式をコンパイルします。dataPool が nil の場合は検証だけを行い、指標は生成しません。
+// エラーは指標名を Path に持つ *ParamError です。
+func CompileIndicators(exprs map[string]string, detail symbol.Symbol, dataPool tick.DataPool, bar time.Duration) (*IndicatorSet, error) {
+	s := &IndicatorSet{env: newRulesEnv(detail, dataPool, bar)}
+	for name := range exprs {
+		s.names = append(s.names, name)
+	}
+	sort.Strings(s.names)
+	for _, name := range s.names {
+		prog, err := expr.CompileNumber(exprs[name], s.env)
+		if err != nil {
+			return nil, &ParamError{Path: name, Err: fmt.Errorf("%w: %v", ErrParamType, err)}
+		}
+		s.programs = append(s.programs, prog)
+	}
+	return s, nil
+}
+
+// Values は input 時点の指標の値を dst に書き込みます。ウォームアップ中などで計算できない指標は dst から取り除きます。
+func (s *IndicatorSet) Values(input StrategyInput, dst map[string]float64) {
+	s.env.input = input
+	for i, prog := range s.programs {
+		v := prog.Number()
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			delete(dst, s.names[i])
+			continue
+		}
+		dst[s.names[i]] = v
+	}
+}
+
+// Len は指標の数を返します
+func (s *IndicatorSet) Len() int {
+	return len(s.programs)
+}
@@ -0,0 +1,186 @@
+package strategy
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// ORBParams は orb 戦略のパラメータです（operations.json の strategy_params.orb）
+type ORBParams struct {
+	Window        time.Duration `json:"window"`         // 寄付からオープニングレンジを測る時間
+	Side          string        `json:"side"`           // "long" / "short" / "both"
+	Units         int           `json:"units"`          // 1回に建てる売買単位の数
+	MinRange      float64       `json:"min_range"`      // レンジ幅の下限（レンジ安値に対する比率）。狭すぎるレンジのブレイクはダマシとして見送る
+	MaxRange      float64       `json:"max_range"`      // レンジ幅の上限（同上）。寄付から大きく動いた日は見送る
+	TargetRatio   float64       `json:"target_ratio"`   // 利確幅（レンジ幅の倍率）。0 の場合は利確の指値を出さない
+	FlattenBefore time.Duration `json:"flatten_before"` // 後場のザラバ終了のこの時間前に手仕舞い、以降は建てない
+	Policy        string        `json:"policy"`         // 疑似約定の判定（none / touch / pierce / volume）
+	TouchTTL      time.Duration `json:"touch_ttl"`      // policy が touch の場合の TTL
+}
+
+var orbParamSchema = ParamSchema{
+	Fields: []ParamField{
+		{Name: "window", Type: PARAM_TYPE_DURATION, Default: "15m", Min: Bound(60), Max: Bound(2 * 60 * 60), Description: "オープニングレンジを測る時間"},
+		{Name: "side", Type: PARAM_TYPE_STRING, Default: "long", Choices: []string{"long", "short", "both"}, Description: "建てる方向"},
+		{Name: "units", Type: PARAM_TYPE_INT, Default: 1, Min: Bound(1), Max: Bound(100), Description: "1回に建てる売買単位の数"},
+		{Name: "min_range", Type: PARAM_TYPE_FLOAT, Default: 0.002, Min: Bound(0), Max: Bound(1), Description: "レンジ幅の下限（比率）"},
+		{Name: "max_range", Type: PARAM_TYPE_FLOAT, Default: 0.03, Min: Bound(0), Max: Bound(1), Description: "レンジ幅の上限（比率）"},
+		{Name: "target_ratio", Type: PARAM_TYPE_FLOAT, Default: 1.0, Min: Bound(0), Max: Bound(10), Description: "利確幅（レンジ幅の倍率）"},
+		{Name: "flatten_before", Type: PARAM_TYPE_DURATION, Default: "10m", Min: Bound(0), Description: "ザラバ終了前に手仕舞う時間"},
+		{Name: "policy", Type: PARAM_TYPE_STRING, Default: POLICY_PIERCE, Choices: PolicyChoices, Description: "疑似約定の判定"},
+		{Name: "touch_ttl", Type: PARAM_TYPE_DURATION, Default: "3s", Min: Bound(0), Description: "touch の TTL"},
+	},
+	New: func() interface{} { return &ORBParams{} },
+}
+
+// ORBStrategy はオープニングレンジ・ブレイクアウト戦略です。
+//   - 前場の寄付から window の間の高値・安値をオープニングレンジとする
+//   - レンジ確定後、高値を上抜けたら成行で買い（short なら安値を下抜けたら売り）、利確の指値をレンジ幅×target_ratio 先に IFD で同時に発注する
+//   - 損切りはレンジの反対側（買いならレンジ安値）で、割り込んだ Tick で成行で手仕舞う
+//   - 1日1回だけ建て、後場のザラバ終了の flatten_before 前に手仕舞う
+type ORBStrategy struct {
+	detail symbol.Symbol
+	params ORBParams
+
+	day         time.Time // レンジを測っている日（日本時間の0時）
+	high        float64
+	low         float64
+	rangeClosed bool // window が過ぎてレンジが確定したか
+	traded      bool // 当日すでに建てたか
+}
+
+func (s *ORBStrategy) Name() string {
+	return "orb"
+}
+
+func (s *ORBStrategy) AnalysisLogger() *slog.Logger {
+	return nil
+}
+
+func (s *ORBStrategy) Evaluate(input StrategyInput) TargetPosition {
+	holdQty := input.HoldQty()
+	t := input.LatestTick
+	if !t.IsExecution() || t.Price <= 0 {
+		return TargetPosition{Qty: holdQty}
+	}
+	s.rollDay(t.CurrentPriceTime)
+
+	sess, elapsed, remaining, ok := session.Default().ContinuousWindow(t.CurrentPriceTime)
+	if !ok {
+		return TargetPosition{Qty: holdQty}
+	}
+	closing := sess == session.SESSION_AFTERNOON && remaining <= s.params.FlattenBefore
+
+	if holdQty != 0 {
+		s.traded = true
+		switch {
+		case holdQty > 0 && s.low > 0 && t.Price <= s.low:
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "StopLoss_ORB_Low"}
+		case holdQty < 0 && s.high > 0 && t.Price >= s.high:
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "StopLoss_ORB_High"}
+		case closing:
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "TimeStop"}
+		}
+		return TargetPosition{Qty: holdQty}
+	}
+
+	if !s.rangeClosed {
+		if sess == session.SESSION_MORNING && elapsed < s.params.Window {
+			if s.high == 0 || t.Price > s.high {
+				s.high = t.Price
+			}
+			if s.low == 0 || t.Price < s.low {
+				s.low = t.Price
+			}
+			return TargetPosition{Qty: 0}
+		}
+		// window を過ぎてから初めて Tick を受け取った日（途中起動など）はレンジが無いため建てない
+		s.rangeClosed = true
+	}
+	if s.traded || closing || s.low <= 0 {
+		return TargetPosition{Qty: 0}
+	}
+
+	width := s.high - s.low
+	if ratio := width / s.low; ratio < s.params.MinRange || ratio > s.params.MaxRange {
+		return TargetPosition{Qty: 0}
+	}
+
+	// 約定するまではブレイクが続く限り同じターゲットを返す（レンジ内に戻ったら未約定の注文は取り消される）
+	qty := float64(s.params.Units) * s.detail.Unit()
+	var target TargetPosition
+	switch {
+	case t.Price > s.high && s.params.Side != "short":
+		target = TargetPosition{Qty: qty, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "ORB_Breakout"}
+		if s.params.TargetRatio > 0 {
+			target.HasIfDone = true
+			target.ExitPrice = s.detail.RoundPrice(s.high + width*s.params.TargetRatio)
+			target.ExitOrderType = order.ORDER_TYPE_LIMIT
+			target.ExitReason = "TakeProfit"
+		}
+	case t.Price < s.low && s.params.Side != "long":
+		target = TargetPosition{Qty: -qty, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "ORB_Breakdown"}
+		if s.params.TargetRatio > 0 {
+			target.HasIfDone = true
+			target.ExitPrice = s.detail.RoundPrice(s.low - width*s.params.TargetRatio)
+			target.ExitOrderType = order.ORDER_TYPE_LIMIT
+			target.ExitReason = "TakeProfit"
+		}
+	default:
+		return TargetPosition{Qty: 0}
+	}
+	return target
+}
+
+// rollDay は日付が変わったらレンジと建てた記録をリセットします
+func (s *ORBStrategy) rollDay(now time.Time) {
+	day := sessionDay(now)
+	if day.Equal(s.day) {
+		return
+	}
+	s.day = day
+	s.high, s.low = 0, 0
+	s.rangeClosed = false
+	s.traded = false
+}
+
+// sessionDay は t の日付（日本時間の0時）を返します
+func sessionDay(t time.Time) time.Time {
+	local := t.In(session.Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, session.Location())
+}
+
+// Reconfigure は稼働中に orb 戦略のパラメータを差し替えます。測定中・確定済みのレンジはそのまま使います
+func (s *ORBStrategy) Reconfigure(params interface{}) error {
+	s.params = *ParamsOf[ORBParams](orbParamSchema, params)
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Factory & Registration
+// ----------------------------------------------------------------------------
+
+type ORBStrategyFactory struct{}
+
+func (f *ORBStrategyFactory) NewStrategy(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) Strategy {
+	return &ORBStrategy{detail: detail, params: *ParamsOf[ORBParams](orbParamSchema, params)}
+}
+
+func (f *ORBStrategyFactory) CreateExecutionPolicy(params interface{}) ExecutionPolicy {
+	p := ParamsOf[ORBParams](orbParamSchema, params)
+	return NewExecutionPolicy(p.Policy, p.TouchTTL)
+}
+
+// ParamSchema は orb 戦略のパラメータ定義を返します
+func (f *ORBStrategyFactory) ParamSchema() ParamSchema {
+	return orbParamSchema
+}
+
+func init() {
+	Register("orb", &ORBStrategyFactory{})
+}
@@ -0,0 +1,71 @@
+package strategy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+func orbTick(clock string, price float64) tick.Tick {
+	c, _ := time.Parse("15:04", clock)
+	at := time.Date(2026, 6, 10, c.Hour(), c.Minute(), 0, 0, session.Location())
+	return tick.Tick{Symbol: "7203", Price: price, TradingVolume: 100, CurrentPriceTime: at, CurrentPriceStatus: tick.PRICE_STATUS_CURRENT}
+}
+
+func TestORBStrategy_Evaluate(t *testing.T) {
+	factory, _ := strategy.GetFactory("orb")
+	s := factory.NewStrategy(symbol.Symbol{Code: "7203", PriceRangeGroup: symbol.PRICE_RANGE_GROUP_TSE_STANDARD}, &dummyDataPool{}, nil)
+	flat := func(clock string, price float64) strategy.TargetPosition {
+		return s.Evaluate(strategy.StrategyInput{LatestTick: orbTick(clock, price)})
+	}
+
+	// レンジの測定中は建てない
+	for _, tt := range []struct {
+		clock string
+		price float64
+	}{{"09:00", 1000}, {"09:05", 1010}, {"09:14", 1005}} {
+		if got := flat(tt.clock, tt.price); !got.IsFlat() {
+			t.Fatalf("expected no entry while measuring the range, got %+v", got)
+		}
+	}
+	if got := flat("09:20", 1010); !got.IsFlat() {
+		t.Errorf("touching the range high is not a breakout, got %+v", got)
+	}
+
+	got := flat("09:21", 1011)
+	if got.Qty != 100 || got.OrderType != order.ORDER_TYPE_MARKET || got.ExitPrice != 1020 || got.ExitOrderType != order.ORDER_TYPE_LIMIT {
+		t.Fatalf("expected breakout entry with take profit at 1020, got %+v", got)
+	}
+
+	holding := func(clock string, price float64) strategy.TargetPosition {
+		return s.Evaluate(strategy.StrategyInput{Position: strategy.Position{Qty: 100, AveragePrice: 1011}, LatestTick: orbTick(clock, price)})
+	}
+	if got := holding("10:00", 1001); got.Qty != 100 {
+		t.Errorf("expected hold above the range low, got %+v", got)
+	}
+	if got := holding("15:16", 1015); !got.IsFlat() || got.Reason != "TimeStop" {
+		t.Errorf("expected time stop before the closing auction, got %+v", got)
+	}
+	if got := holding("10:01", 1000); !got.IsFlat() || got.Reason != "StopLoss_ORB_Low" {
+		t.Errorf("expected stop at the range low, got %+v", got)
+	}
+	if got := flat("10:30", 1030); !got.IsFlat() {
+		t.Errorf("expected only one entry per day, got %+v", got)
+	}
+}
+
+func TestORBStrategyFactory_CreateExecutionPolicy(t *testing.T) {
+	factory, _ := strategy.GetFactory("orb")
+	if _, ok := factory.CreateExecutionPolicy(nil).(*strategy.StrictPiercePolicy); !ok {
+		t.Errorf("expected StrictPiercePolicy by default")
+	}
+	policy := factory.CreateExecutionPolicy(map[string]interface{}{"policy": "touch", "touch_ttl": "5s"})
+	if p, ok := policy.(*strategy.TouchTTLPolicy); !ok || p.TTL != 5*time.Second {
+		t.Errorf("expected TouchTTLPolicy with 5s TTL, got %#v", policy)
+	}
+}
@@ -0,0 +1,369 @@
+package strategy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// パラメータの検証エラーです。ParamError に包まれて返されるため errors.Is で判定できます。
+var (
+	ErrUnknownParam  = errors.New("未定義のパラメータです")
+	ErrParamType     = errors.New("パラメータの型が不正です")
+	ErrParamRange    = errors.New("パラメータが範囲外です")
+	ErrParamRequired = errors.New("必須パラメータがありません")
+)
+
+// ParamType はパラメータの型です
+type ParamType string
+
+const (
+	PARAM_TYPE_INT      ParamType = "int"      // 整数
+	PARAM_TYPE_FLOAT    ParamType = "float"    // 実数
+	PARAM_TYPE_BOOL     ParamType = "bool"     // 真偽値
+	PARAM_TYPE_STRING   ParamType = "string"   // 文字列
+	PARAM_TYPE_DURATION ParamType = "duration" // 期間（"5m" のような time.ParseDuration 形式の文字列）
+	PARAM_TYPE_LIST     ParamType = "list"     // 配列（要素は検証しない）
+	PARAM_TYPE_OBJECT   ParamType = "object"   // オブジェクト（中身は検証しない）
+)
+
+// ParamField は戦略パラメータ1項目の定義です
+type ParamField struct {
+	Name        string      // operations.json 上のキー名（型付き構造体の json タグと揃える）
+	Type        ParamType   // 型
+	Default     interface{} // 省略時の値（nil の場合は省略時に項目自体を渡さない）
+	Required    bool        // 省略を許さない
+	Min         *float64    // 下限（数値型のみ。DURATION は秒で比較する）
+	Max         *float64    // 上限（同上）
+	Choices     []string    // 取り得る値（STRING のみ）
+	Description string      // 説明（エラーメッセージにも使われます）
+}
+
+// Bound は ParamField.Min / Max に指定する値を返します
+func Bound(v float64) *float64 {
+	return &v
+}
+
+// ParamSchema は戦略パラメータのスキーマです
+type ParamSchema struct {
+	Fields []ParamField
+	// New は検証済みのパラメータを受け取る型付き構造体を生成します（ポインタを返すこと）。
+	// nil の場合は、デフォルト値を補った map[string]interface{} のまま戦略に渡します。
+	New func() interface{}
+}
+
+// ParamSchemaProvider はパラメータのスキーマを宣言するファクトリが実装します（任意）。
+// 実装したファクトリの NewStrategy / CreateExecutionPolicy には、Decode 済みの値が渡されます。
+type ParamSchemaProvider interface {
+	ParamSchema() ParamSchema
+}
+
+// ParamDecoder はフラットなスキーマでは表せないパラメータ（子戦略のパラメータを入れ子で持つ ensemble など）を
+// 自前で検証・変換するファクトリが実装します（任意）。ParamSchemaProvider より優先されます。
+type ParamDecoder interface {
+	DecodeParams(params interface{}, path string) (interface{}, error)
+}
+
+// ParamError は設定ファイル上の位置（例: operations[3].params.strategy_params.orb.window）付きのパラメータエラーです
+type ParamError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// PrepareParams は operations.json から読み込んだ生のパラメータを、ファクトリのスキーマで検証・変換します。
+// スキーマを宣言していないファクトリには、従来どおり生の値をそのまま返します。
+// path はエラーメッセージに含める設定ファイル上の位置です。
+func PrepareParams(factory StrategyFactory, params interface{}, path string) (interface{}, error) {
+	if decoder, ok := factory.(ParamDecoder); ok {
+		return decoder.DecodeParams(params, path)
+	}
+	provider, ok := factory.(ParamSchemaProvider)
+	if !ok {
+		return params, nil
+	}
+	return provider.ParamSchema().Decode(params, path)
+}
+
+// Decode はパラメータを検証し、デフォルト値を補って型付き構造体（New が nil の場合は map）に変換します。
+// 未定義のキー・型の不一致・範囲外の値は、path からの位置を含む *ParamError を返します。
+func (s ParamSchema) Decode(params interface{}, path string) (interface{}, error) {
+	raw, err := toParamMap(params)
+	if err != nil {
+		return nil, &ParamError{Path: path, Err: err}
+	}
+
+	fields := make(map[string]ParamField, len(s.Fields))
+	for _, f := range s.Fields {
+		fields[f.Name] = f
+	}
+
+	// 綴り間違いがデフォルト値で黙って置き換わらないよう、未定義のキーはエラーにする
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, ok := fields[k]; !ok {
+			return nil, &ParamError{Path: joinParamPath(path, k), Err: fmt.Errorf("%w（指定可能: %s）", ErrUnknownParam, s.names())}
+		}
+	}
+
+	normalized := make(map[string]interface{}, len(s.Fields))
+	for _, f := range s.Fields {
+		v, ok := raw[f.Name]
+		if !ok || v == nil {
+			if f.Required {
+				return nil, &ParamError{Path: joinParamPath(path, f.Name), Err: f.describe(ErrParamRequired)}
+			}
+			if f.Default == nil {
+				continue
+			}
+			v = f.Default
+		}
+		value, err := f.normalize(v)
+		if err != nil {
+			return nil, &ParamError{Path: joinParamPath(path, f.Name), Err: err}
+		}
+		normalized[f.Name] = value
+	}
+
+	if s.New == nil {
+		return normalized, nil
+	}
+	// 検証済みの値を JSON 経由で型付き構造体に詰め替える（DURATION はナノ秒の整数に正規化済み）
+	encoded, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, &ParamError{Path: path, Err: err}
+	}
+	out := s.New()
+	if err := json.Unmarshal(encoded, out); err != nil {
+		return nil, &ParamError{Path: path, Err: err}
+	}
+	return out, nil
+}
+
+// ParamsOf は NewStrategy などに渡された params を型付き構造体として取り出します。
+// エンジン経由では Decode 済みの *T が渡されます。テストなどで生の値（nil や map）が渡された場合はその場で Decode し、
+// 変換できない場合はデフォルト値だけを詰めた構造体を返します。
+func ParamsOf[T any](schema ParamSchema, params interface{}) *T {
+	if p, ok := params.(*T); ok && p != nil {
+		return p
+	}
+	schema.New = func() interface{} { return new(T) }
+	if decoded, err := schema.Decode(params, ""); err == nil {
+		return decoded.(*T)
+	}
+	decoded, err := schema.Decode(nil, "")
+	if err != nil {
+		return new(T)
+	}
+	return decoded.(*T)
+}
+
+func toParamMap(params interface{}) (map[string]interface{}, error) {
+	switch p := params.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: オブジェクトを指定してください", ErrParamType)
+}
+
+func joinParamPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func (s ParamSchema) names() string {
+	names := make([]string, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		names = append(names, f.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// describe はエラーに項目の説明を添えます
+func (f ParamField) describe(err error) error {
+	if f.Description == "" {
+		return err
+	}
+	return fmt.Errorf("%w（%s）", err, f.Description)
+}
+
+// normalize は値を型に合わせて検証し、JSON でそのまま型付き構造体に詰め替えられる形に変換します
+func (f ParamField) normalize(v interface{}) (interface{}, error) {
+	switch f.Type {
+	case PARAM_TYPE_INT, PARAM_TYPE_FLOAT:
+		n, ok := toFloat(v)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, f.describe(fmt.Errorf("%w: 数値を指定してください (%v)", ErrParamType, v))
+		}
+		if f.Type == PARAM_TYPE_INT && n != math.Trunc(n) {
+			return nil, f.describe(fmt.Errorf("%w: 整数を指定してください (%v)", ErrParamType, v))
+		}
+		if err := f.checkRange(n, v); err != nil {
+			return nil, err
+		}
+		return n, nil
+
+	case PARAM_TYPE_BOOL:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, f.describe(fmt.Errorf("%w: true / false を指定してください (%v)", ErrParamType, v))
+		}
+		return b, nil
+
+	case PARAM_TYPE_STRING:
+		str, ok := v.(string)
+		if !ok {
+			return nil, f.describe(fmt.Errorf("%w: 文字列を指定してください (%v)", ErrParamType, v))
+		}
+		if len(f.Choices) > 0 && !containsString(f.Choices, str) {
+			return nil, f.describe(fmt.Errorf("%w: %s のいずれかを指定してください (%s)", ErrParamRange, strings.Join(f.Choices, ", "), str))
+		}
+		return str, nil
+
+	case PARAM_TYPE_DURATION:
+		var d time.Duration
+		switch dv := v.(type) {
+		case string:
+			parsed, err := time.ParseDuration(dv)
+			if err != nil {
+				return nil, f.describe(fmt.Errorf("%w: \"5m\" のような期間を指定してください (%s)", ErrParamType, dv))
+			}
+			d = parsed
+		case time.Duration:
+			d = dv
+		default:
+			return nil, f.describe(fmt.Errorf("%w: \"5m\" のような期間を指定してください (%v)", ErrParamType, v))
+		}
+		if err := f.checkRange(d.Seconds(), v); err != nil {
+			return nil, err
+		}
+		return int64(d), nil
+
+	case PARAM_TYPE_LIST:
+		if _, ok := v.([]interface{}); !ok {
+			return nil, f.describe(fmt.Errorf("%w: 配列を指定してください", ErrParamType))
+		}
+		return v, nil
+
+	case PARAM_TYPE_OBJECT:
+		if _, ok := v.(map[string]interface{}); !ok {
+			return nil, f.describe(fmt.Errorf("%w: オブジェクトを指定してください", ErrParamType))
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("パラメータ %s の型 %q はサポートされていません", f.Name, f.Type)
+}
+
+func (f ParamField) checkRange(n float64, original interface{}) error {
+	if f.Min != nil && n < *f.Min {
+		return f.describe(fmt.Errorf("%w: %v 以上を指定してください (%v)", ErrParamRange, *f.Min, original))
+	}
+	if f.Max != nil && n > *f.Max {
+		return f.describe(fmt.Errorf("%w: %v 以下を指定してください (%v)", ErrParamRange, *f.Max, original))
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// StrategySpec は作戦に割り当てる戦略の名前と、検証済みのパラメータです
+type StrategySpec struct {
+	Name   string
+	Params interface{}
+}
+
+// ResolveStrategies は default 作戦の params（strategies / strategy_params）から、戦略ごとの検証済みパラメータを組み立てます。
+// 未登録の戦略名や、strategies に無い戦略のパラメータもエラーとし、起動時に設定ミスを検出できるようにします。
+// path は作戦の params の位置（例: operations[3].params）です。
+func ResolveStrategies(opParams map[string]interface{}, path string) ([]StrategySpec, error) {
+	strategiesPath := joinParamPath(path, "strategies")
+	strategiesRaw, ok := opParams["strategies"].([]interface{})
+	if !ok && opParams["strategies"] != nil {
+		return nil, &ParamError{Path: strategiesPath, Err: fmt.Errorf("%w: 戦略名の配列を指定してください", ErrParamType)}
+	}
+
+	paramsPath := joinParamPath(path, "strategy_params")
+	var strategyParams map[string]interface{}
+	if raw := opParams["strategy_params"]; raw != nil {
+		m, err := toParamMap(raw)
+		if err != nil {
+			return nil, &ParamError{Path: paramsPath, Err: err}
+		}
+		strategyParams = m
+	}
+
+	specs := make([]StrategySpec, 0, len(strategiesRaw))
+	listed := make(map[string]bool, len(strategiesRaw))
+	for i, raw := range strategiesRaw {
+		elemPath := fmt.Sprintf("%s[%d]", strategiesPath, i)
+		name, ok := raw.(string)
+		if !ok {
+			return nil, &ParamError{Path: elemPath, Err: fmt.Errorf("%w: 戦略名を指定してください (%v)", ErrParamType, raw)}
+		}
+		factory, err := GetFactory(name)
+		if err != nil {
+			return nil, &ParamError{Path: elemPath, Err: err}
+		}
+		params, err := PrepareParams(factory, strategyParams[name], joinParamPath(paramsPath, name))
+		if err != nil {
+			return nil, err
+		}
+		listed[name] = true
+		specs = append(specs, StrategySpec{Name: name, Params: params})
+	}
+
+	names := make([]string, 0, len(strategyParams))
+	for name := range strategyParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !listed[name] {
+			return nil, &ParamError{Path: joinParamPath(paramsPath, name), Err: fmt.Errorf("%w: strategies に含まれない戦略のパラメータです", ErrUnknownParam)}
+		}
+	}
+	return specs, nil
+}
@@ -0,0 +1,127 @@
+package strategy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+)
+
+type orbParams struct {
+	Window    time.Duration `json:"window"`
+	Breakout  float64       `json:"breakout"`
+	MaxTrades int           `json:"max_trades"`
+	Side      string        `json:"side"`
+	Enabled   bool          `json:"enabled"`
+}
+
+var orbSchema = strategy.ParamSchema{
+	Fields: []strategy.ParamField{
+		{Name: "window", Type: strategy.PARAM_TYPE_DURATION, Default: "15m", Min: strategy.Bound(60), Description: "レンジを作る時間"},
+		{Name: "breakout", Type: strategy.PARAM_TYPE_FLOAT, Required: true, Min: strategy.Bound(0)},
+		{Name: "max_trades", Type: strategy.PARAM_TYPE_INT, Default: 1, Min: strategy.Bound(1), Max: strategy.Bound(5)},
+		{Name: "side", Type: strategy.PARAM_TYPE_STRING, Default: "both", Choices: []string{"long", "short", "both"}},
+		{Name: "enabled", Type: strategy.PARAM_TYPE_BOOL, Default: true},
+	},
+	New: func() interface{} { return &orbParams{} },
+}
+
+func TestParamSchema_Decode(t *testing.T) {
+	decoded, err := orbSchema.Decode(map[string]interface{}{"window": "5m", "breakout": 0.5, "max_trades": 2.0}, "orb")
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	p := decoded.(*orbParams)
+	if p.Window != 5*time.Minute || p.Breakout != 0.5 || p.MaxTrades != 2 || p.Side != "both" || !p.Enabled {
+		t.Errorf("unexpected params: %+v", p)
+	}
+
+	// New が無いスキーマはデフォルト値を補った map を返す
+	untyped := orbSchema
+	untyped.New = nil
+	m, err := untyped.Decode(map[string]interface{}{"breakout": 1.0}, "")
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got := m.(map[string]interface{}); got["max_trades"] != 1.0 || got["window"] != int64(15*time.Minute) {
+		t.Errorf("unexpected normalized params: %+v", got)
+	}
+}
+
+func TestParamSchema_Decode_Errors(t *testing.T) {
+	const base = "operations[3].params.strategy_params.orb"
+	tests := []struct {
+		name     string
+		params   interface{}
+		wantPath string
+		wantErr  error
+	}{
+		{"typo", map[string]interface{}{"breakout": 1.0, "windw": "5m"}, base + ".windw", strategy.ErrUnknownParam},
+		{"missing", map[string]interface{}{}, base + ".breakout", strategy.ErrParamRequired},
+		{"wrong type", map[string]interface{}{"breakout": "1"}, base + ".breakout", strategy.ErrParamType},
+		{"not an integer", map[string]interface{}{"breakout": 1.0, "max_trades": 1.5}, base + ".max_trades", strategy.ErrParamType},
+		{"above max", map[string]interface{}{"breakout": 1.0, "max_trades": 6.0}, base + ".max_trades", strategy.ErrParamRange},
+		{"short duration", map[string]interface{}{"breakout": 1.0, "window": "30s"}, base + ".window", strategy.ErrParamRange},
+		{"bad duration", map[string]interface{}{"breakout": 1.0, "window": 5.0}, base + ".window", strategy.ErrParamType},
+		{"bad choice", map[string]interface{}{"breakout": 1.0, "side": "up"}, base + ".side", strategy.ErrParamRange},
+		{"not an object", []interface{}{1.0}, base, strategy.ErrParamType},
+	}
+	for _, tt := range tests {
+		_, err := orbSchema.Decode(tt.params, base)
+		var pe *strategy.ParamError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: expected ParamError, got %v", tt.name, err)
+			continue
+		}
+		if pe.Path != tt.wantPath || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: expected %s (%v), got %v", tt.name, tt.wantPath, tt.wantErr, err)
+		}
+	}
+
+	// 説明はエラーメッセージに含める
+	_, err := orbSchema.Decode(map[string]interface{}{"breakout": 1.0, "window": "10s"}, base)
+	if err == nil || !strings.Contains(err.Error(), "レンジを作る時間") {
+		t.Errorf("expected the description in the error, got %v", err)
+	}
+}
+
+func TestResolveStrategies(t *testing.T) {
+	specs, err := strategy.ResolveStrategies(map[string]interface{}{
+		"strategies":      []interface{}{"sample"},
+		"strategy_params": map[string]interface{}{"sample": map[string]interface{}{"rising_bars": 4.0}},
+	}, "operations[0].params")
+	if err != nil {
+		t.Fatalf("ResolveStrategies failed: %v", err)
+	}
+	if len(specs) != 1 || specs[0].Name != "sample" {
+		t.Fatalf("unexpected specs: %+v", specs)
+	}
+	if p, ok := specs[0].Params.(*strategy.SampleParams); !ok || p.RisingBars != 4 {
+		t.Errorf("expected typed sample params, got %#v", specs[0].Params)
+	}
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		wantPath string
+	}{
+		{"unknown strategy", map[string]interface{}{"strategies": []interface{}{"sample", "smaple"}}, "operations[2].params.strategies[1]"},
+		{"params of an unlisted strategy", map[string]interface{}{
+			"strategies":      []interface{}{"sample"},
+			"strategy_params": map[string]interface{}{"orb": map[string]interface{}{}},
+		}, "operations[2].params.strategy_params.orb"},
+		{"invalid param", map[string]interface{}{
+			"strategies":      []interface{}{"sample"},
+			"strategy_params": map[string]interface{}{"sample": map[string]interface{}{"rising_bars": 1.0}},
+		}, "operations[2].params.strategy_params.sample.rising_bars"},
+	}
+	for _, tt := range tests {
+		_, err := strategy.ResolveStrategies(tt.params, "operations[2].params")
+		var pe *strategy.ParamError
+		if !errors.As(err, &pe) || pe.Path != tt.wantPath {
+			t.Errorf("%s: expected an error at %s, got %v", tt.name, tt.wantPath, err)
+		}
+	}
+}
@@ -26,7 +26,8 @@ type TouchTTLPolicy struct {
 }
 
 func (p *TouchTTLPolicy) ApplySyntheticFill(ord *order.Order, tick tick.Tick) {
-	if ord.IsCancelSent() || ord.IsCompleted() {
+	// 逆指値は発動するまで板に並ばないため、疑似約定の判定対象にしない
+	if ord.IsCancelSent() || ord.IsCompleted() || ord.Type.IsStop() {
 		return
 	}
 	if ord.OrderPrice > 0 && tick.Price > 0 { // 指値の場合
@@ -74,7 +75,7 @@ func (p *TouchTTLPolicy) IsOrderDesired(ord *order.Order, sig brain.Signal, symb
 type StrictPiercePolicy struct{}
 
 func (p *StrictPiercePolicy) ApplySyntheticFill(ord *order.Order, tick tick.Tick) {
-	if ord.IsCancelSent() || ord.IsCompleted() {
+	if ord.IsCancelSent() || ord.IsCompleted() || ord.Type.IsStop() {
 		return
 	}
 	if ord.OrderPrice > 0 && tick.Price > 0 {
@@ -108,7 +109,7 @@ type VolumeConsumptionPolicy struct {
 }
 
 func (p *VolumeConsumptionPolicy) ApplySyntheticFill(ord *order.Order, tick tick.Tick) {
-	if ord.IsCancelSent() || ord.IsCompleted() {
+	if ord.IsCancelSent() || ord.IsCompleted() || ord.Type.IsStop() {
 		return
 	}
 	if ord.OrderPrice <= 0 || tick.Price <= 0 {
@@ -196,6 +197,30 @@ func (p *NoopPolicy) IsOrderDesired(ord *order.Order, sig brain.Signal, symbol s
 	return false
 }
 
+// 戦略パラメータ（policy）で選べる疑似約定の判定ロジックです
+const (
+	POLICY_NONE   = "none"   // NoopPolicy
+	POLICY_TOUCH  = "touch"  // TouchTTLPolicy
+	POLICY_PIERCE = "pierce" // StrictPiercePolicy
+	POLICY_VOLUME = "volume" // VolumeConsumptionPolicy
+)
+
+// PolicyChoices は ParamField.Choices に指定する疑似約定の判定ロジックの名前です
+var PolicyChoices = []string{POLICY_NONE, POLICY_TOUCH, POLICY_PIERCE, POLICY_VOLUME}
+
+// NewExecutionPolicy は名前から疑似約定の判定ロジックを生成します。touchTTL は touch の場合だけ使います（不明な名前は NoopPolicy）
+func NewExecutionPolicy(name string, touchTTL time.Duration) ExecutionPolicy {
+	switch name {
+	case POLICY_TOUCH:
+		return &TouchTTLPolicy{TTL: touchTTL}
+	case POLICY_PIERCE:
+		return &StrictPiercePolicy{}
+	case POLICY_VOLUME:
+		return &VolumeConsumptionPolicy{QueueOffsetRatio: 1.0}
+	}
+	return &NoopPolicy{}
+}
+
 // --- ヘルパー関数 ---
 
 // isOrderDesiredDefault は「方向・数量が一致」かつ「価格差が1ティック以内」なら維持とみなすデフォルト判定です。
@@ -0,0 +1,165 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/volatility"
+)
+
+// Regime は相場環境の名前です。regime_router では注文理由の接頭辞（例: "BULL:TakeProfit"）にも使われます。
+type Regime string
+
+const (
+	REGIME_UNKNOWN  Regime = ""         // 指標が揃っておらず判定できない
+	REGIME_BULL     Regime = "BULL"     // 上昇トレンド
+	REGIME_BEAR     Regime = "BEAR"     // 下降トレンド
+	REGIME_FLAT     Regime = "FLAT"     // レンジ
+	REGIME_VOLATILE Regime = "VOLATILE" // 値動きが荒い
+)
+
+// RegimeClassifier は最新の Tick 時点の相場環境を判定します。
+// 判定に使う指標は生成時に DataPool から取得し、Tick 毎の Classify では参照だけを行ってください。
+type RegimeClassifier interface {
+	Classify(input StrategyInput) Regime
+}
+
+// RegimeClassifierFactory は regime_router から利用する相場環境の判定器を生成します
+type RegimeClassifierFactory interface {
+	NewClassifier(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) RegimeClassifier
+	// Regimes はこの判定器が返し得る相場環境です（routes のキーの検証に使われます）
+	Regimes() []Regime
+	ParamSchema() ParamSchema
+}
+
+var regimeClassifiers = make(map[string]RegimeClassifierFactory)
+
+// RegisterRegimeClassifier は相場環境の判定器を名前で登録します
+func RegisterRegimeClassifier(name string, f RegimeClassifierFactory) {
+	regimeClassifiers[name] = f
+}
+
+// GetRegimeClassifier は登録済みの相場環境の判定器を取得します
+func GetRegimeClassifier(name string) (RegimeClassifierFactory, error) {
+	f, ok := regimeClassifiers[name]
+	if !ok {
+		names := make([]string, 0, len(regimeClassifiers))
+		for n := range regimeClassifiers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("regime classifier not found: %s (available: %v)", name, names)
+	}
+	return f, nil
+}
+
+// TrendClassifierParams は trend 判定器のパラメータです
+type TrendClassifierParams struct {
+	TrendBars        int           `json:"trend_bars"`        // 傾きを測る1分足（確定足）の本数
+	SlopeThreshold   float64       `json:"slope_threshold"`   // 1本あたりの終値の傾き（価格比）がこれ以上で上昇、マイナス側で下降とみなす
+	VWAPThreshold    float64       `json:"vwap_threshold"`    // VWAP からの乖離（価格比）がこれ以上でトレンド側とみなす
+	VolatilityWindow time.Duration `json:"volatility_window"` // 実現ボラティリティの計測期間
+	VolatilityMax    float64       `json:"volatility_max"`    // 実現ボラティリティがこれを超えたら VOLATILE（0 で判定しない）
+}
+
+var trendClassifierSchema = ParamSchema{
+	Fields: []ParamField{
+		{Name: "trend_bars", Type: PARAM_TYPE_INT, Default: 20, Min: Bound(2), Max: Bound(240), Description: "傾きを測る1分足の本数"},
+		{Name: "slope_threshold", Type: PARAM_TYPE_FLOAT, Default: 0.0002, Min: Bound(0), Description: "1本あたりの傾き（価格比）のしきい値"},
+		{Name: "vwap_threshold", Type: PARAM_TYPE_FLOAT, Default: 0.001, Min: Bound(0), Description: "VWAP乖離（価格比）のしきい値"},
+		{Name: "volatility_window", Type: PARAM_TYPE_DURATION, Default: "5m", Min: Bound(60), Description: "実現ボラティリティの計測期間"},
+		{Name: "volatility_max", Type: PARAM_TYPE_FLOAT, Default: 0.0, Min: Bound(0), Description: "VOLATILE とみなす実現ボラティリティ（0 で判定しない）"},
+	},
+	New: func() interface{} { return &TrendClassifierParams{} },
+}
+
+// TrendClassifier は1分足の終値の傾き・セッションVWAPからの乖離・実現ボラティリティから相場環境を判定します。
+//   - 実現ボラティリティが volatility_max を超えていれば VOLATILE
+//   - 傾きが +slope_threshold 以上かつ VWAP より vwap_threshold 以上上なら BULL
+//   - 傾きが -slope_threshold 以下かつ VWAP より vwap_threshold 以上下なら BEAR
+//   - それ以外は FLAT（確定足や約定が足りない間は REGIME_UNKNOWN）
+type TrendClassifier struct {
+	params TrendClassifierParams
+	bars   *tick.BarIndicator
+	vwap   *volatility.SessionVWAP
+	vol    *volatility.RealizedVolatility
+}
+
+func (c *TrendClassifier) Classify(input StrategyInput) Regime {
+	n := c.params.TrendBars
+	// Last(0) は形成中のバーのため、確定足 n 本には n+1 本必要
+	if c.bars.Len() <= n || !c.vwap.Ready() {
+		return REGIME_UNKNOWN
+	}
+	if c.params.VolatilityMax > 0 && c.vol.Ready() && c.vol.Value() > c.params.VolatilityMax {
+		return REGIME_VOLATILE
+	}
+
+	slope := c.slope(n)
+	price := input.LatestTick.Price
+	if price <= 0 {
+		price = c.bars.Last(0).Close
+	}
+	vwap := c.vwap.VWAP()
+	if vwap <= 0 {
+		return REGIME_UNKNOWN
+	}
+	distance := (price - vwap) / vwap
+
+	switch {
+	case slope >= c.params.SlopeThreshold && distance >= c.params.VWAPThreshold:
+		return REGIME_BULL
+	case slope <= -c.params.SlopeThreshold && distance <= -c.params.VWAPThreshold:
+		return REGIME_BEAR
+	}
+	return REGIME_FLAT
+}
+
+// slope は直近 n 本の確定足の終値を最小二乗法で直線近似した傾きを、平均価格に対する比で返します
+func (c *TrendClassifier) slope(n int) float64 {
+	var sumX, sumY, sumXY, sumXX float64
+	for i := 0; i < n; i++ {
+		x := float64(i)
+		y := c.bars.Last(n - i).Close
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	fn := float64(n)
+	denom := fn*sumXX - sumX*sumX
+	mean := sumY / fn
+	if denom == 0 || mean == 0 {
+		return 0
+	}
+	return (fn*sumXY - sumX*sumY) / denom / mean
+}
+
+type TrendClassifierFactory struct{}
+
+func (f *TrendClassifierFactory) NewClassifier(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) RegimeClassifier {
+	p := *ParamsOf[TrendClassifierParams](trendClassifierSchema, params)
+	bars := tick.GetOrCreateBarIndicator(dataPool, detail.Code, tick.TimeBar(time.Minute))
+	bars.EnsureRetention(p.TrendBars)
+	return &TrendClassifier{
+		params: p,
+		bars:   bars,
+		vwap:   volatility.GetOrCreateSessionVWAP(dataPool, detail.Code),
+		vol:    volatility.GetOrCreateRealizedVolatility(dataPool, detail.Code, p.VolatilityWindow),
+	}
+}
+
+func (f *TrendClassifierFactory) Regimes() []Regime {
+	return []Regime{REGIME_BULL, REGIME_BEAR, REGIME_FLAT, REGIME_VOLATILE}
+}
+
+func (f *TrendClassifierFactory) ParamSchema() ParamSchema {
+	return trendClassifierSchema
+}
+
+func init() {
+	RegisterRegimeClassifier("trend", &TrendClassifierFactory{})
+}
@@ -0,0 +1,356 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// RegimeSwitchMode は建玉を持っている間に相場環境が切り替わった時の扱いです
+type RegimeSwitchMode string
+
+const (
+	REGIME_SWITCH_HOLD     RegimeSwitchMode = "hold"     // 建玉を建てた子戦略に決済まで任せ、ノーポジになってから切り替える
+	REGIME_SWITCH_EXIT     RegimeSwitchMode = "exit"     // 成行で手仕舞ってから切り替える
+	REGIME_SWITCH_HANDOVER RegimeSwitchMode = "handover" // 新しい相場環境の子戦略に建玉ごと引き継ぐ（子戦略が無ければ手仕舞う）
+)
+
+// RegimeRouterParams は regime_router 戦略のパラメータです（operations.json の strategy_params.regime_router）
+type RegimeRouterParams struct {
+	Classifier       string
+	ClassifierParams interface{} // 判定器のスキーマで検証済みのパラメータ
+	Routes           map[Regime]RegimeRoute
+	OnSwitch         RegimeSwitchMode
+}
+
+// RegimeRoute は相場環境に割り当てる子戦略です
+type RegimeRoute struct {
+	Strategy string      // 登録済みの戦略名
+	Params   interface{} // 子戦略のファクトリで検証済みのパラメータ
+}
+
+var regimeRouterParamSchema = ParamSchema{
+	Fields: []ParamField{
+		{Name: "classifier", Type: PARAM_TYPE_STRING, Default: "trend", Description: "相場環境の判定器"},
+		{Name: "classifier_params", Type: PARAM_TYPE_OBJECT, Description: "判定器のパラメータ"},
+		{Name: "routes", Type: PARAM_TYPE_OBJECT, Required: true, Description: "相場環境ごとの子戦略"},
+		{Name: "on_switch", Type: PARAM_TYPE_STRING, Default: string(REGIME_SWITCH_HOLD), Choices: []string{string(REGIME_SWITCH_HOLD), string(REGIME_SWITCH_EXIT), string(REGIME_SWITCH_HANDOVER)}, Description: "建玉保有中に相場環境が切り替わった時の扱い"},
+	},
+}
+
+var regimeRouteSchema = ParamSchema{
+	Fields: []ParamField{
+		{Name: "strategy", Type: PARAM_TYPE_STRING, Required: true, Description: "登録済みの戦略名"},
+		{Name: "params", Type: PARAM_TYPE_OBJECT, Description: "子戦略のパラメータ"},
+	},
+}
+
+type regimeRoute struct {
+	name     string
+	params   interface{}
+	strategy Strategy
+}
+
+// RegimeRouterStrategy は相場環境ごとに子戦略を切り替えるメタ戦略です。
+// 毎 Tick 判定器で相場環境を判定し、その相場環境に割り当てた子戦略だけに Evaluate を委譲します。
+// 注文理由には相場環境を接頭辞として付けます（例: "BULL:TakeProfit"）。
+type RegimeRouterStrategy struct {
+	detail     symbol.Symbol
+	dataPool   tick.DataPool
+	classifier RegimeClassifier
+	params     RegimeRouterParams
+	routes     map[Regime]*regimeRoute
+
+	regime Regime // 直近に判定できた相場環境（判定できない Tick では維持する）
+	owner  Regime // 現在の建玉を建てた子戦略の相場環境（ノーポジなら REGIME_UNKNOWN）
+}
+
+func (r *RegimeRouterStrategy) Name() string {
+	return "regime_router"
+}
+
+func (r *RegimeRouterStrategy) AnalysisLogger() *slog.Logger {
+	return nil
+}
+
+// Regime は直近に判定した相場環境を返します
+func (r *RegimeRouterStrategy) Regime() Regime {
+	return r.regime
+}
+
+func (r *RegimeRouterStrategy) Evaluate(input StrategyInput) TargetPosition {
+	if r.classifier != nil {
+		if regime := r.classifier.Classify(input); regime != REGIME_UNKNOWN {
+			r.regime = regime
+		}
+	}
+
+	holdQty := input.HoldQty()
+	if holdQty == 0 {
+		r.owner = REGIME_UNKNOWN
+	} else if r.owner == REGIME_UNKNOWN {
+		// 起動前からの建玉などは、現在の相場環境の子戦略に任せる
+		r.owner = r.regime
+	}
+
+	active := r.regime
+	if holdQty != 0 && r.owner != r.regime {
+		switch r.params.OnSwitch {
+		case REGIME_SWITCH_EXIT:
+			return TargetPosition{Qty: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: string(r.regime) + ":RegimeSwitch"}
+		case REGIME_SWITCH_HANDOVER:
+			r.owner = r.regime
+		default:
+			active = r.owner
+		}
+	}
+
+	route := r.routes[active]
+	if route == nil {
+		// 子戦略を割り当てていない相場環境では新規に建てず、引き継いだ建玉は手仕舞う
+		if holdQty != 0 {
+			return TargetPosition{Qty: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: string(active) + ":RegimeSwitch"}
+		}
+		return TargetPosition{Qty: 0}
+	}
+
+	target := route.strategy.Evaluate(input)
+	if holdQty == 0 && !target.IsFlat() {
+		r.owner = active
+	}
+	if target.Reason != "" {
+		target.Reason = string(active) + ":" + target.Reason
+	}
+	if target.ExitReason != "" {
+		target.ExitReason = string(active) + ":" + target.ExitReason
+	}
+	return target
+}
+
+// ShouldCancel は現在委譲している子戦略が CancelChecker を実装していれば、その判断に従います
+func (r *RegimeRouterStrategy) ShouldCancel(input StrategyInput, ord *order.Order) bool {
+	active := r.regime
+	if r.owner != REGIME_UNKNOWN {
+		active = r.owner
+	}
+	if route := r.routes[active]; route != nil {
+		if checker, ok := route.strategy.(CancelChecker); ok {
+			return checker.ShouldCancel(input, ord)
+		}
+	}
+	return false
+}
+
+// Reconfigure は稼働中に判定器のパラメータ・on_switch・子戦略のパラメータを差し替えます。
+// 判定器の種類や routes の構成（相場環境と戦略名の対応）の変更はスナイパーの入れ替えが必要なためエラーにします。
+// いずれかの子戦略が差し替えを拒否した場合は、何も変更せずにエラーを返します。
+func (r *RegimeRouterStrategy) Reconfigure(params interface{}) error {
+	p, err := regimeRouterParamsOf(params)
+	if err != nil {
+		return err
+	}
+	if p.Classifier != r.params.Classifier {
+		return fmt.Errorf("regime_router の判定器は稼働中に変更できません (%s -> %s)", r.params.Classifier, p.Classifier)
+	}
+	if len(p.Routes) != len(r.routes) {
+		return fmt.Errorf("regime_router の routes は稼働中に変更できません")
+	}
+	for regime, next := range p.Routes {
+		current := r.routes[regime]
+		if current == nil || current.name != next.Strategy {
+			return fmt.Errorf("regime_router の routes.%s は稼働中に変更できません", regime)
+		}
+		if _, ok := current.strategy.(Reconfigurable); !ok && !reflect.DeepEqual(next.Params, current.params) {
+			return fmt.Errorf("regime_router の routes.%s (%s) は稼働中のパラメータ変更に対応していません", regime, next.Strategy)
+		}
+	}
+
+	// 子戦略のパラメータは regimeRouterParamsOf で全て検証済み。相場環境名の順に差し替え、途中で失敗した場合は差し替え済みの子戦略を元のパラメータに戻す
+	regimes := sortedRegimes(p.Routes)
+	for i, regime := range regimes {
+		rc, ok := r.routes[regime].strategy.(Reconfigurable)
+		if !ok {
+			continue
+		}
+		if err := rc.Reconfigure(p.Routes[regime].Params); err != nil {
+			err = fmt.Errorf("regime_router の routes.%s (%s): %w", regime, p.Routes[regime].Strategy, err)
+			return errors.Join(err, r.restoreRoutes(regimes[:i]))
+		}
+	}
+	for _, regime := range regimes {
+		r.routes[regime].params = p.Routes[regime].Params
+	}
+	if !reflect.DeepEqual(p.ClassifierParams, r.params.ClassifierParams) {
+		factory, _ := GetRegimeClassifier(p.Classifier)
+		r.classifier = factory.NewClassifier(r.detail, r.dataPool, p.ClassifierParams)
+	}
+	r.params = *p
+	return nil
+}
+
+// restoreRoutes は Reconfigure の途中で失敗した場合に、regimes の子戦略を稼働中のパラメータに戻します
+func (r *RegimeRouterStrategy) restoreRoutes(regimes []Regime) error {
+	var errs []error
+	for _, regime := range regimes {
+		route := r.routes[regime]
+		if rc, ok := route.strategy.(Reconfigurable); ok {
+			if err := rc.Reconfigure(route.params); err != nil {
+				errs = append(errs, fmt.Errorf("regime_router の routes.%s (%s) を元のパラメータに戻せませんでした: %w", regime, route.name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close は Closer を実装した子戦略をすべて閉じます
+func (r *RegimeRouterStrategy) Close() error {
+	var errs []error
+	for regime, route := range r.routes {
+		if closer, ok := route.strategy.(Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("regime_router の routes.%s (%s): %w", regime, route.name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ----------------------------------------------------------------------------
+// Factory & Registration
+// ----------------------------------------------------------------------------
+
+type RegimeRouterStrategyFactory struct{}
+
+func (f *RegimeRouterStrategyFactory) NewStrategy(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) Strategy {
+	r := &RegimeRouterStrategy{
+		detail:   detail,
+		dataPool: dataPool,
+		params:   RegimeRouterParams{OnSwitch: REGIME_SWITCH_HOLD},
+		routes:   make(map[Regime]*regimeRoute),
+	}
+	p, err := regimeRouterParamsOf(params)
+	if err != nil {
+		slog.Error("❌ regime_router 戦略のパラメータが不正なため、子戦略なしで起動します", slog.String("symbol", detail.Code), slog.Any("error", err))
+		return r
+	}
+
+	r.params = *p
+	// 検証済みのため判定器・子戦略の取得は失敗しない
+	classifierFactory, _ := GetRegimeClassifier(p.Classifier)
+	r.classifier = classifierFactory.NewClassifier(detail, dataPool, p.ClassifierParams)
+	for regime, route := range p.Routes {
+		factory, _ := GetFactory(route.Strategy)
+		r.routes[regime] = &regimeRoute{
+			name:     route.Strategy,
+			params:   route.Params,
+			strategy: factory.NewStrategy(detail, dataPool, route.Params),
+		}
+	}
+	return r
+}
+
+// CreateExecutionPolicy は相場環境名の順で最初の子戦略の執行ポリシーを使います
+func (f *RegimeRouterStrategyFactory) CreateExecutionPolicy(params interface{}) ExecutionPolicy {
+	p, err := regimeRouterParamsOf(params)
+	if err != nil || len(p.Routes) == 0 {
+		return &NoopPolicy{}
+	}
+	regimes := sortedRegimes(p.Routes)
+	route := p.Routes[regimes[0]]
+	factory, err := GetFactory(route.Strategy)
+	if err != nil {
+		return &NoopPolicy{}
+	}
+	return factory.CreateExecutionPolicy(route.Params)
+}
+
+// DecodeParams は regime_router 自身のパラメータに加え、判定器と子戦略のパラメータもそれぞれのスキーマで検証します。
+// routes のキーは判定器が返し得る相場環境に限ります。
+func (f *RegimeRouterStrategyFactory) DecodeParams(params interface{}, path string) (interface{}, error) {
+	decoded, err := regimeRouterParamSchema.Decode(params, path)
+	if err != nil {
+		return nil, err
+	}
+	raw := decoded.(map[string]interface{})
+
+	p := &RegimeRouterParams{
+		Classifier: raw["classifier"].(string),
+		OnSwitch:   RegimeSwitchMode(raw["on_switch"].(string)),
+		Routes:     make(map[Regime]RegimeRoute),
+	}
+	classifierFactory, err := GetRegimeClassifier(p.Classifier)
+	if err != nil {
+		return nil, &ParamError{Path: joinParamPath(path, "classifier"), Err: err}
+	}
+	p.ClassifierParams, err = classifierFactory.ParamSchema().Decode(raw["classifier_params"], joinParamPath(path, "classifier_params"))
+	if err != nil {
+		return nil, err
+	}
+
+	routesPath := joinParamPath(path, "routes")
+	routes := raw["routes"].(map[string]interface{})
+	if len(routes) == 0 {
+		return nil, &ParamError{Path: routesPath, Err: fmt.Errorf("%w: 子戦略を1つ以上指定してください", ErrParamRequired)}
+	}
+	known := make(map[Regime]bool)
+	var names []string
+	for _, regime := range classifierFactory.Regimes() {
+		known[regime] = true
+		names = append(names, string(regime))
+	}
+	keys := make([]string, 0, len(routes))
+	for k := range routes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		routePath := joinParamPath(routesPath, k)
+		if !known[Regime(k)] {
+			return nil, &ParamError{Path: routePath, Err: fmt.Errorf("%w: 判定器 %s が返す相場環境ではありません（指定可能: %s）", ErrUnknownParam, p.Classifier, strings.Join(names, ", "))}
+		}
+		decodedRoute, err := regimeRouteSchema.Decode(routes[k], routePath)
+		if err != nil {
+			return nil, err
+		}
+		route := decodedRoute.(map[string]interface{})
+		name := route["strategy"].(string)
+		childParams, err := prepareChildParams(name, route["params"], routePath)
+		if err != nil {
+			return nil, err
+		}
+		p.Routes[Regime(k)] = RegimeRoute{Strategy: name, Params: childParams}
+	}
+	return p, nil
+}
+
+// regimeRouterParamsOf は NewStrategy などに渡された params を *RegimeRouterParams として取り出します（生の値はその場で検証します）
+func regimeRouterParamsOf(params interface{}) (*RegimeRouterParams, error) {
+	if p, ok := params.(*RegimeRouterParams); ok && p != nil {
+		return p, nil
+	}
+	decoded, err := (&RegimeRouterStrategyFactory{}).DecodeParams(params, "")
+	if err != nil {
+		return nil, err
+	}
+	return decoded.(*RegimeRouterParams), nil
+}
+
+func sortedRegimes(routes map[Regime]RegimeRoute) []Regime {
+	regimes := make([]Regime, 0, len(routes))
+	for regime := range routes {
+		regimes = append(regimes, regime)
+	}
+	sort.Slice(regimes, func(i, j int) bool { return regimes[i] < regimes[j] })
+	return regimes
+}
+
+func init() {
+	Register("regime_router", &RegimeRouterStrategyFactory{})
+}
@@ -0,0 +1,248 @@
+package strategy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// scriptedClassifier は current に設定した相場環境をそのまま返すテスト用の判定器です
+type scriptedClassifier struct {
+	current *strategy.Regime
+}
+
+func (c *scriptedClassifier) Classify(input strategy.StrategyInput) strategy.Regime {
+	return *c.current
+}
+
+type scriptedClassifierFactory struct {
+	current strategy.Regime
+}
+
+func (f *scriptedClassifierFactory) NewClassifier(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) strategy.RegimeClassifier {
+	return &scriptedClassifier{current: &f.current}
+}
+
+func (f *scriptedClassifierFactory) Regimes() []strategy.Regime {
+	return []strategy.Regime{strategy.REGIME_BULL, strategy.REGIME_BEAR, strategy.REGIME_FLAT}
+}
+
+func (f *scriptedClassifierFactory) ParamSchema() strategy.ParamSchema {
+	return strategy.ParamSchema{}
+}
+
+var scripted = &scriptedClassifierFactory{}
+
+func init() {
+	strategy.RegisterRegimeClassifier("scripted", scripted)
+}
+
+func newRegimeRouter(t *testing.T, onSwitch string) strategy.Strategy {
+	t.Helper()
+	factory, _ := strategy.GetFactory("regime_router")
+	params, err := strategy.PrepareParams(factory, map[string]interface{}{
+		"classifier": "scripted",
+		"on_switch":  onSwitch,
+		"routes": map[string]interface{}{
+			"BULL": fixedChild(100, "TakeProfit"),
+			"BEAR": fixedChild(-100, "StopLoss"),
+		},
+	}, "regime_router")
+	if err != nil {
+		t.Fatalf("PrepareParams failed: %v", err)
+	}
+	return factory.NewStrategy(symbol.Symbol{Code: "7203"}, &dummyDataPool{}, params)
+}
+
+func TestRegimeRouterStrategy_Evaluate(t *testing.T) {
+	s := newRegimeRouter(t, "hold")
+
+	scripted.current = strategy.REGIME_UNKNOWN
+	if got := s.Evaluate(strategy.StrategyInput{}); !got.IsFlat() {
+		t.Errorf("expected flat before the first regime, got %+v", got)
+	}
+
+	scripted.current = strategy.REGIME_BULL
+	got := s.Evaluate(strategy.StrategyInput{})
+	if got.Qty != 100 || got.Reason != "BULL:TakeProfit" {
+		t.Errorf("expected BULL child target, got %+v", got)
+	}
+
+	scripted.current = strategy.REGIME_BEAR
+	got = s.Evaluate(strategy.StrategyInput{})
+	if got.Qty != -100 || got.Reason != "BEAR:StopLoss" {
+		t.Errorf("expected BEAR child target, got %+v", got)
+	}
+
+	// 判定できない Tick では直前の相場環境を維持する
+	scripted.current = strategy.REGIME_UNKNOWN
+	if got := s.Evaluate(strategy.StrategyInput{}); got.Qty != -100 {
+		t.Errorf("expected previous regime to be kept, got %+v", got)
+	}
+
+	// 子戦略の無い相場環境では新規に建てない
+	scripted.current = strategy.REGIME_FLAT
+	if got := s.Evaluate(strategy.StrategyInput{}); !got.IsFlat() {
+		t.Errorf("expected flat for an unrouted regime, got %+v", got)
+	}
+}
+
+func TestRegimeRouterStrategy_SwitchWhileInPosition(t *testing.T) {
+	holding := strategy.StrategyInput{Position: strategy.Position{Qty: 100}}
+
+	tests := []struct {
+		onSwitch   string
+		next       strategy.Regime
+		wantQty    float64
+		wantReason string
+	}{
+		{"hold", strategy.REGIME_BEAR, 100, "BULL:TakeProfit"},
+		{"exit", strategy.REGIME_BEAR, 0, "BEAR:RegimeSwitch"},
+		{"handover", strategy.REGIME_BEAR, -100, "BEAR:StopLoss"},
+		{"handover", strategy.REGIME_FLAT, 0, "FLAT:RegimeSwitch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.onSwitch+"_"+string(tt.next), func(t *testing.T) {
+			s := newRegimeRouter(t, tt.onSwitch)
+			scripted.current = strategy.REGIME_BULL
+			s.Evaluate(strategy.StrategyInput{}) // BULL の子戦略が建てる
+
+			scripted.current = tt.next
+			got := s.Evaluate(holding)
+			if got.Qty != tt.wantQty || got.Reason != tt.wantReason {
+				t.Errorf("expected %v (%s), got %+v", tt.wantQty, tt.wantReason, got)
+			}
+			if got.IsFlat() && got.OrderType != order.ORDER_TYPE_MARKET {
+				t.Errorf("expected market exit, got %v", got.OrderType)
+			}
+		})
+	}
+}
+
+func TestRegimeRouterStrategyFactory_DecodeParams(t *testing.T) {
+	factory, _ := strategy.GetFactory("regime_router")
+
+	params, err := strategy.PrepareParams(factory, map[string]interface{}{
+		"classifier_params": map[string]interface{}{"trend_bars": 10.0},
+		"routes":            map[string]interface{}{"FLAT": map[string]interface{}{"strategy": "sample"}},
+	}, "regime_router")
+	if err != nil {
+		t.Fatalf("PrepareParams failed: %v", err)
+	}
+	p := params.(*strategy.RegimeRouterParams)
+	if p.Classifier != "trend" || p.OnSwitch != strategy.REGIME_SWITCH_HOLD {
+		t.Errorf("unexpected defaults: %+v", p)
+	}
+	if cp := p.ClassifierParams.(*strategy.TrendClassifierParams); cp.TrendBars != 10 || cp.VolatilityWindow != 5*time.Minute {
+		t.Errorf("unexpected classifier params: %+v", cp)
+	}
+	if sp := p.Routes[strategy.REGIME_FLAT].Params.(*strategy.SampleParams); sp.RisingBars != 3 {
+		t.Errorf("unexpected route params: %+v", sp)
+	}
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		wantPath string
+		wantErr  error
+	}{
+		{"missing routes", map[string]interface{}{}, "regime_router.routes", strategy.ErrParamRequired},
+		{"unknown classifier", map[string]interface{}{"classifier": "nope", "routes": map[string]interface{}{"BULL": fixedChild(0, "")}}, "regime_router.classifier", nil},
+		{"invalid classifier params", map[string]interface{}{"classifier_params": map[string]interface{}{"trend_bars": 1.0}, "routes": map[string]interface{}{"BULL": fixedChild(0, "")}}, "regime_router.classifier_params.trend_bars", strategy.ErrParamRange},
+		{"unknown regime", map[string]interface{}{"routes": map[string]interface{}{"BUL": fixedChild(0, "")}}, "regime_router.routes.BUL", strategy.ErrUnknownParam},
+		{"invalid route params", map[string]interface{}{"routes": map[string]interface{}{"BULL": map[string]interface{}{"strategy": "sample", "params": map[string]interface{}{"rising_bars": 1.0}}}}, "regime_router.routes.BULL.params.rising_bars", strategy.ErrParamRange},
+		{"invalid on_switch", map[string]interface{}{"on_switch": "flip", "routes": map[string]interface{}{"BULL": fixedChild(0, "")}}, "regime_router.on_switch", strategy.ErrParamRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := strategy.PrepareParams(factory, tt.params, "regime_router")
+			var pe *strategy.ParamError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected ParamError, got %v", err)
+			}
+			if pe.Path != tt.wantPath {
+				t.Errorf("expected path %q, got %q", tt.wantPath, pe.Path)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTrendClassifier_Classify(t *testing.T) {
+	factory, _ := strategy.GetRegimeClassifier("trend")
+	start := time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name string
+		step float64
+		want strategy.Regime
+	}{
+		{"rising", 1, strategy.REGIME_BULL},
+		{"falling", -1, strategy.REGIME_BEAR},
+		{"flat", 0, strategy.REGIME_FLAT},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := tick.NewDefaultDataPool(nil)
+			c := factory.NewClassifier(symbol.Symbol{Code: "7203"}, pool, map[string]interface{}{"trend_bars": 5.0})
+
+			var last tick.Tick
+			for i := 0; i <= 10; i++ {
+				last = tick.Tick{Symbol: "7203", Price: 1000 + tt.step*float64(i*10), TradingVolume: float64(1000 * (i + 1)), CurrentPriceTime: start.Add(time.Duration(i) * time.Minute)}
+				pool.PushTick(last)
+				if i == 2 {
+					if got := c.Classify(strategy.StrategyInput{LatestTick: last}); got != strategy.REGIME_UNKNOWN {
+						t.Errorf("expected unknown before enough bars, got %q", got)
+					}
+				}
+			}
+			if got := c.Classify(strategy.StrategyInput{LatestTick: last}); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRegimeRouterStrategy_Reconfigure_RejectedRouteKeepsOthers(t *testing.T) {
+	factory, _ := strategy.GetFactory("regime_router")
+	prepare := func(bearQty float64, rejectBull bool) interface{} {
+		params, err := strategy.PrepareParams(factory, map[string]interface{}{
+			"classifier": "scripted",
+			"routes": map[string]interface{}{
+				"BULL": tunableChild(100, rejectBull),
+				"BEAR": tunableChild(bearQty, false),
+			},
+		}, "regime_router")
+		if err != nil {
+			t.Fatalf("PrepareParams failed: %v", err)
+		}
+		return params
+	}
+	s := factory.NewStrategy(symbol.Symbol{Code: "7203"}, &dummyDataPool{}, prepare(-100, false))
+	r := s.(strategy.Reconfigurable)
+	scripted.current = strategy.REGIME_BEAR
+
+	// BULL の子戦略が差し替えを拒否した場合、先に差し替えた BEAR の子戦略も元のパラメータに戻す
+	if err := r.Reconfigure(prepare(-200, true)); err == nil || !strings.Contains(err.Error(), "routes.BULL") {
+		t.Fatalf("expected the rejection of routes.BULL, got %v", err)
+	}
+	if got := s.Evaluate(strategy.StrategyInput{}); got.Qty != -100 {
+		t.Errorf("expected routes.BEAR to keep the previous params, got %v", got.Qty)
+	}
+
+	// 全ての子戦略が受け付ければ差し替わる
+	if err := r.Reconfigure(prepare(-200, false)); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if got := s.Evaluate(strategy.StrategyInput{}); got.Qty != -200 {
+		t.Errorf("expected routes.BEAR to be reconfigured, got %v", got.Qty)
+	}
+}
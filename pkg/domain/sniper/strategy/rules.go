@@ -0,0 +1,428 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy/expr"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/technical"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/volatility"
+)
+
+// RulesParams は rules 戦略のパラメータです（operations.json の strategy_params.rules）
+type RulesParams struct {
+	Bar        time.Duration `json:"bar"`         // 指標の計算に使う時間足
+	Side       string        `json:"side"`        // "long" / "short"
+	Units      int           `json:"units"`       // 1回に建てる売買単位の数
+	Entry      string        `json:"entry"`       // 新規建ての条件（真偽値の式）
+	Exit       string        `json:"exit"`        // 手仕舞いの条件（真偽値の式、任意）
+	Stop       string        `json:"stop"`        // 損切り価格（数値の式、任意）
+	TakeProfit string        `json:"take_profit"` // 利確の指値（数値の式、任意。新規建てと同時に IFD で発注する）
+}
+
+var rulesParamSchema = ParamSchema{
+	Fields: []ParamField{
+		{Name: "bar", Type: PARAM_TYPE_DURATION, Default: "1m", Min: Bound(1), Description: "指標の計算に使う時間足"},
+		{Name: "side", Type: PARAM_TYPE_STRING, Default: "long", Choices: []string{"long", "short"}, Description: "建てる方向"},
+		{Name: "units", Type: PARAM_TYPE_INT, Default: 1, Min: Bound(1), Max: Bound(100), Description: "1回に建てる売買単位の数"},
+		{Name: "entry", Type: PARAM_TYPE_STRING, Required: true, Description: "新規建ての条件"},
+		{Name: "exit", Type: PARAM_TYPE_STRING, Description: "手仕舞いの条件"},
+		{Name: "stop", Type: PARAM_TYPE_STRING, Description: "損切り価格"},
+		{Name: "take_profit", Type: PARAM_TYPE_STRING, Description: "利確の指値"},
+	},
+	New: func() interface{} { return &RulesParams{} },
+}
+
+// rulesPrograms は rules 戦略のコンパイル済みの式です（未指定の式は nil）
+type rulesPrograms struct {
+	entry      *expr.Program
+	exit       *expr.Program
+	stop       *expr.Program
+	takeProfit *expr.Program
+}
+
+// RulesStrategy は operations.json に書いた式で売買する戦略です。
+// 式は起動時にコンパイルされ、Tick 毎の評価ではコンパイル済みのクロージャを呼び出すだけです。
+//   - ノーポジで entry が真になったら成行で建てる（take_profit があれば利確の指値を IFD で同時に発注する）
+//   - 建玉を持っている間は、価格が stop に達するか exit が真になったら成行で手仕舞う
+type RulesStrategy struct {
+	detail   symbol.Symbol
+	params   RulesParams
+	env      *rulesEnv
+	programs rulesPrograms
+}
+
+func (s *RulesStrategy) Name() string {
+	return "rules"
+}
+
+func (s *RulesStrategy) AnalysisLogger() *slog.Logger {
+	return nil
+}
+
+func (s *RulesStrategy) Evaluate(input StrategyInput) TargetPosition {
+	s.env.input = input
+	holdQty := input.HoldQty()
+
+	if holdQty == 0 {
+		if !s.programs.entry.Bool() {
+			return TargetPosition{Qty: 0}
+		}
+		qty := float64(s.params.Units) * s.detail.Unit()
+		if s.params.Side == "short" {
+			qty = -qty
+		}
+		target := TargetPosition{Qty: qty, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "Entry"}
+		if s.programs.takeProfit != nil {
+			if price := s.programs.takeProfit.Number(); s.isProfitSide(price, input.LatestTick.Price) {
+				target.HasIfDone = true
+				target.ExitPrice = s.detail.RoundPrice(price)
+				target.ExitOrderType = order.ORDER_TYPE_LIMIT
+				target.ExitReason = "TakeProfit"
+			}
+		}
+		return target
+	}
+
+	if s.programs.stop != nil {
+		stop := s.programs.stop.Number()
+		price := input.LatestTick.Price
+		if !math.IsNaN(stop) && price > 0 && ((holdQty > 0 && price <= stop) || (holdQty < 0 && price >= stop)) {
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "StopLoss"}
+		}
+	}
+	if s.programs.exit != nil && s.programs.exit.Bool() {
+		return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "Exit"}
+	}
+	return TargetPosition{Qty: holdQty}
+}
+
+// isProfitSide は利確の指値が現在値より利益側にあるかを返します（計算できない値や逆側の値では IFD を付けない）
+func (s *RulesStrategy) isProfitSide(exitPrice, price float64) bool {
+	if math.IsNaN(exitPrice) || math.IsInf(exitPrice, 0) || exitPrice <= 0 || price <= 0 {
+		return false
+	}
+	if s.params.Side == "short" {
+		return exitPrice < price
+	}
+	return exitPrice > price
+}
+
+// Reconfigure は稼働中に式を差し替えます。コンパイルに失敗した場合は何も変更しません
+func (s *RulesStrategy) Reconfigure(params interface{}) error {
+	p, ok := params.(*RulesParams)
+	if !ok || p == nil {
+		decoded, err := (&RulesStrategyFactory{}).DecodeParams(params, "")
+		if err != nil {
+			return err
+		}
+		p = decoded.(*RulesParams)
+	}
+	env := newRulesEnv(s.detail, s.env.pool, p.Bar)
+	programs, err := compileRules(p, env)
+	if err != nil {
+		return err
+	}
+	s.params = *p
+	s.env = env
+	s.programs = programs
+	return nil
+}
+
+// compileRules は式をコンパイルします。エラーは式のキー名（entry など）を Path に持つ *ParamError です
+func compileRules(p *RulesParams, env *rulesEnv) (rulesPrograms, error) {
+	var programs rulesPrograms
+	compile := func(key, src string, dst **expr.Program, compileFn func(string, expr.Env) (*expr.Program, error)) error {
+		if src == "" {
+			return nil
+		}
+		prog, err := compileFn(src, env)
+		if err != nil {
+			return &ParamError{Path: key, Err: fmt.Errorf("%w: %v", ErrParamType, err)}
+		}
+		*dst = prog
+		return nil
+	}
+	if err := compile("entry", p.Entry, &programs.entry, expr.CompileBool); err != nil {
+		return programs, err
+	}
+	if err := compile("exit", p.Exit, &programs.exit, expr.CompileBool); err != nil {
+		return programs, err
+	}
+	if err := compile("stop", p.Stop, &programs.stop, expr.CompileNumber); err != nil {
+		return programs, err
+	}
+	if err := compile("take_profit", p.TakeProfit, &programs.takeProfit, expr.CompileNumber); err != nil {
+		return programs, err
+	}
+	return programs, nil
+}
+
+// ----------------------------------------------------------------------------
+// 式から参照できる値と指標
+// ----------------------------------------------------------------------------
+
+// rulesEnv は rules 戦略の式に現れる識別子と指標関数を解決します。
+// pool が nil の場合は起動時の検証用で、名前と引数だけを検査し指標は生成しません。
+type rulesEnv struct {
+	detail symbol.Symbol
+	pool   tick.DataPool
+	bar    tick.BarSpec
+	input  StrategyInput // Evaluate のたびに更新される評価対象
+
+	bars *tick.BarIndicator
+	vwap *volatility.SessionVWAP
+}
+
+func newRulesEnv(detail symbol.Symbol, pool tick.DataPool, bar time.Duration) *rulesEnv {
+	env := &rulesEnv{detail: detail, pool: pool, bar: tick.TimeBar(bar)}
+	if pool != nil {
+		env.bars = tick.GetOrCreateBarIndicator(pool, detail.Code, env.bar)
+		env.vwap = volatility.GetOrCreateSessionVWAP(pool, detail.Code)
+	}
+	return env
+}
+
+func (e *rulesEnv) hasBar() bool {
+	return e.bars.Len() > 0
+}
+
+func (e *rulesEnv) hasPrice() bool {
+	return e.input.LatestTick.Price > 0
+}
+
+// rulesVars は式から参照できる識別子です
+var rulesVars = map[string]func(e *rulesEnv) expr.Binding{
+	"price": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: func() float64 { return e.input.LatestTick.Price }, Ready: e.hasPrice}
+	},
+	"bid": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: func() float64 { return e.input.LatestTick.BestBid.Price }, Ready: func() bool { return e.input.LatestTick.BestBid.Price > 0 }}
+	},
+	"ask": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: func() float64 { return e.input.LatestTick.BestAsk.Price }, Ready: func() bool { return e.input.LatestTick.BestAsk.Price > 0 }}
+	},
+	"open": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: func() float64 { return e.bars.Last(0).Open }, Ready: e.hasBar}
+	},
+	"high": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: func() float64 { return e.bars.Last(0).High }, Ready: e.hasBar}
+	},
+	"low": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: func() float64 { return e.bars.Last(0).Low }, Ready: e.hasBar}
+	},
+	"close": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: func() float64 { return e.bars.Last(0).Close }, Ready: e.hasBar}
+	},
+	"volume": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: func() float64 { return e.bars.Last(0).Volume }, Ready: e.hasBar}
+	},
+	"vwap": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: e.vwap.VWAP, Ready: e.vwap.Ready}
+	},
+	"position": func(e *rulesEnv) expr.Binding {
+		return expr.Binding{Value: e.input.HoldQty}
+	},
+	"avg_price": func(e *rulesEnv) expr.Binding {
+		// ノーポジの間（新規建て時の take_profit / stop の計算など）は現在値を建値とみなす
+		return expr.Binding{Value: func() float64 {
+			if avg := e.input.AveragePrice(); avg > 0 {
+				return avg
+			}
+			return e.input.LatestTick.Price
+		}, Ready: func() bool { return e.input.AveragePrice() > 0 || e.hasPrice() }}
+	},
+	"unit": func(e *rulesEnv) expr.Binding {
+		unit := e.detail.Unit()
+		return expr.Binding{Value: func() float64 { return unit }}
+	},
+}
+
+// rulesFunc は式から呼び出せる指標関数です。引数はすべて正の定数で、ints 個目までは整数に限ります
+type rulesFunc struct {
+	args int
+	ints int
+	bind func(e *rulesEnv, args []float64) expr.Binding
+}
+
+var rulesFuncs = map[string]rulesFunc{
+	"sma": {1, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateSMA(e.pool, e.detail.Code, e.bars, int(a[0]))
+		return expr.Binding{Value: ind.Value, Ready: ind.Ready}
+	}},
+	"ema": {1, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateEMA(e.pool, e.detail.Code, e.bars, int(a[0]))
+		return expr.Binding{Value: ind.Value, Ready: ind.Ready}
+	}},
+	"rsi": {1, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateRSI(e.pool, e.detail.Code, e.bars, int(a[0]))
+		return expr.Binding{Value: ind.Value, Ready: ind.Ready}
+	}},
+	"atr": {1, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateATR(e.pool, e.detail.Code, e.bars, int(a[0]))
+		return expr.Binding{Value: ind.Value, Ready: ind.Ready}
+	}},
+	"adx": {1, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateADX(e.pool, e.detail.Code, e.bars, int(a[0]))
+		return expr.Binding{Value: ind.Value, Ready: ind.Ready}
+	}},
+	"bb_upper": {2, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateBollinger(e.pool, e.detail.Code, e.bars, int(a[0]), a[1])
+		return expr.Binding{Value: ind.Upper, Ready: ind.Ready}
+	}},
+	"bb_lower": {2, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateBollinger(e.pool, e.detail.Code, e.bars, int(a[0]), a[1])
+		return expr.Binding{Value: ind.Lower, Ready: ind.Ready}
+	}},
+	"macd": {3, 3, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateMACD(e.pool, e.detail.Code, e.bars, int(a[0]), int(a[1]), int(a[2]))
+		return expr.Binding{Value: ind.MACD, Ready: ind.Ready}
+	}},
+	"macd_signal": {3, 3, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateMACD(e.pool, e.detail.Code, e.bars, int(a[0]), int(a[1]), int(a[2]))
+		return expr.Binding{Value: ind.Signal, Ready: ind.Ready}
+	}},
+	"stoch_k": {2, 2, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateStochastics(e.pool, e.detail.Code, e.bars, int(a[0]), int(a[1]))
+		return expr.Binding{Value: ind.K, Ready: ind.Ready}
+	}},
+	"stoch_d": {2, 2, func(e *rulesEnv, a []float64) expr.Binding {
+		ind := technical.GetOrCreateStochastics(e.pool, e.detail.Code, e.bars, int(a[0]), int(a[1]))
+		return expr.Binding{Value: ind.D, Ready: ind.Ready}
+	}},
+	"vwap_upper": {1, 0, func(e *rulesEnv, a []float64) expr.Binding {
+		k := a[0]
+		return expr.Binding{Value: func() float64 { return e.vwap.Upper(k) }, Ready: e.vwap.Ready}
+	}},
+	"vwap_lower": {1, 0, func(e *rulesEnv, a []float64) expr.Binding {
+		k := a[0]
+		return expr.Binding{Value: func() float64 { return e.vwap.Lower(k) }, Ready: e.vwap.Ready}
+	}},
+	"prev_close": {1, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		n := int(a[0])
+		e.bars.EnsureRetention(n)
+		return expr.Binding{Value: func() float64 { return e.bars.Last(n).Close }, Ready: func() bool { return e.bars.Len() > n }}
+	}},
+	"highest": {1, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		n := int(a[0])
+		e.bars.EnsureRetention(n)
+		return expr.Binding{Value: func() float64 {
+			v := e.bars.Last(1).High
+			for i := 2; i <= n; i++ {
+				v = math.Max(v, e.bars.Last(i).High)
+			}
+			return v
+		}, Ready: func() bool { return e.bars.Len() > n }}
+	}},
+	"lowest": {1, 1, func(e *rulesEnv, a []float64) expr.Binding {
+		n := int(a[0])
+		e.bars.EnsureRetention(n)
+		return expr.Binding{Value: func() float64 {
+			v := e.bars.Last(1).Low
+			for i := 2; i <= n; i++ {
+				v = math.Min(v, e.bars.Last(i).Low)
+			}
+			return v
+		}, Ready: func() bool { return e.bars.Len() > n }}
+	}},
+}
+
+func (e *rulesEnv) Var(name string) (expr.Binding, bool) {
+	bind, ok := rulesVars[name]
+	if !ok {
+		return expr.Binding{}, false
+	}
+	if e.pool == nil {
+		return expr.Binding{Value: func() float64 { return 0 }}, true
+	}
+	return bind(e), true
+}
+
+func (e *rulesEnv) Func(name string, args []float64) (expr.Binding, error) {
+	fn, ok := rulesFuncs[name]
+	if !ok {
+		return expr.Binding{}, fmt.Errorf("未定義の関数 %s です（使用可能: %s）", name, rulesFuncNames())
+	}
+	if len(args) != fn.args {
+		return expr.Binding{}, fmt.Errorf("関数 %s の引数は %d 個です（%d 個指定されています）", name, fn.args, len(args))
+	}
+	for i, a := range args {
+		if a <= 0 || (i < fn.ints && a != math.Trunc(a)) || (i < fn.ints && a > tick.DefaultBarRetention) {
+			return expr.Binding{}, fmt.Errorf("関数 %s の %d 番目の引数が不正です (%v)", name, i+1, a)
+		}
+	}
+	if e.pool == nil {
+		return expr.Binding{Value: func() float64 { return 0 }}, nil
+	}
+	return fn.bind(e, args), nil
+}
+
+func rulesFuncNames() string {
+	names := make([]string, 0, len(rulesFuncs))
+	for name := range rulesFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ----------------------------------------------------------------------------
+// Factory & Registration
+// ----------------------------------------------------------------------------
+
+type RulesStrategyFactory struct{}
+
+func (f *RulesStrategyFactory) NewStrategy(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) Strategy {
+	p := ParamsOf[RulesParams](rulesParamSchema, params)
+	if p.Bar <= 0 || p.Units <= 0 {
+		// 検証を経ずに生成された場合（必須の entry が無いなど）はデフォルト値を使えないため補う
+		p.Bar, p.Units = time.Minute, 1
+	}
+	s := &RulesStrategy{detail: detail, params: *p, env: newRulesEnv(detail, dataPool, p.Bar)}
+	programs, err := compileRules(p, s.env)
+	if err != nil || programs.entry == nil {
+		// 起動時に検証済みのため、ここに来るのは検証を経ずに生成された場合だけ。建てない戦略として動かす
+		slog.Error("❌ rules 戦略の式が不正なため、新規建てを行いません", slog.String("symbol", detail.Code), slog.Any("error", err))
+		programs, _ = compileRules(&RulesParams{Entry: "false"}, s.env)
+	}
+	s.programs = programs
+	return s
+}
+
+func (f *RulesStrategyFactory) CreateExecutionPolicy(params interface{}) ExecutionPolicy {
+	return &NoopPolicy{}
+}
+
+// DecodeParams はパラメータを検証し、式の構文・型・識別子・指標関数の引数までを起動時に検査します
+func (f *RulesStrategyFactory) DecodeParams(params interface{}, path string) (interface{}, error) {
+	decoded, err := rulesParamSchema.Decode(params, path)
+	if err != nil {
+		return nil, err
+	}
+	p := decoded.(*RulesParams)
+	if _, err := compileRules(p, newRulesEnv(symbol.Symbol{}, nil, p.Bar)); err != nil {
+		var pe *ParamError
+		if errors.As(err, &pe) {
+			pe.Path = joinParamPath(path, pe.Path)
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// ParamSchema は rules 戦略のパラメータ定義を返します
+func (f *RulesStrategyFactory) ParamSchema() ParamSchema {
+	return rulesParamSchema
+}
+
+func init() {
+	Register("rules", &RulesStrategyFactory{})
+}
@@ -0,0 +1,167 @@
+package strategy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// rulesFeed は1分ごとに1本ずつ終値 closes の1分足を作り、最後の Tick を返します
+func rulesFeed(pool tick.DataPool, closes ...float64) tick.Tick {
+	start := time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+	var last tick.Tick
+	for i, c := range closes {
+		last = tick.Tick{Symbol: "7203", Price: c, TradingVolume: float64(100 * (i + 1)), CurrentPriceTime: start.Add(time.Duration(i) * time.Minute)}
+		pool.PushTick(last)
+	}
+	return last
+}
+
+func newRules(t *testing.T, pool tick.DataPool, params map[string]interface{}) strategy.Strategy {
+	t.Helper()
+	factory, _ := strategy.GetFactory("rules")
+	decoded, err := strategy.PrepareParams(factory, params, "rules")
+	if err != nil {
+		t.Fatalf("PrepareParams failed: %v", err)
+	}
+	return factory.NewStrategy(symbol.Symbol{Code: "7203", TradingUnit: 100, PriceRangeGroup: symbol.PRICE_RANGE_GROUP_TSE_STANDARD}, pool, decoded)
+}
+
+func TestRulesStrategy_Entry(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	s := newRules(t, pool, map[string]interface{}{
+		"entry":       "close > sma(3) && position == 0",
+		"units":       2.0,
+		"take_profit": "avg_price * 1.01",
+	})
+
+	// sma(3) のウォームアップ中は建てない
+	last := rulesFeed(pool, 1000, 1001)
+	if got := s.Evaluate(strategy.StrategyInput{LatestTick: last}); !got.IsFlat() {
+		t.Fatalf("expected no entry while warming up, got %+v", got)
+	}
+
+	last = rulesFeed(pool, 1000, 1001, 1002, 1003, 1010)
+	got := s.Evaluate(strategy.StrategyInput{LatestTick: last})
+	if got.Qty != 200 || got.OrderType != order.ORDER_TYPE_MARKET || got.Reason != "Entry" {
+		t.Fatalf("expected market entry of 2 units, got %+v", got)
+	}
+	if !got.HasIfDone || got.ExitPrice != 1020 || got.ExitOrderType != order.ORDER_TYPE_LIMIT || got.ExitReason != "TakeProfit" {
+		t.Errorf("expected take profit IFD at 1020, got %+v", got)
+	}
+}
+
+func TestRulesStrategy_Exit(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	s := newRules(t, pool, map[string]interface{}{
+		"side":  "short",
+		"entry": "close < vwap",
+		"exit":  "close < lowest(3)",
+		"stop":  "avg_price + 5",
+	})
+
+	holding := func(price float64) strategy.StrategyInput {
+		return strategy.StrategyInput{Position: strategy.Position{Qty: -100, AveragePrice: 1000}, LatestTick: tick.Tick{Symbol: "7203", Price: price}}
+	}
+
+	rulesFeed(pool, 1000, 1002, 1001, 1003)
+	if got := s.Evaluate(holding(1003)); got.Qty != -100 {
+		t.Errorf("expected hold, got %+v", got)
+	}
+	if got := s.Evaluate(holding(1005)); !got.IsFlat() || got.Reason != "StopLoss" {
+		t.Errorf("expected stop loss at avg_price + 5, got %+v", got)
+	}
+
+	rulesFeed(pool, 1000, 1002, 1001, 1003, 990)
+	if got := s.Evaluate(holding(990)); !got.IsFlat() || got.Reason != "Exit" || got.OrderType != order.ORDER_TYPE_MARKET {
+		t.Errorf("expected exit when close breaks the recent low, got %+v", got)
+	}
+}
+
+func TestRulesStrategyFactory_DecodeParams(t *testing.T) {
+	factory, _ := strategy.GetFactory("rules")
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		wantPath string
+		wantMsg  string
+	}{
+		{"missing entry", map[string]interface{}{}, "rules.entry", "必須"},
+		{"syntax error", map[string]interface{}{"entry": "close > sma(20) &&"}, "rules.entry", "途中で終わって"},
+		{"unknown function", map[string]interface{}{"entry": "close > smaa(20)"}, "rules.entry", "未定義の関数 smaa"},
+		{"unknown identifier", map[string]interface{}{"entry": "true", "exit": "clsoe > 1"}, "rules.exit", "未定義の識別子 clsoe"},
+		{"invalid period", map[string]interface{}{"entry": "rsi(14.5) < 30"}, "rules.entry", "引数が不正"},
+		{"bool stop", map[string]interface{}{"entry": "true", "stop": "price < vwap"}, "rules.stop", "数値の式を指定してください"},
+		{"number entry", map[string]interface{}{"entry": "sma(5)"}, "rules.entry", "真偽値の式を指定してください"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := strategy.PrepareParams(factory, tt.params, "rules")
+			var pe *strategy.ParamError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected ParamError, got %v", err)
+			}
+			if pe.Path != tt.wantPath || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("expected %q at %q, got %v", tt.wantMsg, tt.wantPath, err)
+			}
+		})
+	}
+}
+
+func TestRulesStrategy_Reconfigure(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	s := newRules(t, pool, map[string]interface{}{"entry": "false"})
+	last := rulesFeed(pool, 1000, 1001)
+
+	r := s.(strategy.Reconfigurable)
+	if err := r.Reconfigure(map[string]interface{}{"entry": "price > 0 &&"}); err == nil {
+		t.Errorf("expected compile error")
+	}
+	if err := r.Reconfigure(map[string]interface{}{"exit": "price > 0"}); err == nil {
+		t.Errorf("expected error for missing entry")
+	}
+	if got := s.Evaluate(strategy.StrategyInput{LatestTick: last}); !got.IsFlat() {
+		t.Errorf("failed reconfiguration should keep the previous rules, got %+v", got)
+	}
+
+	if err := r.Reconfigure(map[string]interface{}{"entry": "price > 0"}); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if got := s.Evaluate(strategy.StrategyInput{LatestTick: last}); got.Qty != 100 {
+		t.Errorf("expected entry with the new rules, got %+v", got)
+	}
+}
+
+func TestCompileIndicators(t *testing.T) {
+	if _, err := strategy.CompileIndicators(map[string]string{"up": "close > vwap"}, symbol.Symbol{}, nil, time.Minute); err == nil {
+		t.Errorf("expected error for a boolean expression")
+	}
+
+	pool := tick.NewDefaultDataPool(nil)
+	set, err := strategy.CompileIndicators(map[string]string{"sma": "sma(3)", "spread": "close - sma(2)"}, symbol.Symbol{Code: "7203"}, pool, time.Minute)
+	if err != nil {
+		t.Fatalf("CompileIndicators failed: %v", err)
+	}
+
+	// ウォームアップ中の指標は含めない
+	values := map[string]float64{}
+	last := rulesFeed(pool, 1000, 1010, 1020)
+	set.Values(strategy.StrategyInput{LatestTick: last}, values)
+	if _, ok := values["sma"]; ok || values["spread"] != 15 {
+		t.Errorf("unexpected values while warming up: %v", values)
+	}
+
+	last = tick.Tick{Symbol: "7203", Price: 1030, TradingVolume: 400, CurrentPriceTime: last.CurrentPriceTime.Add(time.Minute)}
+	pool.PushTick(last)
+	set.Values(strategy.StrategyInput{LatestTick: last}, values)
+	if values["sma"] != 1010 || values["spread"] != 15 {
+		t.Errorf("unexpected values: %v", values)
+	}
+}
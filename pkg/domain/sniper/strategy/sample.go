@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"fmt"
 	"log/slog"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
@@ -19,6 +20,20 @@ type SampleStrategy struct {
 	state     StrategyState
 	oneMinBar *tick.OneMinBarIndicator
 	highPrice float64
+	unit      float64 // 売買単位
+	params    SampleParams
+}
+
+// SampleParams は sample 戦略のパラメータです（operations.json の strategy_params.sample）
+type SampleParams struct {
+	RisingBars int `json:"rising_bars"` // 買いの判定に使う、終値が連続で上昇した1分足の本数
+}
+
+var sampleParamSchema = ParamSchema{
+	Fields: []ParamField{
+		{Name: "rising_bars", Type: PARAM_TYPE_INT, Default: 3, Min: Bound(2), Max: Bound(20), Description: "終値が連続で上昇した1分足の本数"},
+	},
+	New: func() interface{} { return &SampleParams{} },
 }
 
 func (s *SampleStrategy) Name() string {
@@ -57,23 +72,21 @@ func (s *SampleStrategy) Evaluate(input StrategyInput) TargetPosition {
 		return TargetPosition{Qty: holdQty}
 	}
 
-	// 1分足の終値が3回連続で上昇したら買い
-	bars := s.oneMinBar.Bars()
-	if len(bars) < 3 {
+	// 1分足の終値が rising_bars 本連続で上昇したら買い（デフォルトは3本）
+	// Tick 毎に呼ばれるため、バー全体をコピーする Bars() ではなく Last() で参照する
+	n := s.params.RisingBars
+	if s.oneMinBar.Len() < n {
 		return TargetPosition{Qty: 0}
 	}
 
-	// 過去3本のバーの終値を取得
-	bar1 := bars[len(bars)-3]
-	bar2 := bars[len(bars)-2]
-	bar3 := bars[len(bars)-1]
-
-	// 終値が3回連続で上昇しているかチェック
-	if bar1.Close < bar2.Close && bar2.Close < bar3.Close {
-		return TargetPosition{Qty: 100, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "3 consecutive bars rise"}
+	// 直近 n 本のバーの終値が連続で上昇しているかチェック
+	for i := n - 1; i > 0; i-- {
+		if s.oneMinBar.Last(i).Close >= s.oneMinBar.Last(i-1).Close {
+			return TargetPosition{Qty: 0}
+		}
 	}
 
-	return TargetPosition{Qty: 0}
+	return TargetPosition{Qty: s.unit, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: fmt.Sprintf("%d consecutive bars rise", n)}
 }
 
 // ----------------------------------------------------------------------------
@@ -94,9 +107,22 @@ func (f *SimpleStrategyFactory) NewStrategy(detail symbol.Symbol, dataPool tick.
 			count: 0,
 		},
 		oneMinBar: oneMinBar,
+		unit:      detail.Unit(),
+		params:    *ParamsOf[SampleParams](sampleParamSchema, params),
 	}
 }
 
+// Reconfigure は稼働中に sample 戦略のパラメータを差し替えます
+func (s *SampleStrategy) Reconfigure(params interface{}) error {
+	s.params = *ParamsOf[SampleParams](sampleParamSchema, params)
+	return nil
+}
+
+// ParamSchema は sample 戦略のパラメータ定義を返します
+func (f *SimpleStrategyFactory) ParamSchema() ParamSchema {
+	return sampleParamSchema
+}
+
 func (f *SimpleStrategyFactory) CreateExecutionPolicy(params interface{}) ExecutionPolicy {
 	return &NoopPolicy{}
 }
@@ -25,6 +25,9 @@ func (d *dummyDataPool) GetOrCreateIndicator(symbol, id string, factory func() t
 	}
 	return d.indicator
 }
+func (d *dummyDataPool) GetOrCreateComposite(id string, factory func() tick.CompositeIndicator) tick.CompositeIndicator {
+	return factory()
+}
 
 func TestSampleStrategy_Lifecycle(t *testing.T) {
 	factory, err := strategy.GetFactory("sample")
@@ -189,3 +192,17 @@ func TestSampleStrategy_Evaluate(t *testing.T) {
 		}
 	})
 }
+
+func TestSampleStrategy_ParamsOf(t *testing.T) {
+	factory, _ := strategy.GetFactory("sample")
+	schema := factory.(strategy.ParamSchemaProvider).ParamSchema()
+
+	// 生の map もその場で変換する
+	if p := strategy.ParamsOf[strategy.SampleParams](schema, map[string]interface{}{"rising_bars": 5.0}); p.RisingBars != 5 {
+		t.Errorf("expected 5 rising bars, got %d", p.RisingBars)
+	}
+	// 変換できない値はデフォルト値にフォールバックする
+	if p := strategy.ParamsOf[strategy.SampleParams](schema, map[string]interface{}{"rising_bars": "x"}); p.RisingBars != 3 {
+		t.Errorf("expected the default of 3 rising bars, got %d", p.RisingBars)
+	}
+}
@@ -78,14 +78,47 @@ func (i *StrategyInput) AveragePrice() float64 {
 type TargetPosition struct {
 	Qty           float64         // ターゲットポジション量（プラスならロング、マイナスならショート、0ならノーポジ）
 	Price         float64         // 注文価格（0なら成行）
-	OrderType     order.OrderType // 注文タイプ（指値・成行）
+	OrderType     order.OrderType // 注文タイプ（指値・成行・逆指値）
+	StopPrice     float64         // 逆指値のトリガー価格（OrderType が STOP / STOP_LIMIT の場合）
 	Reason        string          // 理由（分析用）
+	StopDistance  float64         // 損切りまでの値幅（1株あたり）。作戦にサイジング（fixed_risk）が設定されている場合に新規建ての数量を決めるのに使う。省略時は IFD・OCO の逆指値やトレーリングストップの幅から求める
 
 	// IFD用の決済ターゲット（オプション）
 	HasIfDone     bool
 	ExitPrice     float64
 	ExitOrderType order.OrderType
+	ExitStopPrice float64 // 返済の逆指値のトリガー価格
 	ExitReason    string
+
+	// OCO の相手方の決済ターゲット（オプション）。IFD の決済注文（HasIfDone）、または返済の注文（Qty が 0）と対で発注し、
+	// どちらかが約定したら他方を取り消す。利確の指値と損切りの逆指値を同時に置く場合に使う
+	HasOCO       bool
+	OCOPrice     float64
+	OCOOrderType order.OrderType
+	OCOStopPrice float64 // 逆指値のトリガー価格
+	OCOReason    string
+
+	// トレーリングストップ（オプション）。SniperNest が建玉ごとに有利方向の最高値（売り建ては最安値）を追跡し、
+	// そこから TrailWidth 以上逆行したら、その建玉を成行で返済する。新規建ての目標で指定すれば約定した建玉から適用され、
+	// 保有中に指定し直すと幅を変更できる。指定を省略した目標を返しても、建玉が無くなるまで維持される
+	TrailType   TrailType
+	TrailWidth  float64 // 逆行を許す幅（呼値の数・パーセント・ATR の倍率）
+	TrailATR    float64 // TrailType が TRAIL_TYPE_ATR の場合の現在の ATR（0 の場合は前回指定された値を使う）
+	TrailReason string  // 返済注文の理由（省略時は "TrailingStop"）
+}
+
+// TrailType はトレーリングストップの逆行幅の指定方法です
+type TrailType string
+
+const (
+	TRAIL_TYPE_TICKS   TrailType = "ticks"   // 呼値の数
+	TRAIL_TYPE_PERCENT TrailType = "percent" // 最高値（売り建ては最安値）に対するパーセント
+	TRAIL_TYPE_ATR     TrailType = "atr"     // ATR の倍率
+)
+
+// HasTrail はトレーリングストップが指定されているかを返します
+func (t TargetPosition) HasTrail() bool {
+	return t.TrailType != "" && t.TrailWidth > 0
 }
 
 func (t TargetPosition) AbsQty() float64 {
@@ -108,6 +141,19 @@ type CancelChecker interface {
 	ShouldCancel(input StrategyInput, ord *order.Order) bool
 }
 
+// Reconfigurable は稼働中にパラメータを差し替えられる戦略が実装します（任意）。
+// params は NewStrategy と同じく、ファクトリのスキーマで検証・変換済みの値です。
+// Evaluate と同じスナイパーのロック下で呼び出されるため、戦略側での排他は不要です。
+type Reconfigurable interface {
+	Reconfigure(params interface{}) error
+}
+
+// Closer は子プロセスなど、使い終わったら解放すべき資源を持つ戦略が実装します（任意）。
+// 作戦の退役（OrderlyExit）とシステムの終了時に、スナイパーのロック下で1度だけ呼び出されます。以降 Evaluate は呼ばれません。
+type Closer interface {
+	Close() error
+}
+
 type Strategy interface {
 	Name() string
 	Evaluate(input StrategyInput) TargetPosition
@@ -0,0 +1,187 @@
+package strategy
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/volatility"
+)
+
+// VWAPReversionParams は vwap_reversion 戦略のパラメータです（operations.json の strategy_params.vwap_reversion）
+type VWAPReversionParams struct {
+	Band          float64       `json:"band"`           // 新規建てする乖離（σ の倍率）
+	ExitBand      float64       `json:"exit_band"`      // 利確する乖離（σ の倍率）。0 なら VWAP まで戻ったら利確する
+	StopBand      float64       `json:"stop_band"`      // 損切りする乖離（σ の倍率）
+	Side          string        `json:"side"`           // "long" / "short" / "both"
+	Units         int           `json:"units"`          // 1回に建てる売買単位の数
+	MinSigma      float64       `json:"min_sigma"`      // σ の下限（VWAP に対する比率）。バンドが狭すぎる間は建てない
+	WarmUp        time.Duration `json:"warm_up"`        // 前場の寄付から建て始めるまでの時間（σ が安定するまで待つ）
+	MaxTrades     int           `json:"max_trades"`     // 1日に建てる回数の上限
+	FlattenBefore time.Duration `json:"flatten_before"` // 後場のザラバ終了のこの時間前に手仕舞い、以降は建てない
+	Policy        string        `json:"policy"`         // 疑似約定の判定（none / touch / pierce / volume）
+	TouchTTL      time.Duration `json:"touch_ttl"`      // policy が touch の場合の TTL
+}
+
+var vwapReversionParamSchema = ParamSchema{
+	Fields: []ParamField{
+		{Name: "band", Type: PARAM_TYPE_FLOAT, Default: 2.0, Min: Bound(0.1), Max: Bound(10), Description: "新規建てする乖離（σ の倍率）"},
+		{Name: "exit_band", Type: PARAM_TYPE_FLOAT, Default: 0.0, Min: Bound(0), Max: Bound(10), Description: "利確する乖離（σ の倍率）"},
+		{Name: "stop_band", Type: PARAM_TYPE_FLOAT, Default: 3.0, Min: Bound(0.1), Max: Bound(20), Description: "損切りする乖離（σ の倍率）"},
+		{Name: "side", Type: PARAM_TYPE_STRING, Default: "both", Choices: []string{"long", "short", "both"}, Description: "建てる方向"},
+		{Name: "units", Type: PARAM_TYPE_INT, Default: 1, Min: Bound(1), Max: Bound(100), Description: "1回に建てる売買単位の数"},
+		{Name: "min_sigma", Type: PARAM_TYPE_FLOAT, Default: 0.001, Min: Bound(0), Max: Bound(1), Description: "σ の下限（VWAP に対する比率）"},
+		{Name: "warm_up", Type: PARAM_TYPE_DURATION, Default: "15m", Min: Bound(0), Description: "寄付から建て始めるまでの時間"},
+		{Name: "max_trades", Type: PARAM_TYPE_INT, Default: 3, Min: Bound(1), Max: Bound(100), Description: "1日に建てる回数の上限"},
+		{Name: "flatten_before", Type: PARAM_TYPE_DURATION, Default: "10m", Min: Bound(0), Description: "ザラバ終了前に手仕舞う時間"},
+		{Name: "policy", Type: PARAM_TYPE_STRING, Default: POLICY_TOUCH, Choices: PolicyChoices, Description: "疑似約定の判定"},
+		{Name: "touch_ttl", Type: PARAM_TYPE_DURATION, Default: "3s", Min: Bound(0), Description: "touch の TTL"},
+	},
+	New: func() interface{} { return &VWAPReversionParams{} },
+}
+
+// VWAPReversionStrategy はセッション VWAP からの乖離の戻りを狙う逆張り戦略です。
+//   - 価格が VWAP - band×σ 以下に売り込まれたら、その価格の指値で買う（VWAP + band×σ 以上なら売る）
+//   - VWAP - exit_band×σ まで戻ったら成行で利確し、VWAP - stop_band×σ を割り込んだら成行で損切りする
+//   - 後場のザラバ終了の flatten_before 前に手仕舞う
+type VWAPReversionStrategy struct {
+	detail symbol.Symbol
+	params VWAPReversionParams
+	vwap   *volatility.SessionVWAP
+
+	day     time.Time // 建てた回数を数えている日（日本時間の0時）
+	trades  int       // 当日建てた回数
+	holding bool      // 前回の評価で建玉を持っていたか
+}
+
+func (s *VWAPReversionStrategy) Name() string {
+	return "vwap_reversion"
+}
+
+func (s *VWAPReversionStrategy) AnalysisLogger() *slog.Logger {
+	return nil
+}
+
+func (s *VWAPReversionStrategy) Evaluate(input StrategyInput) TargetPosition {
+	holdQty := input.HoldQty()
+	t := input.LatestTick
+	if !t.IsExecution() || t.Price <= 0 {
+		return TargetPosition{Qty: holdQty}
+	}
+	if day := sessionDay(t.CurrentPriceTime); !day.Equal(s.day) {
+		s.day, s.trades = day, 0
+	}
+	if holdQty != 0 && !s.holding {
+		s.trades++
+	}
+	s.holding = holdQty != 0
+
+	sess, elapsed, remaining, ok := session.Default().ContinuousWindow(t.CurrentPriceTime)
+	if !ok || !s.vwap.Ready() {
+		return TargetPosition{Qty: holdQty}
+	}
+	closing := sess == session.SESSION_AFTERNOON && remaining <= s.params.FlattenBefore
+	price := t.Price
+
+	if holdQty > 0 {
+		switch {
+		case price <= s.vwap.Lower(s.params.StopBand):
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "StopLoss_VWAP"}
+		case price >= s.vwap.Lower(s.params.ExitBand):
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "TakeProfit"}
+		case closing:
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "TimeStop"}
+		}
+		return TargetPosition{Qty: holdQty}
+	}
+	if holdQty < 0 {
+		switch {
+		case price >= s.vwap.Upper(s.params.StopBand):
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "StopLoss_VWAP"}
+		case price <= s.vwap.Upper(s.params.ExitBand):
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "TakeProfit"}
+		case closing:
+			return TargetPosition{Qty: 0, Price: 0, OrderType: order.ORDER_TYPE_MARKET, Reason: "TimeStop"}
+		}
+		return TargetPosition{Qty: holdQty}
+	}
+
+	if closing || s.trades >= s.params.MaxTrades || (sess == session.SESSION_MORNING && elapsed < s.params.WarmUp) {
+		return TargetPosition{Qty: 0}
+	}
+	if s.vwap.Sigma() < s.vwap.VWAP()*s.params.MinSigma {
+		return TargetPosition{Qty: 0}
+	}
+	qty := float64(s.params.Units) * s.detail.Unit()
+	z := s.vwap.ZScore(price)
+	// 損切りの水準より先まで走っている間は、建てた直後に損切りになるため見送る
+	switch {
+	case z <= -s.params.Band && z > -s.params.StopBand && s.params.Side != "short":
+		return TargetPosition{Qty: qty, Price: s.detail.RoundPrice(price), OrderType: order.ORDER_TYPE_LIMIT, Reason: "VWAP_Reversion"}
+	case z >= s.params.Band && z < s.params.StopBand && s.params.Side != "long":
+		return TargetPosition{Qty: -qty, Price: s.detail.RoundPrice(price), OrderType: order.ORDER_TYPE_LIMIT, Reason: "VWAP_Reversion"}
+	}
+	return TargetPosition{Qty: 0}
+}
+
+// Reconfigure は稼働中に vwap_reversion 戦略のパラメータを差し替えます
+func (s *VWAPReversionStrategy) Reconfigure(params interface{}) error {
+	p, ok := params.(*VWAPReversionParams)
+	if !ok || p == nil {
+		decoded, err := (&VWAPReversionStrategyFactory{}).DecodeParams(params, "")
+		if err != nil {
+			return err
+		}
+		p = decoded.(*VWAPReversionParams)
+	}
+	s.params = *p
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Factory & Registration
+// ----------------------------------------------------------------------------
+
+type VWAPReversionStrategyFactory struct{}
+
+func (f *VWAPReversionStrategyFactory) NewStrategy(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) Strategy {
+	return &VWAPReversionStrategy{
+		detail: detail,
+		params: *ParamsOf[VWAPReversionParams](vwapReversionParamSchema, params),
+		vwap:   volatility.GetOrCreateSessionVWAP(dataPool, detail.Code),
+	}
+}
+
+func (f *VWAPReversionStrategyFactory) CreateExecutionPolicy(params interface{}) ExecutionPolicy {
+	p := ParamsOf[VWAPReversionParams](vwapReversionParamSchema, params)
+	return NewExecutionPolicy(p.Policy, p.TouchTTL)
+}
+
+// DecodeParams はパラメータを検証し、バンドの大小関係（exit_band < band < stop_band）も検査します
+func (f *VWAPReversionStrategyFactory) DecodeParams(params interface{}, path string) (interface{}, error) {
+	decoded, err := vwapReversionParamSchema.Decode(params, path)
+	if err != nil {
+		return nil, err
+	}
+	p := decoded.(*VWAPReversionParams)
+	if p.StopBand <= p.Band {
+		return nil, &ParamError{Path: joinParamPath(path, "stop_band"), Err: fmt.Errorf("%w: band (%v) より大きい値を指定してください", ErrParamRange, p.Band)}
+	}
+	if p.ExitBand >= p.Band {
+		return nil, &ParamError{Path: joinParamPath(path, "exit_band"), Err: fmt.Errorf("%w: band (%v) より小さい値を指定してください", ErrParamRange, p.Band)}
+	}
+	return p, nil
+}
+
+// ParamSchema は vwap_reversion 戦略のパラメータ定義を返します
+func (f *VWAPReversionStrategyFactory) ParamSchema() ParamSchema {
+	return vwapReversionParamSchema
+}
+
+func init() {
+	Register("vwap_reversion", &VWAPReversionStrategyFactory{})
+}
@@ -0,0 +1,35 @@
+package strategy_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+)
+
+func TestVWAPReversionStrategyFactory_DecodeParams(t *testing.T) {
+	factory, _ := strategy.GetFactory("vwap_reversion")
+
+	if _, err := strategy.PrepareParams(factory, map[string]interface{}{"band": 2.5, "stop_band": 3.5, "exit_band": 0.5}, "vwap_reversion"); err != nil {
+		t.Errorf("expected valid params, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		wantPath string
+	}{
+		{"stop inside band", map[string]interface{}{"band": 2.0, "stop_band": 1.5}, "vwap_reversion.stop_band"},
+		{"exit beyond band", map[string]interface{}{"band": 2.0, "exit_band": 2.0}, "vwap_reversion.exit_band"},
+		{"unknown policy", map[string]interface{}{"policy": "optimistic"}, "vwap_reversion.policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := strategy.PrepareParams(factory, tt.params, "vwap_reversion")
+			var pe *strategy.ParamError
+			if !errors.As(err, &pe) || pe.Path != tt.wantPath {
+				t.Errorf("expected ParamError at %q, got %v", tt.wantPath, err)
+			}
+		})
+	}
+}
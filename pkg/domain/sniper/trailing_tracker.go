@@ -0,0 +1,125 @@
+package sniper
+
+import (
+	"math"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/position"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+)
+
+const defaultTrailReason = "TrailingStop"
+
+// trailSpec はスナイパーに設定されたトレーリングストップです
+type trailSpec struct {
+	typ    strategy.TrailType
+	width  float64
+	atr    float64
+	reason string
+}
+
+// distance は基準価格（最高値・最安値）から返済までに許す逆行幅を返します。幅が決まらない場合は 0 を返します
+func (s trailSpec) distance(mark float64, detail symbol.Symbol) float64 {
+	switch s.typ {
+	case strategy.TRAIL_TYPE_TICKS:
+		return s.width * detail.CalcTickSize(mark)
+	case strategy.TRAIL_TYPE_PERCENT:
+		return mark * s.width / 100
+	case strategy.TRAIL_TYPE_ATR:
+		return s.atr * s.width
+	}
+	return 0
+}
+
+// TrailHit はトレーリングストップに掛かった建玉です
+type TrailHit struct {
+	Position  position.Position
+	Mark      float64 // 有利方向の最高値（売り建ては最安値）
+	StopPrice float64 // 返済する価格の水準
+}
+
+// TrailingTracker はトレーリングストップの設定（スナイパーごと）と、建玉ごとの最高値・最安値を管理します。
+// 最高値は建玉（約定ID）単位で持つため、一部返済しても残りの建玉の値は引き継がれ、後から建て増した建玉は取得価格から追跡を始めます。
+type TrailingTracker struct {
+	specs map[string]trailSpec
+	marks map[string]map[string]float64 // sniperID -> 建玉の約定ID -> 最高値（売り建ては最安値）
+}
+
+func NewTrailingTracker() *TrailingTracker {
+	return &TrailingTracker{
+		specs: make(map[string]trailSpec),
+		marks: make(map[string]map[string]float64),
+	}
+}
+
+// Arm は目標にトレーリングストップの指定があれば、スナイパーの設定を更新します
+func (tt *TrailingTracker) Arm(sniperID string, target strategy.TargetPosition) {
+	if !target.HasTrail() {
+		return
+	}
+	spec := trailSpec{typ: target.TrailType, width: target.TrailWidth, atr: target.TrailATR, reason: target.TrailReason}
+	if spec.atr <= 0 {
+		spec.atr = tt.specs[sniperID].atr
+	}
+	if spec.reason == "" {
+		spec.reason = defaultTrailReason
+	}
+	tt.specs[sniperID] = spec
+}
+
+// Disarm はスナイパーのトレーリングストップの設定と追跡中の値を破棄します
+func (tt *TrailingTracker) Disarm(sniperID string) {
+	delete(tt.specs, sniperID)
+	delete(tt.marks, sniperID)
+}
+
+// IsArmed はスナイパーにトレーリングストップが設定されているかを返します
+func (tt *TrailingTracker) IsArmed(sniperID string) bool {
+	_, ok := tt.specs[sniperID]
+	return ok
+}
+
+// Reason はスナイパーのトレーリングストップの返済注文の理由を返します
+func (tt *TrailingTracker) Reason(sniperID string) string {
+	return tt.specs[sniperID].reason
+}
+
+// Update は最新の約定価格で建玉ごとの最高値（売り建ては最安値）を更新し、逆行幅を超えた建玉を返します。
+// 無くなった建玉の値は破棄します。
+func (tt *TrailingTracker) Update(sniperID string, positions []position.Position, price float64, detail symbol.Symbol) []TrailHit {
+	spec, ok := tt.specs[sniperID]
+	if !ok {
+		return nil
+	}
+	prev := tt.marks[sniperID]
+	marks := make(map[string]float64, len(positions))
+	var hits []TrailHit
+	for _, p := range positions {
+		mark, seen := prev[p.ExecutionID]
+		if !seen {
+			mark = p.Price
+		}
+		short := p.Action == order.ACTION_SELL
+		if short {
+			mark = math.Min(mark, price)
+		} else {
+			mark = math.Max(mark, price)
+		}
+		marks[p.ExecutionID] = mark
+
+		dist := spec.distance(mark, detail)
+		if dist <= 0 {
+			continue
+		}
+		stop := mark - dist
+		if short {
+			stop = mark + dist
+		}
+		if (!short && price <= stop) || (short && price >= stop) {
+			hits = append(hits, TrailHit{Position: p, Mark: mark, StopPrice: stop})
+		}
+	}
+	tt.marks[sniperID] = marks
+	return hits
+}
@@ -0,0 +1,76 @@
+package sniper_test
+
+import (
+	"testing"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/position"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+)
+
+func TestTrailingTracker(t *testing.T) {
+	detail := symbol.Symbol{Code: "7203", PriceRangeGroup: symbol.PRICE_RANGE_GROUP_TSE_STANDARD}
+	long := func(id string, price, qty float64) position.Position {
+		return position.Position{ExecutionID: id, Action: order.ACTION_BUY, Price: price, LeavesQty: qty}
+	}
+
+	t.Run("not armed", func(t *testing.T) {
+		tt := sniper.NewTrailingTracker()
+		if hits := tt.Update("s1", []position.Position{long("e1", 2000, 100)}, 1000, detail); len(hits) != 0 {
+			t.Errorf("expected no hits without a trailing stop, got %+v", hits)
+		}
+	})
+
+	t.Run("ticks", func(t *testing.T) {
+		tt := sniper.NewTrailingTracker()
+		tt.Arm("s1", strategy.TargetPosition{TrailType: strategy.TRAIL_TYPE_TICKS, TrailWidth: 5})
+		pos := []position.Position{long("e1", 2000, 100)}
+		tt.Update("s1", pos, 2050, detail) // 2050 円の呼値は 1 円、水準は 2045
+		if hits := tt.Update("s1", pos, 2046, detail); len(hits) != 0 {
+			t.Fatalf("expected no hit above the stop, got %+v", hits)
+		}
+		hits := tt.Update("s1", pos, 2045, detail)
+		if len(hits) != 1 || hits[0].Mark != 2050 || hits[0].StopPrice != 2045 {
+			t.Errorf("unexpected hits: %+v", hits)
+		}
+	})
+
+	t.Run("partial exit keeps the mark", func(t *testing.T) {
+		tt := sniper.NewTrailingTracker()
+		tt.Arm("s1", strategy.TargetPosition{TrailType: strategy.TRAIL_TYPE_PERCENT, TrailWidth: 2})
+		tt.Update("s1", []position.Position{long("e1", 2000, 100)}, 2200, detail)
+		// 一部返済しても最高値 2200 は維持され、建て増した建玉は取得価格から追跡する
+		pos := []position.Position{long("e1", 2000, 50), long("e2", 2150, 100)}
+		hits := tt.Update("s1", pos, 2150, detail)
+		if len(hits) != 1 || hits[0].Position.ExecutionID != "e1" || hits[0].Position.LeavesQty != 50 {
+			t.Errorf("expected only the older lot to hit, got %+v", hits)
+		}
+	})
+
+	t.Run("atr keeps the last value", func(t *testing.T) {
+		tt := sniper.NewTrailingTracker()
+		tt.Arm("s1", strategy.TargetPosition{TrailType: strategy.TRAIL_TYPE_ATR, TrailWidth: 2, TrailATR: 10})
+		tt.Arm("s1", strategy.TargetPosition{TrailType: strategy.TRAIL_TYPE_ATR, TrailWidth: 3})
+		pos := []position.Position{long("e1", 2000, 100)}
+		if hits := tt.Update("s1", pos, 1971, detail); len(hits) != 0 {
+			t.Fatalf("expected no hit above 1970, got %+v", hits)
+		}
+		if hits := tt.Update("s1", pos, 1970, detail); len(hits) != 1 {
+			t.Errorf("expected a hit at 2000 - 3 x 10, got %+v", hits)
+		}
+	})
+
+	t.Run("disarm", func(t *testing.T) {
+		tt := sniper.NewTrailingTracker()
+		tt.Arm("s1", strategy.TargetPosition{TrailType: strategy.TRAIL_TYPE_PERCENT, TrailWidth: 1, TrailReason: "Trail"})
+		if !tt.IsArmed("s1") || tt.Reason("s1") != "Trail" {
+			t.Fatal("expected the trailing stop to be armed with its reason")
+		}
+		tt.Disarm("s1")
+		if tt.IsArmed("s1") {
+			t.Error("expected the trailing stop to be disarmed")
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package symbol
+
+import (
+	"errors"
+	"math"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+)
+
+// ErrOutsidePriceLimits は指値が値幅制限の外側にあり、取引所で受け付けられない（約定し得ない）ことを表します
+var ErrOutsidePriceLimits = errors.New("指値が値幅制限の範囲外です")
+
+// priceLimitTable は東証の制限値幅表です（基準値段が bound 未満の場合の値幅）
+var priceLimitTable = []struct {
+	bound float64
+	width float64
+}{
+	{100, 30},
+	{200, 50},
+	{500, 80},
+	{700, 100},
+	{1000, 150},
+	{1500, 300},
+	{2000, 400},
+	{3000, 500},
+	{5000, 700},
+	{7000, 1000},
+	{10000, 1500},
+	{15000, 3000},
+	{20000, 4000},
+	{30000, 5000},
+	{50000, 7000},
+	{70000, 10000},
+	{100000, 15000},
+	{150000, 30000},
+	{200000, 40000},
+	{300000, 50000},
+	{500000, 70000},
+	{700000, 100000},
+	{1000000, 150000},
+	{1500000, 300000},
+	{2000000, 400000},
+	{3000000, 500000},
+	{5000000, 700000},
+	{7000000, 1000000},
+	{10000000, 1500000},
+	{15000000, 3000000},
+	{20000000, 4000000},
+	{30000000, 5000000},
+	{50000000, 7000000},
+}
+
+// maxPriceLimitWidth は基準値段が 5,000万円以上の場合の値幅です
+const maxPriceLimitWidth = 10000000
+
+// CalcPriceLimitWidth は基準値段（通常は前日終値）に対する制限値幅を返します
+func CalcPriceLimitWidth(base float64) float64 {
+	for _, row := range priceLimitTable {
+		if base < row.bound {
+			return row.width
+		}
+	}
+	return maxPriceLimitWidth
+}
+
+// PriceLimits は当日の値幅制限（ストップ高・ストップ安）です。上限が 0 の場合は未設定（制限なし）として扱います。
+type PriceLimits struct {
+	Base  float64 // 基準値段（前日終値）。証券会社から制限値を直接取得した場合は 0 のこともある
+	Upper float64 // 制限値幅の上限（ストップ高）
+	Lower float64 // 制限値幅の下限（ストップ安）
+}
+
+// NewPriceLimits は基準値段から値幅制限を計算します。base が 0 以下の場合は未設定を返します。
+func NewPriceLimits(base float64) PriceLimits {
+	if base <= 0 || math.IsNaN(base) {
+		return PriceLimits{}
+	}
+	width := CalcPriceLimitWidth(base)
+	// 下限は1円を下回らない
+	return PriceLimits{Base: base, Upper: base + width, Lower: math.Max(base-width, 1)}
+}
+
+// Known は値幅制限が設定されているかを返します
+func (l PriceLimits) Known() bool {
+	return l.Upper > 0
+}
+
+// Contains は価格が値幅制限の範囲内かを返します（未設定の場合は常に true）
+func (l PriceLimits) Contains(price float64) bool {
+	return !l.Known() || (price >= l.Lower && price <= l.Upper)
+}
+
+// Clamp は価格を値幅制限の範囲内に収めます（未設定の場合はそのまま）
+func (l PriceLimits) Clamp(price float64) float64 {
+	if !l.Known() {
+		return price
+	}
+	return math.Min(math.Max(price, l.Lower), l.Upper)
+}
+
+// FitLimitPrice は指値を値幅制限に合わせます。
+// 買いのストップ高超え・売りのストップ安割れは制限値に丸めても約定条件が変わらないため制限値に寄せ、
+// 買いのストップ安割れ・売りのストップ高超えは取引所に拒否される（約定し得ない）ため ErrOutsidePriceLimits を返します。
+// 価格が 0 以下（成行）や NaN、値幅制限が未設定の場合はそのまま返します。
+func (l PriceLimits) FitLimitPrice(action order.Action, price float64) (float64, error) {
+	if !l.Known() || price <= 0 || math.IsNaN(price) || l.Contains(price) {
+		return price, nil
+	}
+	switch {
+	case action == order.ACTION_BUY && price > l.Upper:
+		return l.Upper, nil
+	case action == order.ACTION_SELL && price < l.Lower:
+		return l.Lower, nil
+	}
+	return price, ErrOutsidePriceLimits
+}
+
+// IsLimitUp は価格がストップ高に張り付いているかを返します（値幅制限が未設定の場合は false）
+func (s Symbol) IsLimitUp(price float64) bool {
+	return s.PriceLimits.Known() && price >= s.PriceLimits.Upper
+}
+
+// IsLimitDown は価格がストップ安に張り付いているかを返します（値幅制限が未設定の場合は false）
+func (s Symbol) IsLimitDown(price float64) bool {
+	return s.PriceLimits.Known() && price > 0 && price <= s.PriceLimits.Lower
+}
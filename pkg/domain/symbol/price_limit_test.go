@@ -0,0 +1,91 @@
+package symbol_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+)
+
+func TestCalcPriceLimitWidth(t *testing.T) {
+	tests := []struct {
+		base     float64
+		expected float64
+	}{
+		{99, 30},
+		{100, 50},
+		{499, 80},
+		{999, 150},
+		{1000, 300},
+		{2999, 500},
+		{3000, 700},
+		{9999, 1500},
+		{10000, 3000},
+		{49999, 7000},
+		{99999, 15000},
+		{100000, 30000},
+		{49999999, 7000000},
+		{50000000, 10000000},
+	}
+	for _, tt := range tests {
+		if got := symbol.CalcPriceLimitWidth(tt.base); got != tt.expected {
+			t.Errorf("base %.0f: expected width %.0f, got %.0f", tt.base, tt.expected, got)
+		}
+	}
+}
+
+func TestNewPriceLimits(t *testing.T) {
+	limits := symbol.NewPriceLimits(2500)
+	if limits.Upper != 3000 || limits.Lower != 2000 || !limits.Known() {
+		t.Errorf("unexpected limits: %+v", limits)
+	}
+	// 下限は1円を下回らない
+	if low := symbol.NewPriceLimits(20); low.Lower != 1 || low.Upper != 50 {
+		t.Errorf("unexpected limits for a low-priced stock: %+v", low)
+	}
+	if unknown := symbol.NewPriceLimits(0); unknown.Known() || !unknown.Contains(1e9) {
+		t.Errorf("expected zero base to mean no limits: %+v", unknown)
+	}
+}
+
+func TestPriceLimits_FitLimitPrice(t *testing.T) {
+	limits := symbol.NewPriceLimits(2500) // 2000〜3000
+
+	tests := []struct {
+		name    string
+		action  order.Action
+		price   float64
+		want    float64
+		wantErr bool
+	}{
+		{"buy within limits", order.ACTION_BUY, 2800, 2800, false},
+		{"buy above upper is clamped", order.ACTION_BUY, 3100, 3000, false},
+		{"buy below lower is rejected", order.ACTION_BUY, 1900, 1900, true},
+		{"sell below lower is clamped", order.ACTION_SELL, 1900, 2000, false},
+		{"sell above upper is rejected", order.ACTION_SELL, 3100, 3100, true},
+		{"market order is untouched", order.ACTION_BUY, 0, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := limits.FitLimitPrice(tt.action, tt.price)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected %.0f (err=%v), got %.0f (err=%v)", tt.name, tt.want, tt.wantErr, got, err)
+		}
+		if tt.wantErr && !errors.Is(err, symbol.ErrOutsidePriceLimits) {
+			t.Errorf("%s: expected ErrOutsidePriceLimits, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestSymbol_IsLimitUpDown(t *testing.T) {
+	s := symbol.Symbol{Code: "7203", PriceLimits: symbol.NewPriceLimits(2500)}
+	if !s.IsLimitUp(3000) || s.IsLimitUp(2999) {
+		t.Errorf("unexpected limit-up detection")
+	}
+	if !s.IsLimitDown(2000) || s.IsLimitDown(2001) {
+		t.Errorf("unexpected limit-down detection")
+	}
+	if (symbol.Symbol{Code: "7203"}).IsLimitUp(1e9) {
+		t.Errorf("expected no limit-up without price limits")
+	}
+}
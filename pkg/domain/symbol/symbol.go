@@ -2,15 +2,68 @@ package symbol
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
 )
 
+// DefaultTradingUnit は銘柄マスタから売買単位を取得できなかった場合の売買単位（国内株式の単元株数）です
+const DefaultTradingUnit = 100
+
 // Symbol は銘柄の基本属性を保持するエンティティです
 type Symbol struct {
 	Code            string
 	Name            string
 	PriceRangeGroup PriceRangeGroup
+	PriceLimits     PriceLimits       // 当日の値幅制限（未取得の場合はゼロ値）
+	TradingUnit     float64           // 売買単位（0 の場合は DefaultTradingUnit）
+	Margin          MarginEligibility // 信用取引の可否
+}
+
+// MarginEligibility は銘柄の信用取引の可否です。Known が false の場合は銘柄マスタが無く、可否を判定しません。
+type MarginEligibility struct {
+	Known       bool
+	SystemBuy   bool // 制度信用の新規買い
+	SystemSell  bool // 制度信用の新規売り（貸借銘柄）
+	GeneralBuy  bool // 一般信用（長期）の新規買い
+	GeneralSell bool // 一般信用（長期）の新規売り
+	DayTrade    bool // 一般信用（デイトレ）の対象銘柄
+}
+
+// Unit は売買単位を返します
+func (s Symbol) Unit() float64 {
+	if s.TradingUnit > 0 {
+		return s.TradingUnit
+	}
+	return DefaultTradingUnit
+}
+
+// RoundQty は数量を売買単位の倍数に切り捨てます（切り上げると戦略やペアの比率が求めた数量より多く建てるため）。
+// 1単位に満たない数量は 0（発注しない）とし、符号（売買方向）は維持します。
+func (s Symbol) RoundQty(qty float64) float64 {
+	unit := s.Unit()
+	rounded := math.Floor(math.Abs(qty)/unit+1e-9) * unit
+	if rounded == 0 {
+		return 0
+	}
+	return math.Copysign(rounded, qty)
+}
+
+// CanOpen は指定した信用区分で新規建てできるかを返します（銘柄マスタが無い場合は常に true）
+func (s Symbol) CanOpen(action order.Action, marginType order.MarginTradeType) bool {
+	if !s.Margin.Known {
+		return true
+	}
+	isBuy := action == order.ACTION_BUY
+	switch marginType {
+	case order.TRADE_TYPE_SYSTEM:
+		return (isBuy && s.Margin.SystemBuy) || (!isBuy && s.Margin.SystemSell)
+	case order.TRADE_TYPE_GENERAL:
+		return (isBuy && s.Margin.GeneralBuy) || (!isBuy && s.Margin.GeneralSell)
+	case order.TRADE_TYPE_GENERAL_DAY:
+		return s.Margin.DayTrade
+	}
+	return true
 }
 
 // WatchTarget は監視対象の設定を保持するバリューオブジェクトです
@@ -3,6 +3,7 @@ package symbol_test
 import (
 	"testing"
 
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 )
 
@@ -185,3 +186,51 @@ func TestSymbol_RoundPrice(t *testing.T) {
 		t.Errorf("expected price to be returned completely untouched when tick is 0, got %f", roundedPrice)
 	}
 }
+
+func TestSymbol_RoundQty(t *testing.T) {
+	s := symbol.Symbol{Code: "7203", TradingUnit: 100}
+	tests := []struct {
+		qty      float64
+		expected float64
+	}{
+		{0, 0},
+		{30, 0}, // 1単位に満たない数量は発注しない
+		{-30, 0},
+		{149, 100},
+		{150, 100}, // 切り上げない
+		{-260, -200},
+	}
+	for _, tt := range tests {
+		if got := s.RoundQty(tt.qty); got != tt.expected {
+			t.Errorf("qty %.0f: expected %.0f, got %.0f", tt.qty, tt.expected, got)
+		}
+	}
+	if got := (symbol.Symbol{Code: "7203"}).RoundQty(130); got != 100 {
+		t.Errorf("expected the default trading unit, got %.0f", got)
+	}
+	if got := (symbol.Symbol{Code: "1540", TradingUnit: 1}).RoundQty(7); got != 7 {
+		t.Errorf("expected a unit of one share, got %.0f", got)
+	}
+}
+
+func TestSymbol_CanOpen(t *testing.T) {
+	s := symbol.Symbol{Code: "7203", Margin: symbol.MarginEligibility{Known: true, SystemBuy: true, GeneralBuy: true, GeneralSell: true}}
+	tests := []struct {
+		action     order.Action
+		marginType order.MarginTradeType
+		expected   bool
+	}{
+		{order.ACTION_BUY, order.TRADE_TYPE_SYSTEM, true},
+		{order.ACTION_SELL, order.TRADE_TYPE_SYSTEM, false}, // 貸借銘柄ではない
+		{order.ACTION_SELL, order.TRADE_TYPE_GENERAL, true},
+		{order.ACTION_BUY, order.TRADE_TYPE_GENERAL_DAY, false}, // デイトレ対象外
+	}
+	for _, tt := range tests {
+		if got := s.CanOpen(tt.action, tt.marginType); got != tt.expected {
+			t.Errorf("%v/%v: expected %v, got %v", tt.action, tt.marginType, tt.expected, got)
+		}
+	}
+	if !(symbol.Symbol{Code: "7203"}).CanOpen(order.ACTION_SELL, order.TRADE_TYPE_GENERAL_DAY) {
+		t.Errorf("expected no restriction without the symbol master")
+	}
+}
@@ -10,4 +10,36 @@ type Bar struct {
 	Low       float64
 	Close     float64
 	Volume    float64
+
+	// Lee-Ready 法で判定した買い手主導・売り手主導の出来高（判定不能分はどちらにも含まれない）
+	BuyVolume  float64
+	SellVolume float64
+}
+
+// Delta はバー内の出来高デルタ（買い出来高 - 売り出来高）を返します
+func (b Bar) Delta() float64 {
+	return b.BuyVolume - b.SellVolume
+}
+
+// BarSource はバーを生成するインジケーターが満たすインターフェースです。
+// テクニカル指標はこれに依存し、確定済みのバーを順番に取り込みます。
+// 確定足は保持上限（既定 DefaultBarRetention 本）を超えると古いものから破棄されます。
+type BarSource interface {
+	Indicator
+	// Bars は保持しているバー（確定足と形成中のバー）のコピーを返します
+	Bars() []Bar
+	// Len は保持しているバーの本数を返します（形成中のバーを含む）
+	Len() int
+	// At は index 番目（0始まり、古い順、末尾は形成中のバー）のバーを返します。範囲外の場合はゼロ値の Bar を返すため、Len で本数を確認してから参照してください
+	At(index int) Bar
+	// Last は最新のバーから n 本遡ったバーを返します（0 で最新＝形成中のバー）。範囲外の場合はゼロ値の Bar を返すため、Len で本数を確認してから参照してください
+	Last(n int) Bar
+	// EnsureRetention は確定足の保持本数を n 本以上に引き上げます（引き下げはしません）
+	EnsureRetention(n int)
+	// ClosedCount はこれまでに確定したバーの累計本数を返します（破棄された分を含む）
+	ClosedCount() int
+	// OldestClosedIndex は保持している最も古い確定足の index を返します
+	OldestClosedIndex() int
+	// ClosedBar は index 番目（0始まり、古い順の累計）の確定足を返します。OldestClosedIndex より前は参照できません
+	ClosedBar(index int) Bar
 }
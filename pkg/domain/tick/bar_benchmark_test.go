@@ -0,0 +1,55 @@
+package tick_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// BenchmarkBarIndicator_PerTickCost は300銘柄を監視した状態での Tick 1件あたりのコストを測ります。
+// 事前に積み上げた確定足の本数によらず、1 Tick あたりのアロケーションが 0 回であることを確認します。
+// 保持本数の上限（DefaultBarRetention）に達するまではリングバッファの伸長分の B/op が計上されますが、
+// 上限に達した後（prefilled_10000）は 0 になり、メモリは上限の本数で頭打ちになります。
+func BenchmarkBarIndicator_PerTickCost(b *testing.B) {
+	const symbols = 300
+	base := time.Date(2026, 4, 20, 9, 0, 0, 0, time.UTC)
+
+	for _, prefilled := range []int{0, 1000, 10000} {
+		b.Run(fmt.Sprintf("prefilled_%d", prefilled), func(b *testing.B) {
+			pool := tick.NewDefaultDataPool(nil)
+			codes := make([]string, symbols)
+			oneMin := make([]*tick.OneMinBarIndicator, symbols)
+			for s := range codes {
+				codes[s] = fmt.Sprintf("%04d", 1000+s)
+				oneMin[s] = pool.GetOrCreateIndicator(codes[s], "1min_bar", func() tick.Indicator {
+					return tick.NewOneMinBarIndicator("1min_bar")
+				}).(*tick.OneMinBarIndicator)
+				tick.GetOrCreateBarIndicator(pool, codes[s], tick.TimeBar(5*time.Second))
+			}
+
+			// 1分間隔の Tick で確定足を prefilled 本まで積み上げる
+			volume := 0.0
+			for k := 0; k < prefilled; k++ {
+				volume += 100
+				for s := range codes {
+					pool.PushTick(tick.Tick{Symbol: codes[s], Price: 100, TradingVolume: volume, CurrentPriceTime: base.Add(time.Duration(k) * time.Minute)})
+				}
+			}
+			now := base.Add(time.Duration(prefilled) * time.Minute)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				s := n % symbols
+				volume += 100
+				// 300銘柄を一巡するごとに1秒進め、5秒足・1分足の確定も含める
+				pool.PushTick(tick.Tick{Symbol: codes[s], Price: float64(100 + n%7), TradingVolume: volume, CurrentPriceTime: now.Add(time.Duration(n/symbols) * time.Second)})
+				if bars := oneMin[s]; bars.Len() >= 3 {
+					_ = bars.Last(2).Close < bars.Last(0).Close
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package tick
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+)
+
+// BarKind はバーの確定条件（時間・Tick数・出来高・売買代金）の種類を表します
+type BarKind int
+
+const (
+	BAR_KIND_TIME   BarKind = iota // 一定時間ごとに確定する時間足
+	BAR_KIND_TICK                  // 一定Tick数ごとに確定するTick足
+	BAR_KIND_VOLUME                // 一定出来高ごとに確定する出来高足
+	BAR_KIND_VALUE                 // 一定売買代金ごとに確定する代金足
+)
+
+// BarSpec はバーの集約ルールを表します。
+// ID() の結果が DataPool 上での共有キーになるため、同じ仕様のバーは同一銘柄内で1インスタンスに集約されます。
+type BarSpec struct {
+	Kind      BarKind
+	Frame     time.Duration // BAR_KIND_TIME の時間枠
+	Threshold float64       // BAR_KIND_TICK / VOLUME / VALUE の確定閾値
+}
+
+// TimeBar は指定時間枠の時間足仕様を返します（例: 5*time.Minute -> "bar_5m"）
+func TimeBar(frame time.Duration) BarSpec {
+	return BarSpec{Kind: BAR_KIND_TIME, Frame: frame}
+}
+
+// TickBar は n Tick ごとに確定する Tick 足仕様を返します（例: 100 -> "bar_100t"）
+func TickBar(n int) BarSpec {
+	return BarSpec{Kind: BAR_KIND_TICK, Threshold: float64(n)}
+}
+
+// VolumeBar は出来高 volume ごとに確定する出来高足仕様を返します（例: 10000 -> "bar_10000v"）
+func VolumeBar(volume float64) BarSpec {
+	return BarSpec{Kind: BAR_KIND_VOLUME, Threshold: volume}
+}
+
+// ValueBar は売買代金 value ごとに確定する代金足仕様を返します（例: 1e8 -> "bar_100000000jpy"）
+func ValueBar(value float64) BarSpec {
+	return BarSpec{Kind: BAR_KIND_VALUE, Threshold: value}
+}
+
+// barTimeUnits は時間足IDのサフィックスと時間単位の対応表です（大きい単位から順に評価します）
+var barTimeUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+// barThresholdSuffixes は閾値型バーのIDサフィックスです
+var barThresholdSuffixes = []struct {
+	suffix string
+	kind   BarKind
+}{
+	{"jpy", BAR_KIND_VALUE},
+	{"t", BAR_KIND_TICK},
+	{"v", BAR_KIND_VOLUME},
+}
+
+// ID はこの仕様に対応するインジケーターIDを返します
+func (s BarSpec) ID() string {
+	switch s.Kind {
+	case BAR_KIND_TIME:
+		for _, u := range barTimeUnits {
+			if s.Frame >= u.unit && s.Frame%u.unit == 0 {
+				return fmt.Sprintf("bar_%d%s", s.Frame/u.unit, u.suffix)
+			}
+		}
+		return fmt.Sprintf("bar_%dms", s.Frame.Milliseconds())
+	case BAR_KIND_TICK:
+		return "bar_" + strconv.FormatFloat(s.Threshold, 'f', -1, 64) + "t"
+	case BAR_KIND_VOLUME:
+		return "bar_" + strconv.FormatFloat(s.Threshold, 'f', -1, 64) + "v"
+	case BAR_KIND_VALUE:
+		return "bar_" + strconv.FormatFloat(s.Threshold, 'f', -1, 64) + "jpy"
+	}
+	return fmt.Sprintf("bar_unknown_%d", s.Kind)
+}
+
+// Validate は仕様が集約可能な値かどうかを検証します
+func (s BarSpec) Validate() error {
+	switch s.Kind {
+	case BAR_KIND_TIME:
+		if s.Frame <= 0 {
+			return fmt.Errorf("invalid bar frame: %v", s.Frame)
+		}
+	case BAR_KIND_TICK, BAR_KIND_VOLUME, BAR_KIND_VALUE:
+		if s.Threshold <= 0 {
+			return fmt.Errorf("invalid bar threshold: %v", s.Threshold)
+		}
+	default:
+		return fmt.Errorf("unknown bar kind: %d", s.Kind)
+	}
+	return nil
+}
+
+// ParseBarSpec はインジケーターID（"bar_5m", "bar_100t", "bar_10000v", "bar_100000000jpy" 等）から仕様を復元します
+func ParseBarSpec(id string) (BarSpec, error) {
+	body, ok := strings.CutPrefix(id, "bar_")
+	if !ok || body == "" {
+		return BarSpec{}, fmt.Errorf("invalid bar id: %q", id)
+	}
+
+	var spec BarSpec
+	if num, ok := strings.CutSuffix(body, "ms"); ok {
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return BarSpec{}, fmt.Errorf("invalid bar id: %q", id)
+		}
+		spec = TimeBar(time.Duration(n) * time.Millisecond)
+		return spec, spec.Validate()
+	}
+
+	for _, s := range barThresholdSuffixes {
+		if num, ok := strings.CutSuffix(body, s.suffix); ok {
+			v, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return BarSpec{}, fmt.Errorf("invalid bar id: %q", id)
+			}
+			spec = BarSpec{Kind: s.kind, Threshold: v}
+			return spec, spec.Validate()
+		}
+	}
+
+	for _, u := range barTimeUnits {
+		if num, ok := strings.CutSuffix(body, u.suffix); ok {
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return BarSpec{}, fmt.Errorf("invalid bar id: %q", id)
+			}
+			spec = TimeBar(time.Duration(n) * u.unit)
+			return spec, spec.Validate()
+		}
+	}
+
+	return BarSpec{}, fmt.Errorf("invalid bar id: %q", id)
+}
+
+var _ BarSource = (*BarIndicator)(nil)
+var _ Snapshotter = (*BarIndicator)(nil)
+
+// BarIndicator は BarSpec に従って Tick を集約する汎用バーインジケーターです。
+// 時間足・Tick足・出来高足・売買代金足を同じ仕組みで扱います。
+type BarIndicator struct {
+	id     string
+	spec   BarSpec
+	series barSeries
+	volume volumeDelta
+	sides  tradeClassifier
+
+	// 閾値型バーで現在形成中のバーに積み上がった量（Tick数・出来高・売買代金）
+	accumulated float64
+}
+
+// NewBarIndicator は仕様に従った BarIndicator を作成します。IDは spec.ID() になります。
+func NewBarIndicator(spec BarSpec) *BarIndicator {
+	return &BarIndicator{
+		id:     spec.ID(),
+		spec:   spec,
+		series: newBarSeries(),
+	}
+}
+
+// GetOrCreateBarIndicator は DataPool 上で仕様に対応するバーを共有インスタンスとして取得します
+func GetOrCreateBarIndicator(pool DataPool, symbol string, spec BarSpec) *BarIndicator {
+	return pool.GetOrCreateIndicator(symbol, spec.ID(), func() Indicator {
+		return NewBarIndicator(spec)
+	}).(*BarIndicator)
+}
+
+// ID はこの指標の一意識別子を返します。
+func (i *BarIndicator) ID() string {
+	return i.id
+}
+
+// Spec はこのバーの集約ルールを返します。
+func (i *BarIndicator) Spec() BarSpec {
+	return i.spec
+}
+
+// Update は Tick データを受け取り、仕様に従ってバーを集約します。
+func (i *BarIndicator) Update(tick Tick) {
+	if tick.Price <= 0 {
+		return // 価格が無効な場合は処理しない
+	}
+
+	tickVolume := i.volume.next(tick.TradingVolume)
+	side := i.sides.classify(tick, tickVolume)
+
+	if i.spec.Kind == BAR_KIND_TIME {
+		i.updateTimeBar(tick, tickVolume, side)
+		return
+	}
+	i.updateThresholdBar(tick, tickVolume, side)
+}
+
+func (i *BarIndicator) updateTimeBar(tick Tick, tickVolume float64, side TradeSide) {
+	// 立会中はセッションの寄付を起点に区切る（後場は 12:30 起点、立会時間外は単純な切り捨て）
+	windowStart := session.Default().BarStart(tick.CurrentPriceTime, i.spec.Frame)
+
+	if !i.series.hasCurrent || windowStart.After(i.series.current.StartTime) {
+		i.series.open(windowStart, tick.Price, tickVolume, side)
+		return
+	}
+	i.series.apply(tick.Price, tickVolume, side)
+}
+
+func (i *BarIndicator) updateThresholdBar(tick Tick, tickVolume float64, side TradeSide) {
+	if !i.series.hasCurrent {
+		i.series.open(tick.CurrentPriceTime, tick.Price, tickVolume, side)
+		i.accumulated = 0
+	} else {
+		i.series.apply(tick.Price, tickVolume, side)
+	}
+
+	switch i.spec.Kind {
+	case BAR_KIND_TICK:
+		i.accumulated++
+	case BAR_KIND_VOLUME:
+		i.accumulated += tickVolume
+	case BAR_KIND_VALUE:
+		i.accumulated += tickVolume * tick.Price
+	}
+
+	// 閾値に到達したTickを含めてバーを確定させる（1Tickの量を複数バーへは分割しない）
+	if i.accumulated >= i.spec.Threshold {
+		i.series.commit()
+		i.accumulated = 0
+	}
+}
+
+// Bars は保持しているバーのリストをコピーして返します（形成中のバーを含みます）。
+// 呼び出しごとに全体をコピーするため、Tick 毎の参照には Len / Last / At を利用してください。
+func (i *BarIndicator) Bars() []Bar {
+	return i.series.snapshot()
+}
+
+// Len は保持しているバーの本数を返します（形成中のバーを含みます）。
+func (i *BarIndicator) Len() int {
+	return i.series.length()
+}
+
+// At は保持しているバーのうち index 番目（0始まり、古い順、末尾は形成中のバー）を返します。範囲外の場合はゼロ値の Bar を返します。
+func (i *BarIndicator) At(index int) Bar {
+	return i.series.at(index)
+}
+
+// Last は最新のバーから n 本遡ったバーを返します（0 で最新＝形成中のバー）。範囲外の場合はゼロ値の Bar を返します。
+func (i *BarIndicator) Last(n int) Bar {
+	return i.series.last(n)
+}
+
+// EnsureRetention は確定足の保持本数を n 本以上に引き上げます。
+// 長期の参照が必要な戦略は、戦略の生成時に必要な本数を指定してください。
+func (i *BarIndicator) EnsureRetention(n int) {
+	i.series.ensureRetention(n)
+}
+
+// SetRetention は確定足の保持本数を n 本に設定します。引き下げた場合は古い確定足から破棄します。
+// DataPool 上で共有しているインスタンスでは他の利用者に影響するため、通常は EnsureRetention を利用してください。
+func (i *BarIndicator) SetRetention(n int) {
+	i.series.setRetention(n)
+}
+
+// ClosedCount はこれまでに確定したバーの累計本数を返します。
+func (i *BarIndicator) ClosedCount() int {
+	return i.series.closedCount()
+}
+
+// OldestClosedIndex は保持している最も古い確定足の index を返します。
+func (i *BarIndicator) OldestClosedIndex() int {
+	return i.series.oldestClosedIndex()
+}
+
+// ClosedBar は index 番目の確定済みバーを返します。
+func (i *BarIndicator) ClosedBar(index int) Bar {
+	return i.series.closedAt(index)
+}
+
+func (i *BarIndicator) Dependencies() []Indicator {
+	return nil
+}
+
+// barIndicatorState はスナップショット用の BarIndicator の状態です
+type barIndicatorState struct {
+	Series      barSeriesState `json:"series"`
+	Accumulated float64        `json:"accumulated"`
+}
+
+// SnapshotVersion はスナップショットの状態フォーマットのバージョンです。
+func (i *BarIndicator) SnapshotVersion() int {
+	return 1
+}
+
+// Snapshot はバー列と閾値型バーの積み上げ量をシリアライズします。
+func (i *BarIndicator) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(barIndicatorState{
+		Series:      i.series.state(),
+		Accumulated: i.accumulated,
+	})
+}
+
+// Restore はスナップショットからバー列を復元します。
+// 出来高の基準値は復元しないため、再起動後の最初の Tick の出来高差分はゼロとして扱われます。
+func (i *BarIndicator) Restore(state json.RawMessage) error {
+	var st barIndicatorState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	i.series.restore(st.Series)
+	i.accumulated = st.Accumulated
+	return nil
+}
@@ -0,0 +1,160 @@
+package tick
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBarSpec_IDRoundTrip(t *testing.T) {
+	tests := []struct {
+		spec BarSpec
+		id   string
+	}{
+		{TimeBar(5 * time.Second), "bar_5s"},
+		{TimeBar(15 * time.Second), "bar_15s"},
+		{TimeBar(5 * time.Minute), "bar_5m"},
+		{TimeBar(90 * time.Second), "bar_90s"},
+		{TimeBar(time.Hour), "bar_1h"},
+		{TickBar(100), "bar_100t"},
+		{VolumeBar(10000), "bar_10000v"},
+		{ValueBar(1e8), "bar_100000000jpy"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.spec.ID(); got != tt.id {
+			t.Errorf("expected id %s, got %s", tt.id, got)
+		}
+		parsed, err := ParseBarSpec(tt.id)
+		if err != nil {
+			t.Fatalf("unexpected parse error for %s: %v", tt.id, err)
+		}
+		if parsed != tt.spec {
+			t.Errorf("expected spec %+v, got %+v", tt.spec, parsed)
+		}
+	}
+
+	for _, bad := range []string{"1min_bar", "bar_", "bar_xm", "bar_0m", "bar_-5v", "bar_5d"} {
+		if _, err := ParseBarSpec(bad); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestBarIndicator_TimeBar(t *testing.T) {
+	ind := NewBarIndicator(TimeBar(5 * time.Minute))
+	if ind.ID() != "bar_5m" {
+		t.Fatalf("expected id bar_5m, got %s", ind.ID())
+	}
+
+	baseTime := time.Date(2026, 4, 19, 9, 0, 0, 0, time.UTC)
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(10 * time.Second), Price: 100, TradingVolume: 1000})
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(2 * time.Minute), Price: 105, TradingVolume: 1200})
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(4*time.Minute + 59*time.Second), Price: 95, TradingVolume: 1500})
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(5 * time.Minute), Price: 98, TradingVolume: 1600})
+
+	bars := ind.Bars()
+	if len(bars) != 2 {
+		t.Fatalf("expected 2 bars, got %d", len(bars))
+	}
+	if bars[0].Open != 100 || bars[0].High != 105 || bars[0].Low != 95 || bars[0].Close != 95 {
+		t.Errorf("unexpected OHLC for bar 1: %+v", bars[0])
+	}
+	if bars[0].Volume != 500 {
+		t.Errorf("expected volume 500, got %f", bars[0].Volume)
+	}
+	if !bars[1].StartTime.Equal(baseTime.Add(5 * time.Minute)) {
+		t.Errorf("expected bar 2 to start at 09:05, got %v", bars[1].StartTime)
+	}
+	if bars[1].Volume != 100 {
+		t.Errorf("expected volume 100, got %f", bars[1].Volume)
+	}
+}
+
+func TestBarIndicator_TickBar(t *testing.T) {
+	ind := NewBarIndicator(TickBar(3))
+	baseTime := time.Date(2026, 4, 19, 9, 0, 0, 0, time.UTC)
+
+	prices := []float64{100, 102, 99, 101, 103}
+	for n, p := range prices {
+		ind.Update(Tick{CurrentPriceTime: baseTime.Add(time.Duration(n) * time.Second), Price: p, TradingVolume: float64(1000 + n*100)})
+	}
+
+	bars := ind.Bars()
+	if len(bars) != 2 {
+		t.Fatalf("expected 2 bars, got %d", len(bars))
+	}
+	if bars[0].Open != 100 || bars[0].High != 102 || bars[0].Low != 99 || bars[0].Close != 99 {
+		t.Errorf("unexpected OHLC for bar 1: %+v", bars[0])
+	}
+	// 初回Tickの出来高差分はゼロなので 0 + 100 + 100
+	if bars[0].Volume != 200 {
+		t.Errorf("expected volume 200, got %f", bars[0].Volume)
+	}
+	// 2本目は形成中（2 Tick分）
+	if bars[1].Open != 101 || bars[1].Close != 103 || !bars[1].StartTime.Equal(baseTime.Add(3*time.Second)) {
+		t.Errorf("unexpected forming bar: %+v", bars[1])
+	}
+}
+
+func TestBarIndicator_VolumeBar(t *testing.T) {
+	ind := NewBarIndicator(VolumeBar(300))
+	baseTime := time.Date(2026, 4, 19, 9, 0, 0, 0, time.UTC)
+
+	ind.Update(Tick{CurrentPriceTime: baseTime, Price: 100, TradingVolume: 1000})                     // 0
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(time.Second), Price: 101, TradingVolume: 1200})    // 200
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(2 * time.Second), Price: 99, TradingVolume: 1500}) // 300 -> 500 で確定
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(3 * time.Second), Price: 98, TradingVolume: 1450}) // 減少は 0 扱い
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(4 * time.Second), Price: 97, TradingVolume: 1750}) // 300 で確定
+
+	bars := ind.Bars()
+	if len(bars) != 2 {
+		t.Fatalf("expected 2 bars, got %d", len(bars))
+	}
+	if bars[0].Volume != 500 || bars[0].Close != 99 {
+		t.Errorf("unexpected bar 1: %+v", bars[0])
+	}
+	if bars[1].Volume != 300 || bars[1].Open != 98 || bars[1].Close != 97 {
+		t.Errorf("unexpected bar 2: %+v", bars[1])
+	}
+}
+
+func TestBarIndicator_ValueBar(t *testing.T) {
+	ind := NewBarIndicator(ValueBar(50000))
+	baseTime := time.Date(2026, 4, 19, 9, 0, 0, 0, time.UTC)
+
+	ind.Update(Tick{CurrentPriceTime: baseTime, Price: 100, TradingVolume: 1000})
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(time.Second), Price: 100, TradingVolume: 1300})     // 30,000
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(2 * time.Second), Price: 200, TradingVolume: 1400}) // 20,000 -> 50,000 で確定
+	ind.Update(Tick{CurrentPriceTime: baseTime.Add(3 * time.Second), Price: 210, TradingVolume: 1410})
+
+	bars := ind.Bars()
+	if len(bars) != 2 {
+		t.Fatalf("expected 2 bars, got %d", len(bars))
+	}
+	if bars[0].Volume != 400 || bars[0].High != 200 {
+		t.Errorf("unexpected bar 1: %+v", bars[0])
+	}
+	if bars[1].Open != 210 || bars[1].Volume != 10 {
+		t.Errorf("unexpected bar 2: %+v", bars[1])
+	}
+}
+
+func TestGetOrCreateBarIndicator_SharesInstance(t *testing.T) {
+	pool := NewDefaultDataPool(nil)
+
+	a := GetOrCreateBarIndicator(pool, "7203", TimeBar(5*time.Minute))
+	b := GetOrCreateBarIndicator(pool, "7203", TimeBar(5*time.Minute))
+	c := GetOrCreateBarIndicator(pool, "7203", TimeBar(15*time.Second))
+
+	if a != b {
+		t.Errorf("expected the same instance for the same spec")
+	}
+	if a == c {
+		t.Errorf("expected different instances for different specs")
+	}
+
+	pool.PushTick(Tick{Symbol: "7203", CurrentPriceTime: time.Date(2026, 4, 19, 9, 0, 0, 0, time.UTC), Price: 100})
+	if len(a.Bars()) != 1 || len(c.Bars()) != 1 {
+		t.Errorf("expected both bars to receive the tick")
+	}
+}
@@ -1,23 +1,28 @@
 package tick
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
 )
 
+var _ BarSource = (*OneMinBarIndicator)(nil)
+var _ Snapshotter = (*OneMinBarIndicator)(nil)
+
 // OneMinBarIndicator は Tick データから1分足の Bar を生成・蓄積するインジケーターです。
 type OneMinBarIndicator struct {
-	id            string
-	bars          []Bar
-	currentBar    *Bar
-	prevVolume    float64
-	isInitialized bool
+	id     string
+	series barSeries
+	volume volumeDelta
+	sides  tradeClassifier
 }
 
 // NewOneMinBarIndicator は新しい OneMinBarIndicator を作成します。
 func NewOneMinBarIndicator(id string) *OneMinBarIndicator {
 	return &OneMinBarIndicator{
-		id:   id,
-		bars: make([]Bar, 0),
+		id:     id,
+		series: newBarSeries(),
 	}
 }
 
@@ -32,70 +37,91 @@ func (i *OneMinBarIndicator) Update(tick Tick) {
 		return // 価格が無効な場合は処理しない
 	}
 
-	var tickVolume float64
-	if !i.isInitialized {
-		i.prevVolume = tick.TradingVolume
-		i.isInitialized = true
-		// 初回Tickの出来高は、それ以前の累積すべてを含んでいる可能性があるため差分ゼロとする
-		tickVolume = 0
-	} else {
-		tickVolume = tick.TradingVolume - i.prevVolume
-		if tickVolume < 0 {
-			tickVolume = 0
-		}
-		i.prevVolume = tick.TradingVolume
-	}
+	tickVolume := i.volume.next(tick.TradingVolume)
+	side := i.sides.classify(tick, tickVolume)
+
+	// 現在のTickの時刻から1分のウィンドウ枠を決定する（大引けちょうどの約定は最後の1分足に含める）
+	windowStart := session.Default().BarStart(tick.CurrentPriceTime, time.Minute)
 
-	// 現在のTickの時刻から「分」以下を切り捨てて1分のウィンドウ枠を決定する
-	windowStart := tick.CurrentPriceTime.Truncate(time.Minute)
-
-	if i.currentBar == nil {
-		// 最初のバーを作成
-		i.currentBar = &Bar{
-			StartTime: windowStart,
-			Open:      tick.Price,
-			High:      tick.Price,
-			Low:       tick.Price,
-			Close:     tick.Price,
-			Volume:    tickVolume,
-		}
+	if !i.series.hasCurrent || windowStart.After(i.series.current.StartTime) {
+		// 最初のバー、または時間の枠が変わったので現在のバーを確定させて新しいバーを開始する
+		i.series.open(windowStart, tick.Price, tickVolume, side)
 		return
 	}
 
-	if windowStart.After(i.currentBar.StartTime) {
-		// 時間の枠が変わったので、現在のバーを確定させて保存し、新しいバーを開始する
-		i.bars = append(i.bars, *i.currentBar)
-		i.currentBar = &Bar{
-			StartTime: windowStart,
-			Open:      tick.Price,
-			High:      tick.Price,
-			Low:       tick.Price,
-			Close:     tick.Price,
-			Volume:    tickVolume,
-		}
-	} else {
-		// 現在のバーのOHLCVを更新する
-		if tick.Price > i.currentBar.High {
-			i.currentBar.High = tick.Price
-		}
-		if tick.Price < i.currentBar.Low {
-			i.currentBar.Low = tick.Price
-		}
-		i.currentBar.Close = tick.Price
-		i.currentBar.Volume += tickVolume
-	}
+	// 現在のバーのOHLCVを更新する
+	i.series.apply(tick.Price, tickVolume, side)
 }
 
-// Bars はこれまでに生成されたバーのリストを返します。
+// Bars は保持しているバーのリストをコピーして返します（形成中のバーを含みます）。
+// 呼び出しごとに全体をコピーするため、Tick 毎の参照には Len / Last / At を利用してください。
 func (i *OneMinBarIndicator) Bars() []Bar {
-	result := make([]Bar, 0, len(i.bars)+1)
-	result = append(result, i.bars...)
-	if i.currentBar != nil {
-		result = append(result, *i.currentBar)
-	}
-	return result
+	return i.series.snapshot()
+}
+
+// Len は保持しているバーの本数を返します（形成中のバーを含みます）。
+func (i *OneMinBarIndicator) Len() int {
+	return i.series.length()
+}
+
+// At は保持しているバーのうち index 番目（0始まり、古い順、末尾は形成中のバー）を返します。範囲外の場合はゼロ値の Bar を返します。
+func (i *OneMinBarIndicator) At(index int) Bar {
+	return i.series.at(index)
+}
+
+// Last は最新のバーから n 本遡ったバーを返します（0 で最新＝形成中のバー）。範囲外の場合はゼロ値の Bar を返します。
+func (i *OneMinBarIndicator) Last(n int) Bar {
+	return i.series.last(n)
+}
+
+// EnsureRetention は確定足の保持本数を n 本以上に引き上げます。
+// 長期の参照が必要な戦略は、戦略の生成時に必要な本数を指定してください。
+func (i *OneMinBarIndicator) EnsureRetention(n int) {
+	i.series.ensureRetention(n)
+}
+
+// SetRetention は確定足の保持本数を n 本に設定します。引き下げた場合は古い確定足から破棄します。
+// DataPool 上で共有しているインスタンスでは他の利用者に影響するため、通常は EnsureRetention を利用してください。
+func (i *OneMinBarIndicator) SetRetention(n int) {
+	i.series.setRetention(n)
 }
 
 func (i *OneMinBarIndicator) Dependencies() []Indicator {
 	return nil
 }
+
+// ClosedCount はこれまでに確定した1分足の累計本数を返します。
+func (i *OneMinBarIndicator) ClosedCount() int {
+	return i.series.closedCount()
+}
+
+// OldestClosedIndex は保持している最も古い確定済み1分足の index を返します。
+func (i *OneMinBarIndicator) OldestClosedIndex() int {
+	return i.series.oldestClosedIndex()
+}
+
+// ClosedBar は index 番目の確定済み1分足を返します。
+func (i *OneMinBarIndicator) ClosedBar(index int) Bar {
+	return i.series.closedAt(index)
+}
+
+// SnapshotVersion はスナップショットの状態フォーマットのバージョンです。
+func (i *OneMinBarIndicator) SnapshotVersion() int {
+	return 1
+}
+
+// Snapshot は確定済みの1分足と形成中の1分足をシリアライズします。
+func (i *OneMinBarIndicator) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(i.series.state())
+}
+
+// Restore はスナップショットから1分足を復元します。
+// 出来高の基準値は復元しないため、再起動後の最初の Tick の出来高差分はゼロとして扱われます（停止中の出来高を1本の足に寄せないため）。
+func (i *OneMinBarIndicator) Restore(state json.RawMessage) error {
+	var st barSeriesState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	i.series.restore(st)
+	return nil
+}
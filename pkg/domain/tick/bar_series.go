@@ -0,0 +1,217 @@
+package tick
+
+import (
+	"fmt"
+	"time"
+)
+
+// volumeDelta は累積出来高 (TradingVolume) から Tick 毎の出来高差分を算出します。
+// 全てのバー系インジケーターで共通の出来高計算ロジックです。
+type volumeDelta struct {
+	prevVolume    float64
+	isInitialized bool
+}
+
+// next は最新の累積出来高を受け取り、前回 Tick からの出来高差分を返します。
+func (v *volumeDelta) next(cumulative float64) float64 {
+	if !v.isInitialized {
+		v.prevVolume = cumulative
+		v.isInitialized = true
+		// 初回Tickの出来高は、それ以前の累積すべてを含んでいる可能性があるため差分ゼロとする
+		return 0
+	}
+
+	delta := cumulative - v.prevVolume
+	if delta < 0 {
+		delta = 0
+	}
+	v.prevVolume = cumulative
+	return delta
+}
+
+// DefaultBarRetention は各バーインジケーターが保持する確定足の既定の本数です。
+// 1分足でおよそ1週間分、5秒足でおよそ半日分に相当します。
+const DefaultBarRetention = 2000
+
+// barSeries は確定済みのバー列と形成中のバーを保持する共通ストレージです。
+// 確定足は retention 本を上限とするリングバッファに保持し、古いものから上書きします。
+// バッファは実際に確定した本数に応じて伸長するため、上限まで事前確保はしません。
+type barSeries struct {
+	ring      []Bar // 確定足のリングバッファ（len(ring) <= retention）
+	head      int   // ring 上で最も古い確定足の位置
+	closed    int   // これまでに確定した本数の累計（上書きされた分も含む）
+	retention int
+
+	current    Bar // 形成中のバー
+	hasCurrent bool
+}
+
+func newBarSeries() barSeries {
+	return barSeries{retention: DefaultBarRetention}
+}
+
+// open は新しいバーを開始します。形成中のバーがあれば確定させます。
+func (s *barSeries) open(startTime time.Time, price, volume float64, side TradeSide) {
+	s.commit()
+	buy, sell := splitVolume(volume, side)
+	s.current = Bar{
+		StartTime: startTime,
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Close:     price,
+		Volume:    volume,
+
+		BuyVolume:  buy,
+		SellVolume: sell,
+	}
+	s.hasCurrent = true
+}
+
+// apply は形成中のバーの HLCV と売買別出来高を更新します。
+func (s *barSeries) apply(price, volume float64, side TradeSide) {
+	if price > s.current.High {
+		s.current.High = price
+	}
+	if price < s.current.Low {
+		s.current.Low = price
+	}
+	s.current.Close = price
+	s.current.Volume += volume
+
+	buy, sell := splitVolume(volume, side)
+	s.current.BuyVolume += buy
+	s.current.SellVolume += sell
+}
+
+// commit は形成中のバーを確定させて保存します。
+func (s *barSeries) commit() {
+	if !s.hasCurrent {
+		return
+	}
+	s.push(s.current)
+	s.current = Bar{}
+	s.hasCurrent = false
+}
+
+// push は確定足をリングバッファへ追加します。上限に達している場合は最も古い確定足を上書きします。
+func (s *barSeries) push(bar Bar) {
+	if len(s.ring) < s.retention {
+		s.ring = append(s.ring, bar)
+	} else {
+		s.ring[s.head] = bar
+		s.head = (s.head + 1) % len(s.ring)
+	}
+	s.closed++
+}
+
+// ensureRetention は保持本数の上限を n 本以上に引き上げます（引き下げはしません）。
+func (s *barSeries) ensureRetention(n int) {
+	if n > s.retention {
+		s.setRetention(n)
+	}
+}
+
+// setRetention は保持本数の上限を n 本に設定します。引き下げた場合は古い確定足から破棄します。
+func (s *barSeries) setRetention(n int) {
+	if n < 1 {
+		n = 1
+	}
+	// 上書きが始まっている場合は古い順に並べ直してから伸縮できるようにする
+	if s.head != 0 {
+		s.ring = append(append(make([]Bar, 0, len(s.ring)), s.ring[s.head:]...), s.ring[:s.head]...)
+		s.head = 0
+	}
+	if len(s.ring) > n {
+		s.ring = append(make([]Bar, 0, n), s.ring[len(s.ring)-n:]...)
+	}
+	s.retention = n
+}
+
+// length は保持している確定足と形成中のバーの合計本数を返します。
+func (s *barSeries) length() int {
+	if s.hasCurrent {
+		return len(s.ring) + 1
+	}
+	return len(s.ring)
+}
+
+// at は保持しているバーのうち i 番目（0始まり、古い順、末尾は形成中のバー）を返します。
+// 範囲外の場合はゼロ値の Bar を返します（保持本数が足りない間の参照で panic させないため）。
+func (s *barSeries) at(i int) Bar {
+	if i < 0 || i >= s.length() {
+		return Bar{}
+	}
+	if i == len(s.ring) {
+		return s.current
+	}
+	return s.ring[(s.head+i)%len(s.ring)]
+}
+
+// last は最新のバーから n 本遡ったバーを返します（0 で最新＝形成中のバー）。範囲外の場合はゼロ値の Bar を返します。
+func (s *barSeries) last(n int) Bar {
+	return s.at(s.length() - 1 - n)
+}
+
+// snapshot は保持している確定足と形成中のバーをコピーして返します。
+func (s *barSeries) snapshot() []Bar {
+	result := make([]Bar, 0, s.length())
+	result = append(result, s.ring[s.head:]...)
+	result = append(result, s.ring[:s.head]...)
+	if s.hasCurrent {
+		result = append(result, s.current)
+	}
+	return result
+}
+
+// closedCount は確定したバーの累計本数を返します（保持上限で上書きされた分も含みます）。
+func (s *barSeries) closedCount() int {
+	return s.closed
+}
+
+// oldestClosedIndex は保持している最も古い確定足の index を返します。
+func (s *barSeries) oldestClosedIndex() int {
+	return s.closed - len(s.ring)
+}
+
+// closedAt は index 番目（0始まり、古い順の累計）の確定足を返します。保持上限で上書きされた index は参照できません。
+func (s *barSeries) closedAt(index int) Bar {
+	rel := index - s.oldestClosedIndex()
+	if rel < 0 || rel >= len(s.ring) {
+		panic(fmt.Sprintf("closed bar index out of range [%d] with retained [%d, %d)", index, s.oldestClosedIndex(), s.closed))
+	}
+	return s.ring[(s.head+rel)%len(s.ring)]
+}
+
+// barSeriesState はスナップショット用のバー列の状態です
+type barSeriesState struct {
+	Bars    []Bar `json:"bars"`
+	Current *Bar  `json:"current,omitempty"`
+	Closed  int   `json:"closed,omitempty"` // 確定足の累計本数（未設定の場合は len(Bars)）
+}
+
+func (s *barSeries) state() barSeriesState {
+	st := barSeriesState{Closed: s.closed}
+	st.Bars = append(st.Bars, s.ring[s.head:]...)
+	st.Bars = append(st.Bars, s.ring[:s.head]...)
+	if s.hasCurrent {
+		current := s.current
+		st.Current = &current
+	}
+	return st
+}
+
+func (s *barSeries) restore(st barSeriesState) {
+	bars := st.Bars
+	if len(bars) > s.retention {
+		bars = bars[len(bars)-s.retention:]
+	}
+	s.ring = append(make([]Bar, 0, len(bars)), bars...)
+	s.head = 0
+	s.closed = max(st.Closed, len(st.Bars))
+
+	s.current, s.hasCurrent = Bar{}, st.Current != nil
+	if st.Current != nil {
+		s.current = *st.Current
+	}
+}
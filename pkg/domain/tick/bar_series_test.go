@@ -0,0 +1,148 @@
+package tick
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// pushMinutes は1分間隔で終値 1, 2, ... n の Tick を流し込みます（最後のバーは形成中のまま）
+func pushMinutes(ind *OneMinBarIndicator, n int) {
+	base := time.Date(2026, 4, 20, 9, 0, 0, 0, time.UTC)
+	for k := 1; k <= n; k++ {
+		ind.Update(Tick{Price: float64(k), TradingVolume: float64(k * 100), CurrentPriceTime: base.Add(time.Duration(k) * time.Minute)})
+	}
+}
+
+func TestBarSeries_RetentionOverwritesOldestBars(t *testing.T) {
+	ind := NewOneMinBarIndicator("1min_bar")
+	ind.SetRetention(3)
+	pushMinutes(ind, 6) // 確定足 1..5、形成中 6
+
+	if ind.ClosedCount() != 5 || ind.OldestClosedIndex() != 2 {
+		t.Fatalf("expected 5 closed bars with oldest index 2, got %d / %d", ind.ClosedCount(), ind.OldestClosedIndex())
+	}
+	if ind.Len() != 4 {
+		t.Fatalf("expected 3 retained bars plus the current bar, got %d", ind.Len())
+	}
+	for k, want := range []float64{3, 4, 5, 6} {
+		if got := ind.At(k).Close; got != want {
+			t.Errorf("At(%d): expected close %.0f, got %.0f", k, want, got)
+		}
+	}
+	if ind.Last(0).Close != 6 || ind.Last(3).Close != 3 {
+		t.Errorf("unexpected Last: %v / %v", ind.Last(0).Close, ind.Last(3).Close)
+	}
+	if ind.ClosedBar(4).Close != 5 || ind.ClosedBar(2).Close != 3 {
+		t.Errorf("ClosedBar should keep absolute indices after overwriting")
+	}
+	bars := ind.Bars()
+	if len(bars) != 4 || bars[0].Close != 3 || bars[3].Close != 6 {
+		t.Errorf("unexpected Bars(): %+v", bars)
+	}
+}
+
+func TestBarSeries_OutOfRangeAccess(t *testing.T) {
+	ind := NewOneMinBarIndicator("1min_bar")
+
+	// バーが無い間も At / Last はゼロ値の Bar を返す
+	if (ind.Last(0) != Bar{}) || (ind.At(0) != Bar{}) {
+		t.Errorf("expected zero bars before the first tick, got %+v / %+v", ind.Last(0), ind.At(0))
+	}
+
+	ind.SetRetention(2)
+	pushMinutes(ind, 5)
+	for name, bar := range map[string]Bar{
+		"At":          ind.At(ind.Len()),
+		"At negative": ind.At(-1),
+		"Last":        ind.Last(ind.Len()),
+	} {
+		if (bar != Bar{}) {
+			t.Errorf("%s: expected a zero bar, got %+v", name, bar)
+		}
+	}
+
+	// 上書きされた確定足の参照は呼び出し側の誤りのため panic する
+	defer func() {
+		if recover() == nil {
+			t.Errorf("evicted closed: expected panic")
+		}
+	}()
+	ind.ClosedBar(ind.OldestClosedIndex() - 1)
+}
+
+func TestBarSeries_EnsureRetentionAfterWrap(t *testing.T) {
+	ind := NewOneMinBarIndicator("1min_bar")
+	ind.SetRetention(3)
+	pushMinutes(ind, 6) // 保持 3, 4, 5（リングは折り返し済み）
+
+	ind.EnsureRetention(2) // 引き下げはしない
+	ind.EnsureRetention(5)
+	pushCloses := func(closes ...float64) {
+		base := time.Date(2026, 4, 20, 10, 0, 0, 0, time.UTC)
+		for k, c := range closes {
+			ind.Update(Tick{Price: c, TradingVolume: 10000, CurrentPriceTime: base.Add(time.Duration(k) * time.Minute)})
+		}
+	}
+	pushCloses(7, 8, 9)
+
+	// 確定足 3..8 のうち新しい5本（4..8）と形成中の 9
+	var got []float64
+	for k := 0; k < ind.Len(); k++ {
+		got = append(got, ind.At(k).Close)
+	}
+	want := []float64{4, 5, 6, 7, 8, 9}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for k := range want {
+		if got[k] != want[k] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestBarSeries_SnapshotKeepsAbsoluteIndices(t *testing.T) {
+	src := NewOneMinBarIndicator("1min_bar")
+	src.SetRetention(3)
+	pushMinutes(src, 6)
+
+	state, err := src.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	var decoded barSeriesState
+	if err := json.Unmarshal(state, &decoded); err != nil {
+		t.Fatalf("unexpected snapshot format: %v", err)
+	}
+	if len(decoded.Bars) != 3 || decoded.Closed != 5 {
+		t.Errorf("expected 3 bars with 5 closed, got %d / %d", len(decoded.Bars), decoded.Closed)
+	}
+
+	// 保持本数の小さいインスタンスへ復元すると新しい確定足だけを残す
+	dst := NewOneMinBarIndicator("1min_bar")
+	dst.SetRetention(2)
+	if err := dst.Restore(state); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if dst.ClosedCount() != 5 || dst.OldestClosedIndex() != 3 || dst.ClosedBar(4).Close != 5 || dst.Last(0).Close != 6 {
+		t.Errorf("unexpected restored series: closed=%d oldest=%d", dst.ClosedCount(), dst.OldestClosedIndex())
+	}
+}
+
+func TestBarSeries_UpdateDoesNotAllocateOnceRetentionIsFull(t *testing.T) {
+	ind := NewOneMinBarIndicator("1min_bar")
+	ind.SetRetention(3)
+	pushMinutes(ind, 10)
+
+	// 上限に達したリングバッファへの確定と、バー境界の判定（営業日カレンダーの参照）はアロケーションを伴わない
+	base := time.Date(2026, 4, 20, 9, 0, 0, 0, time.UTC)
+	k := 10
+	allocs := testing.AllocsPerRun(100, func() {
+		k++
+		ind.Update(Tick{Price: float64(k), TradingVolume: float64(k * 100), CurrentPriceTime: base.Add(time.Duration(k) * time.Minute)})
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations per tick, got %v", allocs)
+	}
+}
@@ -1,10 +1,13 @@
 package calculator
 
 import (
+	"encoding/json"
 	"math"
 )
 
 // 銘柄ごとの標準偏差を計算・保持する構造体
+// プロセス起動後からの累積のため、DataPool で共有しセッション境界でリセットされる VWAP/σ が必要な場合は
+// volatility.SessionVWAP を利用してください。
 type SigmaCalculator struct {
 	prevVolume float64 // 前回のAPI累積売買高（差分計算用）
 
@@ -80,3 +83,34 @@ func (s *SigmaCalculator) calculateVWAP(fallbackPrice float64) float64 {
 	}
 	return s.sumPriceVolume / s.activeVolume
 }
+
+// sigmaState はスナップショット用の累積値です
+type sigmaState struct {
+	PrevVolume      float64 `json:"prev_volume"`
+	ActiveVolume    float64 `json:"active_volume"`
+	SumPriceVolume  float64 `json:"sum_price_volume"`
+	SumPrice2Volume float64 `json:"sum_price2_volume"`
+}
+
+// Snapshot は途中再起動に備えて累積値をシリアライズします（tick.Snapshotter を実装する指標から利用します）
+func (s *SigmaCalculator) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(sigmaState{
+		PrevVolume:      s.prevVolume,
+		ActiveVolume:    s.activeVolume,
+		SumPriceVolume:  s.sumPriceVolume,
+		SumPrice2Volume: s.sumPrice2Volume,
+	})
+}
+
+// Restore はスナップショットから累積値を復元します
+func (s *SigmaCalculator) Restore(state json.RawMessage) error {
+	var st sigmaState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	s.prevVolume = st.PrevVolume
+	s.activeVolume = st.ActiveVolume
+	s.sumPriceVolume = st.SumPriceVolume
+	s.sumPrice2Volume = st.SumPrice2Volume
+	return nil
+}
@@ -92,3 +92,30 @@ func TestSigmaCalculator_NegativeVarianceClamping(t *testing.T) {
 		t.Errorf("expected negative variance to be clamped to 0.0, got %f", sigma)
 	}
 }
+
+func TestSigmaCalculator_SnapshotRestore(t *testing.T) {
+	calc := calculator.NewSigmaCalculator(1000.0)
+	calc.Update(1100.0, 100.0)
+	calc.Update(1300.0, 103.0)
+
+	state, err := calc.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	restored := calculator.NewSigmaCalculator(0)
+	if err := restored.Restore(state); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	if restored.GetSigma() != calc.GetSigma() || restored.GetVWAP(0) != calc.GetVWAP(0) {
+		t.Errorf("expected restored sigma/vwap %f/%f, got %f/%f", calc.GetSigma(), calc.GetVWAP(0), restored.GetSigma(), restored.GetVWAP(0))
+	}
+
+	// 差分計算の基準値も復元されているため、次の更新も同じ結果になる
+	calc.Update(1400.0, 101.0)
+	restored.Update(1400.0, 101.0)
+	if restored.GetSigma() != calc.GetSigma() {
+		t.Errorf("expected identical sigma after next update, got %f vs %f", restored.GetSigma(), calc.GetSigma())
+	}
+}
@@ -0,0 +1,123 @@
+package tick
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CompositeIndicator は複数銘柄の Tick に依存する指標（スプレッド、ベータ、セクター指数など）が満たすインターフェースです。
+// Symbols に含まれるいずれかの銘柄の Tick が届くたびに Update が呼ばれます。
+//
+// 銘柄単位の指標と異なり、銘柄のロックを保持しない状態で更新されるため、
+// Update の中から DataPool（GetState や GetOrCreateIndicator など）を呼び出してはいけません。
+// 各銘柄の価格などは、受け取った Tick から指標自身が保持してください。
+// Dependencies には他の CompositeIndicator のみを指定でき、依存先が先に更新されます。
+type CompositeIndicator interface {
+	Indicator
+	// Symbols はこの指標が入力とする銘柄コードの一覧を返します
+	Symbols() []string
+}
+
+// compositeRegistry は DataPool 上の CompositeIndicator を管理します。
+// 銘柄ごとのロックとは独立した単一のロックで更新を直列化するため、
+// 複数銘柄の Tick が並行して届いても更新順序は決定論的になり、ロックの循環待ちも発生しません。
+type compositeRegistry struct {
+	mu         sync.Mutex
+	indicators map[string]CompositeIndicator
+	registered []CompositeIndicator            // 登録順
+	bySymbol   map[string][]CompositeIndicator // 銘柄 -> 依存順に並べた更新対象
+}
+
+// GetOrCreateComposite は指定したIDの複数銘柄指標を取得し、無ければ生成して登録します
+func (a *DefaultDataPool) GetOrCreateComposite(id string, factory func() CompositeIndicator) CompositeIndicator {
+	r := &a.composites
+
+	r.mu.Lock()
+	if ind, exists := r.indicators[id]; exists {
+		r.mu.Unlock()
+		return ind
+	}
+	// ファクトリーの中でさらに GetOrCreateComposite が呼ばれてもデッドロックしないよう、ロックの外で生成する
+	r.mu.Unlock()
+	newInd := factory()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// ロック解除中に別のゴルーチンが作成していた場合の再チェック
+	if ind, exists := r.indicators[id]; exists {
+		return ind
+	}
+	if r.indicators == nil {
+		r.indicators = make(map[string]CompositeIndicator)
+	}
+	r.indicators[id] = newInd
+	r.registered = append(r.registered, newInd)
+	r.rebuildOrder()
+
+	return newInd
+}
+
+// update は Tick の銘柄を入力とする複数銘柄指標を依存順に更新します
+func (r *compositeRegistry) update(tick Tick) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ind := range r.bySymbol[tick.Symbol] {
+		ind.Update(tick)
+	}
+}
+
+// rebuildOrder は登録順を保ったまま依存先が先に来るように並べ替え（トポロジカルソート）、
+// 銘柄ごとの更新対象リストを再構築します
+func (r *compositeRegistry) rebuildOrder() {
+	var order []CompositeIndicator
+	visited := make(map[string]bool)
+	inProgress := make(map[string]bool)
+
+	var visit func(ind CompositeIndicator) error
+	visit = func(ind CompositeIndicator) error {
+		if inProgress[ind.ID()] {
+			return fmt.Errorf("circular dependency detected involving %s", ind.ID())
+		}
+		if visited[ind.ID()] {
+			return nil
+		}
+		inProgress[ind.ID()] = true
+
+		for _, dep := range ind.Dependencies() {
+			c, ok := dep.(CompositeIndicator)
+			if !ok {
+				return fmt.Errorf("composite indicator %s depends on non-composite indicator", ind.ID())
+			}
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+
+		inProgress[ind.ID()] = false
+		visited[ind.ID()] = true
+		order = append(order, ind)
+		return nil
+	}
+
+	for _, ind := range r.registered {
+		if err := visit(ind); err != nil {
+			// 循環参照などの致命的な設計エラーは、沈黙させずにPanicさせて開発者に直させる
+			panic(fmt.Sprintf("Fatal: Composite indicator TopoSort failed: %v", err))
+		}
+	}
+
+	bySymbol := make(map[string][]CompositeIndicator)
+	for _, ind := range order {
+		seen := make(map[string]bool)
+		for _, symbol := range ind.Symbols() {
+			if seen[symbol] {
+				continue
+			}
+			seen[symbol] = true
+			bySymbol[symbol] = append(bySymbol[symbol], ind)
+		}
+	}
+	r.bySymbol = bySymbol
+}
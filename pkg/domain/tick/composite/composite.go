@@ -0,0 +1,75 @@
+// Package composite は複数銘柄の Tick から算出する tick.CompositeIndicator 群を提供します。
+// 2銘柄のスプレッドと価格比、ローリングのベータと相関、セクター内銘柄から合成したセクター指数があり、
+// いずれも入力銘柄のどれかに Tick が届くたびに DataPool から決定論的な順序で更新されます。
+package composite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// getOrCreate は DataPool 上で複数銘柄指標を共有インスタンスとして取得します
+func getOrCreate[T tick.CompositeIndicator](pool tick.DataPool, id string, factory func() T) T {
+	return pool.GetOrCreateComposite(id, func() tick.CompositeIndicator {
+		return factory()
+	}).(T)
+}
+
+// durationLabel は指標IDに使う期間の表記を返します（例: 5*time.Minute -> "5m"）
+func durationLabel(d time.Duration) string {
+	for _, u := range []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	} {
+		if d >= u.unit && d%u.unit == 0 {
+			return fmt.Sprintf("%d%s", d/u.unit, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// leg は入力銘柄1つ分の最新価格です
+type leg struct {
+	price   float64
+	opening float64
+	time    time.Time
+}
+
+// observe は Tick から最新価格と始値を取り込みます。価格が無効な Tick は無視します
+func (l *leg) observe(t tick.Tick) {
+	if t.Price <= 0 {
+		return
+	}
+	l.price = t.Price
+	l.opening = t.OpeningPrice
+	l.time = t.CurrentPriceTime
+}
+
+// ready は価格を1件以上観測したかを返します
+func (l *leg) ready() bool { return l.price > 0 }
+
+// normalized は始値を基準とした価格（価格 / 始値）を返します。始値が未設定の場合は最新価格を基準とします
+func (l *leg) normalized() float64 {
+	if !l.ready() {
+		return 0
+	}
+	base := l.opening
+	if base == 0 {
+		base = l.price
+	}
+	return l.price / base
+}
+
+// base は正規化の基準価格（始値、未設定の場合は最新価格）を返します
+func (l *leg) base() float64 {
+	if l.opening == 0 {
+		return l.price
+	}
+	return l.opening
+}
@@ -0,0 +1,116 @@
+package composite_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/composite"
+)
+
+func assertNear(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s: expected %.9f, got %.9f", name, want, got)
+	}
+}
+
+var base = time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+
+func price(symbol string, at time.Time, p, opening float64) tick.Tick {
+	return tick.Tick{Symbol: symbol, Price: p, OpeningPrice: opening, CurrentPriceTime: at}
+}
+
+func TestSpreadAndRatio_UpdatedFromBothSymbols(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	spread := composite.GetOrCreateSpread(pool, "7203", "7267")
+	ratio := composite.GetOrCreateRatio(pool, "7203", "7267")
+	if spread != composite.GetOrCreateSpread(pool, "7203", "7267") {
+		t.Errorf("expected the same spread instance")
+	}
+	if spread.ID() != "spread_7203_7267" || ratio.ID() != "ratio_7203_7267" {
+		t.Errorf("unexpected ids: %s, %s", spread.ID(), ratio.ID())
+	}
+
+	pool.PushTick(price("7203", base, 1015, 1000))
+	if spread.Ready() || spread.Value() != 0 {
+		t.Errorf("expected not ready before both symbols tick")
+	}
+	pool.PushTick(price("7267", base, 1000, 0)) // 始値未設定は最新価格が基準
+
+	assertNear(t, "spread", spread.Value(), 0.015)
+	assertNear(t, "ratio", ratio.Value(), 1.015)
+	assertNear(t, "base a", spread.BaseA(), 1000)
+	assertNear(t, "base b", spread.BaseB(), 1000)
+
+	pool.PushTick(price("7267", base.Add(time.Second), 990, 1000))
+	assertNear(t, "spread after b moves", spread.Value(), 0.025)
+}
+
+func TestRollingBetaAndCorrelation(t *testing.T) {
+	beta := composite.NewRollingBeta("A", "B", time.Minute, 3)
+	corr := composite.NewRollingCorrelation("A", "B", time.Minute, 3)
+	if beta.ID() != "beta_A_B_1m_3" || corr.ID() != "corr_A_B_1m_3" {
+		t.Errorf("unexpected ids: %s, %s", beta.ID(), corr.ID())
+	}
+
+	// A は B のちょうど2倍の対数リターンで動く
+	pricesB := []float64{100, 101, 99, 102}
+	for k, pb := range pricesB {
+		pa := 100 * math.Pow(pb/100, 2)
+		at := base.Add(time.Duration(k) * time.Minute)
+		for _, tk := range []tick.Tick{price("A", at, pa, 0), price("B", at, pb, 0)} {
+			beta.Update(tk)
+			corr.Update(tk)
+		}
+	}
+	if beta.Ready() {
+		t.Fatalf("expected not ready: the last minute has not closed yet")
+	}
+
+	// 次の区間の Tick で直前の区間が確定し、3件のリターンが揃う
+	next := price("B", base.Add(4*time.Minute), 100, 0)
+	beta.Update(next)
+	corr.Update(next)
+	if !beta.Ready() || !corr.Ready() {
+		t.Fatalf("expected ready after 3 returns")
+	}
+	assertNear(t, "beta", beta.Value(), 2)
+	assertNear(t, "correlation", corr.Value(), 1)
+
+	// 翌日は前日の価格を基準にしない（夜間の窓をリターンに含めない）
+	nextDay := base.Add(24 * time.Hour)
+	for _, tk := range []tick.Tick{
+		price("A", nextDay, 500, 0), price("B", nextDay, 50, 0),
+		price("A", nextDay.Add(time.Minute), 500, 0), price("B", nextDay.Add(time.Minute), 50, 0),
+	} {
+		beta.Update(tk)
+	}
+	assertNear(t, "beta unaffected by the overnight gap", beta.Value(), 2)
+}
+
+func TestSectorIndex_EqualWeightedFromOpening(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	composite.RegisterSectorIndices(pool, map[string][]string{
+		"銀行業":   {"8316", "8306", "8306"},
+		"輸送用機器": {"7203"},
+	})
+	// 戦略からはセクター名だけで参照できる
+	bank := composite.GetOrCreateSectorIndex(pool, "銀行業", nil)
+	if bank.ID() != "sector_銀行業" || len(bank.Symbols()) != 2 {
+		t.Fatalf("unexpected sector index: %s %v", bank.ID(), bank.Symbols())
+	}
+
+	pool.PushTick(price("8306", base, 1100, 1000))
+	if bank.Ready() || bank.Count() != 1 {
+		t.Errorf("expected partial sector, got count %d", bank.Count())
+	}
+	assertNear(t, "partial index", bank.Value(), 110)
+
+	pool.PushTick(price("8316", base, 4800, 5000))
+	if !bank.Ready() {
+		t.Errorf("expected ready after all constituents tick")
+	}
+	assertNear(t, "index", bank.Value(), (1.1+0.96)/2*100)
+}
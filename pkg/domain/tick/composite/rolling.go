@@ -0,0 +1,201 @@
+package composite
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// pairReturns は2銘柄の最新価格を interval ごとにサンプリングし、直近 window 件の対数リターンの組を保持します。
+// 区間の境界をまたぐ最初の Tick を受け取った時点で、直前の区間の最終価格を1件のサンプルとします。
+// Tick が無かった区間は次の Tick で1件にまとめられ、日付をまたぐリターン（夜間の窓）は含めません。
+type pairReturns struct {
+	a, b     string
+	interval time.Duration
+	window   int
+
+	legA, legB   leg
+	bucket       time.Time
+	prevA, prevB float64 // 前回サンプルの価格
+
+	ra, rb []float64 // リターンのリングバッファ
+	head   int
+}
+
+func newPairReturns(a, b string, interval time.Duration, window int) pairReturns {
+	if window < 2 {
+		window = 2
+	}
+	return pairReturns{a: a, b: b, interval: interval, window: window}
+}
+
+func (p *pairReturns) observe(t tick.Tick) {
+	if t.Price <= 0 || (t.Symbol != p.a && t.Symbol != p.b) {
+		return
+	}
+	bucket := t.CurrentPriceTime.Truncate(p.interval)
+	if bucket.After(p.bucket) {
+		if !p.bucket.IsZero() {
+			p.sample(sameDay(p.bucket, bucket))
+		}
+		p.bucket = bucket
+	}
+
+	if t.Symbol == p.a {
+		p.legA.observe(t)
+	} else {
+		p.legB.observe(t)
+	}
+}
+
+// sample は直前の区間の最終価格からリターンを算出して保持します
+func (p *pairReturns) sample(sameSession bool) {
+	if !sameSession {
+		// 前日の価格を基準にしないよう、当日最初の区間からサンプリングし直す
+		p.prevA, p.prevB = 0, 0
+		return
+	}
+	if !p.legA.ready() || !p.legB.ready() {
+		return
+	}
+	if p.prevA > 0 && p.prevB > 0 {
+		p.push(math.Log(p.legA.price/p.prevA), math.Log(p.legB.price/p.prevB))
+	}
+	p.prevA, p.prevB = p.legA.price, p.legB.price
+}
+
+func (p *pairReturns) push(ra, rb float64) {
+	if len(p.ra) < p.window {
+		p.ra = append(p.ra, ra)
+		p.rb = append(p.rb, rb)
+		return
+	}
+	p.ra[p.head], p.rb[p.head] = ra, rb
+	p.head = (p.head + 1) % p.window
+}
+
+func (p *pairReturns) ready() bool { return len(p.ra) == p.window }
+
+// moments は保持しているリターンの分散・共分散を返します
+func (p *pairReturns) moments() (varA, varB, cov float64) {
+	n := float64(len(p.ra))
+	if n < 2 {
+		return 0, 0, 0
+	}
+	var meanA, meanB float64
+	for k := range p.ra {
+		meanA += p.ra[k]
+		meanB += p.rb[k]
+	}
+	meanA /= n
+	meanB /= n
+	for k := range p.ra {
+		da, db := p.ra[k]-meanA, p.rb[k]-meanB
+		varA += da * da
+		varB += db * db
+		cov += da * db
+	}
+	return varA / (n - 1), varB / (n - 1), cov / (n - 1)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.In(time.Local).Date()
+	by, bm, bd := b.In(time.Local).Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func rollingID(kind, a, b string, interval time.Duration, window int) string {
+	return fmt.Sprintf("%s_%s_%s_%s_%d", kind, a, b, durationLabel(interval), window)
+}
+
+// RollingBeta は銘柄 a のリターンを銘柄 b のリターンで回帰したベータ（Cov(a, b) / Var(b)）です。
+// interval ごとにサンプリングした直近 window 件の対数リターンから算出します。
+type RollingBeta struct {
+	id      string
+	returns pairReturns
+}
+
+// NewRollingBeta は銘柄 b に対する銘柄 a のローリングベータを作成します
+func NewRollingBeta(a, b string, interval time.Duration, window int) *RollingBeta {
+	return &RollingBeta{
+		id:      rollingID("beta", a, b, interval, window),
+		returns: newPairReturns(a, b, interval, window),
+	}
+}
+
+// GetOrCreateRollingBeta は DataPool 上の共有インスタンスを取得します
+func GetOrCreateRollingBeta(pool tick.DataPool, a, b string, interval time.Duration, window int) *RollingBeta {
+	return getOrCreate(pool, rollingID("beta", a, b, interval, window), func() *RollingBeta {
+		return NewRollingBeta(a, b, interval, window)
+	})
+}
+
+func (i *RollingBeta) ID() string { return i.id }
+
+func (i *RollingBeta) Symbols() []string { return []string{i.returns.a, i.returns.b} }
+
+func (i *RollingBeta) Update(t tick.Tick) { i.returns.observe(t) }
+
+func (i *RollingBeta) Dependencies() []tick.Indicator { return nil }
+
+// Ready は window 件のリターンが揃ったかを返します
+func (i *RollingBeta) Ready() bool { return i.returns.ready() }
+
+// Value はベータを返します（未準備、または銘柄 b の分散が0の場合は0）
+func (i *RollingBeta) Value() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	_, varB, cov := i.returns.moments()
+	if varB == 0 {
+		return 0
+	}
+	return cov / varB
+}
+
+// RollingCorrelation は2銘柄の対数リターンのローリング相関係数です。
+// interval ごとにサンプリングした直近 window 件のリターンから算出します。
+type RollingCorrelation struct {
+	id      string
+	returns pairReturns
+}
+
+// NewRollingCorrelation は銘柄 a と b のローリング相関を作成します
+func NewRollingCorrelation(a, b string, interval time.Duration, window int) *RollingCorrelation {
+	return &RollingCorrelation{
+		id:      rollingID("corr", a, b, interval, window),
+		returns: newPairReturns(a, b, interval, window),
+	}
+}
+
+// GetOrCreateRollingCorrelation は DataPool 上の共有インスタンスを取得します
+func GetOrCreateRollingCorrelation(pool tick.DataPool, a, b string, interval time.Duration, window int) *RollingCorrelation {
+	return getOrCreate(pool, rollingID("corr", a, b, interval, window), func() *RollingCorrelation {
+		return NewRollingCorrelation(a, b, interval, window)
+	})
+}
+
+func (i *RollingCorrelation) ID() string { return i.id }
+
+func (i *RollingCorrelation) Symbols() []string { return []string{i.returns.a, i.returns.b} }
+
+func (i *RollingCorrelation) Update(t tick.Tick) { i.returns.observe(t) }
+
+func (i *RollingCorrelation) Dependencies() []tick.Indicator { return nil }
+
+// Ready は window 件のリターンが揃ったかを返します
+func (i *RollingCorrelation) Ready() bool { return i.returns.ready() }
+
+// Value は相関係数（-1〜1）を返します（未準備、またはいずれかの分散が0の場合は0）
+func (i *RollingCorrelation) Value() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	varA, varB, cov := i.returns.moments()
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varA*varB)
+}
@@ -0,0 +1,108 @@
+package composite
+
+import (
+	"sort"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// SectorIndex はセクター（業種）に属する銘柄から合成した等ウェイトの指数です。
+// 各銘柄の始値基準の正規化価格（価格 / 始値）を平均し、100 を基準値とします。
+// 構成銘柄は portfolio.json の sector から決まり、エンジンの起動時に RegisterSectorIndices で登録されます。
+type SectorIndex struct {
+	id      string
+	sector  string
+	symbols []string
+	legs    map[string]*leg
+}
+
+// NewSectorIndex はセクター sector の構成銘柄 symbols から指数を作成します
+func NewSectorIndex(sector string, symbols []string) *SectorIndex {
+	sorted := append([]string(nil), symbols...)
+	sort.Strings(sorted)
+
+	legs := make(map[string]*leg, len(sorted))
+	uniq := sorted[:0]
+	for _, s := range sorted {
+		if _, dup := legs[s]; dup {
+			continue
+		}
+		legs[s] = &leg{}
+		uniq = append(uniq, s)
+	}
+	return &SectorIndex{id: SectorIndexID(sector), sector: sector, symbols: uniq, legs: legs}
+}
+
+// SectorIndexID はセクター指数のインジケーターIDを返します（例: "sector_銀行業"）
+func SectorIndexID(sector string) string {
+	return "sector_" + sector
+}
+
+// GetOrCreateSectorIndex は DataPool 上の共有インスタンスを取得します。
+// 既に登録済みの場合、symbols は無視されます（戦略からはセクター名だけで参照できます）。
+func GetOrCreateSectorIndex(pool tick.DataPool, sector string, symbols []string) *SectorIndex {
+	return getOrCreate(pool, SectorIndexID(sector), func() *SectorIndex {
+		return NewSectorIndex(sector, symbols)
+	})
+}
+
+// RegisterSectorIndices はセクターごとの構成銘柄から、すべてのセクター指数を DataPool に登録します
+func RegisterSectorIndices(pool tick.DataPool, members map[string][]string) {
+	sectors := make([]string, 0, len(members))
+	for sector := range members {
+		sectors = append(sectors, sector)
+	}
+	// 登録順が更新順になるため、セクター名順で決定論的に登録する
+	sort.Strings(sectors)
+	for _, sector := range sectors {
+		GetOrCreateSectorIndex(pool, sector, members[sector])
+	}
+}
+
+func (i *SectorIndex) ID() string { return i.id }
+
+func (i *SectorIndex) Symbols() []string { return i.symbols }
+
+func (i *SectorIndex) Update(t tick.Tick) {
+	if l, ok := i.legs[t.Symbol]; ok {
+		l.observe(t)
+	}
+}
+
+func (i *SectorIndex) Dependencies() []tick.Indicator { return nil }
+
+// Sector はセクター名を返します
+func (i *SectorIndex) Sector() string { return i.sector }
+
+// Count は価格を観測済みの構成銘柄の数を返します
+func (i *SectorIndex) Count() int {
+	n := 0
+	for _, l := range i.legs {
+		if l.ready() {
+			n++
+		}
+	}
+	return n
+}
+
+// Ready はすべての構成銘柄の価格を観測したかを返します
+func (i *SectorIndex) Ready() bool {
+	return len(i.symbols) > 0 && i.Count() == len(i.symbols)
+}
+
+// Value は観測済みの構成銘柄による指数値を返します（始値時点で100、観測済みの銘柄が無い場合は0）
+func (i *SectorIndex) Value() float64 {
+	var sum float64
+	n := 0
+	// 浮動小数点の加算順序を固定するため、銘柄コード順に集計する
+	for _, s := range i.symbols {
+		if l := i.legs[s]; l.ready() {
+			sum += l.normalized()
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n) * 100
+}
@@ -0,0 +1,118 @@
+package composite
+
+import (
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// Spread は2銘柄の始値基準の正規化価格の差（A/始値A − B/始値B）です。
+// 始値が未設定の銘柄は最新価格を基準とします（正規化価格は1）。
+type Spread struct {
+	id   string
+	a, b string
+	legA leg
+	legB leg
+}
+
+// NewSpread は銘柄 a と b のスプレッドを作成します
+func NewSpread(a, b string) *Spread {
+	return &Spread{id: spreadID(a, b), a: a, b: b}
+}
+
+func spreadID(a, b string) string {
+	return "spread_" + a + "_" + b
+}
+
+// GetOrCreateSpread は DataPool 上の共有インスタンスを取得します
+func GetOrCreateSpread(pool tick.DataPool, a, b string) *Spread {
+	return getOrCreate(pool, spreadID(a, b), func() *Spread {
+		return NewSpread(a, b)
+	})
+}
+
+func (i *Spread) ID() string { return i.id }
+
+func (i *Spread) Symbols() []string { return []string{i.a, i.b} }
+
+func (i *Spread) Update(t tick.Tick) {
+	switch t.Symbol {
+	case i.a:
+		i.legA.observe(t)
+	case i.b:
+		i.legB.observe(t)
+	}
+}
+
+func (i *Spread) Dependencies() []tick.Indicator { return nil }
+
+// Ready は両銘柄の価格を観測したかを返します
+func (i *Spread) Ready() bool { return i.legA.ready() && i.legB.ready() }
+
+// Value は正規化価格の差を返します（未準備の場合は0）
+func (i *Spread) Value() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.legA.normalized() - i.legB.normalized()
+}
+
+// PriceA は銘柄 A の最新価格を返します
+func (i *Spread) PriceA() float64 { return i.legA.price }
+
+// PriceB は銘柄 B の最新価格を返します
+func (i *Spread) PriceB() float64 { return i.legB.price }
+
+// BaseA は銘柄 A の正規化の基準価格（始値、未設定の場合は最新価格）を返します
+func (i *Spread) BaseA() float64 { return i.legA.base() }
+
+// BaseB は銘柄 B の正規化の基準価格（始値、未設定の場合は最新価格）を返します
+func (i *Spread) BaseB() float64 { return i.legB.base() }
+
+// Ratio は2銘柄の最新価格の比（A / B）です。
+type Ratio struct {
+	id   string
+	a, b string
+	legA leg
+	legB leg
+}
+
+// NewRatio は銘柄 a と b の価格比を作成します
+func NewRatio(a, b string) *Ratio {
+	return &Ratio{id: ratioID(a, b), a: a, b: b}
+}
+
+func ratioID(a, b string) string {
+	return "ratio_" + a + "_" + b
+}
+
+// GetOrCreateRatio は DataPool 上の共有インスタンスを取得します
+func GetOrCreateRatio(pool tick.DataPool, a, b string) *Ratio {
+	return getOrCreate(pool, ratioID(a, b), func() *Ratio {
+		return NewRatio(a, b)
+	})
+}
+
+func (i *Ratio) ID() string { return i.id }
+
+func (i *Ratio) Symbols() []string { return []string{i.a, i.b} }
+
+func (i *Ratio) Update(t tick.Tick) {
+	switch t.Symbol {
+	case i.a:
+		i.legA.observe(t)
+	case i.b:
+		i.legB.observe(t)
+	}
+}
+
+func (i *Ratio) Dependencies() []tick.Indicator { return nil }
+
+// Ready は両銘柄の価格を観測したかを返します
+func (i *Ratio) Ready() bool { return i.legA.ready() && i.legB.ready() }
+
+// Value は価格比 A / B を返します（未準備の場合は0）
+func (i *Ratio) Value() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.legA.price / i.legB.price
+}
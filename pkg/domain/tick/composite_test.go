@@ -0,0 +1,109 @@
+package tick_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// recordingComposite は更新された順序を記録するテスト用の複数銘柄指標です
+type recordingComposite struct {
+	id      string
+	symbols []string
+	deps    []tick.Indicator
+	log     *[]string
+	count   int
+}
+
+func (c *recordingComposite) ID() string                     { return c.id }
+func (c *recordingComposite) Symbols() []string              { return c.symbols }
+func (c *recordingComposite) Dependencies() []tick.Indicator { return c.deps }
+func (c *recordingComposite) Update(t tick.Tick) {
+	c.count++
+	if c.log != nil {
+		*c.log = append(*c.log, c.id+"@"+t.Symbol)
+	}
+}
+
+func TestDataPool_CompositeUpdatedOnAnyInputSymbolInDependencyOrder(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	var log []string
+
+	base := &recordingComposite{id: "spread", symbols: []string{"7203", "7267"}, log: &log}
+	// 依存先より先に登録しても、依存先が先に更新される
+	derived := pool.GetOrCreateComposite("zscore", func() tick.CompositeIndicator {
+		return &recordingComposite{id: "zscore", symbols: []string{"7203", "7267"}, deps: []tick.Indicator{base}, log: &log}
+	})
+	pool.GetOrCreateComposite("spread", func() tick.CompositeIndicator { return base })
+	other := pool.GetOrCreateComposite("sector", func() tick.CompositeIndicator {
+		return &recordingComposite{id: "sector", symbols: []string{"8306"}, log: &log}
+	})
+
+	if again := pool.GetOrCreateComposite("zscore", func() tick.CompositeIndicator {
+		t.Fatalf("factory must not be called for an existing composite")
+		return nil
+	}); again != derived {
+		t.Errorf("expected the same composite instance")
+	}
+
+	pool.PushTick(tick.Tick{Symbol: "7203", Price: 100})
+	pool.PushTick(tick.Tick{Symbol: "7267", Price: 200})
+	pool.PushTick(tick.Tick{Symbol: "8306", Price: 300})
+	pool.PushTick(tick.Tick{Symbol: "9999", Price: 400})
+
+	want := []string{"spread@7203", "zscore@7203", "spread@7267", "zscore@7267", "sector@8306"}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("unexpected update order:\n got  %v\n want %v", log, want)
+	}
+	if other.(*recordingComposite).count != 1 {
+		t.Errorf("expected sector composite to be updated once")
+	}
+}
+
+// poolReadingIndicator は銘柄単位の指標の更新中に別銘柄の状態を読みに行くテスト用指標です
+type poolReadingIndicator struct {
+	pool  tick.DataPool
+	other string
+}
+
+func (p *poolReadingIndicator) ID() string                     { return "reader" }
+func (p *poolReadingIndicator) Dependencies() []tick.Indicator { return nil }
+func (p *poolReadingIndicator) Update(t tick.Tick)             { _ = p.pool.GetState(p.other) }
+
+func TestDataPool_ConcurrentCompositeUpdatesDoNotDeadlock(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	composite := pool.GetOrCreateComposite("pair", func() tick.CompositeIndicator {
+		return &recordingComposite{id: "pair", symbols: []string{"A", "B"}}
+	}).(*recordingComposite)
+	pool.GetOrCreateIndicator("A", "reader", func() tick.Indicator { return &poolReadingIndicator{pool: pool, other: "B"} })
+	pool.GetOrCreateIndicator("B", "reader", func() tick.Indicator { return &poolReadingIndicator{pool: pool, other: "A"} })
+
+	const n = 2000
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for _, symbol := range []string{"A", "B"} {
+			wg.Add(1)
+			go func(symbol string) {
+				defer wg.Done()
+				for k := 0; k < n; k++ {
+					pool.PushTick(tick.Tick{Symbol: symbol, Price: 100})
+				}
+			}(symbol)
+		}
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("concurrent PushTick did not finish (possible deadlock)")
+	}
+	if composite.count != 2*n {
+		t.Errorf("expected %d composite updates, got %d", 2*n, composite.count)
+	}
+}
@@ -0,0 +1,30 @@
+package tick
+
+import "time"
+
+// DailyBar は1営業日分の日足（四本値と出来高）です
+type DailyBar struct {
+	Date   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+	// Partial は大引けの約定を含まない日足（取引終了前に記録を終えた日）です。
+	// 終値は記録を終えた時点の現値のため、公式の終値と異なる可能性があります
+	Partial bool
+}
+
+// DailyHistoryFeeder は、日足の履歴に基づく問い合わせにも対応した HistoricalFeeder です。
+// いずれの問い合わせも当日を含まない直近 days 営業日分を対象とし、履歴が不足する場合はエラーを返します。
+type DailyHistoryFeeder interface {
+	HistoricalFeeder
+	// FetchHighestHigh は直近 days 日の高値の最大値を返します
+	FetchHighestHigh(days int) (float64, error)
+	// FetchLowestLow は直近 days 日の安値の最小値を返します
+	FetchLowestLow(days int) (float64, error)
+	// FetchAverageVolume は直近 days 日の平均出来高を返します
+	FetchAverageVolume(days int) (float64, error)
+	// FetchDailyBars は直近 days 日の日足を古い順に返します
+	FetchDailyBars(days int) ([]DailyBar, error)
+}
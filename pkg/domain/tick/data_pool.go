@@ -12,12 +12,16 @@ type DataPool interface {
 
 	// 新規汎用指標システム Indicatorをシングルトンで管理する
 	GetOrCreateIndicator(symbol, id string, factory func() Indicator) Indicator
+
+	// GetOrCreateComposite は複数銘柄にまたがる指標をシングルトンで管理する
+	GetOrCreateComposite(id string, factory func() CompositeIndicator) CompositeIndicator
 }
 
 // DefaultDataPool は DataPool インターフェースの標準実装です
 type DefaultDataPool struct {
 	symbols        sync.Map // Key: string (symbol), Value: *symbolData
 	feederProvider HistoricalFeederProvider
+	composites     compositeRegistry
 }
 
 // symbolData は銘柄ごとのデータを保持し、個別にロックを制御します
@@ -55,6 +59,14 @@ func (a *DefaultDataPool) getOrCreateSymbolData(symbol string) *symbolData {
 
 // PushTick は新しいTickデータを受け取り、内部のデータプールを更新します
 func (a *DefaultDataPool) PushTick(tick Tick) {
+	a.updateSymbol(tick)
+
+	// 複数銘柄にまたがる指標は、銘柄のロックを解放してから更新する（ロックを入れ子にしない）
+	a.composites.update(tick)
+}
+
+// updateSymbol は銘柄のロックを取得して、最新のTickと銘柄単位の指標を更新します
+func (a *DefaultDataPool) updateSymbol(tick Tick) {
 	data := a.getOrCreateSymbolData(tick.Symbol)
 	data.mu.Lock()
 	defer data.mu.Unlock()
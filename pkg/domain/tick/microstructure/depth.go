@@ -0,0 +1,64 @@
+package microstructure
+
+import (
+	"fmt"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// DepthImbalance は板の上位 N 本の買い数量と売り数量の偏りです。
+// 値は -1（売り板のみ）〜 +1（買い板のみ）の範囲になります。
+type DepthImbalance struct {
+	id        string
+	levels    int
+	value     float64
+	bidVolume float64
+	askVolume float64
+	ready     bool
+}
+
+// NewDepthImbalance は上位 levels 本を対象とする板インバランスを作成します
+func NewDepthImbalance(levels int) *DepthImbalance {
+	return &DepthImbalance{
+		id:     depthImbalanceID(levels),
+		levels: levels,
+	}
+}
+
+func depthImbalanceID(levels int) string {
+	return fmt.Sprintf("depth_imbalance_%d", levels)
+}
+
+// GetOrCreateDepthImbalance は DataPool 上の共有インスタンスを取得します
+func GetOrCreateDepthImbalance(pool tick.DataPool, symbol string, levels int) *DepthImbalance {
+	return getOrCreate(pool, symbol, depthImbalanceID(levels), func() *DepthImbalance {
+		return NewDepthImbalance(levels)
+	})
+}
+
+func (i *DepthImbalance) ID() string { return i.id }
+
+func (i *DepthImbalance) Update(t tick.Tick) {
+	bid := sumDepth(t.BuyBoard, i.levels)
+	ask := sumDepth(t.SellBoard, i.levels)
+	if bid+ask == 0 {
+		return // 板情報が無いTickでは直前の値を維持する
+	}
+	i.bidVolume, i.askVolume = bid, ask
+	i.value = imbalance(bid, ask)
+	i.ready = true
+}
+
+func (i *DepthImbalance) Dependencies() []tick.Indicator { return nil }
+
+// Ready は一度でも板情報を受け取ったかを返します
+func (i *DepthImbalance) Ready() bool { return i.ready }
+
+// Value は (買い数量 - 売り数量) / (買い数量 + 売り数量) を返します
+func (i *DepthImbalance) Value() float64 { return i.value }
+
+// BidVolume は上位 N 本の買い板数量の合計を返します
+func (i *DepthImbalance) BidVolume() float64 { return i.bidVolume }
+
+// AskVolume は上位 N 本の売り板数量の合計を返します
+func (i *DepthImbalance) AskVolume() float64 { return i.askVolume }
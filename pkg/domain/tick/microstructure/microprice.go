@@ -0,0 +1,49 @@
+package microstructure
+
+import "github.com/r-umemoto/trading-bot/pkg/domain/tick"
+
+// Microprice は最良気配の数量で加重した理論中値です。
+// 買い板が厚いほど売り気配側に寄り、短期的な値動きの方向を仲値より早く示します。
+type Microprice struct {
+	value float64
+	mid   float64
+	ready bool
+}
+
+const microPriceID = "microprice"
+
+// NewMicroprice はマイクロプライス指標を作成します
+func NewMicroprice() *Microprice {
+	return &Microprice{}
+}
+
+// GetOrCreateMicroprice は DataPool 上の共有インスタンスを取得します
+func GetOrCreateMicroprice(pool tick.DataPool, symbol string) *Microprice {
+	return getOrCreate(pool, symbol, microPriceID, NewMicroprice)
+}
+
+func (i *Microprice) ID() string { return microPriceID }
+
+func (i *Microprice) Update(t tick.Tick) {
+	if !hasTwoSidedQuote(t) {
+		return
+	}
+	ask, bid := t.BestAsk, t.BestBid
+	i.value = (ask.Price*bid.Qty + bid.Price*ask.Qty) / (bid.Qty + ask.Qty)
+	i.mid = (ask.Price + bid.Price) / 2
+	i.ready = true
+}
+
+func (i *Microprice) Dependencies() []tick.Indicator { return nil }
+
+// Ready は一度でも両側の気配を受け取ったかを返します
+func (i *Microprice) Ready() bool { return i.ready }
+
+// Value はマイクロプライスを返します
+func (i *Microprice) Value() float64 { return i.value }
+
+// Mid は単純な仲値 (ask + bid) / 2 を返します
+func (i *Microprice) Mid() float64 { return i.mid }
+
+// Skew はマイクロプライスと仲値の乖離（正なら上方向への圧力）を返します
+func (i *Microprice) Skew() float64 { return i.value - i.mid }
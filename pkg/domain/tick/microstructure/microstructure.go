@@ -0,0 +1,45 @@
+// Package microstructure は Tick に含まれる板情報（SellBoard/BuyBoard、最良気配、OVER/UNDER、成行数量）から
+// スキャルピング向けの板系指標を算出する tick.Indicator 群を提供します。
+// いずれもバーには依存せず、Tick 毎に最新の板スナップショットから値を更新します。
+package microstructure
+
+import (
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// getOrCreate は DataPool 上で指標を共有インスタンスとして取得します
+func getOrCreate[T tick.Indicator](pool tick.DataPool, symbol, id string, factory func() T) T {
+	return pool.GetOrCreateIndicator(symbol, id, func() tick.Indicator {
+		return factory()
+	}).(T)
+}
+
+// hasTwoSidedQuote は最良売気配・最良買気配がともに有効（値段・数量が正で、売り > 買い）かを判定します。
+// 特別気配や片側気配のみの場合は false になります。
+func hasTwoSidedQuote(t tick.Tick) bool {
+	return t.BestAsk.Price > 0 && t.BestBid.Price > 0 &&
+		t.BestAsk.Qty > 0 && t.BestBid.Qty > 0 &&
+		t.BestAsk.Price > t.BestBid.Price
+}
+
+// sumDepth は板の先頭から levels 本分の数量を合計します（値段0の空き気配は除外）
+func sumDepth(board []tick.Quote, levels int) float64 {
+	var total float64
+	for i, q := range board {
+		if i >= levels {
+			break
+		}
+		if q.Price > 0 {
+			total += q.Qty
+		}
+	}
+	return total
+}
+
+// imbalance は (buy - sell) / (buy + sell) を返します（合計0の場合は0）
+func imbalance(buy, sell float64) float64 {
+	if buy+sell == 0 {
+		return 0
+	}
+	return (buy - sell) / (buy + sell)
+}
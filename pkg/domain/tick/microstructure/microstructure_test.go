@@ -0,0 +1,151 @@
+package microstructure_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/microstructure"
+)
+
+func assertNear(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s: expected %.6f, got %.6f", name, want, got)
+	}
+}
+
+func boardTick(askP, askQ, bidP, bidQ float64) tick.Tick {
+	return tick.Tick{
+		Symbol:  "7203",
+		Price:   bidP,
+		BestAsk: tick.FirstQuote{Price: askP, Qty: askQ},
+		BestBid: tick.FirstQuote{Price: bidP, Qty: bidQ},
+		SellBoard: []tick.Quote{
+			{Price: askP, Qty: askQ}, {Price: askP + 1, Qty: 300}, {Price: askP + 2, Qty: 500},
+		},
+		BuyBoard: []tick.Quote{
+			{Price: bidP, Qty: bidQ}, {Price: bidP - 1, Qty: 200}, {Price: 0, Qty: 0},
+		},
+	}
+}
+
+func TestDepthImbalance(t *testing.T) {
+	ind := microstructure.NewDepthImbalance(2)
+	if ind.ID() != "depth_imbalance_2" {
+		t.Errorf("unexpected id: %s", ind.ID())
+	}
+
+	ind.Update(tick.Tick{})
+	if ind.Ready() {
+		t.Fatalf("expected not ready without board")
+	}
+
+	ind.Update(boardTick(101, 100, 100, 600))
+	// 買い: 600 + 200 = 800, 売り: 100 + 300 = 400
+	assertNear(t, "imbalance", ind.Value(), (800.0-400.0)/1200.0)
+	assertNear(t, "bid volume", ind.BidVolume(), 800)
+	assertNear(t, "ask volume", ind.AskVolume(), 400)
+
+	// 空き気配（値段0）は深さに含めない
+	all := microstructure.NewDepthImbalance(10)
+	all.Update(boardTick(101, 100, 100, 600))
+	assertNear(t, "bid volume (10 levels)", all.BidVolume(), 800)
+	assertNear(t, "ask volume (10 levels)", all.AskVolume(), 900)
+}
+
+func TestMicroprice(t *testing.T) {
+	ind := microstructure.NewMicroprice()
+
+	// 特別気配（売り <= 買い）は無視する
+	ind.Update(boardTick(100, 100, 100, 100))
+	if ind.Ready() {
+		t.Fatalf("expected not ready for crossed quote")
+	}
+
+	ind.Update(boardTick(101, 100, 100, 300))
+	// (101*300 + 100*100) / 400 = 100.75
+	assertNear(t, "microprice", ind.Value(), 100.75)
+	assertNear(t, "mid", ind.Mid(), 100.5)
+	assertNear(t, "skew", ind.Skew(), 0.25)
+}
+
+func TestOrderFlowImbalance(t *testing.T) {
+	ind := microstructure.NewOrderFlowImbalance(2)
+
+	ind.Update(boardTick(101, 100, 100, 300))
+
+	// 気配値段は据え置き、買い数量 +200、売り数量 -50
+	ind.Update(boardTick(101, 50, 100, 500))
+	// bid: +500 - 300 = +200, ask: -50 + 100 = +50
+	assertNear(t, "ofi event 1", ind.Last(), 250)
+	if ind.Ready() {
+		t.Errorf("expected not ready before the window is filled")
+	}
+
+	// 買い気配が切り上がり、売り気配も切り上がる
+	ind.Update(boardTick(102, 400, 101, 100))
+	// bid 上昇: +100, ask 後退: +50 (直前の売り数量)
+	assertNear(t, "ofi event 2", ind.Last(), 150)
+	assertNear(t, "ofi sum", ind.Value(), 400)
+
+	// 買い気配が下がり、売り気配も下がる（売り圧力）
+	ind.Update(boardTick(101, 200, 100, 100))
+	// bid 下落: -100, ask 前進: -200
+	assertNear(t, "ofi event 3", ind.Last(), -300)
+	// 窓幅2なので最初のイベント(250)は押し出される
+	assertNear(t, "ofi window sum", ind.Value(), -150)
+	if !ind.Ready() {
+		t.Errorf("expected ready after the window is filled")
+	}
+}
+
+func TestSpreadTicks(t *testing.T) {
+	detail := symbol.Symbol{Code: "7203", PriceRangeGroup: symbol.PRICE_RANGE_GROUP_TSE_TOPIX100}
+	ind := microstructure.NewSpreadTicks(detail)
+
+	// 2000円台のTOPIX100は0.5円刻み
+	ind.Update(boardTick(2001.5, 100, 2000, 100))
+	assertNear(t, "spread", ind.Spread(), 1.5)
+	assertNear(t, "spread ticks", ind.Value(), 3)
+
+	unknown := microstructure.NewSpreadTicks(symbol.Symbol{Code: "9999"})
+	unknown.Update(boardTick(101, 100, 100, 100))
+	if unknown.Ready() {
+		t.Errorf("expected not ready when tick size is unknown")
+	}
+	assertNear(t, "spread (unknown group)", unknown.Spread(), 1)
+}
+
+func TestBookPressure(t *testing.T) {
+	ind := microstructure.NewBookPressure()
+
+	tk := boardTick(101, 100, 100, 600)
+	tk.OverSellQty = 1000
+	tk.UnderBuyQty = 3000
+	tk.MarketOrderBuyQty = 300
+	tk.MarketOrderSellQty = 100
+	ind.Update(tk)
+
+	assertNear(t, "over/under ratio", ind.OverUnderRatio(), 3)
+	assertNear(t, "over/under imbalance", ind.OverUnderImbalance(), 0.5)
+	assertNear(t, "market order imbalance", ind.MarketOrderImbalance(), 0.5)
+	// 買い: 800 + 3000 + 300 = 4100, 売り: 900 + 1000 + 100 = 2000
+	assertNear(t, "full book imbalance", ind.FullBookImbalance(), 2100.0/6100.0)
+}
+
+func TestMicrostructure_SharedThroughDataPool(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	detail := symbol.Symbol{Code: "7203", PriceRangeGroup: symbol.PRICE_RANGE_GROUP_TSE_STANDARD}
+
+	spread := microstructure.GetOrCreateSpreadTicks(pool, detail)
+	if again := microstructure.GetOrCreateSpreadTicks(pool, detail); again != spread {
+		t.Errorf("expected the same instance")
+	}
+	micro := microstructure.GetOrCreateMicroprice(pool, detail.Code)
+
+	pool.PushTick(boardTick(102, 100, 100, 100))
+	assertNear(t, "spread ticks via pool", spread.Value(), 2)
+	assertNear(t, "microprice via pool", micro.Value(), 101)
+}
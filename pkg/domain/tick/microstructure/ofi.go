@@ -0,0 +1,98 @@
+package microstructure
+
+import (
+	"fmt"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// OrderFlowImbalance は連続する Tick 間の最良気配の変化から算出する注文フローの偏り（OFI）です。
+// Cont, Kukanov & Stoikov (2014) の定義に従い、買い気配の上昇・増加と売り気配の後退・減少を正として集計し、
+// 直近 window イベント分の合計を保持します。
+type OrderFlowImbalance struct {
+	id     string
+	window int
+
+	prevAsk tick.FirstQuote
+	prevBid tick.FirstQuote
+	hasPrev bool
+
+	events []float64
+	next   int
+	sum    float64
+	last   float64
+}
+
+// NewOrderFlowImbalance は直近 window イベント分を合計する OFI を作成します
+func NewOrderFlowImbalance(window int) *OrderFlowImbalance {
+	return &OrderFlowImbalance{
+		id:     ofiID(window),
+		window: window,
+		events: make([]float64, 0, window),
+	}
+}
+
+func ofiID(window int) string {
+	return fmt.Sprintf("ofi_%d", window)
+}
+
+// GetOrCreateOrderFlowImbalance は DataPool 上の共有インスタンスを取得します
+func GetOrCreateOrderFlowImbalance(pool tick.DataPool, symbol string, window int) *OrderFlowImbalance {
+	return getOrCreate(pool, symbol, ofiID(window), func() *OrderFlowImbalance {
+		return NewOrderFlowImbalance(window)
+	})
+}
+
+func (i *OrderFlowImbalance) ID() string { return i.id }
+
+func (i *OrderFlowImbalance) Update(t tick.Tick) {
+	if !hasTwoSidedQuote(t) {
+		return
+	}
+	if !i.hasPrev {
+		i.prevAsk, i.prevBid = t.BestAsk, t.BestBid
+		i.hasPrev = true
+		return
+	}
+
+	var e float64
+	bid, ask := t.BestBid, t.BestAsk
+	if bid.Price >= i.prevBid.Price {
+		e += bid.Qty
+	}
+	if bid.Price <= i.prevBid.Price {
+		e -= i.prevBid.Qty
+	}
+	if ask.Price <= i.prevAsk.Price {
+		e -= ask.Qty
+	}
+	if ask.Price >= i.prevAsk.Price {
+		e += i.prevAsk.Qty
+	}
+	i.prevAsk, i.prevBid = ask, bid
+
+	i.push(e)
+}
+
+func (i *OrderFlowImbalance) push(e float64) {
+	if len(i.events) < i.window {
+		i.events = append(i.events, e)
+	} else {
+		i.sum -= i.events[i.next]
+		i.events[i.next] = e
+		i.next = (i.next + 1) % i.window
+	}
+	i.sum += e
+	i.last = e
+}
+
+func (i *OrderFlowImbalance) Dependencies() []tick.Indicator { return nil }
+
+// Ready は window イベント分の OFI が揃っているかを返します
+func (i *OrderFlowImbalance) Ready() bool { return len(i.events) == i.window }
+
+// Value は直近 window イベント分の OFI 合計を返します
+func (i *OrderFlowImbalance) Value() float64 { return i.sum }
+
+// Last は直前の Tick との間で発生した OFI を返します
+func (i *OrderFlowImbalance) Last() float64 { return i.last }
@@ -0,0 +1,70 @@
+package microstructure
+
+import "github.com/r-umemoto/trading-bot/pkg/domain/tick"
+
+// BookPressure は板の外側（OVER/UNDER）と成行注文の数量から売り買いの圧力を表す指標です。
+// OVER は表示されている売り板より上の売り注文、UNDER は買い板より下の買い注文の合計数量です。
+type BookPressure struct {
+	over         float64
+	under        float64
+	marketSell   float64
+	marketBuy    float64
+	fullBookBuy  float64
+	fullBookSell float64
+	ready        bool
+}
+
+const bookPressureID = "book_pressure"
+
+// NewBookPressure は板圧力指標を作成します
+func NewBookPressure() *BookPressure {
+	return &BookPressure{}
+}
+
+// GetOrCreateBookPressure は DataPool 上の共有インスタンスを取得します
+func GetOrCreateBookPressure(pool tick.DataPool, symbol string) *BookPressure {
+	return getOrCreate(pool, symbol, bookPressureID, NewBookPressure)
+}
+
+func (i *BookPressure) ID() string { return bookPressureID }
+
+func (i *BookPressure) Update(t tick.Tick) {
+	buy := sumDepth(t.BuyBoard, len(t.BuyBoard))
+	sell := sumDepth(t.SellBoard, len(t.SellBoard))
+	if t.OverSellQty+t.UnderBuyQty+buy+sell == 0 {
+		return // 板情報が無いTickでは直前の値を維持する
+	}
+	i.over, i.under = t.OverSellQty, t.UnderBuyQty
+	i.marketSell, i.marketBuy = t.MarketOrderSellQty, t.MarketOrderBuyQty
+	i.fullBookBuy = buy + t.UnderBuyQty + t.MarketOrderBuyQty
+	i.fullBookSell = sell + t.OverSellQty + t.MarketOrderSellQty
+	i.ready = true
+}
+
+func (i *BookPressure) Dependencies() []tick.Indicator { return nil }
+
+// Ready は一度でも板情報を受け取ったかを返します
+func (i *BookPressure) Ready() bool { return i.ready }
+
+// OverUnderRatio は UNDER / OVER（買い圧力 / 売り圧力）を返します。OVER が0の場合は0を返します
+func (i *BookPressure) OverUnderRatio() float64 {
+	if i.over == 0 {
+		return 0
+	}
+	return i.under / i.over
+}
+
+// OverUnderImbalance は (UNDER - OVER) / (UNDER + OVER) を -1〜+1 で返します
+func (i *BookPressure) OverUnderImbalance() float64 {
+	return imbalance(i.under, i.over)
+}
+
+// MarketOrderImbalance は成行の (買い - 売り) / (買い + 売り) を -1〜+1 で返します
+func (i *BookPressure) MarketOrderImbalance() float64 {
+	return imbalance(i.marketBuy, i.marketSell)
+}
+
+// FullBookImbalance は表示板・OVER/UNDER・成行を合わせた板全体の偏りを -1〜+1 で返します
+func (i *BookPressure) FullBookImbalance() float64 {
+	return imbalance(i.fullBookBuy, i.fullBookSell)
+}
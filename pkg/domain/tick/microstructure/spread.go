@@ -0,0 +1,59 @@
+package microstructure
+
+import (
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// SpreadTicks は最良気配のスプレッドを呼値（ティック）単位で表した指標です。
+// 呼値は銘柄の呼値グループと買い気配の値段から symbol.Symbol.CalcTickSize で求めます。
+type SpreadTicks struct {
+	detail symbol.Symbol
+	spread float64
+	ticks  float64
+	ready  bool
+}
+
+const spreadTicksID = "spread_ticks"
+
+// NewSpreadTicks は銘柄の呼値グループを使うスプレッド指標を作成します
+func NewSpreadTicks(detail symbol.Symbol) *SpreadTicks {
+	return &SpreadTicks{detail: detail}
+}
+
+// GetOrCreateSpreadTicks は DataPool 上の共有インスタンスを取得します
+func GetOrCreateSpreadTicks(pool tick.DataPool, detail symbol.Symbol) *SpreadTicks {
+	return getOrCreate(pool, detail.Code, spreadTicksID, func() *SpreadTicks {
+		return NewSpreadTicks(detail)
+	})
+}
+
+func (i *SpreadTicks) ID() string { return spreadTicksID }
+
+func (i *SpreadTicks) Update(t tick.Tick) {
+	if !hasTwoSidedQuote(t) {
+		return
+	}
+	i.spread = t.BestAsk.Price - t.BestBid.Price
+
+	tickSize := i.detail.CalcTickSize(t.BestBid.Price)
+	if tickSize <= 0 {
+		// 呼値グループが不明な場合はティック換算できないため金額のみ保持する
+		i.ticks = 0
+		i.ready = false
+		return
+	}
+	i.ticks = i.spread / tickSize
+	i.ready = true
+}
+
+func (i *SpreadTicks) Dependencies() []tick.Indicator { return nil }
+
+// Ready はティック換算済みのスプレッドがあるかを返します
+func (i *SpreadTicks) Ready() bool { return i.ready }
+
+// Value はスプレッドのティック数を返します（1.0 なら最小スプレッド）
+func (i *SpreadTicks) Value() float64 { return i.ticks }
+
+// Spread はスプレッドの金額（売り気配 - 買い気配）を返します
+func (i *SpreadTicks) Spread() float64 { return i.spread }
@@ -0,0 +1,186 @@
+package tick
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SnapshotFormatVersion はスナップショットファイル全体のフォーマットバージョンです。
+// ファイル構造を変更した場合はこの値を上げ、古いファイルは復元時に丸ごと破棄されます。
+const SnapshotFormatVersion = 1
+
+// Snapshotter は、途中再起動に備えて内部状態の保存・復元に対応する指標が実装する任意のインターフェースです。
+// 依存先のバーから状態を再計算できる指標（テクニカル指標など）は実装不要です。
+type Snapshotter interface {
+	Indicator
+	// SnapshotVersion は状態フォーマットのバージョンです。変更時に上げると、古い状態は復元時に破棄されます
+	SnapshotVersion() int
+	// Snapshot は現在の内部状態をシリアライズします
+	Snapshot() (json.RawMessage, error)
+	// Restore はシリアライズされた状態から内部状態を復元します
+	Restore(state json.RawMessage) error
+}
+
+// SnapshotPool は、登録済み指標の状態をまとめて保存・復元できる DataPool が実装するインターフェースです
+type SnapshotPool interface {
+	// SaveSnapshot は全銘柄の Snapshotter 指標の状態をファイルへ保存します
+	SaveSnapshot(path string) error
+	// RestoreSnapshot はファイルから指標の状態を復元します。
+	// バージョンが一致しない状態や、現在登録されていない指標IDの状態は破棄されます。
+	RestoreSnapshot(path string) (SnapshotRestoreResult, error)
+}
+
+// SnapshotRestoreResult は復元処理の結果です
+type SnapshotRestoreResult struct {
+	SavedAt   time.Time
+	Restored  int // 復元できた指標の数
+	Discarded int // ID不一致・バージョン不一致・復元失敗で破棄した指標の数
+}
+
+// ErrSnapshotVersionMismatch はスナップショットファイルのフォーマットバージョンが一致しない場合のエラーです
+var ErrSnapshotVersionMismatch = errors.New("snapshot format version mismatch")
+
+type poolSnapshot struct {
+	Version int                                     `json:"version"`
+	SavedAt time.Time                               `json:"saved_at"`
+	Symbols map[string]map[string]indicatorSnapshot `json:"symbols"`
+}
+
+type indicatorSnapshot struct {
+	Version int             `json:"version"`
+	State   json.RawMessage `json:"state"`
+}
+
+var _ SnapshotPool = (*DefaultDataPool)(nil)
+
+// SaveSnapshot は全銘柄の Snapshotter 指標の状態をファイルへ保存します。
+// 書き込み途中のクラッシュで既存ファイルを壊さないよう、一時ファイルに書いてから置き換えます。
+func (a *DefaultDataPool) SaveSnapshot(path string) error {
+	snap := poolSnapshot{
+		Version: SnapshotFormatVersion,
+		SavedAt: time.Now(),
+		Symbols: make(map[string]map[string]indicatorSnapshot),
+	}
+
+	var saveErr error
+	a.symbols.Range(func(key, value any) bool {
+		symbol := key.(string)
+		data := value.(*symbolData)
+
+		data.mu.RLock()
+		defer data.mu.RUnlock()
+
+		for id, ind := range data.indicators {
+			s, ok := ind.(Snapshotter)
+			if !ok {
+				continue
+			}
+			state, err := s.Snapshot()
+			if err != nil {
+				saveErr = fmt.Errorf("failed to snapshot indicator %s/%s: %w", symbol, id, err)
+				return false
+			}
+			if snap.Symbols[symbol] == nil {
+				snap.Symbols[symbol] = make(map[string]indicatorSnapshot)
+			}
+			snap.Symbols[symbol][id] = indicatorSnapshot{Version: s.SnapshotVersion(), State: state}
+		}
+		return true
+	})
+	if saveErr != nil {
+		return saveErr
+	}
+
+	body, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// RestoreSnapshot はファイルから指標の状態を復元します。
+// 復元先は呼び出し時点で登録済みの指標のみであり、戦略の生成（指標の登録）後に呼び出す必要があります。
+func (a *DefaultDataPool) RestoreSnapshot(path string) (SnapshotRestoreResult, error) {
+	var result SnapshotRestoreResult
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return result, err
+	}
+
+	var snap poolSnapshot
+	if err := json.Unmarshal(body, &snap); err != nil {
+		return result, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	if snap.Version != SnapshotFormatVersion {
+		return result, fmt.Errorf("%w: file=%d, expected=%d", ErrSnapshotVersionMismatch, snap.Version, SnapshotFormatVersion)
+	}
+	result.SavedAt = snap.SavedAt
+
+	for symbol, entries := range snap.Symbols {
+		val, ok := a.symbols.Load(symbol)
+		if !ok {
+			result.Discarded += len(entries)
+			slog.Warn("Discarded snapshot for unregistered symbol", slog.String("symbol", symbol), slog.Int("indicators", len(entries)))
+			continue
+		}
+		data := val.(*symbolData)
+
+		data.mu.Lock()
+		for id, entry := range entries {
+			if a.restoreIndicator(data, symbol, id, entry) {
+				result.Restored++
+			} else {
+				result.Discarded++
+			}
+		}
+		data.mu.Unlock()
+	}
+
+	return result, nil
+}
+
+// restoreIndicator は1つの指標の状態を復元します（呼び出し元で data.mu をロックすること）
+func (a *DefaultDataPool) restoreIndicator(data *symbolData, symbol, id string, entry indicatorSnapshot) bool {
+	ind, ok := data.indicators[id]
+	if !ok {
+		slog.Warn("Discarded snapshot for unknown indicator", slog.String("symbol", symbol), slog.String("indicator", id))
+		return false
+	}
+	s, ok := ind.(Snapshotter)
+	if !ok {
+		slog.Warn("Discarded snapshot for indicator without snapshot support", slog.String("symbol", symbol), slog.String("indicator", id))
+		return false
+	}
+	if s.SnapshotVersion() != entry.Version {
+		slog.Warn("Discarded snapshot with mismatched version",
+			slog.String("symbol", symbol),
+			slog.String("indicator", id),
+			slog.Int("snapshot_version", entry.Version),
+			slog.Int("indicator_version", s.SnapshotVersion()),
+		)
+		return false
+	}
+	if err := s.Restore(entry.State); err != nil {
+		slog.Error("Failed to restore indicator snapshot",
+			slog.String("symbol", symbol),
+			slog.String("indicator", id),
+			slog.Any("error", err),
+		)
+		return false
+	}
+	return true
+}
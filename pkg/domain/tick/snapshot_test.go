@@ -0,0 +1,108 @@
+package tick_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// versionedIndicator はスナップショットのバージョン不一致を再現するためのテスト用指標です
+type versionedIndicator struct {
+	id      string
+	version int
+	value   int
+}
+
+func (v *versionedIndicator) ID() string                     { return v.id }
+func (v *versionedIndicator) Update(t tick.Tick)             { v.value++ }
+func (v *versionedIndicator) Dependencies() []tick.Indicator { return nil }
+func (v *versionedIndicator) SnapshotVersion() int           { return v.version }
+func (v *versionedIndicator) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(v.value)
+}
+func (v *versionedIndicator) Restore(state json.RawMessage) error {
+	return json.Unmarshal(state, &v.value)
+}
+
+func TestDataPool_SnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	base := time.Date(2026, 4, 19, 9, 0, 0, 0, time.UTC)
+
+	// 1. 再起動前: 10:30 までの Tick で指標を育てる
+	before := tick.NewDefaultDataPool(nil)
+	bars := before.GetOrCreateIndicator("7203", "1min_bar", func() tick.Indicator {
+		return tick.NewOneMinBarIndicator("1min_bar")
+	}).(*tick.OneMinBarIndicator)
+	fiveMin := tick.GetOrCreateBarIndicator(before, "7203", tick.TimeBar(5*time.Minute))
+	before.GetOrCreateIndicator("7203", "counter", func() tick.Indicator {
+		return &versionedIndicator{id: "counter", version: 1}
+	})
+	before.GetOrCreateIndicator("7203", "obsolete", func() tick.Indicator {
+		return &versionedIndicator{id: "obsolete", version: 1}
+	})
+
+	for n, p := range []float64{100, 101, 99, 102} {
+		before.PushTick(tick.Tick{Symbol: "7203", Price: p, TradingVolume: float64(1000 + n*100), CurrentPriceTime: base.Add(time.Duration(n*40) * time.Second)})
+	}
+	if err := before.SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	// 2. 再起動後: 戦略が指標を登録してから復元する
+	after := tick.NewDefaultDataPool(nil)
+	restoredBars := after.GetOrCreateIndicator("7203", "1min_bar", func() tick.Indicator {
+		return tick.NewOneMinBarIndicator("1min_bar")
+	}).(*tick.OneMinBarIndicator)
+	restoredFive := tick.GetOrCreateBarIndicator(after, "7203", tick.TimeBar(5*time.Minute))
+	// 状態フォーマットが変わった指標
+	counter := after.GetOrCreateIndicator("7203", "counter", func() tick.Indicator {
+		return &versionedIndicator{id: "counter", version: 2}
+	}).(*versionedIndicator)
+
+	result, err := after.RestoreSnapshot(path)
+	if err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+	// 1min_bar と bar_5m が復元され、counter (バージョン違い) と obsolete (未登録ID) は破棄される
+	if result.Restored != 2 || result.Discarded != 2 {
+		t.Errorf("expected 2 restored / 2 discarded, got %+v", result)
+	}
+	if counter.value != 0 {
+		t.Errorf("expected version-mismatched state to be discarded, got %d", counter.value)
+	}
+
+	if got, want := restoredBars.Bars(), bars.Bars(); len(got) != len(want) || got[0] != want[0] || got[len(got)-1] != want[len(want)-1] {
+		t.Errorf("expected restored 1min bars %+v, got %+v", want, got)
+	}
+	if got, want := restoredFive.Bars(), fiveMin.Bars(); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("expected restored 5min bars %+v, got %+v", want, got)
+	}
+
+	// 3. 再起動後の最初の Tick は出来高差分ゼロとして扱われる
+	after.PushTick(tick.Tick{Symbol: "7203", Price: 103, TradingVolume: 5000, CurrentPriceTime: base.Add(170 * time.Second)})
+	last := restoredBars.Bars()[len(restoredBars.Bars())-1]
+	if last.Close != 103 || last.Volume != bars.Bars()[len(bars.Bars())-1].Volume {
+		t.Errorf("unexpected bar after restart: %+v", last)
+	}
+}
+
+func TestDataPool_RestoreSnapshotVersionMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := os.WriteFile(path, []byte(`{"version": 999, "symbols": {}}`), 0644); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+
+	pool := tick.NewDefaultDataPool(nil)
+	if _, err := pool.RestoreSnapshot(path); !errors.Is(err, tick.ErrSnapshotVersionMismatch) {
+		t.Errorf("expected ErrSnapshotVersionMismatch, got %v", err)
+	}
+
+	if _, err := pool.RestoreSnapshot(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist for a missing snapshot, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package technical
+
+import (
+	"math"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// ADX は Wilder の平均方向性指数です。+DI / -DI も合わせて提供します。
+type ADX struct {
+	id     string
+	feed   barFeed
+	period int
+
+	prev    tick.Bar
+	hasPrev bool
+
+	// Wilder 方式の平滑化累計（初回は period 本の単純合計）
+	count    int
+	trSum    float64
+	plusSum  float64
+	minusSum float64
+
+	plusDI  float64
+	minusDI float64
+	adx     wilder
+}
+
+// NewADX は period 本の ADX を作成します
+func NewADX(src tick.BarSource, period int) *ADX {
+	return &ADX{
+		id:     indicatorID("adx", src, period),
+		feed:   barFeed{src: src},
+		period: period,
+		adx:    wilder{period: period},
+	}
+}
+
+// GetOrCreateADX は DataPool 上の共有 ADX を取得します
+func GetOrCreateADX(pool tick.DataPool, symbol string, src tick.BarSource, period int) *ADX {
+	return getOrCreate(pool, symbol, indicatorID("adx", src, period), func() *ADX {
+		return NewADX(src, period)
+	})
+}
+
+func (i *ADX) ID() string { return i.id }
+
+func (i *ADX) Update(_ tick.Tick) {
+	i.feed.drain(i.onBar)
+}
+
+func (i *ADX) onBar(bar tick.Bar) {
+	if !i.hasPrev {
+		i.prev = bar
+		i.hasPrev = true
+		return
+	}
+
+	up := bar.High - i.prev.High
+	down := i.prev.Low - bar.Low
+	plusDM, minusDM := 0.0, 0.0
+	if up > down && up > 0 {
+		plusDM = up
+	}
+	if down > up && down > 0 {
+		minusDM = down
+	}
+	tr := trueRange(bar, i.prev.Close, true)
+	i.prev = bar
+
+	n := float64(i.period)
+	if i.count < i.period {
+		i.trSum += tr
+		i.plusSum += plusDM
+		i.minusSum += minusDM
+		i.count++
+		if i.count < i.period {
+			return
+		}
+	} else {
+		i.trSum = i.trSum - i.trSum/n + tr
+		i.plusSum = i.plusSum - i.plusSum/n + plusDM
+		i.minusSum = i.minusSum - i.minusSum/n + minusDM
+	}
+
+	if i.trSum == 0 {
+		i.plusDI, i.minusDI = 0, 0
+	} else {
+		i.plusDI = 100 * i.plusSum / i.trSum
+		i.minusDI = 100 * i.minusSum / i.trSum
+	}
+
+	dx := 0.0
+	if sum := i.plusDI + i.minusDI; sum != 0 {
+		dx = 100 * math.Abs(i.plusDI-i.minusDI) / sum
+	}
+	i.adx.push(dx)
+}
+
+func (i *ADX) Dependencies() []tick.Indicator {
+	return []tick.Indicator{i.feed.src}
+}
+
+// Ready は ADX の初期値が計算済みか（2*period 本の確定足が揃っているか）を返します
+func (i *ADX) Ready() bool { return i.adx.ready }
+
+// Value は ADX（0〜100）を返します
+func (i *ADX) Value() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.adx.value
+}
+
+// PlusDI は +DI を返します
+func (i *ADX) PlusDI() float64 { return i.plusDI }
+
+// MinusDI は -DI を返します
+func (i *ADX) MinusDI() float64 { return i.minusDI }
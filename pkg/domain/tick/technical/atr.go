@@ -0,0 +1,65 @@
+package technical
+
+import (
+	"math"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// trueRange は前の足の終値を考慮した真の値幅を返します
+func trueRange(bar tick.Bar, prevClose float64, hasPrev bool) float64 {
+	if !hasPrev {
+		return bar.High - bar.Low
+	}
+	return math.Max(bar.High-bar.Low, math.Max(math.Abs(bar.High-prevClose), math.Abs(bar.Low-prevClose)))
+}
+
+// ATR は Wilder の平均真の値幅です。
+type ATR struct {
+	id        string
+	feed      barFeed
+	avg       wilder
+	prevClose float64
+	hasPrev   bool
+}
+
+// NewATR は period 本の ATR を作成します
+func NewATR(src tick.BarSource, period int) *ATR {
+	return &ATR{
+		id:   indicatorID("atr", src, period),
+		feed: barFeed{src: src},
+		avg:  wilder{period: period},
+	}
+}
+
+// GetOrCreateATR は DataPool 上の共有 ATR を取得します
+func GetOrCreateATR(pool tick.DataPool, symbol string, src tick.BarSource, period int) *ATR {
+	return getOrCreate(pool, symbol, indicatorID("atr", src, period), func() *ATR {
+		return NewATR(src, period)
+	})
+}
+
+func (i *ATR) ID() string { return i.id }
+
+func (i *ATR) Update(_ tick.Tick) {
+	i.feed.drain(func(bar tick.Bar) {
+		i.avg.push(trueRange(bar, i.prevClose, i.hasPrev))
+		i.prevClose = bar.Close
+		i.hasPrev = true
+	})
+}
+
+func (i *ATR) Dependencies() []tick.Indicator {
+	return []tick.Indicator{i.feed.src}
+}
+
+// Ready は期間分の確定足が揃っているかを返します
+func (i *ATR) Ready() bool { return i.avg.ready }
+
+// Value は現在の ATR を返します（Ready でない場合は 0）
+func (i *ATR) Value() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.avg.value
+}
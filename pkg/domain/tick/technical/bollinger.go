@@ -0,0 +1,89 @@
+package technical
+
+import (
+	"math"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// Bollinger は終値の単純移動平均と母標準偏差によるボリンジャーバンドです。
+type Bollinger struct {
+	id     string
+	feed   barFeed
+	window *window
+	k      float64
+}
+
+// NewBollinger は period 本、±k σ のボリンジャーバンドを作成します
+func NewBollinger(src tick.BarSource, period int, k float64) *Bollinger {
+	return &Bollinger{
+		id:     indicatorID("bb", src, period, int(math.Round(k*10))),
+		feed:   barFeed{src: src},
+		window: newWindow(period),
+		k:      k,
+	}
+}
+
+// GetOrCreateBollinger は DataPool 上の共有ボリンジャーバンドを取得します。
+// IDは σ 倍率を10倍した整数で表します（例: period=20, k=2.0 -> "bb_20_20@bar_5m"）
+func GetOrCreateBollinger(pool tick.DataPool, symbol string, src tick.BarSource, period int, k float64) *Bollinger {
+	return getOrCreate(pool, symbol, indicatorID("bb", src, period, int(math.Round(k*10))), func() *Bollinger {
+		return NewBollinger(src, period, k)
+	})
+}
+
+func (i *Bollinger) ID() string { return i.id }
+
+func (i *Bollinger) Update(_ tick.Tick) {
+	i.feed.drain(func(bar tick.Bar) {
+		i.window.push(bar.Close)
+	})
+}
+
+func (i *Bollinger) Dependencies() []tick.Indicator {
+	return []tick.Indicator{i.feed.src}
+}
+
+// Ready は期間分の確定足が揃っているかを返します
+func (i *Bollinger) Ready() bool { return i.window.full() }
+
+// Middle は中心線（単純移動平均）を返します
+func (i *Bollinger) Middle() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.window.mean()
+}
+
+// StdDev は期間内の終値の母標準偏差を返します
+func (i *Bollinger) StdDev() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	mean := i.window.mean()
+	var sq float64
+	for _, v := range i.window.values {
+		sq += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sq / float64(len(i.window.values)))
+}
+
+// Upper は上部バンド（中心線 + kσ）を返します
+func (i *Bollinger) Upper() float64 {
+	return i.Middle() + i.k*i.StdDev()
+}
+
+// Lower は下部バンド（中心線 - kσ）を返します
+func (i *Bollinger) Lower() float64 {
+	return i.Middle() - i.k*i.StdDev()
+}
+
+// PercentB は最新の確定足終値のバンド内位置（下部=0, 上部=1）を返します
+func (i *Bollinger) PercentB() float64 {
+	width := i.Upper() - i.Lower()
+	if !i.Ready() || width == 0 {
+		return 0
+	}
+	last := i.window.values[len(i.window.values)-1]
+	return (last - i.Lower()) / width
+}
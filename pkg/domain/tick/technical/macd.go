@@ -0,0 +1,73 @@
+package technical
+
+import "github.com/r-umemoto/trading-bot/pkg/domain/tick"
+
+// MACD は短期EMAと長期EMAの差（MACD線）と、そのEMA（シグナル線）を計算します。
+type MACD struct {
+	id     string
+	feed   barFeed
+	fast   *ema
+	slow   *ema
+	signal *ema
+	line   float64
+}
+
+// NewMACD は MACD を作成します（一般的な設定は fast=12, slow=26, signal=9）
+func NewMACD(src tick.BarSource, fast, slow, signal int) *MACD {
+	return &MACD{
+		id:     indicatorID("macd", src, fast, slow, signal),
+		feed:   barFeed{src: src},
+		fast:   newEMA(fast),
+		slow:   newEMA(slow),
+		signal: newEMA(signal),
+	}
+}
+
+// GetOrCreateMACD は DataPool 上の共有 MACD を取得します
+func GetOrCreateMACD(pool tick.DataPool, symbol string, src tick.BarSource, fast, slow, signal int) *MACD {
+	return getOrCreate(pool, symbol, indicatorID("macd", src, fast, slow, signal), func() *MACD {
+		return NewMACD(src, fast, slow, signal)
+	})
+}
+
+func (i *MACD) ID() string { return i.id }
+
+func (i *MACD) Update(_ tick.Tick) {
+	i.feed.drain(func(bar tick.Bar) {
+		i.fast.push(bar.Close)
+		i.slow.push(bar.Close)
+		if !i.fast.ready || !i.slow.ready {
+			return
+		}
+		i.line = i.fast.value - i.slow.value
+		i.signal.push(i.line)
+	})
+}
+
+func (i *MACD) Dependencies() []tick.Indicator {
+	return []tick.Indicator{i.feed.src}
+}
+
+// Ready はシグナル線まで計算可能かを返します
+func (i *MACD) Ready() bool { return i.signal.ready }
+
+// MACD は MACD線（短期EMA - 長期EMA）を返します
+func (i *MACD) MACD() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.line
+}
+
+// Signal はシグナル線を返します
+func (i *MACD) Signal() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.signal.value
+}
+
+// Histogram は MACD線 - シグナル線 を返します
+func (i *MACD) Histogram() float64 {
+	return i.MACD() - i.Signal()
+}
@@ -0,0 +1,95 @@
+package technical
+
+import "github.com/r-umemoto/trading-bot/pkg/domain/tick"
+
+// SMA は確定足の終値の単純移動平均です。
+type SMA struct {
+	id     string
+	feed   barFeed
+	window *window
+}
+
+// NewSMA は period 本の単純移動平均を作成します
+func NewSMA(src tick.BarSource, period int) *SMA {
+	return &SMA{
+		id:     indicatorID("sma", src, period),
+		feed:   barFeed{src: src},
+		window: newWindow(period),
+	}
+}
+
+// GetOrCreateSMA は DataPool 上の共有 SMA を取得します
+func GetOrCreateSMA(pool tick.DataPool, symbol string, src tick.BarSource, period int) *SMA {
+	return getOrCreate(pool, symbol, indicatorID("sma", src, period), func() *SMA {
+		return NewSMA(src, period)
+	})
+}
+
+func (i *SMA) ID() string { return i.id }
+
+func (i *SMA) Update(_ tick.Tick) {
+	i.feed.drain(func(bar tick.Bar) {
+		i.window.push(bar.Close)
+	})
+}
+
+func (i *SMA) Dependencies() []tick.Indicator {
+	return []tick.Indicator{i.feed.src}
+}
+
+// Ready は期間分の確定足が揃っているかを返します
+func (i *SMA) Ready() bool { return i.window.full() }
+
+// Value は現在の移動平均値を返します（Ready でない場合は 0）
+func (i *SMA) Value() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.window.mean()
+}
+
+// EMA は確定足の終値の指数移動平均です。
+type EMA struct {
+	id   string
+	feed barFeed
+	calc *ema
+}
+
+// NewEMA は period 本の指数移動平均を作成します
+func NewEMA(src tick.BarSource, period int) *EMA {
+	return &EMA{
+		id:   indicatorID("ema", src, period),
+		feed: barFeed{src: src},
+		calc: newEMA(period),
+	}
+}
+
+// GetOrCreateEMA は DataPool 上の共有 EMA を取得します
+func GetOrCreateEMA(pool tick.DataPool, symbol string, src tick.BarSource, period int) *EMA {
+	return getOrCreate(pool, symbol, indicatorID("ema", src, period), func() *EMA {
+		return NewEMA(src, period)
+	})
+}
+
+func (i *EMA) ID() string { return i.id }
+
+func (i *EMA) Update(_ tick.Tick) {
+	i.feed.drain(func(bar tick.Bar) {
+		i.calc.push(bar.Close)
+	})
+}
+
+func (i *EMA) Dependencies() []tick.Indicator {
+	return []tick.Indicator{i.feed.src}
+}
+
+// Ready は初期値の計算に必要な確定足が揃っているかを返します
+func (i *EMA) Ready() bool { return i.calc.ready }
+
+// Value は現在の指数移動平均値を返します（Ready でない場合は 0）
+func (i *EMA) Value() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.calc.value
+}
@@ -0,0 +1,75 @@
+package technical
+
+import "github.com/r-umemoto/trading-bot/pkg/domain/tick"
+
+// RSI は Wilder の相対力指数です。値幅の平滑化には Wilder の平滑化を用います。
+type RSI struct {
+	id        string
+	feed      barFeed
+	avgGain   wilder
+	avgLoss   wilder
+	prevClose float64
+	hasPrev   bool
+}
+
+// NewRSI は period 本の RSI を作成します
+func NewRSI(src tick.BarSource, period int) *RSI {
+	return &RSI{
+		id:      indicatorID("rsi", src, period),
+		feed:    barFeed{src: src},
+		avgGain: wilder{period: period},
+		avgLoss: wilder{period: period},
+	}
+}
+
+// GetOrCreateRSI は DataPool 上の共有 RSI を取得します
+func GetOrCreateRSI(pool tick.DataPool, symbol string, src tick.BarSource, period int) *RSI {
+	return getOrCreate(pool, symbol, indicatorID("rsi", src, period), func() *RSI {
+		return NewRSI(src, period)
+	})
+}
+
+func (i *RSI) ID() string { return i.id }
+
+func (i *RSI) Update(_ tick.Tick) {
+	i.feed.drain(func(bar tick.Bar) {
+		if !i.hasPrev {
+			i.prevClose = bar.Close
+			i.hasPrev = true
+			return
+		}
+		change := bar.Close - i.prevClose
+		i.prevClose = bar.Close
+
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		i.avgGain.push(gain)
+		i.avgLoss.push(loss)
+	})
+}
+
+func (i *RSI) Dependencies() []tick.Indicator {
+	return []tick.Indicator{i.feed.src}
+}
+
+// Ready は period+1 本の確定足が揃っているかを返します
+func (i *RSI) Ready() bool { return i.avgGain.ready }
+
+// Value は 0〜100 の RSI を返します（Ready でない場合は 0）
+func (i *RSI) Value() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	if i.avgLoss.value == 0 {
+		if i.avgGain.value == 0 {
+			return 50
+		}
+		return 100
+	}
+	rs := i.avgGain.value / i.avgLoss.value
+	return 100 - 100/(1+rs)
+}
@@ -0,0 +1,74 @@
+package technical
+
+import "github.com/r-umemoto/trading-bot/pkg/domain/tick"
+
+// Stochastics はストキャスティクス（%K, %D）です。
+// %K は直近 kPeriod 本の高値・安値レンジに対する終値の位置、%D は %K の dPeriod 本単純移動平均です。
+type Stochastics struct {
+	id    string
+	feed  barFeed
+	highs *window
+	lows  *window
+	ks    *window
+	k     float64
+}
+
+// NewStochastics はストキャスティクスを作成します（一般的な設定は kPeriod=14, dPeriod=3）
+func NewStochastics(src tick.BarSource, kPeriod, dPeriod int) *Stochastics {
+	return &Stochastics{
+		id:    indicatorID("stoch", src, kPeriod, dPeriod),
+		feed:  barFeed{src: src},
+		highs: newWindow(kPeriod),
+		lows:  newWindow(kPeriod),
+		ks:    newWindow(dPeriod),
+	}
+}
+
+// GetOrCreateStochastics は DataPool 上の共有ストキャスティクスを取得します
+func GetOrCreateStochastics(pool tick.DataPool, symbol string, src tick.BarSource, kPeriod, dPeriod int) *Stochastics {
+	return getOrCreate(pool, symbol, indicatorID("stoch", src, kPeriod, dPeriod), func() *Stochastics {
+		return NewStochastics(src, kPeriod, dPeriod)
+	})
+}
+
+func (i *Stochastics) ID() string { return i.id }
+
+func (i *Stochastics) Update(_ tick.Tick) {
+	i.feed.drain(func(bar tick.Bar) {
+		i.highs.push(bar.High)
+		i.lows.push(bar.Low)
+		if !i.highs.full() {
+			return
+		}
+		hh, ll := i.highs.max(), i.lows.min()
+		if hh == ll {
+			i.k = 50
+		} else {
+			i.k = (bar.Close - ll) / (hh - ll) * 100
+		}
+		i.ks.push(i.k)
+	})
+}
+
+func (i *Stochastics) Dependencies() []tick.Indicator {
+	return []tick.Indicator{i.feed.src}
+}
+
+// Ready は %D まで計算可能かを返します
+func (i *Stochastics) Ready() bool { return i.ks.full() }
+
+// K は %K（0〜100）を返します
+func (i *Stochastics) K() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.k
+}
+
+// D は %D（%K の移動平均）を返します
+func (i *Stochastics) D() float64 {
+	if !i.Ready() {
+		return 0
+	}
+	return i.ks.mean()
+}
@@ -0,0 +1,148 @@
+// Package technical は tick.BarSource の確定足を入力とする標準的なテクニカル指標群を提供します。
+// 各指標は tick.Indicator を実装し、Dependencies() で入力元のバーを宣言するため、
+// DataPool 上ではバーの更新後に必ず指標が更新されます。
+package technical
+
+import (
+	"fmt"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// indicatorID は "<name>_<params>@<source>" 形式の指標IDを組み立てます（例: "sma_20@bar_5m"）
+func indicatorID(name string, src tick.BarSource, params ...int) string {
+	id := name
+	for _, p := range params {
+		id += fmt.Sprintf("_%d", p)
+	}
+	return id + "@" + src.ID()
+}
+
+// getOrCreate は DataPool 上で指標を共有インスタンスとして取得します
+func getOrCreate[T tick.Indicator](pool tick.DataPool, symbol, id string, factory func() T) T {
+	return pool.GetOrCreateIndicator(symbol, id, func() tick.Indicator {
+		return factory()
+	}).(T)
+}
+
+// barFeed は BarSource の確定足を未処理のものから順に取り出すカーソルです。
+// 指標が後から登録された場合でも、既に確定しているバーから遡って取り込みます。
+type barFeed struct {
+	src  tick.BarSource
+	seen int
+}
+
+// drain は未処理の確定足を古い順に fn へ渡します。
+// 保持上限を超えて破棄された確定足は取り込めないため、保持している最も古い確定足から始めます。
+func (f *barFeed) drain(fn func(bar tick.Bar)) {
+	n := f.src.ClosedCount()
+	if oldest := f.src.OldestClosedIndex(); f.seen < oldest {
+		f.seen = oldest
+	}
+	for ; f.seen < n; f.seen++ {
+		fn(f.src.ClosedBar(f.seen))
+	}
+}
+
+// window は直近 size 個の値を保持する固定長ウィンドウです
+type window struct {
+	values []float64
+	size   int
+	sum    float64
+}
+
+func newWindow(size int) *window {
+	return &window{values: make([]float64, 0, size), size: size}
+}
+
+// push は値を追加し、ウィンドウから溢れた値を取り除きます
+func (w *window) push(v float64) {
+	if len(w.values) == w.size {
+		w.sum -= w.values[0]
+		w.values = append(w.values[:0], w.values[1:]...)
+	}
+	w.values = append(w.values, v)
+	w.sum += v
+}
+
+func (w *window) full() bool {
+	return len(w.values) == w.size
+}
+
+func (w *window) mean() float64 {
+	if len(w.values) == 0 {
+		return 0
+	}
+	return w.sum / float64(len(w.values))
+}
+
+func (w *window) max() float64 {
+	m := w.values[0]
+	for _, v := range w.values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+func (w *window) min() float64 {
+	m := w.values[0]
+	for _, v := range w.values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// ema は指数移動平均の計算器です。最初の period 個の単純平均を初期値とします（TA-Lib 互換）。
+type ema struct {
+	period int
+	alpha  float64
+	seed   *window
+	value  float64
+	ready  bool
+}
+
+func newEMA(period int) *ema {
+	return &ema{
+		period: period,
+		alpha:  2.0 / float64(period+1),
+		seed:   newWindow(period),
+	}
+}
+
+func (e *ema) push(v float64) {
+	if e.ready {
+		e.value += e.alpha * (v - e.value)
+		return
+	}
+	e.seed.push(v)
+	if e.seed.full() {
+		e.value = e.seed.mean()
+		e.ready = true
+	}
+}
+
+// wilder は Wilder の平滑化（RMA）の計算器です。最初の period 個の単純平均を初期値とします。
+type wilder struct {
+	period int
+	sum    float64
+	count  int
+	value  float64
+	ready  bool
+}
+
+func (w *wilder) push(v float64) {
+	if w.ready {
+		w.value = (w.value*float64(w.period-1) + v) / float64(w.period)
+		return
+	}
+	w.sum += v
+	w.count++
+	if w.count == w.period {
+		w.value = w.sum / float64(w.period)
+		w.ready = true
+	}
+}
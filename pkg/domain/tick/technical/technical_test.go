@@ -0,0 +1,218 @@
+package technical_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/technical"
+)
+
+// fakeBars はテスト用に確定足を直接積み上げられる BarSource です
+type fakeBars struct {
+	bars []tick.Bar
+}
+
+var _ tick.BarSource = (*fakeBars)(nil)
+
+func (f *fakeBars) ID() string                     { return "fake_bar" }
+func (f *fakeBars) Update(_ tick.Tick)             {}
+func (f *fakeBars) Dependencies() []tick.Indicator { return nil }
+func (f *fakeBars) Bars() []tick.Bar               { return append([]tick.Bar(nil), f.bars...) }
+func (f *fakeBars) Len() int                       { return len(f.bars) }
+func (f *fakeBars) At(index int) tick.Bar          { return f.bars[index] }
+func (f *fakeBars) Last(n int) tick.Bar            { return f.bars[len(f.bars)-1-n] }
+func (f *fakeBars) EnsureRetention(_ int)          {}
+func (f *fakeBars) ClosedCount() int               { return len(f.bars) }
+func (f *fakeBars) OldestClosedIndex() int         { return 0 }
+func (f *fakeBars) ClosedBar(index int) tick.Bar   { return f.bars[index] }
+
+func (f *fakeBars) addClose(c float64) {
+	f.bars = append(f.bars, tick.Bar{Open: c, High: c, Low: c, Close: c})
+}
+
+func assertNear(t *testing.T, name string, got, want, tol float64) {
+	t.Helper()
+	if math.Abs(got-want) > tol {
+		t.Errorf("%s: expected %.4f, got %.4f", name, want, got)
+	}
+}
+
+// StockCharts の SMA/EMA 解説で使われている10日間の終値サンプル
+var emaCloses = []float64{
+	22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29,
+	22.15, 22.39, 22.38, 22.61, 23.36, 24.05, 23.75, 23.83, 23.95, 23.63,
+	23.82, 23.87, 23.65, 23.19, 23.10, 22.65, 22.48, 23.23, 22.84,
+}
+
+// StockCharts の RSI 解説で使われている終値サンプル
+var rsiCloses = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+	46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57,
+	43.42, 42.66, 43.13,
+}
+
+// OHLC を必要とする指標（MACD/BB/ATR/Stoch/ADX）用の5本のフィクスチャ。期待値は下のテストのコメントで手計算しています
+var ohlcFixture = []tick.Bar{
+	{Open: 10, High: 12, Low: 9, Close: 10},
+	{Open: 12, High: 13, Low: 11, Close: 12},
+	{Open: 11, High: 12, Low: 10, Close: 11},
+	{Open: 15, High: 16, Low: 12, Close: 15},
+	{Open: 14, High: 15, Low: 13, Close: 14},
+}
+
+func ohlcBars() *fakeBars {
+	return &fakeBars{bars: append([]tick.Bar(nil), ohlcFixture...)}
+}
+
+func TestSMAAndEMA_ReferenceValues(t *testing.T) {
+	src := &fakeBars{}
+	sma := technical.NewSMA(src, 10)
+	ema := technical.NewEMA(src, 10)
+
+	wantSMA := []float64{22.22, 22.21, 22.23, 22.26, 22.30, 22.42, 22.61, 22.77, 22.91, 23.08, 23.21, 23.38, 23.52, 23.65, 23.71, 23.68, 23.54, 23.42, 23.36, 23.25}
+	wantEMA := []float64{22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28, 23.34, 23.43, 23.51, 23.53, 23.47, 23.40, 23.27, 23.12, 23.14, 23.09}
+
+	for n, c := range emaCloses {
+		src.addClose(c)
+		sma.Update(tick.Tick{})
+		ema.Update(tick.Tick{})
+
+		if n < 9 {
+			if sma.Ready() || ema.Ready() {
+				t.Fatalf("expected not ready at bar %d", n)
+			}
+			continue
+		}
+		assertNear(t, "SMA", sma.Value(), wantSMA[n-9], 0.006)
+		assertNear(t, "EMA", ema.Value(), wantEMA[n-9], 0.006)
+	}
+
+	if sma.ID() != "sma_10@fake_bar" || ema.ID() != "ema_10@fake_bar" {
+		t.Errorf("unexpected ids: %s, %s", sma.ID(), ema.ID())
+	}
+}
+
+func TestRSI_ReferenceValues(t *testing.T) {
+	src := &fakeBars{}
+	rsi := technical.NewRSI(src, 14)
+
+	want := []float64{70.46, 66.25, 66.48, 69.35, 66.29, 57.92, 62.88, 63.21, 56.01, 62.34, 54.67, 50.39, 40.02, 41.49, 41.90, 45.50, 37.32, 33.09, 37.79}
+	for n, c := range rsiCloses {
+		src.addClose(c)
+		rsi.Update(tick.Tick{})
+		if n < 14 {
+			if rsi.Ready() {
+				t.Fatalf("expected not ready at bar %d", n)
+			}
+			continue
+		}
+		assertNear(t, "RSI", rsi.Value(), want[n-14], 0.006)
+	}
+}
+
+func TestOHLCIndicators_ReferenceValues(t *testing.T) {
+	src := ohlcBars()
+
+	macd := technical.NewMACD(src, 2, 3, 2)
+	bb := technical.NewBollinger(src, 4, 2)
+	atr := technical.NewATR(src, 3)
+	stoch := technical.NewStochastics(src, 3, 2)
+	adx := technical.NewADX(src, 2)
+
+	// 全ての確定足を一度に取り込む（後から登録された指標の遡及計算）
+	for _, ind := range []tick.Indicator{macd, bb, atr, stoch, adx} {
+		ind.Update(tick.Tick{})
+	}
+
+	// 終値 10, 12, 11, 15, 14
+	// EMA(2) (α=2/3): 初期値 (10+12)/2 = 11 → 11 → 41/3 → 41/3 + 2/3×(14-41/3) = 125/9
+	// EMA(3) (α=1/2): 初期値 (10+12+11)/3 = 11 → 13 → 13.5
+	// MACD線: 0 → 2/3 → 125/9 - 13.5 = 7/18
+	// シグナル EMA(2): 初期値 (0+2/3)/2 = 1/3 → 1/3 + 2/3×(7/18-1/3) = 10/27
+	assertNear(t, "MACD", macd.MACD(), 7.0/18, 1e-9)
+	assertNear(t, "MACD signal", macd.Signal(), 10.0/27, 1e-9)
+	assertNear(t, "MACD histogram", macd.Histogram(), 1.0/54, 1e-9)
+
+	// 直近4本 12, 11, 15, 14: 平均 13、偏差² 1+4+4+1 = 10、母分散 2.5
+	sigma := math.Sqrt(2.5)
+	assertNear(t, "BB middle", bb.Middle(), 13, 1e-9)
+	assertNear(t, "BB upper", bb.Upper(), 13+2*sigma, 1e-9)
+	assertNear(t, "BB lower", bb.Lower(), 13-2*sigma, 1e-9)
+	assertNear(t, "BB %B", bb.PercentB(), 0.5+1/(4*sigma), 1e-9) // (14-13)/(4σ) + 0.5
+
+	// 真の値幅: 3 (初回は H-L), 3 (|13-10|), 2, 5 (|16-11|), 2
+	// ATR(3): 初期値 (3+3+2)/3 = 8/3 → (8/3×2+5)/3 = 31/9 → (31/9×2+2)/3 = 80/27
+	assertNear(t, "ATR", atr.Value(), 80.0/27, 1e-9)
+
+	// %K(3): 3本目 (11-9)/(13-9) = 50, 4本目 (15-10)/(16-10) = 83.33, 5本目 (14-10)/(16-10) = 66.67
+	assertNear(t, "Stoch %K", stoch.K(), 200.0/3, 1e-9)
+	assertNear(t, "Stoch %D", stoch.D(), 75, 1e-9) // (83.33+66.67)/2
+
+	// +DM/-DM/TR: 2本目 1/0/3, 3本目 0/1/2, 4本目 4/0/5, 5本目 0/0/2
+	// 3本目: 初回の合計 TR 5, +DM 1, -DM 1 → +DI = -DI = 20, DX 0
+	// 4本目: TR 5-2.5+5 = 7.5, +DM 1-0.5+4 = 4.5, -DM 0.5 → +DI 60, -DI 20/3, DX 80 → ADX (0+80)/2 = 40
+	// 5本目: TR 7.5-3.75+2 = 5.75, +DM 2.25, -DM 0.25 → +DI 900/23, -DI 100/23, DX 80 → ADX (40+80)/2 = 60
+	assertNear(t, "ADX", adx.Value(), 60, 1e-9)
+	assertNear(t, "+DI", adx.PlusDI(), 900.0/23, 1e-9)
+	assertNear(t, "-DI", adx.MinusDI(), 100.0/23, 1e-9)
+}
+
+func TestIndicators_NotReadyReturnZero(t *testing.T) {
+	src := &fakeBars{}
+	src.addClose(100)
+
+	macd := technical.NewMACD(src, 12, 26, 9)
+	adx := technical.NewADX(src, 14)
+	macd.Update(tick.Tick{})
+	adx.Update(tick.Tick{})
+
+	if macd.Ready() || macd.MACD() != 0 || macd.Signal() != 0 {
+		t.Errorf("expected MACD to be not ready")
+	}
+	if adx.Ready() || adx.Value() != 0 {
+		t.Errorf("expected ADX to be not ready")
+	}
+}
+
+func TestIndicators_SharedThroughDataPool(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	symbol := "7203"
+
+	// 1Tick = 1本で確定する Tick 足を入力にする
+	bars := tick.GetOrCreateBarIndicator(pool, symbol, tick.TickBar(1))
+	sma := technical.GetOrCreateSMA(pool, symbol, bars, 3)
+	if again := technical.GetOrCreateSMA(pool, symbol, bars, 3); again != sma {
+		t.Errorf("expected the same SMA instance")
+	}
+	if sma.ID() != "sma_3@bar_1t" {
+		t.Errorf("unexpected id: %s", sma.ID())
+	}
+
+	base := time.Date(2026, 4, 19, 9, 0, 0, 0, time.UTC)
+	for n, p := range []float64{100, 101, 102, 106} {
+		pool.PushTick(tick.Tick{Symbol: symbol, Price: p, CurrentPriceTime: base.Add(time.Duration(n) * time.Second)})
+	}
+
+	// バーが指標より先に更新されるため、同じTickで確定した足が即座に反映される
+	assertNear(t, "SMA via pool", sma.Value(), 103, 1e-9)
+}
+
+func TestIndicators_SkipBarsEvictedFromRetention(t *testing.T) {
+	// 保持上限を超えて破棄された確定足は読み飛ばし、保持している最も古い確定足から取り込む
+	src := tick.NewOneMinBarIndicator("1min_bar")
+	src.SetRetention(3)
+	base := time.Date(2026, 4, 20, 9, 0, 0, 0, time.UTC)
+	for k := 1; k <= 6; k++ {
+		src.Update(tick.Tick{Price: float64(k * 10), TradingVolume: float64(k * 100), CurrentPriceTime: base.Add(time.Duration(k) * time.Minute)})
+	}
+
+	sma := technical.NewSMA(src, 3)
+	sma.Update(tick.Tick{})
+	if !sma.Ready() {
+		t.Fatalf("expected SMA to be ready from the retained bars")
+	}
+	assertNear(t, "sma of retained bars", sma.Value(), 40, 1e-9) // (30+40+50)/3
+}
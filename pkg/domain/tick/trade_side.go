@@ -0,0 +1,204 @@
+package tick
+
+import "encoding/json"
+
+// TradeSide は約定がどちらの主導で発生したか（買い手主導・売り手主導）を表します
+type TradeSide int
+
+const (
+	TRADE_SIDE_UNKNOWN TradeSide = 0 // 判定不能
+	TRADE_SIDE_BUY     TradeSide = 1 // 買い手主導（売り気配への成行買いなど）
+	TRADE_SIDE_SELL    TradeSide = 2 // 売り手主導（買い気配への成行売りなど）
+)
+
+func (s TradeSide) String() string {
+	switch s {
+	case TRADE_SIDE_BUY:
+		return "BUY"
+	case TRADE_SIDE_SELL:
+		return "SELL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// tradeClassifier は Lee-Ready 法による約定主体の判定器です。
+//  1. クォートルール: 約定直前の気配の仲値より上なら買い、下なら売り
+//  2. ティックルール: 仲値と同値なら直前の約定価格と比較（同値の場合は直前の判定を引き継ぐ）
+//  3. 現値前値比較: それでも決まらない場合は CurrentPriceChangeStatus (UP/DOWN) を用いる
+//
+// 約定後に配信される板は既に約定で更新されているため、クォートルールには1つ前の Tick の気配を用います。
+type tradeClassifier struct {
+	prevAsk  FirstQuote
+	prevBid  FirstQuote
+	hasQuote bool
+
+	lastPrice float64
+	lastSide  TradeSide
+}
+
+// classify は Tick とその出来高差分から約定主体を判定します。
+// 気配の状態は約定の有無に関わらず毎 Tick 更新するため、全 Tick で呼び出す必要があります。
+func (c *tradeClassifier) classify(t Tick, volume float64) TradeSide {
+	side := TRADE_SIDE_UNKNOWN
+	if volume > 0 && t.Price > 0 {
+		side = c.quoteRule(t.Price)
+		if side == TRADE_SIDE_UNKNOWN {
+			side = c.tickRule(t.Price)
+		}
+		if side == TRADE_SIDE_UNKNOWN {
+			side = changeStatusRule(t.CurrentPriceChangeStatus)
+		}
+		c.lastSide = side
+	}
+
+	if t.Price > 0 && (volume > 0 || c.lastPrice == 0) {
+		c.lastPrice = t.Price
+	}
+	if t.BestAsk.Price > 0 && t.BestBid.Price > 0 && t.BestAsk.Price > t.BestBid.Price {
+		c.prevAsk, c.prevBid = t.BestAsk, t.BestBid
+		c.hasQuote = true
+	}
+	return side
+}
+
+func (c *tradeClassifier) quoteRule(price float64) TradeSide {
+	if !c.hasQuote {
+		return TRADE_SIDE_UNKNOWN
+	}
+	mid := (c.prevAsk.Price + c.prevBid.Price) / 2
+	switch {
+	case price > mid:
+		return TRADE_SIDE_BUY
+	case price < mid:
+		return TRADE_SIDE_SELL
+	}
+	return TRADE_SIDE_UNKNOWN
+}
+
+func (c *tradeClassifier) tickRule(price float64) TradeSide {
+	if c.lastPrice == 0 {
+		return TRADE_SIDE_UNKNOWN
+	}
+	switch {
+	case price > c.lastPrice:
+		return TRADE_SIDE_BUY
+	case price < c.lastPrice:
+		return TRADE_SIDE_SELL
+	}
+	// ゼロティックは直前の判定（ゼロプラスティック／ゼロマイナスティック）を引き継ぐ
+	return c.lastSide
+}
+
+func changeStatusRule(status PriceChangeStatus) TradeSide {
+	switch status {
+	case PRICE_CHANGE_UP:
+		return TRADE_SIDE_BUY
+	case PRICE_CHANGE_DOWN:
+		return TRADE_SIDE_SELL
+	}
+	return TRADE_SIDE_UNKNOWN
+}
+
+// splitVolume は出来高を判定結果に応じて買い・売りに振り分けます
+func splitVolume(volume float64, side TradeSide) (buy, sell float64) {
+	switch side {
+	case TRADE_SIDE_BUY:
+		return volume, 0
+	case TRADE_SIDE_SELL:
+		return 0, volume
+	}
+	return 0, 0
+}
+
+var _ Snapshotter = (*TradeClassifier)(nil)
+
+// TradeClassifier は Tick 毎の出来高差分を買い手主導・売り手主導に分類し、
+// 累積の買い出来高・売り出来高と CVD（累積出来高デルタ）を公開するインジケーターです。
+type TradeClassifier struct {
+	volume     volumeDelta
+	classifier tradeClassifier
+
+	lastSide   TradeSide
+	lastVolume float64
+	buyVolume  float64
+	sellVolume float64
+}
+
+const TradeClassifierID = "trade_classifier"
+
+// NewTradeClassifier は新しい TradeClassifier を作成します
+func NewTradeClassifier() *TradeClassifier {
+	return &TradeClassifier{}
+}
+
+// GetOrCreateTradeClassifier は DataPool 上の共有インスタンスを取得します
+func GetOrCreateTradeClassifier(pool DataPool, symbol string) *TradeClassifier {
+	return pool.GetOrCreateIndicator(symbol, TradeClassifierID, func() Indicator {
+		return NewTradeClassifier()
+	}).(*TradeClassifier)
+}
+
+func (i *TradeClassifier) ID() string {
+	return TradeClassifierID
+}
+
+// Update は Tick の出来高差分を分類して累積値を更新します
+func (i *TradeClassifier) Update(tick Tick) {
+	if tick.Price <= 0 {
+		return // 価格が無効な場合は処理しない
+	}
+	vol := i.volume.next(tick.TradingVolume)
+	side := i.classifier.classify(tick, vol)
+
+	i.lastVolume = vol
+	i.lastSide = side
+	buy, sell := splitVolume(vol, side)
+	i.buyVolume += buy
+	i.sellVolume += sell
+}
+
+func (i *TradeClassifier) Dependencies() []Indicator {
+	return nil
+}
+
+// LastSide は直近 Tick の約定主体を返します（約定が無い Tick では UNKNOWN）
+func (i *TradeClassifier) LastSide() TradeSide { return i.lastSide }
+
+// LastVolume は直近 Tick の出来高差分を返します
+func (i *TradeClassifier) LastVolume() float64 { return i.lastVolume }
+
+// BuyVolume は買い手主導と判定された累積出来高を返します
+func (i *TradeClassifier) BuyVolume() float64 { return i.buyVolume }
+
+// SellVolume は売り手主導と判定された累積出来高を返します
+func (i *TradeClassifier) SellVolume() float64 { return i.sellVolume }
+
+// CVD は累積出来高デルタ（買い出来高 - 売り出来高）を返します
+func (i *TradeClassifier) CVD() float64 { return i.buyVolume - i.sellVolume }
+
+// tradeClassifierState はスナップショット用の TradeClassifier の状態です
+type tradeClassifierState struct {
+	BuyVolume  float64 `json:"buy_volume"`
+	SellVolume float64 `json:"sell_volume"`
+}
+
+// SnapshotVersion はスナップショットの状態フォーマットのバージョンです。
+func (i *TradeClassifier) SnapshotVersion() int {
+	return 1
+}
+
+// Snapshot は累積の買い・売り出来高をシリアライズします。
+func (i *TradeClassifier) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(tradeClassifierState{BuyVolume: i.buyVolume, SellVolume: i.sellVolume})
+}
+
+// Restore はスナップショットから累積の買い・売り出来高を復元します。
+func (i *TradeClassifier) Restore(state json.RawMessage) error {
+	var st tradeClassifierState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	i.buyVolume, i.sellVolume = st.BuyVolume, st.SellVolume
+	return nil
+}
@@ -0,0 +1,105 @@
+package tick
+
+import (
+	"testing"
+	"time"
+)
+
+func quoteTick(price, cumVol, ask, bid float64) Tick {
+	return Tick{
+		Symbol:        "7203",
+		Price:         price,
+		TradingVolume: cumVol,
+		BestAsk:       FirstQuote{Price: ask, Qty: 100},
+		BestBid:       FirstQuote{Price: bid, Qty: 100},
+	}
+}
+
+func TestTradeClassifier_LeeReady(t *testing.T) {
+	ind := NewTradeClassifier()
+
+	// 初回: 出来高差分ゼロ、気配 101/100 を記憶
+	ind.Update(quoteTick(100, 1000, 101, 100))
+	if ind.LastSide() != TRADE_SIDE_UNKNOWN {
+		t.Errorf("expected UNKNOWN for the first tick, got %v", ind.LastSide())
+	}
+
+	// 直前の売り気配(101)で約定 -> 買い。約定後の板は 102/101 に切り上がっている
+	ind.Update(quoteTick(101, 1200, 102, 101))
+	if ind.LastSide() != TRADE_SIDE_BUY {
+		t.Errorf("expected BUY by quote rule, got %v", ind.LastSide())
+	}
+
+	// 直前の買い気配(101)で約定 -> 売り
+	ind.Update(quoteTick(101, 1500, 102, 101))
+	if ind.LastSide() != TRADE_SIDE_SELL {
+		t.Errorf("expected SELL by quote rule, got %v", ind.LastSide())
+	}
+
+	if ind.BuyVolume() != 200 || ind.SellVolume() != 300 {
+		t.Errorf("expected buy 200 / sell 300, got %f / %f", ind.BuyVolume(), ind.SellVolume())
+	}
+	if ind.CVD() != -100 {
+		t.Errorf("expected CVD -100, got %f", ind.CVD())
+	}
+
+	// 出来高の増加が無い Tick は分類しない
+	ind.Update(quoteTick(101, 1500, 102, 101))
+	if ind.LastSide() != TRADE_SIDE_UNKNOWN || ind.LastVolume() != 0 {
+		t.Errorf("expected no classification without volume, got %v / %f", ind.LastSide(), ind.LastVolume())
+	}
+}
+
+func TestTradeClassifier_Fallbacks(t *testing.T) {
+	// 気配が仲値ちょうど（1呼値スプレッドが広い）の場合はティックルール
+	ind := NewTradeClassifier()
+	ind.Update(quoteTick(100, 1000, 102, 100))
+	ind.Update(quoteTick(101, 1100, 102, 100)) // 仲値=101、前回約定100より上 -> BUY
+	if ind.LastSide() != TRADE_SIDE_BUY {
+		t.Errorf("expected BUY by tick rule, got %v", ind.LastSide())
+	}
+	ind.Update(quoteTick(101, 1200, 102, 100)) // ゼロティック -> 直前の判定を引き継ぐ
+	if ind.LastSide() != TRADE_SIDE_BUY {
+		t.Errorf("expected BUY by zero-uptick, got %v", ind.LastSide())
+	}
+
+	// 気配も前回約定も無い場合は現値前値比較
+	side := (&tradeClassifier{}).classify(Tick{Price: 100, CurrentPriceChangeStatus: PRICE_CHANGE_DOWN}, 100)
+	if side != TRADE_SIDE_SELL {
+		t.Errorf("expected SELL by change status, got %v", side)
+	}
+	side = (&tradeClassifier{}).classify(Tick{Price: 100}, 100)
+	if side != TRADE_SIDE_UNKNOWN {
+		t.Errorf("expected UNKNOWN without any hint, got %v", side)
+	}
+}
+
+func TestBars_BuySellVolume(t *testing.T) {
+	ind := NewBarIndicator(TimeBar(time.Minute))
+	oneMin := NewOneMinBarIndicator("1min_bar")
+	base := time.Date(2026, 4, 19, 9, 0, 0, 0, time.UTC)
+
+	ticks := []Tick{
+		quoteTick(100, 1000, 101, 100),
+		quoteTick(101, 1200, 102, 101), // BUY 200
+		quoteTick(101, 1500, 102, 101), // SELL 300
+		quoteTick(103, 1600, 104, 103), // BUY 100 (次の足)
+	}
+	for n := range ticks {
+		ticks[n].CurrentPriceTime = base.Add(time.Duration(n*20) * time.Second)
+		ind.Update(ticks[n])
+		oneMin.Update(ticks[n])
+	}
+
+	for _, bars := range [][]Bar{ind.Bars(), oneMin.Bars()} {
+		if len(bars) != 2 {
+			t.Fatalf("expected 2 bars, got %d", len(bars))
+		}
+		if bars[0].BuyVolume != 200 || bars[0].SellVolume != 300 || bars[0].Delta() != -100 {
+			t.Errorf("unexpected flow for bar 1: %+v", bars[0])
+		}
+		if bars[1].BuyVolume != 100 || bars[1].SellVolume != 0 {
+			t.Errorf("unexpected flow for bar 2: %+v", bars[1])
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package tick
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ValidationIssue は Tick の品質検証で検出される問題の種類です
+type ValidationIssue string
+
+const (
+	VALIDATION_ISSUE_OUT_OF_ORDER      ValidationIssue = "OUT_OF_ORDER"      // 現値時刻が前回より過去に戻った
+	VALIDATION_ISSUE_VOLUME_REGRESSION ValidationIssue = "VOLUME_REGRESSION" // 累積出来高が前回より減少した
+	VALIDATION_ISSUE_ZERO_PRICE        ValidationIssue = "ZERO_PRICE"        // 約定済みのセッションで現値が0以下になった
+	VALIDATION_ISSUE_DUPLICATE         ValidationIssue = "DUPLICATE"         // 前回と同一の Tick が再送された
+)
+
+// ValidationAction は問題を検出した Tick の扱いです
+type ValidationAction string
+
+const (
+	VALIDATION_ACTION_ACCEPT     ValidationAction = "ACCEPT"     // 記録のみ行い、そのまま通す
+	VALIDATION_ACTION_REPAIR     ValidationAction = "REPAIR"     // 直前の正常値で補正して通す
+	VALIDATION_ACTION_QUARANTINE ValidationAction = "QUARANTINE" // 隔離し、DataPool・戦略へは流さない
+)
+
+// ValidationRules は問題の種類ごとの扱いです
+type ValidationRules struct {
+	OutOfOrder       ValidationAction
+	VolumeRegression ValidationAction
+	ZeroPrice        ValidationAction
+	// Duplicate は補正できないため、REPAIR は QUARANTINE と同じく破棄として扱います
+	Duplicate ValidationAction
+}
+
+// DefaultValidationRules は既定の検証ルールです。
+// 出来高の減少は直前の累積出来高で補正し、それ以外の問題は隔離します。
+func DefaultValidationRules() ValidationRules {
+	return ValidationRules{
+		OutOfOrder:       VALIDATION_ACTION_QUARANTINE,
+		VolumeRegression: VALIDATION_ACTION_REPAIR,
+		ZeroPrice:        VALIDATION_ACTION_QUARANTINE,
+		Duplicate:        VALIDATION_ACTION_QUARANTINE,
+	}
+}
+
+// Validate は未知の扱いが指定されていないかを検証します（未指定は既定の扱いになります）
+func (r ValidationRules) Validate() error {
+	for issue, action := range r.actions() {
+		switch action {
+		case "", VALIDATION_ACTION_ACCEPT, VALIDATION_ACTION_REPAIR, VALIDATION_ACTION_QUARANTINE:
+		default:
+			return fmt.Errorf("invalid validation action for %s: %q", issue, action)
+		}
+	}
+	return nil
+}
+
+// withDefaults は未指定の扱いを既定のルールで補います
+func (r ValidationRules) withDefaults() ValidationRules {
+	d := DefaultValidationRules()
+	for _, f := range []struct {
+		field *ValidationAction
+		def   ValidationAction
+	}{
+		{&r.OutOfOrder, d.OutOfOrder},
+		{&r.VolumeRegression, d.VolumeRegression},
+		{&r.ZeroPrice, d.ZeroPrice},
+		{&r.Duplicate, d.Duplicate},
+	} {
+		if *f.field == "" {
+			*f.field = f.def
+		}
+	}
+	return r
+}
+
+func (r ValidationRules) actions() map[ValidationIssue]ValidationAction {
+	return map[ValidationIssue]ValidationAction{
+		VALIDATION_ISSUE_OUT_OF_ORDER:      r.OutOfOrder,
+		VALIDATION_ISSUE_VOLUME_REGRESSION: r.VolumeRegression,
+		VALIDATION_ISSUE_ZERO_PRICE:        r.ZeroPrice,
+		VALIDATION_ISSUE_DUPLICATE:         r.Duplicate,
+	}
+}
+
+// ValidationStats は銘柄ごとの検証結果の集計です
+type ValidationStats struct {
+	Accepted    int                     // DataPool へ流した Tick 数（補正済みを含む）
+	Repaired    int                     // 補正して流した Tick 数
+	Quarantined int                     // 隔離した Tick 数
+	Issues      map[ValidationIssue]int // 問題の種類ごとの検出数
+}
+
+// QuarantinedTick は隔離された Tick と、その理由です
+type QuarantinedTick struct {
+	Tick  Tick
+	Issue ValidationIssue
+}
+
+// quarantineCapacity は銘柄ごとに保持する隔離 Tick の上限です（古いものから破棄）
+const quarantineCapacity = 100
+
+// warnEvery は同じ銘柄・同じ問題の警告ログを間引く間隔です（初回と warnEvery 件ごとに出力）
+const warnEvery = 100
+
+// Validator は MarketGateway やバックテストのフィーダーと DataPool の間に置く Tick の品質検証ステージです。
+// 銘柄ごとに直前の正常な Tick を保持し、時刻の逆行・累積出来高の減少・現値の欠落・重複を検出して、
+// ルールに従って補正または隔離します。複数のゴルーチンから呼び出せます。
+type Validator struct {
+	rules ValidationRules
+
+	mu      sync.Mutex
+	symbols map[string]*symbolValidation
+}
+
+// symbolValidation は銘柄ごとの検証状態です
+type symbolValidation struct {
+	day         time.Time // 現在のセッション（Tick 時刻の日付）
+	last        Tick      // 直前に通した Tick
+	hasLast     bool
+	lastPrice   float64 // セッション内で最後に観測した有効な現値
+	stats       ValidationStats
+	quarantined []QuarantinedTick
+}
+
+// NewValidator は検証ルールを指定して Validator を作成します。未指定の扱いは既定のルールで補います
+func NewValidator(rules ValidationRules) (*Validator, error) {
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return &Validator{
+		rules:   rules.withDefaults(),
+		symbols: make(map[string]*symbolValidation),
+	}, nil
+}
+
+// Validate は Tick を検証し、DataPool へ流す Tick（補正済み）と、流してよいかを返します。
+// 隔離された場合は false を返し、直前の正常な Tick は更新しません。
+func (v *Validator) Validate(t Tick) (Tick, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	st := v.symbols[t.Symbol]
+	if st == nil {
+		st = &symbolValidation{stats: ValidationStats{Issues: make(map[ValidationIssue]int)}}
+		v.symbols[t.Symbol] = st
+	}
+
+	// 日付が変わった最初の Tick で前セッションの基準をリセットする（累積出来高は翌日0から始まるため）
+	if !t.CurrentPriceTime.IsZero() {
+		local := t.CurrentPriceTime.In(time.Local)
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+		if day.After(st.day) {
+			if !st.day.IsZero() {
+				st.hasLast, st.last, st.lastPrice = false, Tick{}, 0
+			}
+			st.day = day
+		}
+	}
+
+	repaired := false
+	for _, check := range []struct {
+		issue  ValidationIssue
+		action ValidationAction
+		found  bool
+		repair func()
+	}{
+		{
+			issue:  VALIDATION_ISSUE_DUPLICATE,
+			action: v.rules.Duplicate,
+			found:  st.hasLast && sameTick(st.last, t),
+		},
+		{
+			issue:  VALIDATION_ISSUE_ZERO_PRICE,
+			action: v.rules.ZeroPrice,
+			found:  t.Price <= 0 && st.lastPrice > 0,
+			repair: func() {
+				t.Price = st.lastPrice
+				if t.CurrentPriceTime.IsZero() {
+					t.CurrentPriceTime = st.last.CurrentPriceTime
+				}
+			},
+		},
+		{
+			issue:  VALIDATION_ISSUE_OUT_OF_ORDER,
+			action: v.rules.OutOfOrder,
+			found:  st.hasLast && !t.CurrentPriceTime.IsZero() && t.CurrentPriceTime.Before(st.last.CurrentPriceTime),
+			repair: func() { t.CurrentPriceTime = st.last.CurrentPriceTime },
+		},
+		{
+			issue:  VALIDATION_ISSUE_VOLUME_REGRESSION,
+			action: v.rules.VolumeRegression,
+			found:  st.hasLast && t.TradingVolume < st.last.TradingVolume,
+			repair: func() { t.TradingVolume = st.last.TradingVolume },
+		},
+	} {
+		if !check.found {
+			continue
+		}
+		st.stats.Issues[check.issue]++
+		v.warn(t, check.issue, check.action, st.stats.Issues[check.issue])
+
+		switch {
+		case check.action == VALIDATION_ACTION_ACCEPT:
+		case check.action == VALIDATION_ACTION_REPAIR && check.repair != nil:
+			check.repair()
+			repaired = true
+		default:
+			st.stats.Quarantined++
+			st.quarantine(QuarantinedTick{Tick: t, Issue: check.issue})
+			return t, false
+		}
+	}
+
+	st.stats.Accepted++
+	if repaired {
+		st.stats.Repaired++
+	}
+	st.last, st.hasLast = t, true
+	if t.Price > 0 {
+		st.lastPrice = t.Price
+	}
+	return t, true
+}
+
+func (v *Validator) warn(t Tick, issue ValidationIssue, action ValidationAction, count int) {
+	// 壊れたフィードでログが溢れないよう、初回以降は warnEvery 件ごとに出力する
+	if count != 1 && count%warnEvery != 0 {
+		return
+	}
+	slog.Warn("⚠️ [TICK] 不正なTickを検出しました",
+		slog.String("symbol", t.Symbol),
+		slog.String("issue", string(issue)),
+		slog.String("action", string(action)),
+		slog.Int("count", count),
+		slog.Float64("price", t.Price),
+		slog.Float64("volume", t.TradingVolume),
+		slog.Time("price_time", t.CurrentPriceTime),
+	)
+}
+
+func (s *symbolValidation) quarantine(q QuarantinedTick) {
+	if len(s.quarantined) >= quarantineCapacity {
+		s.quarantined = append(s.quarantined[:0], s.quarantined[1:]...)
+	}
+	s.quarantined = append(s.quarantined, q)
+}
+
+// sameTick は2つの Tick が同一の内容かを判定します（重複配信の検出用）
+func sameTick(a, b Tick) bool {
+	return a.Symbol == b.Symbol &&
+		a.Price == b.Price &&
+		a.VWAP == b.VWAP &&
+		a.TradingVolume == b.TradingVolume &&
+		a.CurrentPriceTime.Equal(b.CurrentPriceTime) &&
+		a.BestAsk.Price == b.BestAsk.Price && a.BestAsk.Qty == b.BestAsk.Qty && a.BestAsk.Time.Equal(b.BestAsk.Time) &&
+		a.BestBid.Price == b.BestBid.Price && a.BestBid.Qty == b.BestBid.Qty && a.BestBid.Time.Equal(b.BestBid.Time) &&
+		slices.Equal(a.SellBoard, b.SellBoard) &&
+		slices.Equal(a.BuyBoard, b.BuyBoard) &&
+		a.CurrentPriceStatus == b.CurrentPriceStatus &&
+		a.CurrentPriceChangeStatus == b.CurrentPriceChangeStatus &&
+		a.OpeningPrice == b.OpeningPrice &&
+		a.TradingValue == b.TradingValue &&
+		a.MarketOrderSellQty == b.MarketOrderSellQty &&
+		a.MarketOrderBuyQty == b.MarketOrderBuyQty &&
+		a.OverSellQty == b.OverSellQty &&
+		a.UnderBuyQty == b.UnderBuyQty
+}
+
+// Stats は銘柄の検証結果の集計を返します
+func (v *Validator) Stats(symbol string) ValidationStats {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	st, ok := v.symbols[symbol]
+	if !ok {
+		return ValidationStats{Issues: map[ValidationIssue]int{}}
+	}
+	stats := st.stats
+	stats.Issues = make(map[ValidationIssue]int, len(st.stats.Issues))
+	for k, n := range st.stats.Issues {
+		stats.Issues[k] = n
+	}
+	return stats
+}
+
+// Quarantined は銘柄の直近の隔離 Tick を古い順に返します（最大 100 件）
+func (v *Validator) Quarantined(symbol string) []QuarantinedTick {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	st, ok := v.symbols[symbol]
+	if !ok {
+		return nil
+	}
+	return append([]QuarantinedTick(nil), st.quarantined...)
+}
+
+// Symbols は検証した銘柄コードを昇順で返します
+func (v *Validator) Symbols() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	symbols := make([]string, 0, len(v.symbols))
+	for s := range v.symbols {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// LogSummary は問題を検出した銘柄ごとの集計をログへ出力します
+func (v *Validator) LogSummary() {
+	for _, symbol := range v.Symbols() {
+		stats := v.Stats(symbol)
+		if stats.Repaired == 0 && stats.Quarantined == 0 && len(stats.Issues) == 0 {
+			continue
+		}
+		attrs := []any{
+			slog.String("symbol", symbol),
+			slog.Int("accepted", stats.Accepted),
+			slog.Int("repaired", stats.Repaired),
+			slog.Int("quarantined", stats.Quarantined),
+		}
+		for _, issue := range []ValidationIssue{VALIDATION_ISSUE_OUT_OF_ORDER, VALIDATION_ISSUE_VOLUME_REGRESSION, VALIDATION_ISSUE_ZERO_PRICE, VALIDATION_ISSUE_DUPLICATE} {
+			if n := stats.Issues[issue]; n > 0 {
+				attrs = append(attrs, slog.Int(string(issue), n))
+			}
+		}
+		slog.Warn("📊 [TICK] Tick品質検証の集計", attrs...)
+	}
+}
@@ -0,0 +1,156 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+var validationBase = time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+
+func trade(symbol string, offset time.Duration, price, volume float64) tick.Tick {
+	return tick.Tick{Symbol: symbol, Price: price, TradingVolume: volume, CurrentPriceTime: validationBase.Add(offset)}
+}
+
+func newValidator(t *testing.T, rules tick.ValidationRules) *tick.Validator {
+	t.Helper()
+	v, err := tick.NewValidator(rules)
+	if err != nil {
+		t.Fatalf("NewValidator failed: %v", err)
+	}
+	return v
+}
+
+func TestValidator_DefaultRules(t *testing.T) {
+	v := newValidator(t, tick.DefaultValidationRules())
+
+	steps := []struct {
+		name       string
+		in         tick.Tick
+		wantOK     bool
+		wantVolume float64
+	}{
+		{"first tick", trade("7203", 0, 100, 1000), true, 1000},
+		{"normal", trade("7203", time.Second, 101, 1100), true, 1100},
+		{"duplicate", trade("7203", time.Second, 101, 1100), false, 0},
+		{"out of order", trade("7203", 0, 102, 1200), false, 0},
+		{"zero price after trades", trade("7203", 2*time.Second, 0, 1200), false, 0},
+		{"volume regression is repaired", trade("7203", 3*time.Second, 103, 900), true, 1100},
+		{"next session resets the baseline", trade("7203", 24*time.Hour, 110, 50), true, 50},
+	}
+	for _, step := range steps {
+		got, ok := v.Validate(step.in)
+		if ok != step.wantOK {
+			t.Fatalf("%s: expected ok=%v, got %v", step.name, step.wantOK, ok)
+		}
+		if ok && got.TradingVolume != step.wantVolume {
+			t.Errorf("%s: expected volume %.0f, got %.0f", step.name, step.wantVolume, got.TradingVolume)
+		}
+	}
+
+	stats := v.Stats("7203")
+	if stats.Accepted != 4 || stats.Repaired != 1 || stats.Quarantined != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	for _, issue := range []tick.ValidationIssue{
+		tick.VALIDATION_ISSUE_DUPLICATE,
+		tick.VALIDATION_ISSUE_OUT_OF_ORDER,
+		tick.VALIDATION_ISSUE_ZERO_PRICE,
+		tick.VALIDATION_ISSUE_VOLUME_REGRESSION,
+	} {
+		if stats.Issues[issue] != 1 {
+			t.Errorf("expected one %s, got %d", issue, stats.Issues[issue])
+		}
+	}
+
+	quarantined := v.Quarantined("7203")
+	if len(quarantined) != 3 || quarantined[0].Issue != tick.VALIDATION_ISSUE_DUPLICATE || quarantined[2].Issue != tick.VALIDATION_ISSUE_ZERO_PRICE {
+		t.Errorf("unexpected quarantine: %+v", quarantined)
+	}
+}
+
+func TestValidator_ZeroPriceBeforeFirstTradeIsNormal(t *testing.T) {
+	v := newValidator(t, tick.DefaultValidationRules())
+
+	// 寄付前は現値が無いのが正常なため、板情報だけの Tick は通す
+	preOpen := tick.Tick{Symbol: "7203", BestAsk: tick.FirstQuote{Price: 101, Qty: 100}}
+	if _, ok := v.Validate(preOpen); !ok {
+		t.Errorf("expected a pre-open board tick to pass")
+	}
+	if stats := v.Stats("7203"); len(stats.Issues) != 0 {
+		t.Errorf("expected no issues, got %+v", stats.Issues)
+	}
+}
+
+func TestValidator_RepairAndAcceptRules(t *testing.T) {
+	v := newValidator(t, tick.ValidationRules{
+		OutOfOrder:       tick.VALIDATION_ACTION_REPAIR,
+		VolumeRegression: tick.VALIDATION_ACTION_ACCEPT,
+		ZeroPrice:        tick.VALIDATION_ACTION_REPAIR,
+		Duplicate:        tick.VALIDATION_ACTION_ACCEPT,
+	})
+
+	v.Validate(trade("7203", 10*time.Second, 100, 1000))
+
+	got, ok := v.Validate(trade("7203", 5*time.Second, 101, 1100))
+	if !ok || !got.CurrentPriceTime.Equal(validationBase.Add(10*time.Second)) {
+		t.Errorf("expected the time to be clamped, got ok=%v time=%v", ok, got.CurrentPriceTime)
+	}
+
+	got, ok = v.Validate(tick.Tick{Symbol: "7203", TradingVolume: 1100})
+	if !ok || got.Price != 101 || !got.CurrentPriceTime.Equal(validationBase.Add(10*time.Second)) {
+		t.Errorf("expected the last price to be carried forward, got %+v", got)
+	}
+
+	got, ok = v.Validate(trade("7203", 11*time.Second, 102, 900))
+	if !ok || got.TradingVolume != 900 {
+		t.Errorf("expected the regression to be accepted as is, got ok=%v volume=%.0f", ok, got.TradingVolume)
+	}
+
+	if _, ok := v.Validate(got); !ok {
+		t.Errorf("expected the duplicate to be accepted")
+	}
+
+	stats := v.Stats("7203")
+	if stats.Quarantined != 0 || stats.Repaired != 2 || stats.Accepted != 5 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestValidator_CountersArePerSymbol(t *testing.T) {
+	v := newValidator(t, tick.DefaultValidationRules())
+
+	v.Validate(trade("7203", time.Second, 100, 1000))
+	v.Validate(trade("7203", 0, 100, 1000)) // 7203 だけ逆行
+	if _, ok := v.Validate(trade("6758", 0, 3000, 500)); !ok {
+		t.Errorf("another symbol must not be affected by 7203's history")
+	}
+
+	if v.Stats("7203").Quarantined != 1 || v.Stats("6758").Quarantined != 0 {
+		t.Errorf("unexpected per-symbol counters: %+v / %+v", v.Stats("7203"), v.Stats("6758"))
+	}
+	if got := v.Symbols(); len(got) != 2 || got[0] != "6758" || got[1] != "7203" {
+		t.Errorf("unexpected symbols: %v", got)
+	}
+}
+
+func TestNewValidator_EmptyActionsFallBackToDefaults(t *testing.T) {
+	v := newValidator(t, tick.ValidationRules{VolumeRegression: tick.VALIDATION_ACTION_QUARANTINE})
+
+	v.Validate(trade("7203", time.Second, 100, 1000))
+	if _, ok := v.Validate(trade("7203", 0, 100, 1000)); ok {
+		t.Errorf("expected the default rule to quarantine an out-of-order tick")
+	}
+	if _, ok := v.Validate(trade("7203", 2*time.Second, 100, 900)); ok {
+		t.Errorf("expected the configured rule to quarantine a volume regression")
+	}
+}
+
+func TestNewValidator_RejectsUnknownAction(t *testing.T) {
+	rules := tick.DefaultValidationRules()
+	rules.ZeroPrice = "DROP"
+	if _, err := tick.NewValidator(rules); err == nil {
+		t.Errorf("expected an error for an unknown action")
+	}
+}
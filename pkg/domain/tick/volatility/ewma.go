@@ -0,0 +1,105 @@
+package volatility
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+var _ tick.Snapshotter = (*EWMASigma)(nil)
+
+// EWMASigma は時間減衰型の出来高加重平均価格とσです。
+// 各約定の重み（出来高）を半減期 halfLife で指数的に減衰させるため、直近の値動きほど強く反映されます。
+// セッションが変わった最初の Tick でリセットされます。
+type EWMASigma struct {
+	id       string
+	halfLife time.Duration
+
+	clock    sessionClock
+	volume   sessionVolume
+	moments  weightedMoments
+	lastTime time.Time
+}
+
+// NewEWMASigma は半減期 halfLife の EWMASigma を作成します
+func NewEWMASigma(halfLife time.Duration) *EWMASigma {
+	return &EWMASigma{
+		id:       ewmaSigmaID(halfLife),
+		halfLife: halfLife,
+	}
+}
+
+func ewmaSigmaID(halfLife time.Duration) string {
+	return "ewma_sigma_" + durationLabel(halfLife)
+}
+
+// GetOrCreateEWMASigma は DataPool 上の共有インスタンスを取得します
+func GetOrCreateEWMASigma(pool tick.DataPool, symbol string, halfLife time.Duration) *EWMASigma {
+	return getOrCreate(pool, symbol, ewmaSigmaID(halfLife), func() *EWMASigma {
+		return NewEWMASigma(halfLife)
+	})
+}
+
+func (i *EWMASigma) ID() string { return i.id }
+
+func (i *EWMASigma) Update(t tick.Tick) {
+	if t.Price <= 0 {
+		return
+	}
+	newSession := i.clock.advance(t.CurrentPriceTime)
+	if newSession {
+		i.moments = weightedMoments{}
+		i.lastTime = time.Time{}
+	}
+	v := i.volume.next(t.TradingVolume, newSession)
+	if v <= 0 {
+		return
+	}
+
+	// 前回の約定からの経過時間に応じて過去の重みを減衰させる
+	if !i.lastTime.IsZero() {
+		if dt := t.CurrentPriceTime.Sub(i.lastTime); dt > 0 {
+			i.moments.decay(math.Exp(-math.Ln2 * float64(dt) / float64(i.halfLife)))
+		}
+	}
+	i.moments.add(t.Price, v)
+	i.lastTime = t.CurrentPriceTime
+}
+
+func (i *EWMASigma) Dependencies() []tick.Indicator { return nil }
+
+// Ready はセッション内で約定（出来高）を1件以上観測したかを返します
+func (i *EWMASigma) Ready() bool { return i.moments.Weight > 0 }
+
+// Mean は時間減衰型の出来高加重平均価格を返します（未約定の場合は0）
+func (i *EWMASigma) Mean() float64 { return i.moments.mean() }
+
+// Sigma は時間減衰型の出来高加重標準偏差を返します
+func (i *EWMASigma) Sigma() float64 { return i.moments.sigma() }
+
+type ewmaSigmaState struct {
+	Clock    sessionClock    `json:"clock"`
+	Moments  weightedMoments `json:"moments"`
+	LastTime time.Time       `json:"last_time"`
+}
+
+// SnapshotVersion はスナップショットの状態フォーマットのバージョンです。
+func (i *EWMASigma) SnapshotVersion() int { return 1 }
+
+// Snapshot は減衰済みの累積値をシリアライズします。
+func (i *EWMASigma) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(ewmaSigmaState{Clock: i.clock, Moments: i.moments, LastTime: i.lastTime})
+}
+
+// Restore はスナップショットから累積値を復元します。
+// 出来高の基準値は復元しないため、再起動後の最初の Tick の出来高差分はゼロとして扱われます。
+func (i *EWMASigma) Restore(state json.RawMessage) error {
+	var st ewmaSigmaState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	i.clock, i.moments, i.lastTime = st.Clock, st.Moments, st.LastTime
+	return nil
+}
@@ -0,0 +1,148 @@
+package volatility
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+var _ tick.Snapshotter = (*RealizedVolatility)(nil)
+
+// RealizedVolatility は直近 window 時間の Tick 間対数リターンから算出する実現ボラティリティ √Σr² です。
+// 期間は Tick の時刻で判定し、セッションをまたぐリターン（前日終値からの窓）は含めません。
+type RealizedVolatility struct {
+	id     string
+	window time.Duration
+
+	clock        sessionClock
+	sessionStart time.Time
+	prevPrice    float64
+	returns      []timedReturn
+	head         int
+	sum          float64
+}
+
+// timedReturn は1 Tick 分の二乗対数リターンです
+type timedReturn struct {
+	Time    time.Time `json:"time"`
+	Squared float64   `json:"squared"`
+}
+
+// NewRealizedVolatility は直近 window 時間の実現ボラティリティを作成します
+func NewRealizedVolatility(window time.Duration) *RealizedVolatility {
+	return &RealizedVolatility{
+		id:     realizedVolatilityID(window),
+		window: window,
+	}
+}
+
+func realizedVolatilityID(window time.Duration) string {
+	return "realized_vol_" + durationLabel(window)
+}
+
+// GetOrCreateRealizedVolatility は DataPool 上の共有インスタンスを取得します
+func GetOrCreateRealizedVolatility(pool tick.DataPool, symbol string, window time.Duration) *RealizedVolatility {
+	return getOrCreate(pool, symbol, realizedVolatilityID(window), func() *RealizedVolatility {
+		return NewRealizedVolatility(window)
+	})
+}
+
+func (i *RealizedVolatility) ID() string { return i.id }
+
+func (i *RealizedVolatility) Update(t tick.Tick) {
+	if t.Price <= 0 {
+		return
+	}
+	now := t.CurrentPriceTime
+	first := !i.clock.Started
+	if i.clock.advance(now) || first {
+		i.reset(now)
+	}
+
+	if i.prevPrice > 0 {
+		r := math.Log(t.Price / i.prevPrice)
+		i.returns = append(i.returns, timedReturn{Time: now, Squared: r * r})
+		i.sum += r * r
+	}
+	i.prevPrice = t.Price
+
+	i.evict(now)
+}
+
+func (i *RealizedVolatility) reset(now time.Time) {
+	i.sessionStart = now
+	i.prevPrice = 0
+	i.returns = i.returns[:0]
+	i.head = 0
+	i.sum = 0
+}
+
+// evict は window より古いリターンを取り除きます
+func (i *RealizedVolatility) evict(now time.Time) {
+	cutoff := now.Add(-i.window)
+	for i.head < len(i.returns) && !i.returns[i.head].Time.After(cutoff) {
+		i.sum -= i.returns[i.head].Squared
+		i.head++
+	}
+	if i.sum < 0 {
+		i.sum = 0
+	}
+	// 取り除いた分が溜まったら詰め直す
+	if i.head > 0 && i.head*2 >= len(i.returns) {
+		i.returns = append(i.returns[:0], i.returns[i.head:]...)
+		i.head = 0
+	}
+}
+
+func (i *RealizedVolatility) Dependencies() []tick.Indicator { return nil }
+
+// Ready はセッション開始から window 時間以上の Tick を観測したかを返します
+func (i *RealizedVolatility) Ready() bool {
+	n := len(i.returns)
+	if n == 0 {
+		return false
+	}
+	return i.returns[n-1].Time.Sub(i.sessionStart) >= i.window
+}
+
+// Value は直近 window 時間の実現ボラティリティ（対数リターン、年率換算なし）を返します
+func (i *RealizedVolatility) Value() float64 { return math.Sqrt(i.sum) }
+
+// Count は直近 window 時間に含まれるリターンの数を返します
+func (i *RealizedVolatility) Count() int { return len(i.returns) - i.head }
+
+type realizedVolatilityState struct {
+	Clock        sessionClock  `json:"clock"`
+	SessionStart time.Time     `json:"session_start"`
+	PrevPrice    float64       `json:"prev_price"`
+	Returns      []timedReturn `json:"returns"`
+}
+
+// SnapshotVersion はスナップショットの状態フォーマットのバージョンです。
+func (i *RealizedVolatility) SnapshotVersion() int { return 1 }
+
+// Snapshot は期間内のリターンと直前の価格をシリアライズします。
+func (i *RealizedVolatility) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(realizedVolatilityState{
+		Clock:        i.clock,
+		SessionStart: i.sessionStart,
+		PrevPrice:    i.prevPrice,
+		Returns:      i.returns[i.head:],
+	})
+}
+
+// Restore はスナップショットから期間内のリターンを復元します。
+func (i *RealizedVolatility) Restore(state json.RawMessage) error {
+	var st realizedVolatilityState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	i.clock, i.sessionStart, i.prevPrice = st.Clock, st.SessionStart, st.PrevPrice
+	i.returns, i.head, i.sum = st.Returns, 0, 0
+	for _, r := range i.returns {
+		i.sum += r.Squared
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package volatility
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// SessionVWAPID は SessionVWAP のインジケーターIDです
+const SessionVWAPID = "session_vwap"
+
+var _ tick.Snapshotter = (*SessionVWAP)(nil)
+
+// SessionVWAP はセッション（取引日）内の出来高加重平均価格と、その周りの出来高加重標準偏差（σ）です。
+// VWAP ± kσ のバンドを提供し、日付が変わった最初の Tick でリセットされます。
+type SessionVWAP struct {
+	clock   sessionClock
+	volume  sessionVolume
+	moments weightedMoments
+}
+
+// NewSessionVWAP は SessionVWAP を作成します
+func NewSessionVWAP() *SessionVWAP {
+	return &SessionVWAP{}
+}
+
+// GetOrCreateSessionVWAP は DataPool 上の共有インスタンスを取得します
+func GetOrCreateSessionVWAP(pool tick.DataPool, symbol string) *SessionVWAP {
+	return getOrCreate(pool, symbol, SessionVWAPID, NewSessionVWAP)
+}
+
+func (i *SessionVWAP) ID() string { return SessionVWAPID }
+
+func (i *SessionVWAP) Update(t tick.Tick) {
+	if t.Price <= 0 {
+		return
+	}
+	newSession := i.clock.advance(t.CurrentPriceTime)
+	if newSession {
+		i.moments = weightedMoments{}
+	}
+	if v := i.volume.next(t.TradingVolume, newSession); v > 0 {
+		i.moments.add(t.Price, v)
+	}
+}
+
+func (i *SessionVWAP) Dependencies() []tick.Indicator { return nil }
+
+// Ready はセッション内で約定（出来高）を1件以上観測したかを返します
+func (i *SessionVWAP) Ready() bool { return i.moments.Weight > 0 }
+
+// VWAP はセッションの出来高加重平均価格を返します（未約定の場合は0）
+func (i *SessionVWAP) VWAP() float64 { return i.moments.mean() }
+
+// Sigma は VWAP の周りの出来高加重標準偏差を返します
+func (i *SessionVWAP) Sigma() float64 { return i.moments.sigma() }
+
+// Upper は VWAP + kσ を返します
+func (i *SessionVWAP) Upper(k float64) float64 { return i.VWAP() + k*i.Sigma() }
+
+// Lower は VWAP - kσ を返します
+func (i *SessionVWAP) Lower(k float64) float64 { return i.VWAP() - k*i.Sigma() }
+
+// ZScore は price が VWAP から何σ離れているかを返します（σが0の場合は0）
+func (i *SessionVWAP) ZScore(price float64) float64 {
+	sigma := i.Sigma()
+	if sigma == 0 {
+		return 0
+	}
+	return (price - i.VWAP()) / sigma
+}
+
+// Volume はセッション内で観測した出来高の合計を返します
+func (i *SessionVWAP) Volume() float64 { return i.moments.Weight }
+
+// SessionDate は現在のセッションの日付を返します
+func (i *SessionVWAP) SessionDate() time.Time { return i.clock.Day }
+
+type sessionVWAPState struct {
+	Clock   sessionClock    `json:"clock"`
+	Moments weightedMoments `json:"moments"`
+}
+
+// SnapshotVersion はスナップショットの状態フォーマットのバージョンです。
+func (i *SessionVWAP) SnapshotVersion() int { return 1 }
+
+// Snapshot はセッションの日付と累積値をシリアライズします。
+func (i *SessionVWAP) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(sessionVWAPState{Clock: i.clock, Moments: i.moments})
+}
+
+// Restore はスナップショットから累積値を復元します。
+// 出来高の基準値は復元しないため、再起動後の最初の Tick の出来高差分はゼロとして扱われます。
+func (i *SessionVWAP) Restore(state json.RawMessage) error {
+	var st sessionVWAPState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	i.clock, i.moments = st.Clock, st.Moments
+	return nil
+}
@@ -0,0 +1,126 @@
+// Package volatility は Tick から算出するボラティリティ系の tick.Indicator 群を提供します。
+// セッションVWAPとσバンド、直近N分の実現ボラティリティ、時間減衰型のσがあり、
+// いずれも Tick の時刻（CurrentPriceTime）で日付が変わった時点をセッションの境界としてリセットします。
+// プロセスの起動時刻には依存しないため、本番とバックテストで同じ Tick 列から同じ値になります。
+package volatility
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// getOrCreate は DataPool 上で指標を共有インスタンスとして取得します
+func getOrCreate[T tick.Indicator](pool tick.DataPool, symbol, id string, factory func() T) T {
+	return pool.GetOrCreateIndicator(symbol, id, func() tick.Indicator {
+		return factory()
+	}).(T)
+}
+
+// durationLabel は指標IDに使う期間の表記を返します（例: 5*time.Minute -> "5m"）
+func durationLabel(d time.Duration) string {
+	for _, u := range []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	} {
+		if d >= u.unit && d%u.unit == 0 {
+			return fmt.Sprintf("%d%s", d/u.unit, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// sessionClock は Tick の時刻から現在のセッション（取引日）を追跡します
+type sessionClock struct {
+	Day     time.Time `json:"day"`
+	Started bool      `json:"started"`
+}
+
+// advance は t を現在のセッションに進め、前回の Tick から日付が変わった場合に true を返します
+func (c *sessionClock) advance(t time.Time) bool {
+	local := t.In(time.Local)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+	if !c.Started {
+		c.Day, c.Started = day, true
+		return false
+	}
+	if !day.After(c.Day) {
+		return false
+	}
+	c.Day = day
+	return true
+}
+
+// sessionVolume は累積出来高から Tick 毎の出来高を算出します。
+// セッション最初の Tick は、プロセスを当日に起動した場合と同じく出来高の基準としてのみ扱います。
+// これにより、前日から動き続けたプロセスと当日起動のプロセス（バックテスト含む）で値が一致します。
+type sessionVolume struct {
+	prev        float64
+	initialized bool
+}
+
+func (v *sessionVolume) next(cumulative float64, newSession bool) float64 {
+	if !v.initialized || newSession {
+		// 初回Tickの出来高は、それ以前の累積すべてを含んでいる可能性があるため差分ゼロとする
+		v.prev, v.initialized = cumulative, true
+		return 0
+	}
+	delta := cumulative - v.prev
+	if delta < 0 {
+		delta = 0
+	}
+	v.prev = cumulative
+	return delta
+}
+
+// weightedMoments は重み付きの平均・標準偏差を O(1) で算出するための累積値です。
+// 桁落ちを避けるため、最初の価格を基準値とした偏差で累積します。
+type weightedMoments struct {
+	Ref    float64 `json:"ref"`
+	Weight float64 `json:"weight"`
+	SumX   float64 `json:"sum_x"`
+	SumXX  float64 `json:"sum_xx"`
+}
+
+func (m *weightedMoments) add(price, weight float64) {
+	if m.Weight == 0 && m.SumX == 0 && m.SumXX == 0 {
+		m.Ref = price
+	}
+	x := price - m.Ref
+	m.Weight += weight
+	m.SumX += weight * x
+	m.SumXX += weight * x * x
+}
+
+// decay は過去の重みを factor 倍に減衰させます
+func (m *weightedMoments) decay(factor float64) {
+	m.Weight *= factor
+	m.SumX *= factor
+	m.SumXX *= factor
+}
+
+func (m *weightedMoments) mean() float64 {
+	if m.Weight == 0 {
+		return 0
+	}
+	return m.Ref + m.SumX/m.Weight
+}
+
+func (m *weightedMoments) sigma() float64 {
+	if m.Weight == 0 {
+		return 0
+	}
+	meanX := m.SumX / m.Weight
+	variance := m.SumXX/m.Weight - meanX*meanX
+	// 浮動小数点計算の誤差によるマイナス値を防止
+	if variance < 0 {
+		variance = 0
+	}
+	return math.Sqrt(variance)
+}
@@ -0,0 +1,182 @@
+package volatility_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/volatility"
+)
+
+func assertNear(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s: expected %.9f, got %.9f", name, want, got)
+	}
+}
+
+var day1 = time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+var day2 = time.Date(2026, 6, 11, 9, 0, 0, 0, time.Local)
+
+func trade(at time.Time, price, cumulative float64) tick.Tick {
+	return tick.Tick{Symbol: "7203", Price: price, TradingVolume: cumulative, CurrentPriceTime: at}
+}
+
+func TestSessionVWAP_BandsAndSessionReset(t *testing.T) {
+	ind := volatility.NewSessionVWAP()
+	if ind.ID() != "session_vwap" {
+		t.Errorf("unexpected id: %s", ind.ID())
+	}
+
+	ind.Update(trade(day1, 100, 1000)) // 初回は出来高の基準のみ
+	if ind.Ready() {
+		t.Fatalf("expected not ready before any traded volume")
+	}
+	ind.Update(trade(day1.Add(time.Second), 100, 1200))   // 200 @ 100
+	ind.Update(trade(day1.Add(2*time.Second), 110, 1400)) // 200 @ 110
+
+	// VWAP = (100*200 + 110*200) / 400 = 105, 分散 = (200*5^2 + 200*5^2) / 400 = 25
+	assertNear(t, "vwap", ind.VWAP(), 105)
+	assertNear(t, "sigma", ind.Sigma(), 5)
+	assertNear(t, "upper", ind.Upper(2), 115)
+	assertNear(t, "lower", ind.Lower(2), 95)
+	assertNear(t, "zscore", ind.ZScore(110), 1)
+
+	// 翌日の最初の Tick で前日分を破棄し、出来高の基準を取り直す
+	ind.Update(trade(day2, 200, 500))
+	if ind.Ready() || ind.Volume() != 0 {
+		t.Errorf("expected reset at the session boundary, got volume %f", ind.Volume())
+	}
+	ind.Update(trade(day2.Add(time.Second), 202, 800))
+	assertNear(t, "vwap after reset", ind.VWAP(), 202)
+	assertNear(t, "volume after reset", ind.Volume(), 300)
+	if !ind.SessionDate().Equal(time.Date(2026, 6, 11, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("unexpected session date: %v", ind.SessionDate())
+	}
+}
+
+func TestRealizedVolatility_RollingWindow(t *testing.T) {
+	ind := volatility.NewRealizedVolatility(time.Minute)
+	if ind.ID() != "realized_vol_1m" {
+		t.Errorf("unexpected id: %s", ind.ID())
+	}
+
+	ind.Update(trade(day1, 100, 0))
+	ind.Update(trade(day1.Add(10*time.Second), 110, 0))
+	ind.Update(trade(day1.Add(30*time.Second), 99, 0))
+	if ind.Ready() {
+		t.Errorf("expected not ready before the window has elapsed")
+	}
+
+	r1, r2 := math.Log(110.0/100), math.Log(99.0/110)
+	assertNear(t, "rv", ind.Value(), math.Sqrt(r1*r1+r2*r2))
+
+	// 10秒時点のリターンは 70秒時点で期間外になる
+	ind.Update(trade(day1.Add(70*time.Second), 99, 0))
+	if !ind.Ready() || ind.Count() != 2 {
+		t.Fatalf("expected ready with 2 returns, got ready=%v count=%d", ind.Ready(), ind.Count())
+	}
+	assertNear(t, "rv after eviction", ind.Value(), math.Abs(r2))
+
+	// セッションをまたぐ窓はリターンに含めない
+	ind.Update(trade(day2, 150, 0))
+	if ind.Count() != 0 || ind.Value() != 0 || ind.Ready() {
+		t.Errorf("expected reset at the session boundary, got count=%d value=%f", ind.Count(), ind.Value())
+	}
+}
+
+func TestEWMASigma_TimeDecay(t *testing.T) {
+	ind := volatility.NewEWMASigma(10 * time.Second)
+	if ind.ID() != "ewma_sigma_10s" {
+		t.Errorf("unexpected id: %s", ind.ID())
+	}
+
+	ind.Update(trade(day1, 100, 1000))
+	ind.Update(trade(day1.Add(time.Second), 100, 1100))    // 重み 100 @ 100
+	ind.Update(trade(day1.Add(11*time.Second), 130, 1150)) // 半減期後: 50 @ 100, 50 @ 130
+
+	assertNear(t, "mean", ind.Mean(), 115)
+	assertNear(t, "sigma", ind.Sigma(), 15)
+
+	// 翌日はリセットされる
+	ind.Update(trade(day2, 200, 10))
+	ind.Update(trade(day2.Add(time.Second), 200, 20))
+	assertNear(t, "mean after reset", ind.Mean(), 200)
+	assertNear(t, "sigma after reset", ind.Sigma(), 0)
+}
+
+func TestVolatility_MatchesRegardlessOfProcessStart(t *testing.T) {
+	// 前日から動き続けたプロセスと、当日に起動したプロセス（バックテスト）で当日の値が一致する
+	continuousVWAP, freshVWAP := volatility.NewSessionVWAP(), volatility.NewSessionVWAP()
+	continuousEWMA, freshEWMA := volatility.NewEWMASigma(time.Minute), volatility.NewEWMASigma(time.Minute)
+	continuousRV, freshRV := volatility.NewRealizedVolatility(time.Minute), volatility.NewRealizedVolatility(time.Minute)
+
+	for _, tk := range []tick.Tick{trade(day1, 500, 100), trade(day1.Add(time.Second), 520, 900)} {
+		continuousVWAP.Update(tk)
+		continuousEWMA.Update(tk)
+		continuousRV.Update(tk)
+	}
+
+	for _, tk := range []tick.Tick{
+		trade(day2, 100, 1000),
+		trade(day2.Add(time.Second), 102, 1200),
+		trade(day2.Add(20*time.Second), 98, 1500),
+		trade(day2.Add(40*time.Second), 101, 1600),
+	} {
+		continuousVWAP.Update(tk)
+		freshVWAP.Update(tk)
+		continuousEWMA.Update(tk)
+		freshEWMA.Update(tk)
+		continuousRV.Update(tk)
+		freshRV.Update(tk)
+	}
+
+	assertNear(t, "session vwap", continuousVWAP.VWAP(), freshVWAP.VWAP())
+	assertNear(t, "session sigma", continuousVWAP.Sigma(), freshVWAP.Sigma())
+	assertNear(t, "ewma sigma", continuousEWMA.Sigma(), freshEWMA.Sigma())
+	assertNear(t, "realized vol", continuousRV.Value(), freshRV.Value())
+}
+
+func TestGetOrCreate_SharesInstances(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+
+	a := volatility.GetOrCreateSessionVWAP(pool, "7203")
+	b := volatility.GetOrCreateSessionVWAP(pool, "7203")
+	rv := volatility.GetOrCreateRealizedVolatility(pool, "7203", 5*time.Minute)
+	ew := volatility.GetOrCreateEWMASigma(pool, "7203", 30*time.Second)
+	if a != b {
+		t.Errorf("expected the same session VWAP instance")
+	}
+	if rv.ID() != "realized_vol_5m" || ew.ID() != "ewma_sigma_30s" {
+		t.Errorf("unexpected ids: %s, %s", rv.ID(), ew.ID())
+	}
+
+	pool.PushTick(trade(day1, 100, 1000))
+	pool.PushTick(trade(day1.Add(time.Second), 101, 1100))
+	if !a.Ready() || !ew.Ready() || rv.Count() != 1 {
+		t.Errorf("expected all indicators to receive ticks through the pool")
+	}
+}
+
+func TestSessionVWAP_SnapshotRoundTrip(t *testing.T) {
+	src := volatility.NewSessionVWAP()
+	src.Update(trade(day1, 100, 1000))
+	src.Update(trade(day1.Add(time.Second), 100, 1100))
+	src.Update(trade(day1.Add(2*time.Second), 110, 1400))
+
+	state, err := src.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	dst := volatility.NewSessionVWAP()
+	if err := dst.Restore(state); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	assertNear(t, "restored vwap", dst.VWAP(), src.VWAP())
+	assertNear(t, "restored sigma", dst.Sigma(), src.Sigma())
+
+	// 同じセッションの Tick ではリセットされない（出来高の基準のみ取り直す）
+	dst.Update(trade(day1.Add(3*time.Second), 120, 2000))
+	assertNear(t, "vwap after restart", dst.VWAP(), 107.5)
+}
@@ -4,7 +4,11 @@ package engine
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+	"github.com/r-umemoto/trading-bot/pkg/portfolio"
 )
 
 // UseCaseHandler はシステムライフサイクルと取引実行を統合的に調整する唯一の窓口となるインターフェースです
@@ -14,9 +18,19 @@ type UseCaseHandler interface {
 	PrintReport(enableCSV bool)
 }
 
+// OperationsReloader は作戦設定の変更を稼働中の作戦へ反映します
+type OperationsReloader interface {
+	Apply(ctx context.Context, targets []portfolio.OperationTarget) error
+}
+
 // Engine はシステム全体のライフサイクル（起動、終了、キルスイッチ監視）を統括するホストコンテナです
 type Engine struct {
 	usecase UseCaseHandler
+
+	// 作戦設定ファイルの監視（reloader が nil の場合は無効）
+	operationsPath   string
+	reloadInterval   time.Duration
+	operationsReload OperationsReloader
 }
 
 func NewEngine(usecase UseCaseHandler) *Engine {
@@ -25,6 +39,13 @@ func NewEngine(usecase UseCaseHandler) *Engine {
 	}
 }
 
+// EnableOperationsReload は稼働中に作戦設定ファイルを interval ごとに確認し、変更を reloader で反映するよう設定します
+func (e *Engine) EnableOperationsReload(path string, interval time.Duration, reloader OperationsReloader) {
+	e.operationsPath = path
+	e.reloadInterval = interval
+	e.operationsReload = reloader
+}
+
 // Run はシステムの起動を行い、時刻監視とメインスレッド待機を開始します
 func (e *Engine) Run(ctx context.Context) error {
 	// 1. バックグラウンドワーカー（ディスパッチャ、WebSocket、ポーリング等）用のコンテキストを準備します。
@@ -41,6 +62,9 @@ func (e *Engine) Run(ctx context.Context) error {
 	killCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	go e.monitorKillSwitch(killCtx, cancel)
+	if e.operationsReload != nil && e.reloadInterval > 0 {
+		go e.watchOperations(killCtx)
+	}
 
 	// 4. メインスレッドの待機（Ctrl+Cによる強制終了、または15:15のキルスイッチによるキャンセルまでここでブロック）
 	fmt.Println("🚀 リアルタイム監視ストリームを監視中...")
@@ -58,17 +82,37 @@ func (e *Engine) Run(ctx context.Context) error {
 	return err
 }
 
-// monitorKillSwitch は取引終了時刻（15:15）を監視し、到達時にコンテキストをキャンセルします
+// watchOperations は作戦設定ファイルの変更を監視し、稼働中の作戦へ反映します。
+// 反映に失敗した場合は稼働中の設定のまま続行します。
+func (e *Engine) watchOperations(ctx context.Context) {
+	slog.Info("👀 作戦設定ファイルの変更監視を開始しました", slog.String("path", e.operationsPath), slog.Duration("interval", e.reloadInterval))
+	portfolio.WatchOperations(ctx, e.operationsPath, e.reloadInterval,
+		func(targets []portfolio.OperationTarget) {
+			slog.Info("🔄 作戦設定ファイルの変更を検知しました", slog.String("path", e.operationsPath))
+			if err := e.operationsReload.Apply(ctx, targets); err != nil {
+				slog.Error("❌ 作戦設定の再読み込みに失敗しました。稼働中の設定のまま続行します", slog.Any("error", err))
+			}
+		},
+		func(err error) {
+			slog.Error("❌ 作戦設定ファイルを読み込めません。稼働中の設定のまま続行します", slog.String("path", e.operationsPath), slog.Any("error", err))
+		},
+	)
+}
+
+// monitorKillSwitch は取引終了時刻（大引けの10分前、半日立会は前場引けの10分前）を監視し、到達時にコンテキストをキャンセルします
 func (e *Engine) monitorKillSwitch(ctx context.Context, cancel context.CancelFunc) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	deadline := killSwitchDeadline(session.Default(), time.Now())
+	slog.Info("⏰ キルスイッチの作動時刻を設定しました", slog.Time("deadline", deadline))
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case t := <-ticker.C:
-			if t.Hour() == 15 && t.Minute() >= 20 {
+			if !t.Before(deadline) {
 				fmt.Println("\n⏰【キルスイッチ作動】指定時刻到達。全スナイパーに撤収を命じます！")
 				cancel()
 				return
@@ -77,6 +121,23 @@ func (e *Engine) monitorKillSwitch(ctx context.Context, cancel context.CancelFun
 	}
 }
 
+// killSwitchLeadTime は取引終了時刻の何分前にキルスイッチを作動させるかです
+const killSwitchLeadTime = 10 * time.Minute
+
+// killSwitchDeadline は now 以降で最初のキルスイッチ作動時刻を返します。
+// 休場日や取引終了後に起動した場合は、次の営業日の作動時刻になります。
+func killSwitchDeadline(market *session.Market, now time.Time) time.Time {
+	if market.IsTradingDay(now) {
+		if deadline := market.Close(now).Add(-killSwitchLeadTime); now.Before(deadline) {
+			return deadline
+		}
+	} else {
+		slog.Warn("📅 本日は休場日です", slog.String("date", now.In(session.Location()).Format("2006-01-02")))
+	}
+	next := market.Calendar().NextTradingDay(now)
+	return market.Close(next).Add(-killSwitchLeadTime)
+}
+
 func (e *Engine) PrintReport(enableCSV bool) {
 	e.usecase.PrintReport(enableCSV)
 }
@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+)
+
+func TestKillSwitchDeadline(t *testing.T) {
+	cal := session.NewCalendar()
+	cal.AddHoliday(time.Date(2026, 7, 20, 0, 0, 0, 0, session.Location()), "海の日")
+	cal.AddHalfDay(time.Date(2026, 12, 30, 0, 0, 0, 0, session.Location()), "")
+	market := session.NewMarket(cal, session.DefaultSchedule())
+	jst := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, session.Location())
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"trading day", jst(6, 10, 8, 0), jst(6, 10, 15, 20)},
+		{"half day", jst(12, 30, 8, 0), jst(12, 30, 11, 20)},
+		{"after the deadline rolls to the next trading day", jst(7, 17, 15, 25), jst(7, 21, 15, 20)},
+		{"holiday", jst(7, 20, 9, 0), jst(7, 21, 15, 20)},
+	}
+	for _, tt := range tests {
+		if got := killSwitchDeadline(market, tt.now); !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
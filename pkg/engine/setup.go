@@ -13,12 +13,18 @@ import (
 	"github.com/r-umemoto/trading-bot/pkg/config"
 	"github.com/r-umemoto/trading-bot/pkg/domain/market"
 	"github.com/r-umemoto/trading-bot/pkg/domain/report"
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/sizing"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/composite"
+	"github.com/r-umemoto/trading-bot/pkg/infra/daily"
+	_ "github.com/r-umemoto/trading-bot/pkg/infra/external" // external 戦略を登録する
 	"github.com/r-umemoto/trading-bot/pkg/infra/kabu"
 	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/api"
+	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/storage"
 	reportinfra "github.com/r-umemoto/trading-bot/pkg/infra/report"
 	"github.com/r-umemoto/trading-bot/pkg/portfolio"
 	"github.com/r-umemoto/trading-bot/pkg/usecase"
@@ -28,6 +34,9 @@ import (
 
 // BuildEngine は、システム全体を俯瞰する「目次」です
 func BuildEngine(ctx context.Context, cfg *config.AppConfig, targets []portfolio.SymbolTarget, opTargets []portfolio.OperationTarget) (*Engine, error) {
+	// 0. 立会カレンダーの読み込み（キルスイッチ・作戦の時間帯判定・時間足の区切りが参照する）
+	loadMarketSession(cfg.CalendarPath)
+
 	// 1. インフラ層の構築（泥臭い設定はすべてここへ）
 	gateway, err := buildInfrastructure(cfg)
 	if err != nil {
@@ -41,6 +50,8 @@ func BuildEngine(ctx context.Context, cfg *config.AppConfig, targets []portfolio
 	}
 
 	// 3. ドメイン層（スナイパー）の配備（DataPoolはGatewayから直接もらう！）
+	// セクター指数は戦略から参照できるよう、スナイパーの配備前に登録しておく
+	composite.RegisterSectorIndices(gateway.DataPool(), portfolio.SectorMembers(targets))
 	snipers, err := deploySnipers(watchList, gateway.DataPool())
 	if err != nil {
 		return nil, fmt.Errorf("スナイパーの配備に失敗: %w", err)
@@ -68,10 +79,57 @@ func BuildEngine(ctx context.Context, cfg *config.AppConfig, targets []portfolio
 
 	tradeUC := usecase.NewTradeUseCase(operations, gateway, reportRepo)
 	systemUC := usecase.NewSystemUseCase(allWatchTargets, operations, gateway)
+	systemUC.EnableIndicatorSnapshot(indicatorSnapshotPath(time.Now()), time.Minute)
+	systemUC.EnableTickWarmUp(storage.NewCSVTickReplayer(recordedTicksPath(time.Now())))
+	systemUC.EnableSessionArchive(daily.NewSessionArchiver(daily.NewStore(daily.DefaultDir), recordedTicksPath(time.Now())))
 	handler := usecase.NewUseCaseHandler(systemUC, tradeUC)
 
 	// 5. エンジンの完成
-	return NewEngine(handler), nil
+	eng := NewEngine(handler)
+	if cfg.OperationsPath != "" && cfg.OperationsReloadInterval > 0 {
+		// 稼働中に追加される作戦も、起動時と同じ手順で銘柄の突合・スナイパーの配備・作戦の構築を行う
+		build := func(ctx context.Context, added []portfolio.OperationTarget) ([]sniper.Operation, error) {
+			watchList, err := buildWatchListFromOperations(ctx, gateway, targets, added)
+			if err != nil {
+				return nil, err
+			}
+			snipers, err := deploySnipers(watchList, gateway.DataPool())
+			if err != nil {
+				return nil, err
+			}
+			return buildOperationsFromConfigs(gateway.DataPool(), snipers, added), nil
+		}
+		eng.EnableOperationsReload(cfg.OperationsPath, cfg.OperationsReloadInterval, usecase.NewReloadUseCase(tradeUC, systemUC, build, opTargets))
+	}
+	return eng, nil
+}
+
+// loadMarketSession は JPX 休場日カレンダーを読み込み、プロセス全体の立会モデルとして設定します。
+// ファイルが無い場合は土日のみを休場とする既定のモデルのまま続行します。
+func loadMarketSession(path string) {
+	if path == "" {
+		path = session.DefaultCalendarPath
+	}
+	market, err := session.LoadMarket(path)
+	if err != nil {
+		slog.Warn("⚠️ 休場日カレンダーを読み込めません。土日のみを休場として扱います", slog.String("path", path), slog.Any("error", err))
+		return
+	}
+	session.SetDefault(market)
+}
+
+// indicatorSnapshotPath は当日の指標スナップショットの保存先を返します。
+// 日付ごとにファイルを分けることで、前日の状態が翌日に復元されることを防ぎます。
+func indicatorSnapshotPath(now time.Time) string {
+	today := now.Format("20060102")
+	return filepath.Join("data", today, "indicators_snapshot.json")
+}
+
+// recordedTicksPath は MarketGateway が当日の全Tickを記録している CSV のパスを返します。
+// 途中再起動時は、このファイルを再生して指標をウォームアップします。
+func recordedTicksPath(now time.Time) string {
+	today := now.Format("20060102")
+	return filepath.Join("data", today, fmt.Sprintf("all_%s.csv", today))
 }
 
 // buildReportRepository は環境変数に応じてFirestoreまたはローカルJSONの成績保存リポジトリを構築します。
@@ -122,12 +180,18 @@ func buildWatchListFromOperations(
 
 	var watchList []symbol.WatchTarget
 
-	for _, op := range opTargets {
+	for i, op := range opTargets {
 		switch op.Type {
 		case "default":
 			symbolCode, _ := op.Params["symbol"].(string)
-			strategiesRaw, _ := op.Params["strategies"].([]interface{})
-			strategyParams, _ := op.Params["strategy_params"].(map[string]interface{})
+			// 戦略パラメータは銘柄の有無に関わらず起動時に検証し、設定ミスを設定ファイル上の位置付きで報告する
+			specs, err := strategy.ResolveStrategies(op.Params, fmt.Sprintf("operations[%d].params", i))
+			if err != nil {
+				return nil, fmt.Errorf("作戦 '%s' の戦略パラメータが不正です: %w", op.ID, err)
+			}
+			if _, err := sizing.Resolve(op.Params, fmt.Sprintf("operations[%d].params", i)); err != nil {
+				return nil, fmt.Errorf("作戦 '%s' のサイジングの設定が不正です: %w", op.ID, err)
+			}
 
 			asset, ok := enabledAssets[symbolCode]
 			if !ok {
@@ -140,22 +204,19 @@ func buildWatchListFromOperations(
 				return nil, err
 			}
 
-			for _, stratRaw := range strategiesRaw {
-				stratName, _ := stratRaw.(string)
-				var params interface{}
-				if strategyParams != nil {
-					params = strategyParams[stratName]
-				}
-
+			for _, spec := range specs {
 				watchList = append(watchList, symbol.WatchTarget{
 					Detail:       detail,
-					StrategyName: stratName,
+					StrategyName: spec.Name,
 					Exchange:     asset.Exchange,
-					Params:       params,
+					Params:       spec.Params,
 				})
 			}
 
 		case "pair_trading":
+			if err := sizing.Forbid(op.Type, op.Params, fmt.Sprintf("operations[%d].params", i)); err != nil {
+				return nil, fmt.Errorf("作戦 '%s' のサイジングの設定が不正です: %w", op.ID, err)
+			}
 			symbolA, _ := op.Params["symbol_a"].(string)
 			symbolB, _ := op.Params["symbol_b"].(string)
 
@@ -219,6 +280,10 @@ func buildOperationsFromConfigs(
 			symSnipers, ok := snipersBySymbol[symbolCode]
 			if ok && len(symSnipers) > 0 {
 				nest := buildNestHelper(symbolCode, symSnipers)
+				// sizing の設定は監視リストの構築時に検証済み
+				if p, _ := sizing.Resolve(op.Params, ""); p != nil {
+					nest.UseSizing(*p, dataPool)
+				}
 				operations = append(operations, sniper.NewDefaultOperation(op.ID, nest))
 				delete(snipersBySymbol, symbolCode)
 			}
@@ -282,6 +347,13 @@ func buildInfrastructure(cfg *config.AppConfig) (market.MarketGateway, error) {
 	// 統合された KabuMarket を生成
 	marketGateway := kabu.NewMarketGateway(client, wsClient)
 
+	// 受信した Tick は設定された検証ルールを通してから DataPool・戦略へ流す
+	validator, err := tick.NewValidator(cfg.TickValidation.Rules())
+	if err != nil {
+		return nil, fmt.Errorf("Tick検証ルールの設定エラー: %w", err)
+	}
+	marketGateway.UseTickValidator(validator)
+
 	return marketGateway, nil
 }
 
@@ -311,7 +383,7 @@ func deploySnipers(watchList []symbol.WatchTarget, dataPool tick.DataPool) ([]*s
 		}
 		analysisLogger := slog.New(slog.NewJSONHandler(f, nil))
 
-		sniperID := fmt.Sprintf("%s_%s", t.StrategyName, t.Detail.Code)
+		sniperID := sniper.SniperID(t.StrategyName, t.Detail.Code)
 		s := sniper.NewSniper(sniperID, t.Detail, st, policy, t.Exchange, analysisLogger)
 		snipers = append(snipers, s)
 	}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/r-umemoto/trading-bot/pkg/config"
@@ -98,3 +99,39 @@ func TestBuildEngine_UnsupportedBroker(t *testing.T) {
 		t.Fatal("expected BuildEngine to fail for unsupported broker")
 	}
 }
+
+func TestBuildEngine_InvalidStrategyParams(t *testing.T) {
+	defer os.RemoveAll("logs")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/token":
+			json.NewEncoder(w).Encode(api.TokenResponse{ResultCode: 0, Token: "dummy-test-token"})
+		case "/symbol/7203@1":
+			json.NewEncoder(w).Encode(api.SymbolSuccess{Symbol: "7203", PriceRangeGroup: "1"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.AppConfig{BrokerType: "kabu", Kabu: api.Config{APIURL: server.URL, Password: "test-pass"}}
+	targets := []portfolio.SymbolTarget{{Symbol: "7203", Exchange: order.EXCHANGE_TOSHO, Enabled: true}}
+	opTargets := []portfolio.OperationTarget{
+		{
+			Type: "default",
+			ID:   "TestOp_7203",
+			Params: map[string]interface{}{
+				"symbol":          "7203",
+				"strategies":      []interface{}{"sample"},
+				"strategy_params": map[string]interface{}{"sample": map[string]interface{}{"rising_bar": 4.0}},
+			},
+		},
+	}
+
+	_, err := engine.BuildEngine(context.Background(), cfg, targets, opTargets)
+	if err == nil || !strings.Contains(err.Error(), "operations[0].params.strategy_params.sample.rising_bar") {
+		t.Fatalf("expected an error with the parameter path, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -16,6 +17,7 @@ import (
 	"github.com/r-umemoto/trading-bot/pkg/domain/position"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/infra/daily"
 )
 
 type ExecutionModel string
@@ -50,14 +52,29 @@ type SyncBacktestGateway struct {
 	cumulativeVolumes map[string]float64
 	cancelRequested   map[string]bool
 
+	// OCO の組（組の識別子 → 注文ID）
+	ocoIdx    int
+	ocoGroups map[string][]string
+
+	// 前回の通知以降に、約定以外で注文の状態が変わったか（即時の取消・OCO の連動取消）
+	pendingReport bool
+
 	// 障害注入用のサイレントキャンセル用マップ
 	simulateCancelSilent map[string]bool
 
 	// 建玉管理
 	positions map[string][]position.Position
 
-	// 前日終値データ
+	// 前日終値データと、そこから計算した銘柄ごとの値幅制限
 	previousCloses map[string]float64
+	priceLimits    map[string]symbol.PriceLimits
+
+	// 銘柄マスタ（売買単位・信用区分の可否・値幅制限）
+	symbolMaster map[string]symbol.Symbol
+
+	// 日足ストアと、その問い合わせの基準日（バックテスト対象日）
+	dailyStore  *daily.Store
+	sessionDate time.Time
 }
 
 func NewSyncBacktestGateway(model ExecutionModel, latency time.Duration) *SyncBacktestGateway {
@@ -75,6 +92,7 @@ func NewSyncBacktestGateway(model ExecutionModel, latency time.Duration) *SyncBa
 		initialDepths:        make(map[string]float64),
 		cumulativeVolumes:    make(map[string]float64),
 		cancelRequested:      make(map[string]bool),
+		ocoGroups:            make(map[string][]string),
 		simulateCancelSilent: make(map[string]bool),
 		positions:            make(map[string][]position.Position),
 		previousCloses:       make(map[string]float64),
@@ -123,11 +141,54 @@ func (g *SyncBacktestGateway) DataPool() tick.DataPool {
 func (g *SyncBacktestGateway) SendOrder(ctx context.Context, input order.SendOrderInput) (*order.Order, error) {
 	ord := input.Order
 
+	// OCO は取引所が両方の注文を受け付けた場合だけ成立させるため、先に相方も検証する
+	if err := g.validateOrder(ord); err != nil {
+		return nil, err
+	}
+	if ord.OCO != nil {
+		if err := g.validateOrder(ord.OCO); err != nil {
+			return nil, err
+		}
+	}
+
+	g.register(ord)
+	if ord.OCO != nil {
+		g.register(ord.OCO)
+		g.ocoIdx++
+		groupID := fmt.Sprintf("bt_oco_%d", g.ocoIdx)
+		ord.OCOGroupID = groupID
+		ord.OCO.OCOGroupID = groupID
+		g.ocoGroups[groupID] = []string{ord.ID, ord.OCO.ID}
+	}
+	return ord, nil
+}
+
+// validateOrder は取引所が受け付けない注文（値幅制限の範囲外・単元未満・建玉不足・両建て）を拒否します
+func (g *SyncBacktestGateway) validateOrder(ord *order.Order) error {
 	// 信用返済または決済順序が指定されている場合は返済注文と判定
 	isExit := ord.CashMargin == order.CASH_MARGIN_MARGIN_EXIT ||
 		(ord.Request != nil && (ord.Request.ClosePositionOrder != order.CLOSE_POSITION_ORDER_NONE ||
 		len(ord.Request.ClosePositions) > 0))
 
+	// 値幅制限の範囲外の指値は取引所で受け付けられないため拒否する
+	if ord.Type != order.ORDER_TYPE_MARKET && ord.OrderPrice > 0 {
+		if limits := g.priceLimitsFor(ord.Symbol); !limits.Contains(ord.OrderPrice) {
+			return fmt.Errorf("バックテスト発注拒否: 発注価格 %.1f が値幅制限（%.1f〜%.1f）の範囲外です: %w",
+				ord.OrderPrice, limits.Lower, limits.Upper, symbol.ErrOutsidePriceLimits)
+		}
+	}
+	if ord.Type.IsStop() {
+		if limits := g.priceLimitsFor(ord.Symbol); ord.TriggerPrice <= 0 || !limits.Contains(ord.TriggerPrice) {
+			return fmt.Errorf("バックテスト発注拒否: 逆指値のトリガー価格 %.1f が不正です（値幅制限 %.1f〜%.1f）: %w",
+				ord.TriggerPrice, limits.Lower, limits.Upper, symbol.ErrOutsidePriceLimits)
+		}
+	}
+
+	// 売買単位の倍数でない数量は取引所で受け付けられないため拒否する
+	if unit := g.symbolMaster[ord.Symbol].TradingUnit; unit > 0 && math.Mod(ord.OrderQty, unit) != 0 {
+		return fmt.Errorf("バックテスト発注拒否: 発注数量 %.0f が売買単位 %.0f の倍数ではありません", ord.OrderQty, unit)
+	}
+
 	if isExit {
 		// 返済注文の場合：口座に反対の建玉が存在するか検証
 		targetAction := order.ACTION_BUY
@@ -144,7 +205,7 @@ func (g *SyncBacktestGateway) SendOrder(ctx context.Context, input order.SendOrd
 
 		if availableQty < ord.OrderQty {
 			// 建玉不足エラー
-			return nil, fmt.Errorf("カブコムAPI発注失敗: 発注失敗: APIエラー (Status: 400): {\"Code\":1009001,\"Message\":\"建玉が選択されていません。\"}")
+			return fmt.Errorf("カブコムAPI発注失敗: 発注失敗: APIエラー (Status: 400): {\"Code\":1009001,\"Message\":\"建玉が選択されていません。\"}")
 		}
 	} else {
 		// 新規注文の場合：デイトレ両建て規制をシミュレート
@@ -163,10 +224,15 @@ func (g *SyncBacktestGateway) SendOrder(ctx context.Context, input order.SendOrd
 
 		if oppositeQty > 0 {
 			// 両建て規制エラー
-			return nil, fmt.Errorf("カブコムAPI発注失敗: 発注失敗: APIエラー (Status: 400): {\"Code\":1009001,\"Message\":\"建玉が選択されていません。\"}")
+			return fmt.Errorf("カブコムAPI発注失敗: 発注失敗: APIエラー (Status: 400): {\"Code\":1009001,\"Message\":\"建玉が選択されていません。\"}")
 		}
 	}
 
+	return nil
+}
+
+// register は注文に ID を採番して板に並べます
+func (g *SyncBacktestGateway) register(ord *order.Order) {
 	g.orderIdx++
 	orderID := fmt.Sprintf("bt_order_%d", g.orderIdx)
 
@@ -188,8 +254,31 @@ func (g *SyncBacktestGateway) SendOrder(ctx context.Context, input order.SendOrd
 		g.initialDepths[orderID] = g.getDepth(ord.Symbol, ord.Action, ord.OrderPrice)
 		g.cumulativeVolumes[orderID] = 0
 	}
+}
 
-	return ord, nil
+// cancelOCOSiblings は OCO の片方が約定・取消された際に、相方の注文を取り消します（取引所側の OCO と同じく即時に取り消す）
+func (g *SyncBacktestGateway) cancelOCOSiblings(ord *order.Order) {
+	ids, ok := g.ocoGroups[ord.OCOGroupID]
+	if !ok {
+		return
+	}
+	delete(g.ocoGroups, ord.OCOGroupID)
+	for _, id := range ids {
+		sibling := g.orders[id]
+		if id == ord.ID || sibling.IsCompleted() {
+			continue
+		}
+		sibling.BypassTransition(order.ORDER_STATUS_CANCELED, order.STATE_CLOSED)
+		g.pendingReport = true
+		fmt.Printf("⚡ [Backtest] OCO: %s の%sに伴い相方の注文(%s)を取り消しました\n", ord.ID, ocoEvent(ord), id)
+	}
+}
+
+func ocoEvent(ord *order.Order) string {
+	if ord.IsFilled() {
+		return "約定"
+	}
+	return "取消"
 }
 
 func (g *SyncBacktestGateway) CancelOrder(ctx context.Context, orderID string) error {
@@ -200,12 +289,17 @@ func (g *SyncBacktestGateway) CancelOrder(ctx context.Context, orderID string) e
 	if ord.IsCompleted() {
 		return nil
 	}
+	g.cancelOCOSiblings(ord)
 	g.cancelRequested[orderID] = true
 	if g.Latency > 0 {
 		g.cancelActiveAt[orderID] = g.currentTime.Add(g.Latency)
 		ord.BypassTransition(order.ORDER_STATUS_CANCEL_SENT, order.STATE_CANCELING)
 	} else {
 		ord.BypassTransition(order.ORDER_STATUS_CANCELED, order.STATE_CLOSED)
+		// 障害注入: キャンセル成功をサイレント化（イベント通知を遮断）
+		if g.simulateCancelSilent == nil || !g.simulateCancelSilent[orderID] {
+			g.pendingReport = true
+		}
 	}
 	return nil
 }
@@ -231,10 +325,14 @@ func (g *SyncBacktestGateway) GetPositions(ctx context.Context, product order.Pr
 }
 
 func (g *SyncBacktestGateway) RegisterSymbol(ctx context.Context, req market.ResisterSymbolRequest) error {
-	return nil
+	return g.RegisterSymbols(ctx, []market.ResisterSymbolRequest{req})
 }
 
 func (g *SyncBacktestGateway) RegisterSymbols(ctx context.Context, reqs []market.ResisterSymbolRequest) error {
+	// 本番と同様に、登録直後から売買主体の判定と CVD の集計を開始する
+	for _, req := range reqs {
+		tick.GetOrCreateTradeClassifier(g.dataPool, req.Symbol)
+	}
 	return nil
 }
 
@@ -243,7 +341,37 @@ func (g *SyncBacktestGateway) UnregisterSymbolAll(ctx context.Context) error {
 }
 
 func (g *SyncBacktestGateway) GetSymbol(ctx context.Context, symbolCode string, exchange order.ExchangeMarket) (symbol.Symbol, error) {
-	return symbol.Symbol{Code: symbolCode}, nil
+	detail, ok := g.symbolMaster[symbolCode]
+	if !ok {
+		detail = symbol.Symbol{Code: symbolCode}
+	}
+	detail.PriceLimits = g.priceLimitsFor(symbolCode)
+	return detail, nil
+}
+
+// UseSymbolMaster は GetSymbol が返す銘柄情報（売買単位・信用区分の可否など）を銘柄マスタから補完するよう設定します。
+// 戦略が生成される前に呼び出す必要があります。
+func (g *SyncBacktestGateway) UseSymbolMaster(master map[string]symbol.Symbol) {
+	g.symbolMaster = master
+	g.priceLimits = nil
+}
+
+// priceLimitsFor は本番と同じく前日終値（日足ストア優先、無ければ前日終値CSV）から当日の値幅制限を計算します。
+// 前日終値が無い銘柄は、銘柄マスタに記載された値幅制限を使います。
+func (g *SyncBacktestGateway) priceLimitsFor(symbolCode string) symbol.PriceLimits {
+	if limits, ok := g.priceLimits[symbolCode]; ok {
+		return limits
+	}
+	limits := g.symbolMaster[symbolCode].PriceLimits
+	feeder := (&backtestHistoricalFeederProvider{gateway: g}).GetFeeder(symbolCode)
+	if prevClose, err := feeder.FetchPreviousClose(); err == nil && prevClose > 0 {
+		limits = symbol.NewPriceLimits(prevClose)
+	}
+	if g.priceLimits == nil {
+		g.priceLimits = make(map[string]symbol.PriceLimits)
+	}
+	g.priceLimits[symbolCode] = limits
+	return limits
 }
 
 func (g *SyncBacktestGateway) ProcessTick(t tick.Tick) {
@@ -295,6 +423,14 @@ func (g *SyncBacktestGateway) ProcessTick(t tick.Tick) {
 			}
 		}
 
+		// 逆指値はトリガー価格に到達した Tick で発動し、同じ Tick から成行（STOP_LIMIT は指値）として約定判定する
+		if g.orderTypes[id].IsStop() {
+			if !ord.IsTriggeredBy(t.Price) {
+				continue
+			}
+			g.triggerStop(id, t.Price)
+		}
+
 		// 約定判定
 		askPrice := t.BestAsk.Price
 		bidPrice := t.BestBid.Price
@@ -334,7 +470,9 @@ func (g *SyncBacktestGateway) ProcessTick(t tick.Tick) {
 		}
 	}
 
-	if executed {
+	// 即時の取消や OCO の連動取消は、約定が無くても通知する
+	if executed || g.pendingReport {
+		g.pendingReport = false
 		ords, _ := g.GetOrders(context.Background())
 		select {
 		case g.orderCh <- ords:
@@ -348,6 +486,22 @@ func (g *SyncBacktestGateway) ProcessTick(t tick.Tick) {
 	}
 }
 
+// triggerStop は発動した逆指値を、以降の約定判定では成行または指値として扱うよう切り替えます
+func (g *SyncBacktestGateway) triggerStop(id string, price float64) {
+	ord := g.orders[id]
+	if g.orderTypes[id] == order.ORDER_TYPE_STOP_LIMIT {
+		g.orderTypes[id] = order.ORDER_TYPE_LIMIT
+	} else {
+		g.orderTypes[id] = order.ORDER_TYPE_MARKET
+	}
+	if g.Model == ExecutionModelVolume {
+		// 板に並ぶのは発動してからのため、待ち行列もこの時点の板の厚みから数える
+		g.initialDepths[id] = g.getDepth(ord.Symbol, ord.Action, ord.OrderPrice)
+		g.cumulativeVolumes[id] = 0
+	}
+	fmt.Printf("⚡ [Backtest] 逆指値発動: %s %s (Trigger: %.1f, Tick: %.1f)\n", ord.ID, ord.Action, ord.TriggerPrice, price)
+}
+
 func (g *SyncBacktestGateway) tryExecuteVolume(id string, volDelta float64, price float64) bool {
 	if g.Model == ExecutionModelPrice || g.Model == ExecutionModelTouch || g.orderTypes[id] == order.ORDER_TYPE_MARKET {
 		g.executeAll(id, price)
@@ -372,6 +526,7 @@ func (g *SyncBacktestGateway) executeAll(id string, price float64) {
 	}
 	ord.AddExecution(exec)
 	ord.BypassTransition(order.ORDER_STATUS_FILLED, order.STATE_CLOSED)
+	g.cancelOCOSiblings(ord)
 
 	// --- ポジション管理の更新 ---
 	isExit := ord.CashMargin == order.CASH_MARGIN_MARGIN_EXIT ||
@@ -420,11 +575,17 @@ func (g *SyncBacktestGateway) executeAll(id string, price float64) {
 	}
 
 	// 🌟 IFD自動発火ロジック (ゲートウェイ側での自動実行)
+	// 子注文は Bot が保持する雛形（IfDone）の複製を発注する（雛形を書き換えると、Bot が子注文を親と紐付けられなくなる）
 	if ord.IfDone != nil {
-		ord.IfDone.ParentOrderID = ord.ID
-		fmt.Printf("⚡ [Backtest] IFD発動: 親注文(%s)約定 -> 子注文(%s)を即時発射します\n", ord.ID, ord.IfDone.Action)
+		child := *ord.IfDone
+		child.ParentOrderID = ord.ID
+		if ord.IfDone.OCO != nil {
+			sibling := *ord.IfDone.OCO
+			child.OCO = &sibling
+		}
+		fmt.Printf("⚡ [Backtest] IFD発動: 親注文(%s)約定 -> 子注文(%s)を即時発射します\n", ord.ID, child.Action)
 		_, _ = g.SendOrder(context.Background(), order.SendOrderInput{
-			Order: ord.IfDone,
+			Order: &child,
 		})
 	}
 }
@@ -467,10 +628,24 @@ type backtestHistoricalFeederProvider struct {
 }
 
 func (p *backtestHistoricalFeederProvider) GetFeeder(symbol string) tick.HistoricalFeeder {
-	return &backtestHistoricalFeeder{
+	closes := &backtestHistoricalFeeder{
 		symbol:  symbol,
 		gateway: p.gateway,
 	}
+	if p.gateway.dailyStore == nil {
+		return closes
+	}
+	// 本番と同じく日足ストアを優先し、履歴の無い銘柄は前日終値CSVにフォールバックする
+	return daily.NewFeeder(p.gateway.dailyStore, symbol, p.gateway.sessionDate, closes)
+}
+
+// UseDailyStore は指標の初期化時に日足ストアを参照するよう設定します。
+// sessionDate より前の日足のみを参照するため、本番で当日に起動した場合と同じ値になります。
+// 指標が登録される（戦略が生成される）前に呼び出す必要があります。
+func (g *SyncBacktestGateway) UseDailyStore(store *daily.Store, sessionDate time.Time) {
+	g.dailyStore = store
+	g.sessionDate = sessionDate
+	g.priceLimits = nil
 }
 
 // LoadPreviousCloses はCSVファイルから前日終値データを読み込み、前日終値マップを更新します。
@@ -599,6 +774,7 @@ func (g *SyncBacktestGateway) LoadPreviousCloses(csvPath string) error {
 	for k, v := range loadedCloses {
 		g.previousCloses[k] = v
 	}
+	g.priceLimits = nil // 基準値段が変わるため値幅制限を計算し直す
 
 	slog.Info("CSVから前日終値データを正常に読み込みました", slog.Int("count", len(loadedCloses)))
 	return nil
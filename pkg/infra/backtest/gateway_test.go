@@ -2,17 +2,21 @@ package backtest
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/r-umemoto/trading-bot/pkg/domain/market"
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/infra/daily"
 )
 
 func TestGatewayLatency_OrderMatchingDelay(t *testing.T) {
@@ -431,3 +435,263 @@ func TestSyncBacktestGateway_LoadPreviousCloses(t *testing.T) {
 		t.Errorf("expected loaded value 2200.0 for 8308, got %f", g.previousCloses["8308"])
 	}
 }
+
+func TestSyncBacktestGateway_RegisterSymbolsStartsTradeClassification(t *testing.T) {
+	g := NewSyncBacktestGateway(ExecutionModelPrice, 0)
+	if _, err := g.Listen(context.Background()); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	if err := g.RegisterSymbols(context.Background(), []market.ResisterSymbolRequest{{Symbol: "7203"}}); err != nil {
+		t.Fatalf("RegisterSymbols failed: %v", err)
+	}
+
+	base := time.Date(2026, 6, 18, 9, 0, 0, 0, time.Local)
+	ticks := []tick.Tick{
+		{Symbol: "7203", Price: 100, TradingVolume: 1000, CurrentPriceTime: base,
+			BestAsk: tick.FirstQuote{Price: 101, Qty: 100}, BestBid: tick.FirstQuote{Price: 100, Qty: 100}},
+		{Symbol: "7203", Price: 101, TradingVolume: 1300, CurrentPriceTime: base.Add(time.Second),
+			BestAsk: tick.FirstQuote{Price: 102, Qty: 100}, BestBid: tick.FirstQuote{Price: 101, Qty: 100}},
+	}
+	for _, tk := range ticks {
+		g.ProcessTick(tk)
+		<-g.TickCh()
+	}
+
+	classifier := tick.GetOrCreateTradeClassifier(g.DataPool(), "7203")
+	if classifier.BuyVolume() != 300 || classifier.CVD() != 300 {
+		t.Errorf("expected buy volume 300 and CVD 300, got %f / %f", classifier.BuyVolume(), classifier.CVD())
+	}
+}
+
+// feederCapture は初期化時に受け取った HistoricalFeeder を保持するテスト用の指標です
+type feederCapture struct {
+	feeder tick.HistoricalFeeder
+}
+
+func (c *feederCapture) ID() string                                       { return "feeder_capture" }
+func (c *feederCapture) Update(tick.Tick)                                 {}
+func (c *feederCapture) Dependencies() []tick.Indicator                   { return nil }
+func (c *feederCapture) FetchAndInitialize(f tick.HistoricalFeeder) error { c.feeder = f; return nil }
+
+func TestSyncBacktestGateway_UseDailyStore(t *testing.T) {
+	store := daily.NewStore(t.TempDir())
+	err := store.Upsert("8604",
+		tick.DailyBar{Date: time.Date(2026, 6, 16, 0, 0, 0, 0, time.Local), High: 1510, Close: 1490},
+		tick.DailyBar{Date: time.Date(2026, 6, 17, 0, 0, 0, 0, time.Local), High: 1530, Close: 1510},
+		tick.DailyBar{Date: time.Date(2026, 6, 18, 0, 0, 0, 0, time.Local), High: 9999, Close: 9999}, // 対象日当日
+	)
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	g := NewSyncBacktestGateway(ExecutionModelPrice, 0)
+	g.previousCloses["8308"] = 2200
+	g.UseDailyStore(store, time.Date(2026, 6, 18, 0, 0, 0, 0, time.Local))
+
+	capture := func(symbol string) tick.HistoricalFeeder {
+		ind := g.DataPool().GetOrCreateIndicator(symbol, "feeder_capture", func() tick.Indicator { return &feederCapture{} })
+		return ind.(*feederCapture).feeder
+	}
+
+	feeder, ok := capture("8604").(tick.DailyHistoryFeeder)
+	if !ok {
+		t.Fatalf("expected a DailyHistoryFeeder")
+	}
+	if v, err := feeder.FetchPreviousClose(); err != nil || v != 1510 {
+		t.Errorf("expected previous close 1510 from the daily store, got %v (err=%v)", v, err)
+	}
+	if v, err := feeder.FetchSMA(2); err != nil || v != 1500 {
+		t.Errorf("expected SMA 1500, got %v (err=%v)", v, err)
+	}
+	if v, err := feeder.FetchHighestHigh(2); err != nil || v != 1530 {
+		t.Errorf("expected highest high 1530, got %v (err=%v)", v, err)
+	}
+
+	// 日足の無い銘柄は前日終値CSVの値にフォールバックする
+	if v, err := capture("8308").FetchPreviousClose(); err != nil || v != 2200 {
+		t.Errorf("expected fallback previous close 2200, got %v (err=%v)", v, err)
+	}
+}
+
+func TestSyncBacktestGateway_PriceLimits(t *testing.T) {
+	g := NewSyncBacktestGateway(ExecutionModelTouch, 0)
+	g.previousCloses["8604"] = 1400 // 値幅 300 円: 1100〜1700
+
+	detail, err := g.GetSymbol(context.Background(), "8604", order.EXCHANGE_TOSHO)
+	if err != nil {
+		t.Fatalf("GetSymbol failed: %v", err)
+	}
+	if detail.PriceLimits.Upper != 1700 || detail.PriceLimits.Lower != 1100 {
+		t.Errorf("unexpected price limits: %+v", detail.PriceLimits)
+	}
+
+	send := func(price float64, orderType order.OrderType) error {
+		_, err := g.SendOrder(context.Background(), order.SendOrderInput{
+			Order: &order.Order{Symbol: "8604", Action: order.ACTION_BUY, OrderQty: 100, OrderPrice: price, Type: orderType},
+		})
+		return err
+	}
+	if err := send(1750, order.ORDER_TYPE_LIMIT); !errors.Is(err, symbol.ErrOutsidePriceLimits) {
+		t.Errorf("expected a limit order above the upper limit to be rejected, got %v", err)
+	} else if !strings.HasPrefix(err.Error(), "バックテスト発注拒否") {
+		t.Errorf("expected a backtest rejection rather than a broker API error, got %v", err)
+	}
+	if err := send(1700, order.ORDER_TYPE_LIMIT); err != nil {
+		t.Errorf("expected a limit order at the upper limit to be accepted, got %v", err)
+	}
+	if err := send(0, order.ORDER_TYPE_MARKET); err != nil {
+		t.Errorf("expected a market order to be accepted, got %v", err)
+	}
+
+	// 前日終値の無い銘柄は制限しない
+	if _, err := g.SendOrder(context.Background(), order.SendOrderInput{
+		Order: &order.Order{Symbol: "9999", Action: order.ACTION_BUY, OrderQty: 100, OrderPrice: 1e6, Type: order.ORDER_TYPE_LIMIT},
+	}); err != nil {
+		t.Errorf("expected no limits without a previous close, got %v", err)
+	}
+}
+
+func TestSyncBacktestGateway_StopOrders(t *testing.T) {
+	baseTime := time.Date(2026, 6, 10, 10, 0, 0, 0, time.Local)
+	run := func(tmpl order.Order, prices ...float64) []order.Execution {
+		t.Helper()
+		g := NewSyncBacktestGateway(ExecutionModelTouch, 0)
+		g.ProcessTick(tick.Tick{Symbol: "6758", Price: 1000, CurrentPriceTime: baseTime})
+		ord := tmpl
+		if _, err := g.SendOrder(context.Background(), order.SendOrderInput{Order: &ord}); err != nil {
+			t.Fatalf("SendOrder failed: %v", err)
+		}
+		for i, price := range prices {
+			g.ProcessTick(tick.Tick{Symbol: "6758", Price: price, CurrentPriceTime: baseTime.Add(time.Duration(i+1) * time.Second)})
+		}
+		orders, _ := g.GetOrders(context.Background())
+		return orders.Orders[0].Executions
+	}
+
+	t.Run("sell stop executes at market after the trigger", func(t *testing.T) {
+		ord := order.Order{Symbol: "6758", Action: order.ACTION_SELL, OrderQty: 100, Type: order.ORDER_TYPE_STOP, TriggerPrice: 990}
+		if execs := run(ord, 995, 991); len(execs) != 0 {
+			t.Fatalf("expected no fill above the trigger, got %+v", execs)
+		}
+		execs := run(ord, 995, 988)
+		if len(execs) != 1 || execs[0].Price != 988 {
+			t.Errorf("expected a fill at 988 once triggered, got %+v", execs)
+		}
+	})
+
+	t.Run("buy stop triggers at the trigger price", func(t *testing.T) {
+		ord := order.Order{Symbol: "6758", Action: order.ACTION_BUY, OrderQty: 100, Type: order.ORDER_TYPE_STOP, TriggerPrice: 1010}
+		execs := run(ord, 1005, 1010)
+		if len(execs) != 1 || execs[0].Price != 1010 {
+			t.Errorf("expected a fill at 1010, got %+v", execs)
+		}
+	})
+
+	t.Run("stop limit rests as a limit order after the trigger", func(t *testing.T) {
+		// 990 で発動し、985 の売り指値として板に並ぶ。発動後に価格が戻っても指値として約定する
+		ord := order.Order{Symbol: "6758", Action: order.ACTION_SELL, OrderQty: 100, Type: order.ORDER_TYPE_STOP_LIMIT, TriggerPrice: 990, OrderPrice: 985}
+		if execs := run(ord, 984); len(execs) != 0 {
+			t.Fatalf("expected no fill below the limit price, got %+v", execs)
+		}
+		execs := run(ord, 984, 992)
+		if len(execs) != 1 || execs[0].Price != 992 {
+			t.Errorf("expected a fill at 992 after the trigger, got %+v", execs)
+		}
+	})
+
+	t.Run("trigger outside the price limits is rejected", func(t *testing.T) {
+		g := NewSyncBacktestGateway(ExecutionModelTouch, 0)
+		g.previousCloses["8604"] = 1400 // 値幅 300 円: 1100〜1700
+		_, err := g.SendOrder(context.Background(), order.SendOrderInput{
+			Order: &order.Order{Symbol: "8604", Action: order.ACTION_SELL, OrderQty: 100, Type: order.ORDER_TYPE_STOP, TriggerPrice: 1000},
+		})
+		if !errors.Is(err, symbol.ErrOutsidePriceLimits) {
+			t.Errorf("expected the stop order to be rejected, got %v", err)
+		}
+	})
+}
+
+func TestSyncBacktestGateway_OCOBracket(t *testing.T) {
+	baseTime := time.Date(2026, 6, 10, 10, 0, 0, 0, time.Local)
+	ctx := context.Background()
+
+	// 成行買いの約定で、利確の指値 1020 と損切りの逆指値 990 を OCO で IFD 発注する
+	run := func(prices ...float64) (*SyncBacktestGateway, *order.Order, *order.Order) {
+		t.Helper()
+		g := NewSyncBacktestGateway(ExecutionModelTouch, 0)
+		g.ProcessTick(tick.Tick{Symbol: "6758", Price: 1000, CurrentPriceTime: baseTime})
+		tp := order.NewOrder("", "6758", order.ACTION_SELL, 1020, 100, order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT), order.WithReason("TakeProfit"))
+		sl := order.NewOrder("", "6758", order.ACTION_SELL, 0, 100, order.WithType(order.ORDER_TYPE_STOP), order.WithTriggerPrice(990),
+			order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT), order.WithReason("StopLoss"))
+		tp.OCO = sl
+		entry := order.NewOrder("", "6758", order.ACTION_BUY, 0, 100, order.WithType(order.ORDER_TYPE_MARKET), order.WithCashMargin(order.CASH_MARGIN_MARGIN_ENTRY))
+		entry.IfDone = tp
+		if _, err := g.SendOrder(ctx, order.SendOrderInput{Order: entry}); err != nil {
+			t.Fatalf("SendOrder failed: %v", err)
+		}
+		for i, price := range prices {
+			g.ProcessTick(tick.Tick{Symbol: "6758", Price: price, CurrentPriceTime: baseTime.Add(time.Duration(i+1) * time.Second)})
+		}
+		// IFD の子注文は雛形の複製として発注されるため、ゲートウェイ上の注文を理由で引く
+		legs := make(map[string]*order.Order)
+		for _, id := range g.orderKeys {
+			legs[g.orders[id].Reason] = g.orders[id]
+		}
+		if legs["TakeProfit"] == nil || legs["StopLoss"] == nil {
+			t.Fatalf("expected both legs to be sent, got %v", legs)
+		}
+		if !tp.IsPending() || tp.ID != "" {
+			t.Errorf("expected the if-done template to be left untouched, got %+v", tp)
+		}
+		return g, legs["TakeProfit"], legs["StopLoss"]
+	}
+	flat := func(g *SyncBacktestGateway) bool {
+		positions, _ := g.GetPositions(ctx, order.PRODUCT_MARGIN)
+		for _, p := range positions {
+			if p.LeavesQty > 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	t.Run("stop fills and cancels the take profit", func(t *testing.T) {
+		g, tp, sl := run(1000, 995, 988)
+		if tp.OCOGroupID == "" || tp.OCOGroupID != sl.OCOGroupID {
+			t.Fatalf("expected both legs in the same OCO group, got %q / %q", tp.OCOGroupID, sl.OCOGroupID)
+		}
+		if !sl.IsFilled() || !tp.IsCanceled() {
+			t.Errorf("expected stop filled and take profit cancelled, got %v / %v", sl.Status(), tp.Status())
+		}
+		if !flat(g) {
+			t.Errorf("expected the position to be closed exactly once")
+		}
+	})
+
+	t.Run("take profit fills and cancels the stop", func(t *testing.T) {
+		g, tp, sl := run(1000, 1010, 1025, 980)
+		if !tp.IsFilled() || !sl.IsCanceled() {
+			t.Errorf("expected take profit filled and stop cancelled, got %v / %v", tp.Status(), sl.Status())
+		}
+		if !flat(g) {
+			t.Errorf("expected the position to be closed exactly once")
+		}
+	})
+
+	t.Run("cancelling one leg cancels the other", func(t *testing.T) {
+		g, tp, sl := run(1000, 1005)
+		if err := g.CancelOrder(ctx, sl.ID); err != nil {
+			t.Fatalf("CancelOrder failed: %v", err)
+		}
+		if !tp.IsCanceled() || !sl.IsCanceled() {
+			t.Errorf("expected both legs cancelled, got %v / %v", tp.Status(), sl.Status())
+		}
+		for len(g.OrderCh()) > 0 {
+			<-g.OrderCh()
+		}
+		g.ProcessTick(tick.Tick{Symbol: "6758", Price: 1006, CurrentPriceTime: baseTime.Add(time.Minute)})
+		if len(g.OrderCh()) == 0 {
+			t.Errorf("expected the cascaded cancel to be reported")
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// 組み込み戦略のバックテスト回帰テストです。合成した1日分の Tick を runner.RunBacktest と同じ順序
+// （約定通知 → Tick → 作戦の評価 → 発注）で流し、約定した注文の並びを検証します。
+
+// leg はシナリオの折れ線の頂点です（時刻は "15:04:05"）
+type leg struct {
+	at    string
+	price float64
+}
+
+// scenario は legs の間を step 間隔で直線補間した Tick 列を作ります。価格は1円単位、出来高は1 Tick 100株です。
+func scenario(t *testing.T, step time.Duration, legs ...leg) []tick.Tick {
+	t.Helper()
+	day := time.Date(2026, 6, 10, 0, 0, 0, 0, session.Location())
+	at := func(s string) time.Time {
+		clock, err := time.Parse("15:04:05", s)
+		if err != nil {
+			t.Fatalf("invalid time %q: %v", s, err)
+		}
+		return day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute + time.Duration(clock.Second())*time.Second)
+	}
+
+	var ticks []tick.Tick
+	volume := 0.0
+	for i := 1; i < len(legs); i++ {
+		from, to := at(legs[i-1].at), at(legs[i].at)
+		span := to.Sub(from)
+		for ts := from; ts.Before(to); ts = ts.Add(step) {
+			ratio := float64(ts.Sub(from)) / float64(span)
+			volume += 100
+			ticks = append(ticks, tick.Tick{
+				Symbol:             "7203",
+				Price:              math.Round(legs[i-1].price + (legs[i].price-legs[i-1].price)*ratio),
+				TradingVolume:      volume,
+				CurrentPriceTime:   ts,
+				CurrentPriceStatus: tick.PRICE_STATUS_CURRENT,
+			})
+		}
+	}
+	return ticks
+}
+
+// replay は戦略を1つ配備した作戦にシナリオを流し、約定した注文を「売買 理由」の形で返します
+func replay(t *testing.T, name string, params map[string]interface{}, ticks []tick.Tick) []string {
+	t.Helper()
+	ctx := context.Background()
+	g := NewSyncBacktestGateway(ExecutionModelTouch, 0)
+	g.UseSymbolMaster(map[string]symbol.Symbol{
+		"7203": {Code: "7203", TradingUnit: 100, PriceRangeGroup: symbol.PRICE_RANGE_GROUP_TSE_STANDARD},
+	})
+	detail, _ := g.GetSymbol(ctx, "7203", order.EXCHANGE_TOSHO)
+
+	factory, err := strategy.GetFactory(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := strategy.PrepareParams(factory, params, name)
+	if err != nil {
+		t.Fatalf("PrepareParams failed: %v", err)
+	}
+	s := sniper.NewSniper(sniper.SniperID(name, "7203"), detail, factory.NewStrategy(detail, g.DataPool(), decoded), factory.CreateExecutionPolicy(decoded), order.EXCHANGE_TOSHO, nil)
+	op := sniper.NewDefaultOperation("Regression_"+name, sniper.NewSniperNest("7203", detail, []*sniper.Sniper{s}, nil))
+
+	for _, tk := range ticks {
+		g.ProcessTick(tk)
+		for len(g.OrderCh()) > 0 {
+			op.UpdateOrders(<-g.OrderCh())
+		}
+		for _, act := range op.HandleTick(<-g.TickCh()) {
+			switch b := act.Bullet.(type) {
+			case sniper.CancelBullet:
+				_ = g.CancelOrder(ctx, b.OrderID)
+			case sniper.OrderBullet:
+				sent, err := g.SendOrder(ctx, order.SendOrderInput{Order: b.Order})
+				if err != nil {
+					op.FailSendingOrder(act.SniperID, b.Order)
+				} else {
+					op.UpdateOrderID(act.SniperID, b.Order, sent.ID)
+				}
+			}
+		}
+	}
+
+	orders, _ := g.GetOrders(ctx)
+	var filled []string
+	for _, o := range orders.Orders {
+		if o.Status() == order.ORDER_STATUS_FILLED {
+			filled = append(filled, fmt.Sprintf("%s %s", o.Action, o.Reason))
+		}
+	}
+	return filled
+}
+
+func assertFills(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, " / ") != strings.Join(want, " / ") {
+		t.Errorf("unexpected fills:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestRegression_ORB(t *testing.T) {
+	// 9:00〜9:15 は 1000〜1010 のレンジ（幅 1%）
+	opening := []leg{
+		{"09:00:00", 1000}, {"09:05:00", 1010}, {"09:10:00", 1000}, {"09:15:00", 1008},
+	}
+
+	t.Run("breakout and take profit", func(t *testing.T) {
+		ticks := scenario(t, 10*time.Second, append(opening,
+			leg{"09:20:00", 1015}, // 高値 1010 を上抜けて成行買い、利確は 1010 + 10 = 1020
+			leg{"09:40:00", 1025}, // 利確の指値に到達
+			leg{"10:00:00", 1015},
+		)...)
+		assertFills(t, replay(t, "orb", nil, ticks), "BUY ORB_Breakout", "SELL TakeProfit")
+	})
+
+	t.Run("stop at the range low and no second entry", func(t *testing.T) {
+		ticks := scenario(t, 10*time.Second, append(opening,
+			leg{"09:20:00", 1014},
+			leg{"09:40:00", 995},  // レンジ安値 1000 を割り込んで損切り
+			leg{"10:00:00", 1018}, // 再度ブレイクしても1日1回しか建てない
+		)...)
+		assertFills(t, replay(t, "orb", nil, ticks), "BUY ORB_Breakout", "SELL StopLoss_ORB_Low")
+	})
+
+	t.Run("short breakdown", func(t *testing.T) {
+		ticks := scenario(t, 10*time.Second, append(opening,
+			leg{"09:20:00", 996}, // 安値 1000 を下抜けて成行売り、利確は 1000 - 10 = 990
+			leg{"09:40:00", 985},
+		)...)
+		assertFills(t, replay(t, "orb", map[string]interface{}{"side": "short"}, ticks), "SELL ORB_Breakdown", "BUY TakeProfit")
+	})
+
+	t.Run("narrow range is skipped", func(t *testing.T) {
+		ticks := scenario(t, 10*time.Second,
+			leg{"09:00:00", 1000}, leg{"09:15:00", 1001}, leg{"09:30:00", 1010},
+		)
+		assertFills(t, replay(t, "orb", nil, ticks))
+	})
+}
+
+func TestRegression_VWAPReversion(t *testing.T) {
+	// 9:00〜9:20 は 1000 を中心に ±5円で揉み合い、σ ≒ 3円
+	var calm []leg
+	for m := 0; m < 20; m += 2 {
+		calm = append(calm, leg{fmt.Sprintf("09:%02d:00", m), 995}, leg{fmt.Sprintf("09:%02d:00", m+1), 1005})
+	}
+	calm = append(calm, leg{"09:20:00", 1000})
+
+	t.Run("fade the dip back to VWAP", func(t *testing.T) {
+		ticks := scenario(t, 10*time.Second, append(calm,
+			leg{"09:22:00", 992}, // -2σ を割り込んで指値で買い
+			leg{"09:30:00", 1003},
+			leg{"09:40:00", 1000},
+		)...)
+		assertFills(t, replay(t, "vwap_reversion", nil, ticks), "BUY VWAP_Reversion", "SELL TakeProfit")
+	})
+
+	t.Run("stop beyond the stop band", func(t *testing.T) {
+		ticks := scenario(t, 10*time.Second, append(calm,
+			leg{"09:21:00", 993},
+			leg{"09:23:00", 970}, // -3σ を割り込んで損切り
+			leg{"09:30:00", 968},
+		)...)
+		assertFills(t, replay(t, "vwap_reversion", map[string]interface{}{"max_trades": 1.0}, ticks), "BUY VWAP_Reversion", "SELL StopLoss_VWAP")
+	})
+
+	t.Run("no entry during warm up", func(t *testing.T) {
+		ticks := scenario(t, 10*time.Second,
+			leg{"09:00:00", 1000}, leg{"09:02:00", 1005}, leg{"09:04:00", 995}, leg{"09:06:00", 980}, leg{"09:10:00", 1000},
+		)
+		assertFills(t, replay(t, "vwap_reversion", nil, ticks))
+	})
+}
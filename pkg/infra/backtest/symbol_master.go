@@ -0,0 +1,140 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+)
+
+// DefaultSymbolMasterPath はバックテスト用の銘柄マスタCSVの既定の配置場所です
+const DefaultSymbolMasterPath = "configs/symbol_master.csv"
+
+// 銘柄マスタCSVのヘッダー名です（カブコムAPI /symbol の項目名に合わせています）
+const (
+	colSymbol          = "Symbol"
+	colSymbolName      = "SymbolName"
+	colPriceRangeGroup = "PriceRangeGroup"
+	colTradingUnit     = "TradingUnit"
+	colMarginBuy       = "MarginBuy"
+	colMarginSell      = "MarginSell"
+	colKCMarginBuy     = "KCMarginBuy"
+	colKCMarginSell    = "KCMarginSell"
+	colDayTrade        = "DayTrade"
+	colUpperLimit      = "UpperLimit"
+	colLowerLimit      = "LowerLimit"
+)
+
+// LoadSymbolMaster は銘柄マスタCSVを読み込みます。
+// 1行目はヘッダーで、列はヘッダー名で識別します（Symbol 以外の列は省略可、順不同）。
+//
+//	Symbol,SymbolName,PriceRangeGroup,TradingUnit,MarginBuy,MarginSell,KCMarginBuy,KCMarginSell,DayTrade,UpperLimit,LowerLimit
+//	7203,トヨタ自動車,10003,100,true,true,true,true,true,3500,2500
+//
+// 信用取引の可否の列が1つでもあれば、その銘柄の可否は判定済み（MarginEligibility.Known）として扱います。
+func LoadSymbolMaster(path string) (map[string]symbol.Symbol, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("銘柄マスタCSVの読み込みに失敗しました: %w", err)
+	}
+	if len(records) == 0 {
+		return map[string]symbol.Symbol{}, nil
+	}
+
+	index := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		index[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	if _, ok := index[colSymbol]; !ok {
+		return nil, fmt.Errorf("銘柄マスタCSVに %s 列がありません: %s", colSymbol, path)
+	}
+	_, hasMargin := index[colMarginBuy]
+	for _, col := range []string{colMarginSell, colKCMarginBuy, colKCMarginSell, colDayTrade} {
+		if _, ok := index[col]; ok {
+			hasMargin = true
+		}
+	}
+
+	master := make(map[string]symbol.Symbol, len(records)-1)
+	for line, record := range records[1:] {
+		row := masterRow{record: record, index: index}
+		code := row.text(colSymbol)
+		if code == "" {
+			continue
+		}
+		s := symbol.Symbol{
+			Code: code,
+			Name: row.text(colSymbolName),
+			Margin: symbol.MarginEligibility{
+				Known:       hasMargin,
+				SystemBuy:   row.flag(colMarginBuy),
+				SystemSell:  row.flag(colMarginSell),
+				GeneralBuy:  row.flag(colKCMarginBuy),
+				GeneralSell: row.flag(colKCMarginSell),
+				DayTrade:    row.flag(colDayTrade),
+			},
+		}
+		var parseErr error
+		if v := row.text(colPriceRangeGroup); v != "" {
+			var prg int
+			prg, parseErr = strconv.Atoi(v)
+			s.PriceRangeGroup = symbol.PriceRangeGroup(prg)
+		}
+		if parseErr == nil {
+			s.TradingUnit, parseErr = row.number(colTradingUnit)
+		}
+		if parseErr == nil {
+			s.PriceLimits.Upper, parseErr = row.number(colUpperLimit)
+		}
+		if parseErr == nil {
+			s.PriceLimits.Lower, parseErr = row.number(colLowerLimit)
+		}
+		if parseErr != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line+2, parseErr)
+		}
+		master[code] = s
+	}
+	return master, nil
+}
+
+// masterRow は銘柄マスタCSVの1行をヘッダー名で参照するためのヘルパーです
+type masterRow struct {
+	record []string
+	index  map[string]int
+}
+
+func (r masterRow) text(col string) string {
+	i, ok := r.index[col]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+func (r masterRow) flag(col string) bool {
+	b, _ := strconv.ParseBool(r.text(col))
+	return b
+}
+
+func (r masterRow) number(col string) (float64, error) {
+	v := r.text(col)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s の数値変換に失敗しました (%s): %w", col, v, err)
+	}
+	return n, nil
+}
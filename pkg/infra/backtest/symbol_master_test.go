@@ -0,0 +1,88 @@
+package backtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+)
+
+func writeSymbolMaster(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "symbol_master.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSymbolMaster(t *testing.T) {
+	path := writeSymbolMaster(t, "\ufeffSymbol,SymbolName,PriceRangeGroup,TradingUnit,MarginBuy,MarginSell,KCMarginBuy,KCMarginSell,DayTrade,UpperLimit,LowerLimit\n"+
+		"7203,トヨタ自動車,10003,100,true,true,true,true,true,3500,2500\n"+
+		"9999,テスト,10000,1,true,false,false,false,false,,\n")
+
+	master, err := LoadSymbolMaster(path)
+	if err != nil {
+		t.Fatalf("LoadSymbolMaster failed: %v", err)
+	}
+	toyota := master["7203"]
+	if toyota.Name != "トヨタ自動車" || toyota.PriceRangeGroup != symbol.PRICE_RANGE_GROUP_TSE_TOPIX100 || toyota.TradingUnit != 100 {
+		t.Errorf("unexpected symbol: %+v", toyota)
+	}
+	if toyota.PriceLimits.Upper != 3500 || toyota.PriceLimits.Lower != 2500 {
+		t.Errorf("unexpected price limits: %+v", toyota.PriceLimits)
+	}
+	test := master["9999"]
+	if !test.Margin.Known || !test.Margin.SystemBuy || test.Margin.SystemSell || test.Margin.DayTrade || test.PriceLimits.Known() {
+		t.Errorf("unexpected symbol: %+v", test)
+	}
+}
+
+func TestLoadSymbolMaster_Errors(t *testing.T) {
+	if _, err := LoadSymbolMaster(writeSymbolMaster(t, "Code,Name\n7203,トヨタ\n")); err == nil {
+		t.Errorf("expected an error without the Symbol column")
+	}
+	if _, err := LoadSymbolMaster(writeSymbolMaster(t, "Symbol,TradingUnit\n7203,abc\n")); err == nil {
+		t.Errorf("expected an error for an invalid trading unit")
+	}
+	// 信用区分の列が無いマスタは可否を判定しない
+	master, err := LoadSymbolMaster(writeSymbolMaster(t, "Symbol,TradingUnit\n7203,100\n"))
+	if err != nil || master["7203"].Margin.Known {
+		t.Errorf("expected unknown margin eligibility, got %+v (err=%v)", master["7203"], err)
+	}
+}
+
+func TestSyncBacktestGateway_UseSymbolMaster(t *testing.T) {
+	g := NewSyncBacktestGateway(ExecutionModelTouch, 0)
+	g.UseSymbolMaster(map[string]symbol.Symbol{
+		"7203": {Code: "7203", Name: "トヨタ自動車", TradingUnit: 100, PriceLimits: symbol.PriceLimits{Upper: 3500, Lower: 2500}},
+	})
+
+	detail, _ := g.GetSymbol(context.Background(), "7203", order.EXCHANGE_TOSHO)
+	if detail.Name != "トヨタ自動車" || detail.TradingUnit != 100 || detail.PriceLimits.Upper != 3500 {
+		t.Errorf("expected the master to fill the symbol, got %+v", detail)
+	}
+
+	// 前日終値があれば、当日の値幅制限は前日終値から計算する
+	g.previousCloses["7203"] = 2800
+	g.UseSymbolMaster(g.symbolMaster)
+	if detail, _ := g.GetSymbol(context.Background(), "7203", order.EXCHANGE_TOSHO); detail.PriceLimits.Upper != 3300 {
+		t.Errorf("expected limits from the previous close, got %+v", detail.PriceLimits)
+	}
+
+	send := func(qty float64) error {
+		_, err := g.SendOrder(context.Background(), order.SendOrderInput{
+			Order: &order.Order{Symbol: "7203", Action: order.ACTION_BUY, OrderQty: qty, Type: order.ORDER_TYPE_MARKET},
+		})
+		return err
+	}
+	if err := send(50); err == nil {
+		t.Errorf("expected an odd-lot order to be rejected")
+	}
+	if err := send(200); err != nil {
+		t.Errorf("expected a round-lot order to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package daily_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/infra/daily"
+	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/storage"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, 6, d, 0, 0, 0, 0, time.Local)
+}
+
+type stubFeeder struct{}
+
+func (stubFeeder) FetchSMA(period int) (float64, error) { return -1, nil }
+func (stubFeeder) FetchPreviousClose() (float64, error) { return -2, nil }
+
+// officialFeeder はカブコムAPIの前日終値（公式の終値）を返す fallback です
+type officialFeeder struct {
+	close float64
+	calls *int
+}
+
+func (f officialFeeder) FetchSMA(period int) (float64, error) { return -1, nil }
+func (f officialFeeder) FetchPreviousClose() (float64, error) {
+	*f.calls++
+	return f.close, nil
+}
+
+func TestStore_UpsertKeepsDateOrderAndOverwritesSameDay(t *testing.T) {
+	store := daily.NewStore(t.TempDir())
+
+	if err := store.Upsert("7203", tick.DailyBar{Date: day(3), Close: 103}, tick.DailyBar{Date: day(1), Close: 101}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	// 同じ日付は時刻が異なっても上書きされる
+	if err := store.Upsert("7203", tick.DailyBar{Date: day(3).Add(15 * time.Hour), Close: 113}, tick.DailyBar{Date: day(2), Close: 102}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	bars, err := store.Bars("7203")
+	if err != nil {
+		t.Fatalf("Bars failed: %v", err)
+	}
+	if len(bars) != 3 || bars[0].Close != 101 || bars[1].Close != 102 || bars[2].Close != 113 {
+		t.Errorf("unexpected bars: %+v", bars)
+	}
+	if !bars[2].Date.Equal(day(3)) {
+		t.Errorf("expected date truncated to the day, got %v", bars[2].Date)
+	}
+
+	if bars, err := store.Bars("9984"); err != nil || len(bars) != 0 {
+		t.Errorf("expected no bars for unknown symbol, got %+v (err=%v)", bars, err)
+	}
+}
+
+func TestFeeder_AnswersFromBarsBeforeAsOf(t *testing.T) {
+	store := daily.NewStore(t.TempDir())
+	err := store.Upsert("7203",
+		tick.DailyBar{Date: day(1), High: 110, Low: 95, Close: 100, Volume: 1000},
+		tick.DailyBar{Date: day(2), High: 120, Low: 98, Close: 110, Volume: 2000},
+		tick.DailyBar{Date: day(3), High: 115, Low: 90, Close: 120, Volume: 3000},
+		tick.DailyBar{Date: day(4), High: 999, Low: 1, Close: 999, Volume: 9999}, // 基準日当日は参照しない
+	)
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	provider := daily.NewFeederProvider(store, day(4).Add(9*time.Hour), nil)
+	feeder := provider.GetFeeder("7203").(tick.DailyHistoryFeeder)
+
+	if v, err := feeder.FetchPreviousClose(); err != nil || v != 120 {
+		t.Errorf("expected previous close 120, got %v (err=%v)", v, err)
+	}
+	if v, err := feeder.FetchSMA(3); err != nil || v != 110 {
+		t.Errorf("expected SMA 110, got %v (err=%v)", v, err)
+	}
+	if v, err := feeder.FetchHighestHigh(2); err != nil || v != 120 {
+		t.Errorf("expected highest high 120, got %v (err=%v)", v, err)
+	}
+	if v, err := feeder.FetchLowestLow(3); err != nil || v != 90 {
+		t.Errorf("expected lowest low 90, got %v (err=%v)", v, err)
+	}
+	if v, err := feeder.FetchAverageVolume(2); err != nil || v != 2500 {
+		t.Errorf("expected average volume 2500, got %v (err=%v)", v, err)
+	}
+	if bars, err := feeder.FetchDailyBars(2); err != nil || len(bars) != 2 || !bars[0].Date.Equal(day(2)) {
+		t.Errorf("unexpected daily bars: %+v (err=%v)", bars, err)
+	}
+	if _, err := feeder.FetchSMA(4); err == nil {
+		t.Errorf("expected error for insufficient history")
+	}
+}
+
+func TestFeeder_FallsBackOnlyWithoutHistory(t *testing.T) {
+	store := daily.NewStore(t.TempDir())
+	if err := store.Upsert("7203", tick.DailyBar{Date: day(1), Close: 100}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	withHistory := daily.NewFeeder(store, "7203", day(2), stubFeeder{})
+	if v, _ := withHistory.FetchPreviousClose(); v != 100 {
+		t.Errorf("expected the store to take precedence, got %v", v)
+	}
+
+	withoutHistory := daily.NewFeeder(store, "9984", day(2), stubFeeder{})
+	if v, _ := withoutHistory.FetchPreviousClose(); v != -2 {
+		t.Errorf("expected fallback previous close, got %v", v)
+	}
+	if v, _ := withoutHistory.FetchSMA(5); v != -1 {
+		t.Errorf("expected fallback SMA, got %v", v)
+	}
+}
+
+func TestFeeder_ReconcilesPartialBarWithOfficialClose(t *testing.T) {
+	store := daily.NewStore(t.TempDir())
+	err := store.Upsert("7203",
+		tick.DailyBar{Date: day(9), High: 110, Low: 100, Close: 104},
+		tick.DailyBar{Date: day(10), High: 108, Low: 100, Close: 104, Partial: true}, // 引け10分前に記録を終えた日
+	)
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	calls := 0
+	feeder := daily.NewFeeder(store, "7203", day(11), officialFeeder{close: 111, calls: &calls})
+	if v, _ := feeder.FetchPreviousClose(); v != 111 {
+		t.Errorf("expected the official close to replace the partial close, got %v", v)
+	}
+	if v, _ := feeder.FetchSMA(2); v != 107.5 {
+		t.Errorf("expected SMA over the reconciled close, got %v", v)
+	}
+	if calls != 1 {
+		t.Errorf("expected a single fallback query, got %d", calls)
+	}
+
+	// 補正した日足は確定した日足として保存される
+	bars, _ := store.Bars("7203")
+	want := tick.DailyBar{Date: day(10), High: 111, Low: 100, Close: 111}
+	if len(bars) != 2 || bars[1] != want {
+		t.Errorf("expected %+v to be stored, got %+v", want, bars)
+	}
+}
+
+func TestFeeder_PartialBarNeverOverridesFallback(t *testing.T) {
+	store := daily.NewStore(t.TempDir())
+	if err := store.Upsert("7203", tick.DailyBar{Date: day(8), Close: 104, Partial: true}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	// 前営業日（10日）の日足ではないため補正できず、前日終値・SMA は fallback に任せる
+	feeder := daily.NewFeeder(store, "7203", day(11), stubFeeder{})
+	if v, _ := feeder.FetchPreviousClose(); v != -2 {
+		t.Errorf("expected fallback previous close, got %v", v)
+	}
+	if v, _ := feeder.FetchSMA(1); v != -1 {
+		t.Errorf("expected fallback SMA, got %v", v)
+	}
+
+	// fallback が無いバックテストでは記録した日足で答える
+	if v, _ := daily.NewFeeder(store, "7203", day(11), nil).FetchPreviousClose(); v != 104 {
+		t.Errorf("expected the recorded close without fallback, got %v", v)
+	}
+	if bars, _ := store.Bars("7203"); !bars[0].Partial {
+		t.Errorf("expected the partial flag to survive a round trip, got %+v", bars)
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	dir := t.TempDir()
+	store := daily.NewStore(filepath.Join(dir, "daily"))
+
+	single := filepath.Join(dir, "7203.csv")
+	content := "日付,始値,高値,安値,終値,出来高\n" +
+		"2026/06/01,100,110,95,105,\"1,000\"\n" +
+		"2026/06/02,105,112,101,108,1200\n"
+	if err := os.WriteFile(single, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write csv: %v", err)
+	}
+	if n, err := daily.ImportCSV(store, single, "7203"); err != nil || n != 2 {
+		t.Fatalf("expected 2 bars imported, got %d (err=%v)", n, err)
+	}
+	bars, _ := store.Bars("7203")
+	if len(bars) != 2 || bars[0].Volume != 1000 || bars[1].Close != 108 {
+		t.Errorf("unexpected imported bars: %+v", bars)
+	}
+
+	multi := filepath.Join(dir, "multi.csv")
+	content = "Symbol,Date,Open,High,Low,Close\n" +
+		"9984,2026-06-01,5000,5100,4900,5050\n" +
+		"6758,20260601,3000,3100,2900,3050\n"
+	if err := os.WriteFile(multi, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write csv: %v", err)
+	}
+	if n, err := daily.ImportCSV(store, multi, ""); err != nil || n != 2 {
+		t.Fatalf("expected 2 bars imported, got %d (err=%v)", n, err)
+	}
+	if bars, _ := store.Bars("6758"); len(bars) != 1 || bars[0].Close != 3050 {
+		t.Errorf("unexpected imported bars for 6758: %+v", bars)
+	}
+
+	if _, err := daily.ImportCSV(store, single, ""); err == nil {
+		t.Errorf("expected error without symbol column and symbol argument")
+	}
+}
+
+func TestAppendSession_BuildsDailyBarFromRecordedTicks(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := storage.NewCSVLogger("all", "20260610", dir)
+	if err != nil {
+		t.Fatalf("failed to create CSVLogger: %v", err)
+	}
+	base := time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+	logger.Log(tick.Tick{Symbol: "7203", Price: 102, OpeningPrice: 100, TradingVolume: 5000, CurrentPriceTime: base})
+	logger.Log(tick.Tick{Symbol: "7203", Price: 108, OpeningPrice: 100, TradingVolume: 7000, CurrentPriceTime: base.Add(time.Hour)})
+	logger.Log(tick.Tick{Symbol: "7203", Price: 104, OpeningPrice: 100, TradingVolume: 9000, CurrentPriceTime: base.Add(390 * time.Minute)}) // 大引け
+	logger.Log(tick.Tick{Symbol: "6758", Price: 3000, TradingVolume: 100, CurrentPriceTime: base.Add(380 * time.Minute)})                    // 引け前に記録を終えた
+	logger.Log(tick.Tick{Symbol: "9984", Price: 0, CurrentPriceTime: base})                                                                  // 約定前は無視
+	time.Sleep(50 * time.Millisecond)
+	logger.Close()
+
+	store := daily.NewStore(filepath.Join(dir, "daily"))
+	n, partial, err := daily.AppendSession(store, filepath.Join(dir, "all_20260610.csv"))
+	if err != nil || n != 2 || partial != 1 {
+		t.Fatalf("expected 2 symbols (1 partial) appended, got %d/%d (err=%v)", n, partial, err)
+	}
+
+	bars, _ := store.Bars("7203")
+	want := tick.DailyBar{Date: day(10), Open: 100, High: 108, Low: 100, Close: 104, Volume: 9000}
+	if len(bars) != 1 || bars[0] != want {
+		t.Errorf("expected %+v, got %+v", want, bars)
+	}
+	if bars, _ := store.Bars("6758"); len(bars) != 1 || !bars[0].Partial {
+		t.Errorf("expected a partial bar for a session recorded before the close, got %+v", bars)
+	}
+}
@@ -0,0 +1,200 @@
+package daily
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// FeederProvider は日足ストアを参照する HistoricalFeederProvider です。
+// 本番では当日、バックテストではデータの日付を基準日に指定することで、どちらも「基準日より前の日足」から同じ値を返します。
+type FeederProvider struct {
+	store    *Store
+	asOf     time.Time
+	fallback tick.HistoricalFeederProvider
+}
+
+var _ tick.HistoricalFeederProvider = (*FeederProvider)(nil)
+
+// NewFeederProvider は asOf の前営業日までの日足で問い合わせに答える FeederProvider を作成します。
+// ストアに日足が1本も無い銘柄、または最新の日足が終値未確定（Partial）のままの銘柄の
+// FetchSMA / FetchPreviousClose は fallback（nil 可）へ委譲します。
+func NewFeederProvider(store *Store, asOf time.Time, fallback tick.HistoricalFeederProvider) *FeederProvider {
+	return &FeederProvider{store: store, asOf: asOf, fallback: fallback}
+}
+
+func (p *FeederProvider) GetFeeder(symbol string) tick.HistoricalFeeder {
+	var fallback tick.HistoricalFeeder
+	if p.fallback != nil {
+		fallback = p.fallback.GetFeeder(symbol)
+	}
+	return NewFeeder(p.store, symbol, p.asOf, fallback)
+}
+
+// Feeder は1銘柄分の日足の問い合わせに答える DailyHistoryFeeder です
+type Feeder struct {
+	store    *Store
+	symbol   string
+	asOf     time.Time
+	fallback tick.HistoricalFeeder
+
+	reconcileOnce sync.Once
+}
+
+var _ tick.DailyHistoryFeeder = (*Feeder)(nil)
+
+// NewFeeder は asOf の前営業日までの日足で問い合わせに答える Feeder を作成します（fallback は nil 可）
+func NewFeeder(store *Store, symbol string, asOf time.Time, fallback tick.HistoricalFeeder) *Feeder {
+	return &Feeder{store: store, symbol: symbol, asOf: truncateDate(asOf), fallback: fallback}
+}
+
+// history は基準日より前の日足を古い順に返します。
+// 最新の日足が終値未確定（Partial）であれば、最初の問い合わせで fallback の前日終値により補正します
+func (f *Feeder) history() ([]tick.DailyBar, error) {
+	bars, err := f.store.Bars(f.symbol)
+	if err != nil {
+		return nil, err
+	}
+	n := len(bars)
+	for n > 0 && !bars[n-1].Date.Before(f.asOf) {
+		n--
+	}
+	bars = bars[:n]
+	if n > 0 && bars[n-1].Partial {
+		f.reconcileOnce.Do(func() { bars[n-1] = f.reconcile(bars[n-1]) })
+	}
+	return bars, nil
+}
+
+// reconcile は取引終了前に記録を終えた前営業日の日足を、fallback（カブコムAPI）の前日終値で確定させてストアへ保存します。
+// 前日終値は前営業日の公式の終値のため、日足が前営業日のものでない場合は補正しません。
+// 記録を終えてから大引けまでの値動きは高値・安値に終値を含める分しか反映できず、出来高も大引け分が不足したままです。
+func (f *Feeder) reconcile(bar tick.DailyBar) tick.DailyBar {
+	prev := session.Default().Calendar().PreviousTradingDay(f.asOf)
+	if f.fallback == nil || bar.Date.Format(dateLayout) != prev.Format(dateLayout) {
+		return bar
+	}
+	prevClose, err := f.fallback.FetchPreviousClose()
+	if err != nil || prevClose <= 0 {
+		slog.Warn("⚠️ 終値未確定の日足を公式の前日終値で補正できませんでした", slog.String("symbol", f.symbol), slog.String("date", bar.Date.Format(dateLayout)), slog.Any("error", err))
+		return bar
+	}
+
+	recorded := bar.Close
+	bar.Close = prevClose
+	bar.High = max(bar.High, prevClose)
+	bar.Low = min(bar.Low, prevClose)
+	bar.Partial = false
+	if err := f.store.Upsert(f.symbol, bar); err != nil {
+		slog.Warn("⚠️ 補正した日足の保存に失敗しました", slog.String("symbol", f.symbol), slog.Any("error", err))
+	}
+	slog.Info("🩹 終値未確定の日足を公式の前日終値で補正しました", slog.String("symbol", f.symbol), slog.String("date", bar.Date.Format(dateLayout)),
+		slog.Float64("recorded_close", recorded), slog.Float64("close", prevClose))
+	return bar
+}
+
+// recent は直近 days 本の日足を返します。履歴が不足する場合はエラーを返します
+func (f *Feeder) recent(days int) ([]tick.DailyBar, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("invalid days: %d", days)
+	}
+	bars, err := f.history()
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) < days {
+		return nil, fmt.Errorf("insufficient daily bars for %s: need %d, have %d", f.symbol, days, len(bars))
+	}
+	return bars[len(bars)-days:], nil
+}
+
+// hasSettledHistory はストアに基準日より前の日足があり、最新の日足の終値が確定しているかを返します。
+// 終値未確定（Partial）の日足を前日終値や SMA の根拠にすると、値幅制限の基準値まで誤るためです
+func (f *Feeder) hasSettledHistory() bool {
+	bars, err := f.history()
+	return err == nil && len(bars) > 0 && !bars[len(bars)-1].Partial
+}
+
+// FetchSMA は直近 period 日の終値の単純移動平均を返します
+func (f *Feeder) FetchSMA(period int) (float64, error) {
+	if f.fallback != nil && !f.hasSettledHistory() {
+		return f.fallback.FetchSMA(period)
+	}
+	bars, err := f.recent(period)
+	if err != nil {
+		return 0, err
+	}
+	sum := 0.0
+	for _, b := range bars {
+		sum += b.Close
+	}
+	return sum / float64(period), nil
+}
+
+// FetchPreviousClose は基準日の前営業日の終値を返します
+func (f *Feeder) FetchPreviousClose() (float64, error) {
+	if f.fallback != nil && !f.hasSettledHistory() {
+		return f.fallback.FetchPreviousClose()
+	}
+	bars, err := f.recent(1)
+	if err != nil {
+		return 0, err
+	}
+	return bars[0].Close, nil
+}
+
+// FetchHighestHigh は直近 days 日の高値の最大値を返します
+func (f *Feeder) FetchHighestHigh(days int) (float64, error) {
+	bars, err := f.recent(days)
+	if err != nil {
+		return 0, err
+	}
+	high := bars[0].High
+	for _, b := range bars[1:] {
+		if b.High > high {
+			high = b.High
+		}
+	}
+	return high, nil
+}
+
+// FetchLowestLow は直近 days 日の安値の最小値を返します
+func (f *Feeder) FetchLowestLow(days int) (float64, error) {
+	bars, err := f.recent(days)
+	if err != nil {
+		return 0, err
+	}
+	low := bars[0].Low
+	for _, b := range bars[1:] {
+		if b.Low < low {
+			low = b.Low
+		}
+	}
+	return low, nil
+}
+
+// FetchAverageVolume は直近 days 日の平均出来高を返します
+func (f *Feeder) FetchAverageVolume(days int) (float64, error) {
+	bars, err := f.recent(days)
+	if err != nil {
+		return 0, err
+	}
+	sum := 0.0
+	for _, b := range bars {
+		sum += b.Volume
+	}
+	return sum / float64(days), nil
+}
+
+// FetchDailyBars は直近 days 日の日足を古い順に返します
+func (f *Feeder) FetchDailyBars(days int) ([]tick.DailyBar, error) {
+	bars, err := f.recent(days)
+	if err != nil {
+		return nil, err
+	}
+	return append([]tick.DailyBar(nil), bars...), nil
+}
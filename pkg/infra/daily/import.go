@@ -0,0 +1,145 @@
+package daily
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// importColumns はインポート元CSVのヘッダー名（小文字化済み）と列の対応です
+var importColumns = map[string][]string{
+	"symbol": {"symbol", "code", "銘柄", "銘柄コード"},
+	"date":   {"date", "日付"},
+	"open":   {"open", "始値"},
+	"high":   {"high", "高値"},
+	"low":    {"low", "安値"},
+	"close":  {"close", "終値"},
+	"volume": {"volume", "出来高"},
+}
+
+// importDateLayouts はインポート元CSVで受け付ける日付の書式です
+var importDateLayouts = []string{"2006-01-02", "2006/01/02", "20060102", "2006/1/2"}
+
+// ImportCSV はヘッダー付きの日足CSVをストアへ取り込み、取り込んだ日足の本数を返します。
+// 必須列は Date / Open / High / Low / Close で、Volume は任意です。
+// Symbol 列が無いファイルは全行を symbol の日足として扱います。
+func ImportCSV(store *Store, path string, symbol string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return 0, fmt.Errorf("empty csv: %s", path)
+	}
+
+	cols := detectColumns(records[0])
+	for _, required := range []string{"date", "open", "high", "low", "close"} {
+		if _, ok := cols[required]; !ok {
+			return 0, fmt.Errorf("missing %s column in %s", required, path)
+		}
+	}
+	if _, ok := cols["symbol"]; !ok && symbol == "" {
+		return 0, fmt.Errorf("symbol is required for %s without a symbol column", path)
+	}
+
+	bySymbol := make(map[string][]tick.DailyBar)
+	for i, row := range records[1:] {
+		sym := symbol
+		if idx, ok := cols["symbol"]; ok && idx < len(row) {
+			sym = strings.TrimSpace(row[idx])
+		}
+		bar, err := parseImportRow(row, cols)
+		if err != nil {
+			return 0, fmt.Errorf("invalid row %d in %s: %w", i+2, path, err)
+		}
+		bySymbol[sym] = append(bySymbol[sym], bar)
+	}
+
+	imported := 0
+	for sym, bars := range bySymbol {
+		if err := store.Upsert(sym, bars...); err != nil {
+			return imported, err
+		}
+		imported += len(bars)
+	}
+	return imported, nil
+}
+
+func detectColumns(header []string) map[string]int {
+	cols := make(map[string]int)
+	for i, col := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))
+		for key, aliases := range importColumns {
+			for _, alias := range aliases {
+				if name == alias {
+					cols[key] = i
+				}
+			}
+		}
+	}
+	return cols
+}
+
+func parseImportRow(row []string, cols map[string]int) (tick.DailyBar, error) {
+	field := func(key string) (string, bool) {
+		idx, ok := cols[key]
+		if !ok || idx >= len(row) {
+			return "", false
+		}
+		return strings.TrimSpace(row[idx]), true
+	}
+	number := func(key string) (float64, error) {
+		s, ok := field(key)
+		if !ok {
+			return 0, fmt.Errorf("missing %s", key)
+		}
+		return strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	}
+
+	dateStr, _ := field("date")
+	date, err := parseImportDate(dateStr)
+	if err != nil {
+		return tick.DailyBar{}, err
+	}
+
+	var bar tick.DailyBar
+	bar.Date = date
+	if bar.Open, err = number("open"); err != nil {
+		return tick.DailyBar{}, err
+	}
+	if bar.High, err = number("high"); err != nil {
+		return tick.DailyBar{}, err
+	}
+	if bar.Low, err = number("low"); err != nil {
+		return tick.DailyBar{}, err
+	}
+	if bar.Close, err = number("close"); err != nil {
+		return tick.DailyBar{}, err
+	}
+	if _, ok := cols["volume"]; ok {
+		if bar.Volume, err = number("volume"); err != nil {
+			return tick.DailyBar{}, err
+		}
+	}
+	return bar, nil
+}
+
+func parseImportDate(s string) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		if d, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %q", s)
+}
@@ -0,0 +1,94 @@
+package daily
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/storage"
+)
+
+// SessionBars は CSVLogger が記録した1日分の Tick から銘柄ごとの日足を組み立てます。
+// 日付はファイル名（all_YYYYMMDD.csv）から取得します。
+// 記録開始前の値動きは Tick に含まれないため、途中から記録した日の高値・安値は記録区間のものになります。
+// 取引終了（大引け）以降の Tick が無い銘柄は、終値が確定していないため Partial として返します。
+func SessionBars(ticksPath string) (map[string]tick.DailyBar, error) {
+	date := truncateDate(storage.DateFromFileName(ticksPath, time.Now()))
+	bars := make(map[string]tick.DailyBar)
+	lastTimes := make(map[string]time.Time)
+
+	err := storage.ReadTicks(ticksPath, func(t tick.Tick) {
+		if t.Price <= 0 {
+			return
+		}
+		bar, ok := bars[t.Symbol]
+		if !ok {
+			bar = tick.DailyBar{Date: date, Open: t.Price, High: t.Price, Low: t.Price}
+		}
+		// 寄付値が配信されていれば、記録開始時の現値より優先する
+		if t.OpeningPrice > 0 {
+			bar.Open = t.OpeningPrice
+		}
+		bar.High = max(bar.High, t.Price, bar.Open)
+		bar.Low = min(bar.Low, t.Price, bar.Open)
+		bar.Close = t.Price
+		bar.Volume = max(bar.Volume, t.TradingVolume) // 累積出来高の最大値が当日の出来高
+		bars[t.Symbol] = bar
+		if t.CurrentPriceTime.After(lastTimes[t.Symbol]) {
+			lastTimes[t.Symbol] = t.CurrentPriceTime
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Tick 記録の時刻はファイル名の日付とローカルタイムの時刻で復元されるため、取引終了時刻も同じ日付・時刻で比べる
+	c := session.Default().Close(date).In(session.Location())
+	closedAt := time.Date(date.Year(), date.Month(), date.Day(), c.Hour(), c.Minute(), c.Second(), 0, time.Local)
+	for symbol, bar := range bars {
+		bar.Partial = lastTimes[symbol].Before(closedAt)
+		bars[symbol] = bar
+	}
+	return bars, nil
+}
+
+// AppendSession は1日分の Tick 記録から日足を組み立ててストアへ追記し、追記した銘柄数と Partial の銘柄数を返します。
+// 同じ日を再度追記した場合は上書きされるため、途中再起動などで複数回呼ばれても問題ありません。
+// Partial の日足は翌営業日に Feeder が公式の前日終値で補正するまで、前日終値・SMA の根拠になりません。
+func AppendSession(store *Store, ticksPath string) (n, partial int, err error) {
+	bars, err := SessionBars(ticksPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	for symbol, bar := range bars {
+		if err := store.Upsert(symbol, bar); err != nil {
+			return 0, 0, err
+		}
+		if bar.Partial {
+			partial++
+		}
+	}
+	return len(bars), partial, nil
+}
+
+// SessionArchiver は終了時に当日の Tick 記録を日足ストアへ追記します
+type SessionArchiver struct {
+	store     *Store
+	ticksPath string
+}
+
+// NewSessionArchiver は ticksPath の Tick 記録を store へ追記する SessionArchiver を作成します
+func NewSessionArchiver(store *Store, ticksPath string) *SessionArchiver {
+	return &SessionArchiver{store: store, ticksPath: ticksPath}
+}
+
+// ArchiveSession は当日の日足をストアへ追記します
+func (a *SessionArchiver) ArchiveSession() error {
+	n, partial, err := AppendSession(a.store, a.ticksPath)
+	if err != nil {
+		return err
+	}
+	slog.Info("🗄️ 当日の日足をストアへ追記しました", slog.String("ticks", a.ticksPath), slog.Int("symbols", n), slog.Int("partial", partial))
+	return nil
+}
@@ -0,0 +1,206 @@
+package daily
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// DefaultDir は日足ストアの既定の保存先です
+const DefaultDir = "./data/daily"
+
+// dateLayout は日足ファイルの日付列の書式です
+const dateLayout = "2006-01-02"
+
+var fileHeader = []string{"Date", "Open", "High", "Low", "Close", "Volume", "Partial"}
+
+// minColumns は日足ファイルの必須の列数です（Partial 列の無い以前のファイルは確定した日足として読み込みます）
+const minColumns = 6
+
+// Store は銘柄ごとの日足を "<dir>/<symbol>.csv" に保存するローカルストアです。
+// 同じ日付の日足は後から書き込んだもので上書きされます。
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore は dir 配下に日足を保存する Store を作成します
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) path(symbol string) string {
+	return filepath.Join(s.dir, symbol+".csv")
+}
+
+// Bars は銘柄の全日足を古い順に返します。ファイルが無い場合は空を返します
+func (s *Store) Bars(symbol string) ([]tick.DailyBar, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(symbol)
+}
+
+// Upsert は日足を追加します。既に同じ日付の日足がある場合は置き換えます
+func (s *Store) Upsert(symbol string, bars ...tick.DailyBar) error {
+	if len(bars) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.load(symbol)
+	if err != nil {
+		return err
+	}
+
+	byDate := make(map[string]tick.DailyBar, len(existing)+len(bars))
+	for _, b := range existing {
+		byDate[b.Date.Format(dateLayout)] = b
+	}
+	for _, b := range bars {
+		b.Date = truncateDate(b.Date)
+		byDate[b.Date.Format(dateLayout)] = b
+	}
+
+	merged := make([]tick.DailyBar, 0, len(byDate))
+	for _, b := range byDate {
+		merged = append(merged, b)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Date.Before(merged[j].Date) })
+
+	return s.write(symbol, merged)
+}
+
+func (s *Store) load(symbol string) ([]tick.DailyBar, error) {
+	file, err := os.Open(s.path(symbol))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	if _, err := reader.Read(); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var bars []tick.DailyBar
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read daily bars of %s: %w", symbol, err)
+		}
+		bar, err := parseStoredBar(record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid daily bar of %s: %w", symbol, err)
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+// write は一時ファイルに書いてから置き換えることで、書き込み途中のクラッシュで既存の履歴を壊さないようにします
+func (s *Store) write(symbol string, bars []tick.DailyBar) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	path := s.path(symbol)
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	_ = writer.Write(fileHeader)
+	for _, b := range bars {
+		_ = writer.Write([]string{
+			b.Date.Format(dateLayout),
+			formatFloat(b.Open),
+			formatFloat(b.High),
+			formatFloat(b.Low),
+			formatFloat(b.Close),
+			formatFloat(b.Volume),
+			formatPartial(b.Partial),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func parseStoredBar(record []string) (tick.DailyBar, error) {
+	if len(record) < minColumns {
+		return tick.DailyBar{}, fmt.Errorf("too few columns: %d", len(record))
+	}
+	date, err := time.ParseInLocation(dateLayout, record[0], time.Local)
+	if err != nil {
+		return tick.DailyBar{}, err
+	}
+	values := make([]float64, 5)
+	for i := range values {
+		v, err := strconv.ParseFloat(record[i+1], 64)
+		if err != nil {
+			return tick.DailyBar{}, err
+		}
+		values[i] = v
+	}
+	partial := false
+	if len(record) > minColumns && record[minColumns] != "" {
+		if partial, err = strconv.ParseBool(record[minColumns]); err != nil {
+			return tick.DailyBar{}, err
+		}
+	}
+	return tick.DailyBar{
+		Date:    date,
+		Open:    values[0],
+		High:    values[1],
+		Low:     values[2],
+		Close:   values[3],
+		Volume:  values[4],
+		Partial: partial,
+	}, nil
+}
+
+// formatPartial は確定した日足を空欄で書き出します
+func formatPartial(partial bool) string {
+	if !partial {
+		return ""
+	}
+	return "true"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// truncateDate は時刻を切り捨ててローカルタイムの日付のみにします
+func truncateDate(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,130 @@
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// maxLineSize は子プロセスから受け取る1行の上限です
+const maxLineSize = 1 << 20
+
+// errBusy は子プロセスが標準入力を読まず、送信待ちのメッセージが溜まっている場合のエラーです
+var errBusy = errors.New("子プロセスが標準入力を読んでいないため送信できません")
+
+// process は起動中の子プロセスです。
+// 標準入力への書き込みと標準出力の読み込みはそれぞれ専用の goroutine が行い、呼び出し側（スナイパーのロックの中）を塞ぎません。
+// 応答は responses に流し、子プロセスが終了すると done が閉じられます。
+type process struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	requests  chan Request
+	responses chan Response
+	done      chan struct{}
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+func startProcess(command string, args []string) (*process, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &process{
+		cmd:       cmd,
+		stdin:     stdin,
+		requests:  make(chan Request, 16),
+		responses: make(chan Response, 16),
+		done:      make(chan struct{}),
+		stop:      make(chan struct{}),
+	}
+	go p.readLoop(stdout)
+	go p.writeLoop()
+	return p, nil
+}
+
+// writeLoop は送信待ちのメッセージを1行の JSON として標準入力に書き込みます
+func (p *process) writeLoop() {
+	enc := json.NewEncoder(p.stdin)
+	for {
+		select {
+		case req := <-p.requests:
+			if err := enc.Encode(req); err != nil {
+				// パイプが閉じられた場合など。readLoop がプロセスの終了を検知して done を閉じる
+				slog.Warn("⚠️ [EXTERNAL] 子プロセスへの書き込みに失敗しました",
+					slog.String("command", p.cmd.Path), slog.String("method", req.Method), slog.Any("error", err))
+				p.cmd.Process.Kill()
+				return
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *process) readLoop(stdout io.Reader) {
+	defer func() {
+		p.cmd.Wait()
+		close(p.done)
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var res Response
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			slog.Warn("⚠️ [EXTERNAL] 子プロセスの出力を JSON-RPC の応答として解釈できないため無視します",
+				slog.String("command", p.cmd.Path), slog.String("line", scanner.Text()), slog.Any("error", err))
+			continue
+		}
+		select {
+		case p.responses <- res:
+		case <-p.stop:
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		// 1行が長すぎる場合など。以降の応答と対応が取れなくなるためプロセスを止める
+		slog.Warn("⚠️ [EXTERNAL] 子プロセスの出力の読み込みに失敗しました", slog.String("command", p.cmd.Path), slog.Any("error", err))
+		p.cmd.Process.Kill()
+	}
+}
+
+// send はメッセージを送信待ちに積みます。書き込みは writeLoop が行うため、子プロセスが読まなくても待ちません。
+// 送信待ちが溢れている場合は errBusy を返します
+func (p *process) send(req Request) error {
+	req.JSONRPC = jsonRPCVersion
+	select {
+	case p.requests <- req:
+		return nil
+	case <-p.done:
+		return fmt.Errorf("%s の送信に失敗しました: 子プロセスは終了しています", req.Method)
+	default:
+		return fmt.Errorf("%s の送信に失敗しました: %w", req.Method, errBusy)
+	}
+}
+
+// kill は標準入力を閉じて子プロセスを強制終了します
+func (p *process) kill() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		p.stdin.Close()
+		p.cmd.Process.Kill()
+	})
+}
@@ -0,0 +1,195 @@
+package external
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+)
+
+// 子プロセスとは標準入出力で JSON-RPC 2.0 のメッセージを1行ずつやり取りします。
+//
+//	→ {"jsonrpc":"2.0","method":"initialize","params":{"symbol":"7203","params":{...}}}          起動直後に1度（通知）
+//	→ {"jsonrpc":"2.0","id":1,"method":"evaluate","params":{"symbol":"7203","tick":{...},...}}   Tick 毎
+//	← {"jsonrpc":"2.0","id":1,"result":{"qty":100,"order_type":"market","reason":"..."}}
+//
+// 子プロセスは標準入力が閉じられたら終了してください。標準エラー出力はそのまま Bot のログに流れます。
+const (
+	methodInitialize = "initialize"
+	methodEvaluate   = "evaluate"
+	jsonRPCVersion   = "2.0"
+)
+
+// Request は Bot から子プロセスへのメッセージです（ID が nil のものは応答不要の通知）
+type Request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *uint64     `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// InitializeParams は起動直後に送る initialize 通知のパラメータです
+type InitializeParams struct {
+	Symbol string                 `json:"symbol"`
+	Params map[string]interface{} `json:"params"` // operations.json の strategy_params.external.params
+}
+
+// EvaluateParams は Tick 毎に送る evaluate リクエストのパラメータです
+type EvaluateParams struct {
+	Symbol     string             `json:"symbol"`
+	Tick       TickMessage        `json:"tick"`
+	Position   PositionMessage    `json:"position"`
+	Indicators map[string]float64 `json:"indicators"` // ウォームアップ中の指標は含まれません
+}
+
+// TickMessage は最新の Tick です
+type TickMessage struct {
+	Time      time.Time `json:"time"`
+	Price     float64   `json:"price"`
+	VWAP      float64   `json:"vwap"`
+	Volume    float64   `json:"volume"` // 当日の累積出来高
+	Bid       float64   `json:"bid"`
+	Ask       float64   `json:"ask"`
+	Execution bool      `json:"execution"` // この Tick で約定があったか
+}
+
+// PositionMessage は現在の建玉です（ショートはマイナス）
+type PositionMessage struct {
+	Qty          float64 `json:"qty"`
+	AveragePrice float64 `json:"average_price"`
+}
+
+// Response は子プロセスからの evaluate への応答です
+type Response struct {
+	JSONRPC string         `json:"jsonrpc"`
+	ID      uint64         `json:"id"`
+	Result  *TargetMessage `json:"result,omitempty"`
+	Error   *RPCError      `json:"error,omitempty"`
+}
+
+// RPCError は JSON-RPC のエラーオブジェクトです
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// TargetMessage は子プロセスが返すターゲットポジションです
+type TargetMessage struct {
+	Hold      bool           `json:"hold"`       // true の場合は現在の建玉を維持する（qty などは無視）
+	Qty       float64        `json:"qty"`        // プラスならロング、マイナスならショート、0ならノーポジ
+	Price     float64        `json:"price"`      // 指値（成行の場合は 0）
+	OrderType string         `json:"order_type"` // "market" / "limit" / "stop" / "stop_limit"（省略時は price が 0 なら成行、それ以外は指値）
+	StopPrice float64        `json:"stop_price"` // 逆指値のトリガー価格（stop / stop_limit の場合）
+	Reason    string         `json:"reason"`
+	IfDone    *IfDoneMessage `json:"if_done,omitempty"` // 新規建てと同時に発注する決済注文
+	Trail     *TrailMessage  `json:"trail,omitempty"`   // トレーリングストップ（hold の場合も反映する）
+}
+
+// IfDoneMessage は IFD の決済注文です
+type IfDoneMessage struct {
+	Price     float64 `json:"price"`
+	OrderType string  `json:"order_type"`
+	StopPrice float64 `json:"stop_price"`
+	Reason    string  `json:"reason"`
+}
+
+// TrailMessage はトレーリングストップの指定です
+type TrailMessage struct {
+	Type   string  `json:"type"`  // "ticks" / "percent" / "atr"
+	Width  float64 `json:"width"` // 逆行を許す幅（呼値の数・パーセント・ATR の倍率）
+	ATR    float64 `json:"atr"`   // type が atr の場合の現在の ATR
+	Reason string  `json:"reason"`
+}
+
+// apply はトレーリングストップの指定を目標に反映します
+func (m *TrailMessage) apply(target *strategy.TargetPosition) error {
+	switch t := strategy.TrailType(m.Type); t {
+	case strategy.TRAIL_TYPE_TICKS, strategy.TRAIL_TYPE_PERCENT, strategy.TRAIL_TYPE_ATR:
+		target.TrailType = t
+	default:
+		return fmt.Errorf("type %q は指定できません（ticks / percent / atr）", m.Type)
+	}
+	if m.Width <= 0 {
+		return fmt.Errorf("width には正の値を指定してください (%v)", m.Width)
+	}
+	target.TrailWidth = m.Width
+	target.TrailATR = m.ATR
+	target.TrailReason = m.Reason
+	return nil
+}
+
+func parseOrderType(s string, price, stopPrice float64) (order.OrderType, error) {
+	switch s {
+	case "stop", "stop_limit":
+		if stopPrice <= 0 {
+			return 0, fmt.Errorf("逆指値注文には stop_price が必要です")
+		}
+		if s == "stop" {
+			return order.ORDER_TYPE_STOP, nil
+		}
+		if price <= 0 {
+			return 0, fmt.Errorf("逆指値（指値）注文には price が必要です")
+		}
+		return order.ORDER_TYPE_STOP_LIMIT, nil
+	case "market":
+		return order.ORDER_TYPE_MARKET, nil
+	case "limit":
+		if price <= 0 {
+			return 0, fmt.Errorf("指値注文には price が必要です")
+		}
+		return order.ORDER_TYPE_LIMIT, nil
+	case "":
+		if price > 0 {
+			return order.ORDER_TYPE_LIMIT, nil
+		}
+		return order.ORDER_TYPE_MARKET, nil
+	}
+	return 0, fmt.Errorf("order_type %q は指定できません（market / limit / stop / stop_limit）", s)
+}
+
+// toTarget は子プロセスの応答を TargetPosition に変換します
+func (m *TargetMessage) toTarget(input strategy.StrategyInput) (strategy.TargetPosition, error) {
+	if m.Hold {
+		target := strategy.TargetPosition{Qty: input.HoldQty()}
+		if m.Trail != nil {
+			if err := m.Trail.apply(&target); err != nil {
+				return strategy.TargetPosition{}, fmt.Errorf("trail: %w", err)
+			}
+		}
+		return target, nil
+	}
+	orderType, err := parseOrderType(m.OrderType, m.Price, m.StopPrice)
+	if err != nil {
+		return strategy.TargetPosition{}, err
+	}
+	target := strategy.TargetPosition{Qty: m.Qty, Price: m.Price, OrderType: orderType, Reason: m.Reason}
+	if orderType == order.ORDER_TYPE_MARKET || orderType == order.ORDER_TYPE_STOP {
+		target.Price = 0
+	}
+	if orderType.IsStop() {
+		target.StopPrice = m.StopPrice
+	}
+	if m.IfDone != nil {
+		exitType, err := parseOrderType(m.IfDone.OrderType, m.IfDone.Price, m.IfDone.StopPrice)
+		if err != nil {
+			return strategy.TargetPosition{}, fmt.Errorf("if_done: %w", err)
+		}
+		target.HasIfDone = true
+		target.ExitPrice = m.IfDone.Price
+		target.ExitOrderType = exitType
+		if exitType == order.ORDER_TYPE_STOP {
+			target.ExitPrice = 0
+		}
+		if exitType.IsStop() {
+			target.ExitStopPrice = m.IfDone.StopPrice
+		}
+		target.ExitReason = m.IfDone.Reason
+	}
+	if m.Trail != nil {
+		if err := m.Trail.apply(&target); err != nil {
+			return strategy.TargetPosition{}, fmt.Errorf("trail: %w", err)
+		}
+	}
+	return target, nil
+}
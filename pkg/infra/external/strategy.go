@@ -0,0 +1,313 @@
+// Package external は Go 以外の言語で書かれた戦略を子プロセスとして動かす external 戦略を提供します。
+// 戦略ごとに子プロセスを1つ起動し、Tick 毎に StrategyInput を JSON-RPC で送って TargetPosition を受け取ります。
+package external
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// Params は external 戦略のパラメータです（operations.json の strategy_params.external）
+type Params struct {
+	Command        string                 `json:"command"`         // 実行するコマンド（PATH から検索）
+	Args           []string               `json:"args"`            // コマンドの引数
+	Params         map[string]interface{} `json:"params"`          // initialize で子プロセスに渡す任意のパラメータ
+	Indicators     map[string]string      `json:"indicators"`      // Tick 毎に渡す指標（名前 → rules 戦略と同じ数値の式）
+	Bar            time.Duration          `json:"bar"`             // 指標の計算に使う時間足
+	Timeout        time.Duration          `json:"timeout"`         // 1 Tick あたりの応答期限
+	RestartBackoff time.Duration          `json:"restart_backoff"` // 異常終了から再起動までの待ち時間（連続で落ちるたびに倍にする）
+	MaxBackoff     time.Duration          `json:"max_backoff"`     // 再起動までの待ち時間の上限
+	MaxTimeouts    int                    `json:"max_timeouts"`    // 応答が期限に間に合わない Tick がこの回数続いたら再起動する
+}
+
+var paramSchema = strategy.ParamSchema{
+	Fields: []strategy.ParamField{
+		{Name: "command", Type: strategy.PARAM_TYPE_STRING, Required: true, Description: "実行するコマンド"},
+		{Name: "args", Type: strategy.PARAM_TYPE_LIST, Description: "コマンドの引数（文字列の配列）"},
+		{Name: "params", Type: strategy.PARAM_TYPE_OBJECT, Description: "子プロセスに渡すパラメータ"},
+		{Name: "indicators", Type: strategy.PARAM_TYPE_OBJECT, Description: "子プロセスに渡す指標（名前 → 数値の式）"},
+		{Name: "bar", Type: strategy.PARAM_TYPE_DURATION, Default: "1m", Min: strategy.Bound(1), Description: "指標の計算に使う時間足"},
+		{Name: "timeout", Type: strategy.PARAM_TYPE_DURATION, Default: "50ms", Min: strategy.Bound(0.001), Description: "1 Tick あたりの応答期限"},
+		{Name: "restart_backoff", Type: strategy.PARAM_TYPE_DURATION, Default: "1s", Min: strategy.Bound(0), Description: "異常終了から再起動までの待ち時間"},
+		{Name: "max_backoff", Type: strategy.PARAM_TYPE_DURATION, Default: "30s", Min: strategy.Bound(0), Description: "再起動までの待ち時間の上限"},
+		{Name: "max_timeouts", Type: strategy.PARAM_TYPE_INT, Default: 10, Min: strategy.Bound(1), Description: "応答が期限に間に合わない Tick が続いた場合に再起動する回数"},
+	},
+	New: func() interface{} { return &Params{} },
+}
+
+// Strategy は子プロセスに判断を委ねる戦略です。
+//   - 応答が timeout 以内に返らない Tick は建玉を維持する（遅れて届いた応答は捨てる）。標準入力を読まず送信できない場合も同じ
+//   - 期限切れが max_timeouts 回続いた場合は固まったとみなし、落ちた場合と同じく再起動する
+//   - 子プロセスが落ちた場合も建玉を維持し、restart_backoff 後に再起動する。連続で落ちるたびに待ち時間を倍にする
+type Strategy struct {
+	detail     symbol.Symbol
+	dataPool   tick.DataPool
+	params     Params
+	indicators *strategy.IndicatorSet
+	values     map[string]float64
+
+	proc      *process
+	nextID    uint64
+	timer     *time.Timer
+	backoff   time.Duration
+	restartAt time.Time
+	timeouts  int
+	now       func() time.Time
+}
+
+func (s *Strategy) Name() string {
+	return "external"
+}
+
+func (s *Strategy) AnalysisLogger() *slog.Logger {
+	return nil
+}
+
+// Evaluate は子プロセスに evaluate を送り、timeout まで応答を待ちます
+func (s *Strategy) Evaluate(input strategy.StrategyInput) strategy.TargetPosition {
+	hold := strategy.TargetPosition{Qty: input.HoldQty()}
+	p := s.ensureProcess()
+	if p == nil {
+		return hold
+	}
+
+	// 前の Tick で期限切れになった応答が残っていれば捨てる
+	for len(p.responses) > 0 {
+		<-p.responses
+	}
+
+	s.nextID++
+	id := s.nextID
+	if err := p.send(Request{ID: &id, Method: methodEvaluate, Params: s.evaluateParams(input)}); err != nil {
+		if errors.Is(err, errBusy) {
+			s.timedOut()
+		} else {
+			s.crashed(err)
+		}
+		return hold
+	}
+
+	s.timer.Reset(s.params.Timeout)
+	defer s.timer.Stop()
+	for {
+		select {
+		case res := <-p.responses:
+			if res.ID != id {
+				continue
+			}
+			s.timeouts = 0
+			s.backoff = s.params.RestartBackoff
+			if res.Error != nil {
+				slog.Warn("⚠️ [EXTERNAL] 子プロセスがエラーを返したため建玉を維持します",
+					slog.String("symbol", s.detail.Code), slog.Int("code", res.Error.Code), slog.String("message", res.Error.Message))
+				return hold
+			}
+			if res.Result == nil {
+				slog.Warn("⚠️ [EXTERNAL] 子プロセスの応答に result が無いため建玉を維持します", slog.String("symbol", s.detail.Code))
+				return hold
+			}
+			target, err := res.Result.toTarget(input)
+			if err != nil {
+				slog.Warn("⚠️ [EXTERNAL] 子プロセスの応答が不正なため建玉を維持します", slog.String("symbol", s.detail.Code), slog.Any("error", err))
+				return hold
+			}
+			return target
+		case <-p.done:
+			s.crashed(errors.New("子プロセスが終了しました"))
+			return hold
+		case <-s.timer.C:
+			s.timedOut()
+			return hold
+		}
+	}
+}
+
+// timedOut は応答の期限切れを数え、max_timeouts 回続いたら子プロセスを再起動します
+func (s *Strategy) timedOut() {
+	s.timeouts++
+	if s.timeouts == 1 {
+		slog.Warn("⏱️ [EXTERNAL] 子プロセスの応答が期限に間に合わないため建玉を維持します",
+			slog.String("symbol", s.detail.Code), slog.Duration("timeout", s.params.Timeout))
+	}
+	if s.timeouts >= s.params.MaxTimeouts {
+		s.crashed(fmt.Errorf("%d 回続けて応答が期限に間に合いませんでした", s.timeouts))
+	}
+}
+
+func (s *Strategy) evaluateParams(input strategy.StrategyInput) EvaluateParams {
+	t := input.LatestTick
+	if s.indicators != nil {
+		s.indicators.Values(input, s.values)
+	}
+	return EvaluateParams{
+		Symbol: s.detail.Code,
+		Tick: TickMessage{
+			Time:      t.CurrentPriceTime,
+			Price:     t.Price,
+			VWAP:      t.VWAP,
+			Volume:    t.TradingVolume,
+			Bid:       t.BestBid.Price,
+			Ask:       t.BestAsk.Price,
+			Execution: t.IsExecution(),
+		},
+		Position:   PositionMessage{Qty: input.HoldQty(), AveragePrice: input.AveragePrice()},
+		Indicators: s.values,
+	}
+}
+
+// ensureProcess は子プロセスを返します。停止中で再起動の待ち時間が過ぎていれば起動し直します
+func (s *Strategy) ensureProcess() *process {
+	if s.proc != nil {
+		return s.proc
+	}
+	if s.now().Before(s.restartAt) {
+		return nil
+	}
+	p, err := startProcess(s.params.Command, s.params.Args)
+	if err != nil {
+		s.crashed(err)
+		return nil
+	}
+	if err := p.send(Request{Method: methodInitialize, Params: InitializeParams{Symbol: s.detail.Code, Params: s.params.Params}}); err != nil {
+		p.kill()
+		s.crashed(err)
+		return nil
+	}
+	slog.Info("🚀 [EXTERNAL] 外部戦略のプロセスを起動しました", slog.String("symbol", s.detail.Code), slog.String("command", s.params.Command))
+	s.proc = p
+	return p
+}
+
+// crashed は子プロセスを止め、backoff 後に再起動するよう予約します
+func (s *Strategy) crashed(err error) {
+	if s.proc != nil {
+		s.proc.kill()
+		s.proc = nil
+	}
+	s.timeouts = 0
+	s.restartAt = s.now().Add(s.backoff)
+	slog.Warn("💥 [EXTERNAL] 外部戦略のプロセスが停止しました。再起動するまで建玉を維持します",
+		slog.String("symbol", s.detail.Code), slog.String("command", s.params.Command),
+		slog.Duration("restart_in", s.backoff), slog.Any("error", err))
+	s.backoff = min(s.backoff*2, s.params.MaxBackoff)
+}
+
+// Reconfigure は稼働中にパラメータを差し替えます。子プロセスは次の Tick で新しいパラメータで起動し直します
+func (s *Strategy) Reconfigure(params interface{}) error {
+	p, ok := params.(*Params)
+	if !ok || p == nil {
+		decoded, err := (&Factory{}).DecodeParams(params, "")
+		if err != nil {
+			return err
+		}
+		p = decoded.(*Params)
+	}
+	var indicators *strategy.IndicatorSet
+	if len(p.Indicators) > 0 {
+		var err error
+		if indicators, err = strategy.CompileIndicators(p.Indicators, s.detail, s.dataPool, p.Bar); err != nil {
+			return err
+		}
+	}
+	s.Close()
+	s.timeouts = 0
+	s.params = *p
+	s.indicators = indicators
+	s.values = make(map[string]float64, len(p.Indicators))
+	s.backoff = p.RestartBackoff
+	s.restartAt = time.Time{}
+	return nil
+}
+
+// Close は子プロセスを終了させます（strategy.Closer）。以降の Evaluate で再び起動されます
+func (s *Strategy) Close() error {
+	if s.proc != nil {
+		s.proc.kill()
+		s.proc = nil
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Factory & Registration
+// ----------------------------------------------------------------------------
+
+type Factory struct{}
+
+// NewStrategy は戦略を生成します。子プロセスは最初の Evaluate で起動します
+func (f *Factory) NewStrategy(detail symbol.Symbol, dataPool tick.DataPool, params interface{}) strategy.Strategy {
+	p := strategy.ParamsOf[Params](paramSchema, params)
+	if p.Timeout <= 0 {
+		// 検証を経ずに生成された場合（必須の command が無いなど）はデフォルト値を使えないため補う
+		p.Bar, p.Timeout, p.RestartBackoff, p.MaxBackoff, p.MaxTimeouts = time.Minute, 50*time.Millisecond, time.Second, 30*time.Second, 10
+	}
+	s := &Strategy{
+		detail:   detail,
+		dataPool: dataPool,
+		params:   *p,
+		values:   make(map[string]float64, len(p.Indicators)),
+		timer:    time.NewTimer(0),
+		backoff:  p.RestartBackoff,
+		now:      time.Now,
+	}
+	s.timer.Stop()
+	if len(p.Indicators) > 0 {
+		indicators, err := strategy.CompileIndicators(p.Indicators, detail, dataPool, p.Bar)
+		if err != nil {
+			// 起動時に検証済みのため、ここに来るのは検証を経ずに生成された場合だけ。指標を渡さずに動かす
+			slog.Error("❌ external 戦略の指標の式が不正なため、指標を渡しません", slog.String("symbol", detail.Code), slog.Any("error", err))
+		}
+		s.indicators = indicators
+	}
+	return s
+}
+
+func (f *Factory) CreateExecutionPolicy(params interface{}) strategy.ExecutionPolicy {
+	return &strategy.NoopPolicy{}
+}
+
+// DecodeParams はパラメータを検証し、コマンドの存在と指標の式までを起動時に検査します
+func (f *Factory) DecodeParams(params interface{}, path string) (interface{}, error) {
+	decoded, err := paramSchema.Decode(params, path)
+	if err != nil {
+		return nil, err
+	}
+	p := decoded.(*Params)
+	if _, err := exec.LookPath(p.Command); err != nil {
+		return nil, &strategy.ParamError{Path: joinPath(path, "command"), Err: fmt.Errorf("コマンドが見つかりません: %w", err)}
+	}
+	if p.MaxBackoff < p.RestartBackoff {
+		return nil, &strategy.ParamError{Path: joinPath(path, "max_backoff"), Err: fmt.Errorf("%w: restart_backoff 以上を指定してください", strategy.ErrParamRange)}
+	}
+	if _, err := strategy.CompileIndicators(p.Indicators, symbol.Symbol{}, nil, p.Bar); err != nil {
+		var pe *strategy.ParamError
+		if errors.As(err, &pe) {
+			pe.Path = joinPath(joinPath(path, "indicators"), pe.Path)
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// ParamSchema は external 戦略のパラメータ定義を返します
+func (f *Factory) ParamSchema() strategy.ParamSchema {
+	return paramSchema
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func init() {
+	strategy.Register("external", &Factory{})
+}
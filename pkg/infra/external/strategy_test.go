@@ -0,0 +1,258 @@
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// TestHelperProcess はテスト用の外部戦略です。テストバイナリ自身を子プロセスとして起動し、
+// "--" の後ろのモードに従って応答します（通常のテストとして実行された場合は何もしません）。
+//   - echo:  ノーポジなら price で 100 株の指値を返す。reason に initialize のパラメータと指標を載せる
+//   - slow:  応答の前に 200ms 待つ
+//   - crash: 最初の evaluate を受け取ったら異常終了する
+//   - deaf:  標準入力を読まずに止まったままになる
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_EXTERNAL_STRATEGY") != "1" {
+		return
+	}
+	mode := os.Args[len(os.Args)-1]
+	if mode == "deaf" {
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+
+	var init InitializeParams
+	out := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     *uint64         `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+		if req.Method == methodInitialize {
+			json.Unmarshal(req.Params, &init)
+			continue
+		}
+
+		var in EvaluateParams
+		json.Unmarshal(req.Params, &in)
+		switch mode {
+		case "crash":
+			os.Exit(3)
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+		result := &TargetMessage{Hold: true}
+		if in.Position.Qty == 0 {
+			result = &TargetMessage{
+				Qty:       100,
+				Price:     in.Tick.Price,
+				OrderType: "limit",
+				Reason:    fmt.Sprintf("%v sma=%v", init.Params["tag"], in.Indicators["sma"]),
+				IfDone:    &IfDoneMessage{Price: in.Tick.Price + 10, Reason: "TakeProfit"},
+			}
+		}
+		out.Encode(Response{JSONRPC: jsonRPCVersion, ID: *req.ID, Result: result})
+	}
+	os.Exit(0)
+}
+
+func newTestStrategy(t *testing.T, pool tick.DataPool, mode string, params map[string]interface{}) *Strategy {
+	t.Helper()
+	t.Setenv("GO_WANT_EXTERNAL_STRATEGY", "1")
+	params["command"] = os.Args[0]
+	params["args"] = []interface{}{"-test.run=^TestHelperProcess$", "--", mode}
+
+	factory, _ := strategy.GetFactory("external")
+	decoded, err := strategy.PrepareParams(factory, params, "external")
+	if err != nil {
+		t.Fatalf("PrepareParams failed: %v", err)
+	}
+	s := factory.NewStrategy(symbol.Symbol{Code: "7203", TradingUnit: 100}, pool, decoded).(*Strategy)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStrategy_Evaluate(t *testing.T) {
+	pool := tick.NewDefaultDataPool(nil)
+	s := newTestStrategy(t, pool, "echo", map[string]interface{}{
+		"timeout":    "5s", // 子プロセスの起動を待てるよう長めにする
+		"params":     map[string]interface{}{"tag": "hello"},
+		"indicators": map[string]interface{}{"sma": "sma(2)"},
+	})
+
+	start := time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+	var last tick.Tick
+	for i, price := range []float64{1000, 1010, 1020} {
+		last = tick.Tick{Symbol: "7203", Price: price, TradingVolume: float64(100 * (i + 1)), CurrentPriceTime: start.Add(time.Duration(i) * time.Minute)}
+		pool.PushTick(last)
+	}
+
+	got := s.Evaluate(strategy.StrategyInput{LatestTick: last})
+	if got.Qty != 100 || got.Price != 1020 || got.OrderType != order.ORDER_TYPE_LIMIT || got.Reason != "hello sma=1005" {
+		t.Fatalf("unexpected target: %+v", got)
+	}
+	if !got.HasIfDone || got.ExitPrice != 1030 || got.ExitOrderType != order.ORDER_TYPE_LIMIT || got.ExitReason != "TakeProfit" {
+		t.Errorf("unexpected if-done exit: %+v", got)
+	}
+
+	holding := strategy.StrategyInput{Position: strategy.Position{Qty: 100, AveragePrice: 1020}, LatestTick: last}
+	if got := s.Evaluate(holding); got.Qty != 100 || got.Reason != "" {
+		t.Errorf("expected hold, got %+v", got)
+	}
+}
+
+func TestStrategy_Timeout(t *testing.T) {
+	s := newTestStrategy(t, tick.NewDefaultDataPool(nil), "slow", map[string]interface{}{"timeout": "20ms"})
+	input := strategy.StrategyInput{Position: strategy.Position{Qty: 100}, LatestTick: tick.Tick{Symbol: "7203", Price: 1000}}
+
+	for i := 0; i < 2; i++ {
+		begin := time.Now()
+		got := s.Evaluate(input)
+		if got.Qty != 100 {
+			t.Errorf("expected hold on timeout, got %+v", got)
+		}
+		if elapsed := time.Since(begin); elapsed > 150*time.Millisecond {
+			t.Errorf("Evaluate should return by the deadline, took %v", elapsed)
+		}
+	}
+	if s.proc == nil {
+		t.Errorf("a slow process should not be restarted")
+	}
+}
+
+func TestStrategy_RestartAfterConsecutiveTimeouts(t *testing.T) {
+	s := newTestStrategy(t, tick.NewDefaultDataPool(nil), "slow", map[string]interface{}{
+		"timeout":      "20ms",
+		"max_timeouts": 2.0,
+	})
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+	s.now = func() time.Time { return now }
+	input := strategy.StrategyInput{Position: strategy.Position{Qty: 100}, LatestTick: tick.Tick{Symbol: "7203", Price: 1000}}
+
+	if got := s.Evaluate(input); got.Qty != 100 || s.proc == nil {
+		t.Fatalf("expected hold without restart on the first timeout, got %+v", got)
+	}
+	// 期限切れが max_timeouts 回続いたら固まったとみなし、落ちた場合と同じく backoff 後に再起動する
+	if got := s.Evaluate(input); got.Qty != 100 {
+		t.Errorf("expected hold, got %+v", got)
+	}
+	if s.proc != nil || !s.restartAt.Equal(now.Add(time.Second)) || s.timeouts != 0 {
+		t.Errorf("expected a restart after consecutive timeouts, got proc=%v restart_in=%v timeouts=%d", s.proc, s.restartAt.Sub(now), s.timeouts)
+	}
+}
+
+func TestProcess_SendDoesNotBlockOnFullPipe(t *testing.T) {
+	t.Setenv("GO_WANT_EXTERNAL_STRATEGY", "1")
+	p, err := startProcess(os.Args[0], []string{"-test.run=^TestHelperProcess$", "--", "deaf"})
+	if err != nil {
+		t.Fatalf("startProcess failed: %v", err)
+	}
+	defer p.kill()
+
+	// 子プロセスが読まないためパイプが詰まり、送信待ちも溢れる。呼び出し側は待たずに errBusy を受け取る
+	payload := strings.Repeat("x", 256*1024)
+	begin := time.Now()
+	for i := 0; i < 64; i++ {
+		if err = p.send(Request{Method: methodInitialize, Params: payload}); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, errBusy) {
+		t.Fatalf("expected errBusy once the queue is full, got %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("send should not block on a full pipe, took %v", elapsed)
+	}
+}
+
+func TestStrategy_CrashRestart(t *testing.T) {
+	s := newTestStrategy(t, tick.NewDefaultDataPool(nil), "crash", map[string]interface{}{
+		"timeout":         "5s",
+		"restart_backoff": "1s",
+		"max_backoff":     "3s",
+	})
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local)
+	s.now = func() time.Time { return now }
+	input := strategy.StrategyInput{Position: strategy.Position{Qty: -100}, LatestTick: tick.Tick{Symbol: "7203", Price: 1000}}
+
+	// 落ちたら建玉を維持し、再起動までの待ち時間を倍にしていく（上限は max_backoff）
+	for _, wantBackoff := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		if got := s.Evaluate(input); got.Qty != -100 {
+			t.Fatalf("expected hold on crash, got %+v", got)
+		}
+		if s.proc != nil || !s.restartAt.Equal(now.Add(wantBackoff)) {
+			t.Fatalf("expected restart after %v, got %v", wantBackoff, s.restartAt.Sub(now))
+		}
+
+		// 待ち時間の間は起動しない
+		if got := s.Evaluate(input); got.Qty != -100 || s.proc != nil {
+			t.Fatalf("expected no restart during backoff, got %+v", got)
+		}
+		now = now.Add(wantBackoff)
+	}
+}
+
+func TestFactory_DecodeParams(t *testing.T) {
+	factory, _ := strategy.GetFactory("external")
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		wantPath string
+		wantMsg  string
+	}{
+		{"missing command", map[string]interface{}{}, "external.command", "必須"},
+		{"unknown command", map[string]interface{}{"command": "no-such-strategy-command"}, "external.command", "コマンドが見つかりません"},
+		{"bad indicator", map[string]interface{}{"command": os.Args[0], "indicators": map[string]interface{}{"fast": "sma(5) >"}}, "external.indicators.fast", "途中で終わって"},
+		{"bool indicator", map[string]interface{}{"command": os.Args[0], "indicators": map[string]interface{}{"up": "close > vwap"}}, "external.indicators.up", "数値の式"},
+		{"backoff", map[string]interface{}{"command": os.Args[0], "restart_backoff": "1m", "max_backoff": "10s"}, "external.max_backoff", "範囲外"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := strategy.PrepareParams(factory, tt.params, "external")
+			var pe *strategy.ParamError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected ParamError, got %v", err)
+			}
+			if pe.Path != tt.wantPath || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("expected %q at %q, got %v", tt.wantMsg, tt.wantPath, err)
+			}
+		})
+	}
+}
+
+func TestTargetMessage_Trail(t *testing.T) {
+	holding := strategy.StrategyInput{Position: strategy.Position{Qty: 100, AveragePrice: 1000}}
+
+	msg := TargetMessage{Hold: true, Trail: &TrailMessage{Type: "atr", Width: 2, ATR: 12.5}}
+	got, err := msg.toTarget(holding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Qty != 100 || got.TrailType != strategy.TRAIL_TYPE_ATR || got.TrailWidth != 2 || got.TrailATR != 12.5 {
+		t.Errorf("expected the trailing stop to be applied while holding, got %+v", got)
+	}
+
+	for _, bad := range []TrailMessage{{Type: "points", Width: 1}, {Type: "percent", Width: 0}} {
+		msg := TargetMessage{Qty: 100, Trail: &bad}
+		if _, err := msg.toTarget(holding); err == nil || !strings.HasPrefix(err.Error(), "trail:") {
+			t.Errorf("expected a trail error for %+v, got %v", bad, err)
+		}
+	}
+}
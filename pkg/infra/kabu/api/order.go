@@ -35,20 +35,41 @@ type ClosePosition struct {
 // OrderRequest は新規・決済注文を発注するためのリクエストデータです
 // https://kabucom.github.io/kabusapi/reference/index.html#operation/sendorderPost
 type OrderRequest struct {
-	Symbol             string          `json:"Symbol"`                       // 銘柄コード (例: "9434")
-	Exchange           ExchageType     `json:"Exchange"`                     // 市場コード (1: 東証)
-	SecurityType       int             `json:"SecurityType"`                 // 商品種別 (1: 株式)
-	Side               string          `json:"Side"`                         // 売買区分 ("1": 売, "2": 買)
-	CashMargin         int             `json:"CashMargin"`                   // 信用区分 (1: 現物, 2: 信用新規, 3: 信用返済)
-	MarginTradeType    int             `json:"MarginTradeType"`              // 信用取引区分 (1: 制度信用, 3: 一般信用デイトレ)
-	AccountType        int             `json:"AccountType"`                  // 口座種別 (4: 特定口座)
-	Qty                float64         `json:"Qty"`                          // 注文数量
-	Price              float64         `json:"Price"`                        // 注文価格 (0: 成行)
-	ExpireDay          int             `json:"ExpireDay"`                    // 注文有効期限 (0: 当日)
-	FrontOrderType     int32           `json:"FrontOrderType"`               // 執行条件 (10: 成行, 20: 指値)
-	DelivType          int32           `json:"DelivType"`                    // 受渡区分 (0: 指定なし, 2: お預かり金, 3: Auマネーコネクト)
-	ClosePositionOrder *int32          `json:"ClosePositionOrder,omitempty"` // 決済順序
-	ClosePositions     []ClosePosition `json:"ClosePositions,omitempty"`     // 指定返済
+	Symbol             string             `json:"Symbol"`                       // 銘柄コード (例: "9434")
+	Exchange           ExchageType        `json:"Exchange"`                     // 市場コード (1: 東証)
+	SecurityType       int                `json:"SecurityType"`                 // 商品種別 (1: 株式)
+	Side               string             `json:"Side"`                         // 売買区分 ("1": 売, "2": 買)
+	CashMargin         int                `json:"CashMargin"`                   // 信用区分 (1: 現物, 2: 信用新規, 3: 信用返済)
+	MarginTradeType    int                `json:"MarginTradeType"`              // 信用取引区分 (1: 制度信用, 3: 一般信用デイトレ)
+	AccountType        int                `json:"AccountType"`                  // 口座種別 (4: 特定口座)
+	Qty                float64            `json:"Qty"`                          // 注文数量
+	Price              float64            `json:"Price"`                        // 注文価格 (0: 成行)
+	ExpireDay          int                `json:"ExpireDay"`                    // 注文有効期限 (0: 当日)
+	FrontOrderType     int32              `json:"FrontOrderType"`               // 執行条件 (10: 成行, 20: 指値, 30: 逆指値)
+	DelivType          int32              `json:"DelivType"`                    // 受渡区分 (0: 指定なし, 2: お預かり金, 3: Auマネーコネクト)
+	ClosePositionOrder *int32             `json:"ClosePositionOrder,omitempty"` // 決済順序
+	ClosePositions     []ClosePosition    `json:"ClosePositions,omitempty"`     // 指定返済
+	ReverseLimitOrder  *ReverseLimitOrder `json:"ReverseLimitOrder,omitempty"`  // 逆指値条件 (FrontOrderType が 30 の場合のみ)
+}
+
+// 逆指値条件の定数です
+const (
+	TRIGGER_SEC_SYMBOL int32 = 1 // トリガ銘柄: 発注銘柄
+
+	UNDER_OVER_UNDER int32 = 1 // 以下
+	UNDER_OVER_OVER  int32 = 2 // 以上
+
+	AFTER_HIT_MARKET int32 = 1 // ヒット後執行条件: 成行
+	AFTER_HIT_LIMIT  int32 = 2 // ヒット後執行条件: 指値
+)
+
+// ReverseLimitOrder は逆指値（FrontOrderType: 30）の条件です
+type ReverseLimitOrder struct {
+	TriggerSec        int32   `json:"TriggerSec"`        // トリガ銘柄 (1: 発注銘柄)
+	TriggerPrice      float64 `json:"TriggerPrice"`      // トリガ価格
+	UnderOver         int32   `json:"UnderOver"`         // 以上・以下 (1: 以下, 2: 以上)
+	AfterHitOrderType int32   `json:"AfterHitOrderType"` // ヒット後執行条件 (1: 成行, 2: 指値)
+	AfterHitPrice     float64 `json:"AfterHitPrice"`     // ヒット後注文価格 (成行の場合は 0)
 }
 
 // OrderResponse は発注後のレスポンスデータです
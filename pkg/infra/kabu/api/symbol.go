@@ -68,8 +68,47 @@ type SymbolSuccess struct {
 	Symbol          string  `json:"Symbol"`
 	SymbolName      string  `json:"SymbolName"`
 	PriceRangeGroup string  `json:"PriceRangeGroup"`
-	UpperLimit      float64 `json:"UpperLimit"`
-	LowerLimit      float64 `json:"LowerLimit"`
+	TradingUnit     float64 `json:"TradingUnit"`  // 売買単位
+	MarginBuy       bool    `json:"MarginBuy"`    // 制度信用買可否
+	MarginSell      bool    `json:"MarginSell"`   // 制度信用売可否
+	KCMarginBuy     bool    `json:"KCMarginBuy"`  // 一般信用買可否
+	KCMarginSell    bool    `json:"KCMarginSell"` // 一般信用売可否
+	UpperLimit      float64 `json:"UpperLimit"`   // 値幅上限
+	LowerLimit      float64 `json:"LowerLimit"`   // 値幅下限
+}
+
+// MarginPremiumDetail は一般信用（長期）またはデイトレのプレミアム料情報です
+type MarginPremiumDetail struct {
+	// MarginPremiumType はプレミアム料入力区分です（null: 対象外, 0: プレミアム料なし, 1: 固定, 2: 入札）
+	MarginPremiumType *int     `json:"MarginPremiumType"`
+	MarginPremium     *float64 `json:"MarginPremium"`
+}
+
+// MarginPremiumResponse は /margin/marginpremium のレスポンスです
+type MarginPremiumResponse struct {
+	Symbol        string              `json:"Symbol"`
+	GeneralMargin MarginPremiumDetail `json:"GeneralMargin"`
+	DayTrade      MarginPremiumDetail `json:"DayTrade"`
+}
+
+// GetMarginPremium は銘柄の一般信用（長期・デイトレ）のプレミアム料情報を取得します。
+// デイトレの MarginPremiumType が null の銘柄は一般信用デイトレの対象外です。
+func (c *KabuClient) GetMarginPremium(symbol string) (*MarginPremiumResponse, error) {
+	resp, err := c.doRequest("GET", "/margin/marginpremium/"+symbol, nil)
+	if err != nil {
+		return nil, fmt.Errorf("プレミアム料取得API通信エラー: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("プレミアム料取得APIエラー: status=%d", resp.StatusCode)
+	}
+
+	var premiumResp MarginPremiumResponse
+	if err := json.NewDecoder(resp.Body).Decode(&premiumResp); err != nil {
+		return nil, fmt.Errorf("プレミアム料レスポンス解析エラー: %v", err)
+	}
+	return &premiumResp, nil
 }
 
 func (c *KabuClient) GetSymbol(symbol string, exchange ExchageType) (*SymbolSuccess, error) {
@@ -20,6 +20,7 @@ import (
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
 
+	"github.com/r-umemoto/trading-bot/pkg/infra/daily"
 	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/api"
 	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/storage"
 )
@@ -34,15 +35,33 @@ func NewMarketGateway(client *api.KabuClient, wsClient *api.WSClient) *MarketGat
 		childToParent:       make(map[string]string),
 		childClosePositions: make(map[string][]order.ClosePosition),
 		firedExecutions:     make(map[string]bool),
+		ocoGroups:           make(map[string]*ocoGroup),
+		ocoOrders:           make(map[string]*order.Order),
 		registeredSymbols:   make(map[string]market.ResisterSymbolRequest),
 		shortDisabledUntil:  make(map[string]time.Time),
 	}
+	// 日足ストアを優先し、履歴の無い銘柄の前日終値はカブコムAPIから取得する
 	kabuProvider := NewKabuHistoricalFeederProvider(m.client)
-	m.dataPool = tick.NewDefaultDataPool(kabuProvider)
+	historyProvider := daily.NewFeederProvider(daily.NewStore(daily.DefaultDir), time.Now(), kabuProvider)
+	m.dataPool = tick.NewDefaultDataPool(historyProvider)
+	m.historyProvider = historyProvider
+	// 既定ルールは常に有効なため、エラーにはならない
+	m.validator, _ = tick.NewValidator(tick.DefaultValidationRules())
 	m.dispatcher = NewOrderDispatcher(m)
 	return m
 }
 
+// UseTickValidator は受信した Tick を DataPool・戦略へ流す前に検証する Validator を差し替えます。
+// Listen の前に呼び出してください。
+func (m *MarketGateway) UseTickValidator(v *tick.Validator) {
+	m.validator = v
+}
+
+// TickValidator は Tick の品質検証ステージを返します
+func (m *MarketGateway) TickValidator() *tick.Validator {
+	return m.validator
+}
+
 type KabuClientInterface interface {
 	GetToken() error
 	GetOrders() ([]api.Order, error)
@@ -53,6 +72,7 @@ type KabuClientInterface interface {
 	UnregisterSymbolAll() (*api.UnregisterSymbolAllResponse, error)
 	GetSymbol(symbol string, exchange api.ExchageType) (*api.SymbolSuccess, error)
 	GetBoard(symbol string) (*api.BoardResponse, error)
+	GetMarginPremium(symbol string) (*api.MarginPremiumResponse, error)
 }
 
 type MarketGateway struct {
@@ -61,6 +81,7 @@ type MarketGateway struct {
 	tickChannels  map[string]chan tick.Tick
 	orderChannels map[string]chan order.Orders
 	dataPool      tick.DataPool
+	validator     *tick.Validator
 	dispatcher    *OrderDispatcher
 
 	// IFD tracking fields
@@ -70,12 +91,26 @@ type MarketGateway struct {
 	childClosePositions map[string][]order.ClosePosition // Key: Child Broker ID -> Value: ClosePositions specified
 	firedExecutions     map[string]bool         // Key: Execution ID -> Value: Fired child order
 
+	// OCO tracking fields (guarded by ifdMu)
+	ocoSeq    int
+	ocoGroups map[string]*ocoGroup    // Key: OCO Group ID -> Value: locally managed OCO pair (one resting order and one held leg)
+	ocoOrders map[string]*order.Order // Key: Broker ID -> Value: OCO leg as sent (restores type, trigger and reason)
+
 	// Registered symbols tracking for reconnection
 	regMu             sync.RWMutex
 	registeredSymbols map[string]market.ResisterSymbolRequest
 
 	shortDisabledMu sync.RWMutex
 	shortDisabledUntil map[string]time.Time // key: symbol
+
+	// 値幅制限の基準となる前日終値の取得元と、銘柄ごとの計算結果
+	historyProvider tick.HistoricalFeederProvider
+	limitsMu        sync.Mutex
+	priceLimits     map[string]symbol.PriceLimits // key: symbol
+
+	// 一般信用（デイトレ）の対象可否。取得できた銘柄のみ保持し、間隔を空けて再取得しても失敗した銘柄は次回の GetSymbol で再取得する
+	dayTradeMu sync.Mutex
+	dayTrade   map[string]bool // key: symbol
 }
 
 var _ market.MarketGateway = (*MarketGateway)(nil)
@@ -153,6 +188,9 @@ func (m *MarketGateway) SendOrder(ctx context.Context, input order.SendOrderInpu
 				m.ifdTracker[res.Order.ID] = res.Order.IfDone
 				m.ifdMu.Unlock()
 			}
+			if res.Order.OCO != nil {
+				m.holdOCOSibling(res.Order)
+			}
 			return res.Order, nil
 		}
 		return input.Order, nil
@@ -222,11 +260,39 @@ func (m *MarketGateway) SendOrderRaw(ctx context.Context, input order.SendOrderI
 		}
 	case order.ORDER_TYPE_LIMIT:
 		orderType = 20
+	case order.ORDER_TYPE_STOP, order.ORDER_TYPE_STOP_LIMIT:
+		orderType = 30
 	}
 	if orderType == 0 {
 		return ord, fmt.Errorf("注文種別が不正です")
 	}
 
+	// 逆指値は Price を 0 にし、トリガー価格とヒット後の執行条件を ReverseLimitOrder で指定する
+	price := ord.OrderPrice
+	var reverseLimit *api.ReverseLimitOrder
+	if ord.Type.IsStop() {
+		if ord.TriggerPrice <= 0 {
+			return ord, fmt.Errorf("バリデーションエラー: 逆指値のトリガー価格が指定されていません (Symbol: %s)", ord.Symbol)
+		}
+		reverseLimit = &api.ReverseLimitOrder{
+			TriggerSec:        api.TRIGGER_SEC_SYMBOL,
+			TriggerPrice:      ord.TriggerPrice,
+			UnderOver:         api.UNDER_OVER_UNDER,
+			AfterHitOrderType: api.AFTER_HIT_MARKET,
+		}
+		if ord.Action == order.ACTION_BUY {
+			reverseLimit.UnderOver = api.UNDER_OVER_OVER
+		}
+		if ord.Type == order.ORDER_TYPE_STOP_LIMIT {
+			if ord.OrderPrice <= 0 {
+				return ord, fmt.Errorf("バリデーションエラー: 逆指値（指値）のヒット後の価格が指定されていません (Symbol: %s)", ord.Symbol)
+			}
+			reverseLimit.AfterHitOrderType = api.AFTER_HIT_LIMIT
+			reverseLimit.AfterHitPrice = ord.OrderPrice
+		}
+		price = 0
+	}
+
 	deliverType := 0
 	switch cashMargin {
 	case order.CASH_MARGIN_CASH:
@@ -282,10 +348,11 @@ func (m *MarketGateway) SendOrderRaw(ctx context.Context, input order.SendOrderI
 		ExpireDay:          0,
 		Qty:                ord.OrderQty,
 		FrontOrderType:     int32(orderType),
-		Price:              ord.OrderPrice,
+		Price:              price,
 		DelivType:          int32(deliverType),
 		ClosePositionOrder: closePositionOrder,
 		ClosePositions:     closePositions,
+		ReverseLimitOrder:  reverseLimit,
 	}
 
 	fmt.Printf("発注完了 %+v\n", kabReq)
@@ -316,7 +383,12 @@ func (m *MarketGateway) CancelOrder(ctx context.Context, orderID string) error {
 		return fmt.Errorf("%w: local order not in queue (might be in-flight or already processed)", order.ErrDispatchQueueBypass)
 	}
 
-	// 3. なければ、取引所に対して実際にキャンセルを送信する
+	// 3. OCO の片方であれば組を解散する（保持している相方は取引所に出していないため、取消の送信は不要）
+	if orderID = m.cancelOCOGroup(orderID); orderID == "" {
+		return nil
+	}
+
+	// 4. 取引所に対して実際にキャンセルを送信する
 	jobID := "cancel_" + orderID
 	resCh := m.dispatcher.Submit(jobID, "", nil, orderID, 30)
 	select {
@@ -450,10 +522,25 @@ func (m *MarketGateway) GetOrders(ctx context.Context) (order.Orders, error) {
 				}
 			}
 		}
+		// OCO の注文はAPIの一覧に無い注文種別・トリガー価格・理由と組の識別子を、発注した内容から復元する
+		if leg, ok := m.ocoOrders[ord.ID]; ok {
+			o.Type = leg.Type
+			o.OrderPrice = leg.OrderPrice
+			o.TriggerPrice = leg.TriggerPrice
+			o.Reason = leg.Reason
+			o.OCOGroupID = leg.OCOGroupID
+			if o.Request == nil && leg.Request != nil {
+				o.Request = &order.OrderRequest{
+					ClosePositions: leg.Request.ClosePositions,
+				}
+			}
+		}
 		m.ifdMu.Unlock()
 
 		domainOrders = append(domainOrders, *o)
 	}
+	// 取引所に出さずに保持している OCO の相方も、注文として載せる
+	domainOrders = append(domainOrders, m.heldOCOOrders()...)
 
 	return order.Orders{Orders: domainOrders}, nil
 }
@@ -541,6 +628,8 @@ func (m *MarketGateway) startPollingLoop(ctx context.Context) {
 
 			// 自動発注されるIFD子注文のチェック
 			m.checkAndFireIFD(ctx, ords)
+			// ローカルで管理している OCO の組のチェック
+			m.checkOCO(ords)
 
 			// 注文一覧を全銘柄のチャネルへ安全にディスパッチ
 			for symbol, ch := range m.orderChannels {
@@ -587,9 +676,21 @@ func (m *MarketGateway) checkAndFireIFD(ctx context.Context, ords order.Orders)
 			// この部分約定専用の決済注文をクローンして作成
 			execChild := m.cloneChildOrderForExecution(childTemplate, exec)
 
+			// OCO の相方があれば、この約定の数量で組を作る（相方は発動するまで取引所に出さない）
+			var oco *ocoGroup
+			if childTemplate.OCO != nil {
+				oco = m.newOCOGroup(execChild, m.cloneChildOrderForExecution(childTemplate.OCO, exec))
+			}
+
 			go func(c *order.Order, parentID string) {
 				resCh := m.dispatcher.Submit(c.ID, c.Symbol, c, "", 20)
 				res := <-resCh
+				if oco != nil {
+					m.ifdMu.Lock()
+					cancels := m.recordOCOResting(oco, c, res)
+					m.ifdMu.Unlock()
+					m.cancelOCOOrders(oco, cancels...)
+				}
 				if res.Error != nil {
 					fmt.Printf("⚠️ [MarketGateway] 部分決済自動発注失敗 (Symbol: %s, ExecID: %s): %v\n",
 						c.Symbol, exec.ID, res.Error)
@@ -641,6 +742,7 @@ func (m *MarketGateway) cloneChildOrderForExecution(template *order.Order, exec
 		template.OrderPrice,
 		exec.Qty, // 部分約定した数量と同じにする
 		order.WithType(template.Type),
+		order.WithTriggerPrice(template.TriggerPrice),
 		order.WithCashMargin(template.CashMargin),
 		order.WithReason(template.Reason),
 	)
@@ -679,13 +781,20 @@ func (s *MarketGateway) startWebSocketLoop(ctx context.Context) {
 		for {
 			select {
 			case <-ctx.Done():
+				s.validator.LogSummary()
 				return
 			case msg := <-rawCh:
-				t := s.toTick(api.BoardResponse(msg))
+				// 不正な Tick は記録・DataPool・戦略のいずれにも流さない（補正された場合は補正後の値を流す）
+				t, ok := s.validator.Validate(s.toTick(api.BoardResponse(msg)))
+				if !ok {
+					continue
+				}
 				logger.Log(t)
 
 				// 内部の DataPool を更新
 				s.dataPool.PushTick(t)
+				// ローカルに保持している OCO の相方の発動を判定
+				s.checkOCOTriggers(t)
 
 				// 該当する銘柄のチャネルへルーティング (skip-on-full)
 				if ch, ok := s.tickChannels[t.Symbol]; ok {
@@ -757,7 +866,10 @@ func (s *MarketGateway) startWebSocketLoop(ctx context.Context) {
 							slog.Error("❌ Failed to fetch board via REST for sync", slog.String("symbol", req.Symbol), slog.Any("error", err))
 							continue
 						}
-						t := s.toTick(*board)
+						t, ok := s.validator.Validate(s.toTick(*board))
+						if !ok {
+							continue
+						}
 						s.dataPool.PushTick(t)
 						slog.Info("✅ Synchronized DataPool for symbol", slog.String("symbol", req.Symbol))
 					}
@@ -861,6 +973,11 @@ func (m *MarketGateway) RegisterSymbols(ctx context.Context, reqs []market.Resis
 		}
 	}
 
+	// 売買主体の判定器を登録し、戦略の生成タイミングに関わらず登録直後から CVD を集計する
+	for _, req := range reqs {
+		tick.GetOrCreateTradeClassifier(m.dataPool, req.Symbol)
+	}
+
 	// 50銘柄ずつバッチ処理
 	const batchSize = 50
 	for i := 0; i < len(reqs); i += batchSize {
@@ -906,13 +1023,104 @@ func (m *MarketGateway) GetSymbol(ctx context.Context, symbolCode string, exchan
 	if err != nil {
 		return symbol.Symbol{}, fmt.Errorf("PriceRangeGroupの数値変換失敗 (%s): %w", resp.PriceRangeGroup, err)
 	}
+
+	// 値幅制限は取引所の公表値を優先し、取得できない場合は前日終値から計算する
+	limits := symbol.PriceLimits{Upper: resp.UpperLimit, Lower: resp.LowerLimit}
+	if !limits.Known() {
+		limits = m.priceLimitsFor(resp.Symbol)
+	}
+
 	return symbol.Symbol{
 		Code:            resp.Symbol,
 		Name:            resp.SymbolName,
 		PriceRangeGroup: symbol.PriceRangeGroup(prg),
+		PriceLimits:     limits,
+		TradingUnit:     resp.TradingUnit,
+		Margin: symbol.MarginEligibility{
+			Known:       true,
+			SystemBuy:   resp.MarginBuy,
+			SystemSell:  resp.MarginSell,
+			GeneralBuy:  resp.KCMarginBuy,
+			GeneralSell: resp.KCMarginSell,
+			DayTrade:    m.isDayTradeEligible(ctx, resp.Symbol),
+		},
 	}, nil
 }
 
+// dayTradeRetryWaits はプレミアム料情報の取得に失敗した場合の、再取得までの待ち時間です（失敗が続くほど間隔を広げる）
+var dayTradeRetryWaits = []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+
+// isDayTradeEligible は銘柄が一般信用（デイトレ）の対象かを返します。
+// 対象可否は銘柄ごとに1度だけ取得します。一時的な通信エラーで起動中ずっと新規建てできなくならないよう、
+// 取得に失敗した場合は間隔を空けて再取得します。それでも取得できない場合は、対象外の銘柄で新規建てを
+// 繰り返し拒否されないよう対象外として扱い、結果を保持せず次回の GetSymbol で再取得します。
+func (m *MarketGateway) isDayTradeEligible(ctx context.Context, code string) bool {
+	m.dayTradeMu.Lock()
+	eligible, ok := m.dayTrade[code]
+	m.dayTradeMu.Unlock()
+	if ok {
+		return eligible
+	}
+
+	premium, err := m.client.GetMarginPremium(code)
+	for _, wait := range dayTradeRetryWaits {
+		if err == nil && premium != nil {
+			break
+		}
+		slog.Warn("⚠️ 一般信用デイトレの対象可否を取得できません。再取得します", slog.String("symbol", code), slog.String("wait", wait.String()), slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(wait):
+		}
+		premium, err = m.client.GetMarginPremium(code)
+	}
+	if err != nil || premium == nil {
+		slog.Warn("⚠️ 一般信用デイトレの対象可否を取得できません。対象外として扱います", slog.String("symbol", code), slog.Any("error", err))
+		return false
+	}
+
+	m.dayTradeMu.Lock()
+	defer m.dayTradeMu.Unlock()
+	if m.dayTrade == nil {
+		m.dayTrade = make(map[string]bool)
+	}
+	eligible = premium.DayTrade.MarginPremiumType != nil
+	m.dayTrade[code] = eligible
+	return eligible
+}
+
+// priceLimitsFor は前日終値から当日の値幅制限を計算します。
+// 前日終値は銘柄ごとに1度だけ取得し、取得できない場合は値幅制限なしとして扱います。
+func (m *MarketGateway) priceLimitsFor(code string) symbol.PriceLimits {
+	if m.historyProvider == nil {
+		return symbol.PriceLimits{}
+	}
+
+	m.limitsMu.Lock()
+	defer m.limitsMu.Unlock()
+	if limits, ok := m.priceLimits[code]; ok {
+		return limits
+	}
+	if m.priceLimits == nil {
+		m.priceLimits = make(map[string]symbol.PriceLimits)
+	}
+
+	var limits symbol.PriceLimits
+	if feeder := m.historyProvider.GetFeeder(code); feeder != nil {
+		prevClose, err := feeder.FetchPreviousClose()
+		if err != nil {
+			slog.Warn("⚠️ 前日終値を取得できないため、値幅制限なしとして扱います", slog.String("symbol", code), slog.Any("error", err))
+		} else {
+			limits = symbol.NewPriceLimits(prevClose)
+			slog.Info("📏 値幅制限を設定しました", slog.String("symbol", code),
+				slog.Float64("base", limits.Base), slog.Float64("upper", limits.Upper), slog.Float64("lower", limits.Lower))
+		}
+	}
+	m.priceLimits[code] = limits
+	return limits
+}
+
 func (m *MarketGateway) UnregisterSymbolAll(ctx context.Context) error {
 	_, err := m.client.UnregisterSymbolAll()
 	if err != nil {
@@ -14,6 +14,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/r-umemoto/trading-bot/pkg/domain/market"
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
 	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/api"
 )
@@ -26,6 +27,9 @@ type MockKabuClient struct {
 	RegisterCount   int
 	GetBoardCount   int
 	GetBoardFunc    func(symbol string) (*api.BoardResponse, error)
+
+	GetSymbolFunc        func(symbol string, exchange api.ExchageType) (*api.SymbolSuccess, error)
+	GetMarginPremiumFunc func(symbol string) (*api.MarginPremiumResponse, error)
 }
 
 func (m *MockKabuClient) GetToken() error {
@@ -55,8 +59,17 @@ func (m *MockKabuClient) UnregisterSymbolAll() (*api.UnregisterSymbolAllResponse
 	return nil, nil
 }
 func (m *MockKabuClient) GetSymbol(symbol string, exchange api.ExchageType) (*api.SymbolSuccess, error) {
+	if m.GetSymbolFunc != nil {
+		return m.GetSymbolFunc(symbol, exchange)
+	}
 	return nil, nil
 }
+func (m *MockKabuClient) GetMarginPremium(symbol string) (*api.MarginPremiumResponse, error) {
+	if m.GetMarginPremiumFunc != nil {
+		return m.GetMarginPremiumFunc(symbol)
+	}
+	return &api.MarginPremiumResponse{Symbol: symbol}, nil
+}
 func (m *MockKabuClient) GetBoard(symbol string) (*api.BoardResponse, error) {
 	m.GetBoardCount++
 	if m.GetBoardFunc != nil {
@@ -278,6 +291,64 @@ func TestMarketGateway_SendOrderRaw_DelivType(t *testing.T) {
 	}
 }
 
+func TestMarketGateway_SendOrderRaw_ReverseLimit(t *testing.T) {
+	mockClient := &MockKabuClient{}
+	gateway := &MarketGateway{client: mockClient}
+
+	tests := []struct {
+		name      string
+		action    order.Action
+		orderType order.OrderType
+		price     float64
+		want      api.ReverseLimitOrder
+	}{
+		{
+			name:      "売りの逆指値（成行） -> 以下でヒット",
+			action:    order.ACTION_SELL,
+			orderType: order.ORDER_TYPE_STOP,
+			want:      api.ReverseLimitOrder{TriggerSec: 1, TriggerPrice: 990, UnderOver: 1, AfterHitOrderType: 1},
+		},
+		{
+			name:      "買いの逆指値（指値） -> 以上でヒット",
+			action:    order.ACTION_BUY,
+			orderType: order.ORDER_TYPE_STOP_LIMIT,
+			price:     995,
+			want:      api.ReverseLimitOrder{TriggerSec: 1, TriggerPrice: 990, UnderOver: 2, AfterHitOrderType: 2, AfterHitPrice: 995},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ord := order.NewOrder("test-local-id", "8801", tt.action, tt.price, 100,
+				order.WithType(tt.orderType), order.WithTriggerPrice(990), order.WithCashMargin(order.CASH_MARGIN_MARGIN_ENTRY))
+			ord.Request = &order.OrderRequest{
+				Exchange:        order.EXCHANGE_TOSHO,
+				SecurityType:    order.SECURITY_TYPE_STOCK,
+				MarginTradeType: order.TRADE_TYPE_GENERAL_DAY,
+				AccountType:     order.ACCOUNT_SPECIAL,
+			}
+
+			if _, err := gateway.SendOrderRaw(context.Background(), order.SendOrderInput{Order: ord}); err != nil {
+				t.Fatalf("SendOrderRaw failed: %v", err)
+			}
+			req := mockClient.LastSendRequest
+			if req.FrontOrderType != 30 || req.Price != 0 {
+				t.Errorf("expected FrontOrderType 30 with Price 0, got %d / %v", req.FrontOrderType, req.Price)
+			}
+			if req.ReverseLimitOrder == nil || *req.ReverseLimitOrder != tt.want {
+				t.Errorf("expected ReverseLimitOrder %+v, got %+v", tt.want, req.ReverseLimitOrder)
+			}
+		})
+	}
+
+	// トリガー価格の無い逆指値は発注しない
+	ord := order.NewOrder("test-local-id", "8801", order.ACTION_SELL, 0, 100, order.WithType(order.ORDER_TYPE_STOP))
+	ord.Request = &order.OrderRequest{Exchange: order.EXCHANGE_TOSHO, SecurityType: order.SECURITY_TYPE_STOCK, MarginTradeType: order.TRADE_TYPE_GENERAL_DAY, AccountType: order.ACCOUNT_SPECIAL}
+	if _, err := gateway.SendOrderRaw(context.Background(), order.SendOrderInput{Order: ord}); err == nil {
+		t.Errorf("expected an error for a stop order without a trigger price")
+	}
+}
+
 func TestMarketGateway_StartWebSocketLoop(t *testing.T) {
 	// 1. WebSocketサーバの起動
 	upgrader := websocket.Upgrader{}
@@ -720,3 +791,131 @@ func TestMarketGateway_CancelOrder_LocalBypass(t *testing.T) {
 	}
 }
 
+
+type stubPreviousCloseFeeder struct {
+	close float64
+	err   error
+	calls *int
+}
+
+func (f stubPreviousCloseFeeder) FetchSMA(period int) (float64, error) { return 0, nil }
+func (f stubPreviousCloseFeeder) FetchPreviousClose() (float64, error) {
+	*f.calls++
+	return f.close, f.err
+}
+
+type stubFeederProvider map[string]stubPreviousCloseFeeder
+
+func (p stubFeederProvider) GetFeeder(symbol string) tick.HistoricalFeeder { return p[symbol] }
+
+func TestMarketGateway_PriceLimitsFromPreviousClose(t *testing.T) {
+	var calls int
+	gateway := &MarketGateway{
+		historyProvider: stubFeederProvider{
+			"7203": {close: 2500, calls: &calls},
+			"9999": {err: errors.New("board unavailable"), calls: &calls},
+		},
+	}
+
+	limits := gateway.priceLimitsFor("7203")
+	if limits.Upper != 3000 || limits.Lower != 2000 {
+		t.Errorf("unexpected limits: %+v", limits)
+	}
+	gateway.priceLimitsFor("7203")
+	if calls != 1 {
+		t.Errorf("expected the previous close to be fetched once, got %d", calls)
+	}
+
+	if unknown := gateway.priceLimitsFor("9999"); unknown.Known() {
+		t.Errorf("expected no limits when the previous close is unavailable, got %+v", unknown)
+	}
+}
+
+func TestMarketGateway_GetSymbol_MapsSymbolMaster(t *testing.T) {
+	dayTrade := 2
+	var calls int
+	gateway := &MarketGateway{
+		client: &MockKabuClient{
+			GetSymbolFunc: func(code string, exchange api.ExchageType) (*api.SymbolSuccess, error) {
+				return &api.SymbolSuccess{
+					Symbol: code, SymbolName: "トヨタ自動車", PriceRangeGroup: "10003",
+					TradingUnit: 100, MarginBuy: true, MarginSell: true, KCMarginBuy: true,
+					UpperLimit: 3000, LowerLimit: 2000,
+				}, nil
+			},
+			GetMarginPremiumFunc: func(code string) (*api.MarginPremiumResponse, error) {
+				return &api.MarginPremiumResponse{Symbol: code, DayTrade: api.MarginPremiumDetail{MarginPremiumType: &dayTrade}}, nil
+			},
+		},
+		historyProvider: stubFeederProvider{"7203": {close: 9999, calls: &calls}},
+	}
+
+	detail, err := gateway.GetSymbol(context.Background(), "7203", order.EXCHANGE_TOSHO)
+	if err != nil {
+		t.Fatalf("GetSymbol failed: %v", err)
+	}
+	if detail.TradingUnit != 100 || detail.PriceRangeGroup != symbol.PRICE_RANGE_GROUP_TSE_TOPIX100 {
+		t.Errorf("unexpected symbol: %+v", detail)
+	}
+	want := symbol.MarginEligibility{Known: true, SystemBuy: true, SystemSell: true, GeneralBuy: true, DayTrade: true}
+	if detail.Margin != want {
+		t.Errorf("unexpected margin eligibility: %+v", detail.Margin)
+	}
+	// 取引所の公表値があれば前日終値からは計算しない
+	if detail.PriceLimits.Upper != 3000 || detail.PriceLimits.Lower != 2000 || calls != 0 {
+		t.Errorf("unexpected price limits: %+v (previous close fetched %d times)", detail.PriceLimits, calls)
+	}
+}
+
+func TestMarketGateway_GetSymbol_DayTradeRetriesThenFailsClosed(t *testing.T) {
+	defer func(waits []time.Duration) { dayTradeRetryWaits = waits }(dayTradeRetryWaits)
+	dayTradeRetryWaits = []time.Duration{time.Millisecond, time.Millisecond}
+
+	dayTrade := 2
+	var failures, premiumCalls int
+	gateway := &MarketGateway{
+		client: &MockKabuClient{
+			GetSymbolFunc: func(code string, exchange api.ExchageType) (*api.SymbolSuccess, error) {
+				return &api.SymbolSuccess{Symbol: code, PriceRangeGroup: "10003", TradingUnit: 100, UpperLimit: 3000, LowerLimit: 2000}, nil
+			},
+			GetMarginPremiumFunc: func(code string) (*api.MarginPremiumResponse, error) {
+				premiumCalls++
+				if failures > 0 {
+					failures--
+					return nil, errors.New("503 Service Unavailable")
+				}
+				return &api.MarginPremiumResponse{Symbol: code, DayTrade: api.MarginPremiumDetail{MarginPremiumType: &dayTrade}}, nil
+			},
+		},
+	}
+
+	// 1. 一時的なエラーは再取得で回復する
+	failures = 2
+	if detail, _ := gateway.GetSymbol(context.Background(), "7203", order.EXCHANGE_TOSHO); !detail.Margin.DayTrade {
+		t.Errorf("expected day trade eligibility after retrying, got %+v", detail.Margin)
+	}
+	if premiumCalls != 3 {
+		t.Errorf("expected 2 retries, got %d calls", premiumCalls)
+	}
+
+	// 2. 再取得しても取得できない間は対象外として扱い、新規建てを止める
+	premiumCalls, failures = 0, 3
+	detail, _ := gateway.GetSymbol(context.Background(), "6758", order.EXCHANGE_TOSHO)
+	if detail.Margin.DayTrade || detail.CanOpen(order.ACTION_BUY, order.TRADE_TYPE_GENERAL_DAY) {
+		t.Errorf("expected day trade to fail closed on API error, got %+v", detail.Margin)
+	}
+	if premiumCalls != 3 {
+		t.Errorf("expected the retries to stop after 2 waits, got %d calls", premiumCalls)
+	}
+
+	// 取得に失敗した結果は保持せず、取得できた結果は以降の GetSymbol で使い回す
+	premiumCalls = 0
+	for i := 0; i < 2; i++ {
+		if detail, _ := gateway.GetSymbol(context.Background(), "6758", order.EXCHANGE_TOSHO); !detail.Margin.DayTrade {
+			t.Errorf("expected day trade eligibility after recovery, got %+v", detail.Margin)
+		}
+	}
+	if premiumCalls != 1 {
+		t.Errorf("expected the margin premium to be fetched once after the failure, got %d calls", premiumCalls)
+	}
+}
@@ -0,0 +1,342 @@
+package kabu
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// カブコムAPIには OCO 注文が無く、同じ建玉を返済する注文を2つ出すと、後から出した注文は返済可能数量の不足で拒否されます。
+// そのためゲートウェイは組の片方（板の注文）だけを取引所に出し、相方（通常は損切りの逆指値）はローカルに保持して Tick で発動を判定します。
+//   - 板の注文に約定が付いたら、保持している注文の残数量がその分減る（取消や出し直しは不要）
+//   - Tick が保持している注文のトリガー価格（指値なら指値）に達したら板の注文を取り消し、取消の完了後に残数量で発注する。
+//     逆指値は発動済みのため成行、逆指値付き指値は指値として発注する
+//   - 組の残数量が無くなった、または板の注文が外部で終了したら、保持している注文を破棄して組を解散する
+//
+// 保持している注文は組の識別子から作った ID で注文一覧に載せ、発注・破棄した後はしばらく取消済みとして載せます。
+// 発注した注文は、保持していた注文の内容（逆指値の種別・トリガー価格）で注文一覧に載せます。
+
+// heldRetention は解散した組の保持注文を、取消済みとして注文一覧に載せ続ける時間です
+const heldRetention = time.Minute
+
+// ocoGroup はゲートウェイがローカルで管理する OCO の組です
+type ocoGroup struct {
+	id        string
+	qty       float64      // 組として返済する数量
+	restingID string       // 板の注文の取引所の注文ID（発注中は空）
+	failed    bool         // 板の注文の発注に失敗した（保持している注文は発動時に単独で発注する）
+	filled    float64      // 板の注文の約定数量
+	held      *order.Order // ローカルに保持している注文（注文一覧に載せる状態を兼ねる）
+	triggered bool         // 保持している注文が発動し、板の注文の終了を待っている
+	firing    bool         // 保持している注文を発注中
+	dissolved bool         // 解散済み（発注中だった注文は受付後に取り消す）
+	closedAt  time.Time
+}
+
+// newOCOGroup は板に出す注文と相方から OCO の組を作り、相方をローカルに保持します。両方の注文に組の識別子を付与します
+func (m *MarketGateway) newOCOGroup(resting, sibling *order.Order) *ocoGroup {
+	m.ocoSeq++
+	g := &ocoGroup{
+		id:  fmt.Sprintf("oco-%s-%d", resting.Symbol, m.ocoSeq),
+		qty: resting.OrderQty,
+	}
+	resting.OCOGroupID = g.id
+	sibling.OCOGroupID = g.id
+
+	held := *sibling
+	held.ID = g.id + "-held"
+	held.IfDone, held.OCO = nil, nil
+	held.CreatedAt = time.Now()
+	held.BypassTransition(order.ORDER_STATUS_WAITING, order.STATE_ACTIVE)
+	g.held = &held
+
+	m.ocoGroups[g.id] = g
+	return g
+}
+
+// holdOCOSibling は発注済みの注文と OCO の相方で組を作ります。相方は発動するまで取引所に出しません
+func (m *MarketGateway) holdOCOSibling(primary *order.Order) {
+	m.ifdMu.Lock()
+	defer m.ifdMu.Unlock()
+
+	g := m.newOCOGroup(primary, primary.OCO)
+	g.restingID = primary.ID
+	sent := *primary
+	m.ocoOrders[primary.ID] = &sent
+	slog.Info("✅ [MarketGateway] OCO の相方を発動まで保持します",
+		slog.String("symbol", primary.Symbol), slog.String("group", g.id), slog.String("orderID", primary.ID),
+		slog.String("held", g.held.Reason), slog.Float64("trigger", g.held.TriggerPrice))
+}
+
+// recordOCOResting は板の注文の発注結果を組に記録し、取り消すべき注文IDを返します（組が解散済み・発動済みの場合）
+func (m *MarketGateway) recordOCOResting(g *ocoGroup, ord *order.Order, res order.OrderResult) []string {
+	if res.Error != nil {
+		g.failed = true
+		slog.Error("⚠️ [MarketGateway] OCO の板の注文の発注に失敗しました。保持している注文は発動時に単独で発注します",
+			slog.String("symbol", ord.Symbol), slog.String("group", g.id), slog.String("reason", ord.Reason), slog.Any("error", res.Error))
+		return nil
+	}
+	g.restingID = res.OrderID
+	sent := *ord
+	m.ocoOrders[res.OrderID] = &sent
+	if g.dissolved || g.triggered {
+		return []string{res.OrderID}
+	}
+	slog.Info("✅ [MarketGateway] OCO の板の注文を発注しました",
+		slog.String("symbol", ord.Symbol), slog.String("group", g.id), slog.String("orderID", res.OrderID),
+		slog.String("reason", ord.Reason), slog.Float64("qty", ord.OrderQty))
+	return nil
+}
+
+// checkOCOTriggers は Tick で保持している注文の発動を判定し、発動した組の板の注文を取り消します
+func (m *MarketGateway) checkOCOTriggers(t tick.Tick) {
+	if t.Price <= 0 {
+		return
+	}
+	type cancel struct {
+		g       *ocoGroup
+		orderID string
+	}
+	var cancels []cancel
+
+	m.ifdMu.Lock()
+	for _, g := range m.ocoGroups {
+		if g.dissolved || g.triggered || g.held.Symbol != t.Symbol || !heldTriggered(g.held, t.Price) {
+			continue
+		}
+		g.triggered = true
+		slog.Info("⚡ [MarketGateway] OCO の保持している注文が発動しました。板の注文を取り消してから発注します",
+			slog.String("symbol", t.Symbol), slog.String("group", g.id), slog.Float64("price", t.Price), slog.String("reason", g.held.Reason))
+		if g.restingID != "" {
+			cancels = append(cancels, cancel{g, g.restingID})
+		}
+	}
+	m.ifdMu.Unlock()
+
+	for _, c := range cancels {
+		m.cancelOCOOrders(c.g, c.orderID)
+	}
+}
+
+// checkOCO は注文一覧から板の注文の約定・終了を検知し、保持している注文の発注と組の解散を行います
+func (m *MarketGateway) checkOCO(ords order.Orders) {
+	type fire struct {
+		g   *ocoGroup
+		ord *order.Order
+	}
+	var fires []fire
+
+	m.ifdMu.Lock()
+	now := time.Now()
+	byID := make(map[string]*order.Order, len(ords.Orders))
+	for i := range ords.Orders {
+		byID[ords.Orders[i].ID] = &ords.Orders[i]
+	}
+	for id, g := range m.ocoGroups {
+		if g.dissolved {
+			if !g.firing && now.Sub(g.closedAt) > heldRetention {
+				delete(m.ocoGroups, id)
+			}
+			continue
+		}
+		if g.firing {
+			continue
+		}
+
+		restingDone := false
+		if ext := byID[g.restingID]; g.restingID != "" && ext != nil {
+			g.filled = ext.FilledQty()
+			restingDone = ext.IsCompleted()
+		}
+		remaining := g.qty - g.filled
+
+		switch {
+		case remaining <= 0:
+			m.closeOCOGroup(g, "組の数量を返済し終えました")
+		case g.triggered && (restingDone || g.failed):
+			// 板の注文が終了して建玉の返済可能数量が空いたため、保持している注文を残数量で発注する
+			g.firing = true
+			fires = append(fires, fire{g, heldOrderToSend(g.held, remaining, g.filled)})
+		case restingDone:
+			m.closeOCOGroup(g, "板の注文が外部で終了しました")
+		}
+	}
+	m.ifdMu.Unlock()
+
+	for _, f := range fires {
+		go m.fireOCOHeld(f.g, f.ord)
+	}
+}
+
+// fireOCOHeld は発動した保持注文を発注します。発注結果に関わらず組を解散します
+func (m *MarketGateway) fireOCOHeld(g *ocoGroup, ord *order.Order) {
+	res := <-m.dispatcher.Submit(ord.ID, ord.Symbol, ord, "", 20)
+
+	m.ifdMu.Lock()
+	if res.Error != nil {
+		g.firing = false
+		m.closeOCOGroup(g, "保持している注文の発注に失敗しました")
+		m.ifdMu.Unlock()
+		slog.Error("🚨 [MarketGateway] OCO の保持している注文の発注に失敗しました",
+			slog.String("symbol", ord.Symbol), slog.String("group", g.id), slog.String("reason", ord.Reason), slog.Any("error", res.Error))
+		return
+	}
+
+	// 注文一覧では、保持していた注文の内容（逆指値の種別・トリガー価格）で報告する
+	sent := *g.held
+	sent.ID = res.OrderID
+	sent.OrderQty = ord.OrderQty
+	sent.Request = ord.Request
+	m.ocoOrders[res.OrderID] = &sent
+
+	canceled := g.dissolved // 発注中に Bot が取り消した
+	g.firing = false
+	if !canceled {
+		m.closeOCOGroup(g, "保持している注文を発注しました")
+	}
+	m.ifdMu.Unlock()
+
+	slog.Info("✅ [MarketGateway] OCO の保持している注文を発注しました",
+		slog.String("symbol", ord.Symbol), slog.String("group", g.id), slog.String("orderID", res.OrderID),
+		slog.String("reason", ord.Reason), slog.Float64("qty", ord.OrderQty))
+	if canceled {
+		m.cancelOCOOrders(g, res.OrderID)
+	}
+}
+
+// closeOCOGroup は組を解散し、保持している注文を取消済みにします。呼び出し元で ifdMu を保持してください
+func (m *MarketGateway) closeOCOGroup(g *ocoGroup, reason string) {
+	if g.dissolved {
+		return
+	}
+	g.dissolved = true
+	g.closedAt = time.Now()
+	g.held.BypassTransition(order.ORDER_STATUS_CANCELED, order.STATE_CLOSED)
+	slog.Info("⚡ [MarketGateway] OCO の組を解散しました",
+		slog.String("group", g.id), slog.String("cause", reason), slog.Float64("filled", g.filled), slog.Float64("qty", g.qty))
+}
+
+// cancelOCOOrders は OCO の注文の取消を依頼します。呼び出し元で ifdMu を保持しないでください。
+// 発動による板の注文の取消に失敗した場合は発動を取り消し、次の Tick で判定し直します
+func (m *MarketGateway) cancelOCOOrders(g *ocoGroup, orderIDs ...string) {
+	for _, orderID := range orderIDs {
+		resCh := m.dispatcher.Submit("cancel_"+orderID, "", nil, orderID, 30)
+		go func(orderID string) {
+			res := <-resCh
+			if res.Error == nil {
+				return
+			}
+			slog.Warn("⚠️ [MarketGateway] OCO の注文の取消に失敗しました", slog.String("group", g.id), slog.String("orderID", orderID), slog.Any("error", res.Error))
+			m.ifdMu.Lock()
+			if !g.dissolved && !g.firing {
+				g.triggered = false
+			}
+			m.ifdMu.Unlock()
+		}(orderID)
+	}
+}
+
+// cancelOCOGroup は Bot が OCO の片方を取り消す際に組を解散し、取引所に取消を送る注文IDを返します。
+// 保持している注文（取引所に出していない）の取消であれば、板の注文の取消を依頼して "" を返します
+func (m *MarketGateway) cancelOCOGroup(orderID string) string {
+	m.ifdMu.Lock()
+	var g *ocoGroup
+	for _, c := range m.ocoGroups {
+		if !c.dissolved && (c.held.ID == orderID || c.restingID == orderID) {
+			g = c
+			break
+		}
+	}
+	if g == nil {
+		m.ifdMu.Unlock()
+		return orderID
+	}
+	restingID := g.restingID
+	resting := orderID == restingID
+	firing := g.firing
+	m.closeOCOGroup(g, "Bot が片方を取り消しました")
+	m.ifdMu.Unlock()
+
+	if resting {
+		return orderID
+	}
+	// 発注中の保持注文は、受付後に fireOCOHeld が取り消す
+	if restingID != "" && !firing {
+		m.cancelOCOOrders(g, restingID)
+	}
+	return ""
+}
+
+// heldOCOOrders は注文一覧に載せる、ローカルに保持している OCO の注文を返します
+func (m *MarketGateway) heldOCOOrders() []order.Order {
+	m.ifdMu.Lock()
+	defer m.ifdMu.Unlock()
+
+	held := make([]order.Order, 0, len(m.ocoGroups))
+	for _, g := range m.ocoGroups {
+		held = append(held, *g.held)
+	}
+	return held
+}
+
+// heldTriggered は保持している注文が価格で発動するかを判定します（逆指値はトリガー価格、指値は指値に達したら発動する）
+func heldTriggered(o *order.Order, price float64) bool {
+	switch o.Type {
+	case order.ORDER_TYPE_STOP, order.ORDER_TYPE_STOP_LIMIT:
+		if o.Action == order.ACTION_SELL {
+			return price <= o.TriggerPrice
+		}
+		return price >= o.TriggerPrice
+	case order.ORDER_TYPE_MARKET:
+		return true
+	}
+	if o.Action == order.ACTION_SELL {
+		return price >= o.OrderPrice
+	}
+	return price <= o.OrderPrice
+}
+
+// heldOrderToSend は発動した保持注文を、残数量で発注する注文に作り直します。逆指値は発動済みのため成行・指値にします
+func heldOrderToSend(held *order.Order, qty, filled float64) *order.Order {
+	ord := resizeOCOLeg(held, qty, filled)
+	switch held.Type {
+	case order.ORDER_TYPE_STOP:
+		ord.Type, ord.OrderPrice, ord.TriggerPrice = order.ORDER_TYPE_MARKET, 0, 0
+	case order.ORDER_TYPE_STOP_LIMIT:
+		ord.Type, ord.TriggerPrice = order.ORDER_TYPE_LIMIT, 0
+	}
+	return ord
+}
+
+// resizeOCOLeg は OCO の片方を、組の残数量で発注する注文として作り直します。
+// 返済建玉の指定は先頭から約定済みの数量を差し引きます。
+func resizeOCOLeg(template *order.Order, qty float64, filled float64) *order.Order {
+	resized := order.NewOrder(
+		order.GenerateLocalID(),
+		template.Symbol,
+		template.Action,
+		template.OrderPrice,
+		qty,
+		order.WithType(template.Type),
+		order.WithTriggerPrice(template.TriggerPrice),
+		order.WithCashMargin(template.CashMargin),
+		order.WithReason(template.Reason),
+	)
+	resized.OCOGroupID = template.OCOGroupID
+	if template.Request != nil {
+		req := *template.Request
+		req.ClosePositions = nil
+		for _, cp := range template.Request.ClosePositions {
+			consumed := min(cp.Qty, filled)
+			filled -= consumed
+			if cp.Qty-consumed > 0 {
+				req.ClosePositions = append(req.ClosePositions, order.ClosePosition{HoldID: cp.HoldID, Qty: cp.Qty - consumed})
+			}
+		}
+		resized.Request = &req
+	}
+	return resized
+}
@@ -0,0 +1,325 @@
+package kabu
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/api"
+)
+
+// ocoExchange は取引所の代わりに Dispatcher から発注・取消を受ける Sender です。
+// 取引所と同じく、同じ建玉を返済する注文の合計が建玉数量を超える発注は拒否します。
+// 受け付けた注文はコピーを sent に、取消は注文IDを canceled に流すため、テストは非同期の発注を待ち合わせられます。
+type ocoExchange struct {
+	mu       sync.Mutex
+	seq      int
+	holds    map[string]float64      // 建玉ID → 建玉数量
+	open     map[string]*order.Order // 取引所で有効な返済注文
+	rejected []error
+	sent     chan *order.Order
+	canceled chan string
+}
+
+func newOCOExchange(holds map[string]float64) *ocoExchange {
+	return &ocoExchange{
+		holds:    holds,
+		open:     make(map[string]*order.Order),
+		sent:     make(chan *order.Order, 10),
+		canceled: make(chan string, 10),
+	}
+}
+
+func (e *ocoExchange) SendOrderRaw(ctx context.Context, input order.SendOrderInput) (*order.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ord := *input.Order
+	if ord.CashMargin == order.CASH_MARGIN_MARGIN_EXIT && ord.Request != nil {
+		for _, cp := range ord.Request.ClosePositions {
+			reserved := cp.Qty
+			for _, o := range e.open {
+				for _, other := range o.Request.ClosePositions {
+					if other.HoldID == cp.HoldID {
+						reserved += other.Qty
+					}
+				}
+			}
+			if reserved > e.holds[cp.HoldID] {
+				err := fmt.Errorf("返済可能数量が不足しています (HoldID: %s, reason: %s)", cp.HoldID, ord.Reason)
+				e.rejected = append(e.rejected, err)
+				return input.Order, err
+			}
+		}
+	}
+	e.seq++
+	ord.ID = fmt.Sprintf("order-%d", e.seq)
+	if ord.CashMargin == order.CASH_MARGIN_MARGIN_EXIT && ord.Request != nil {
+		open := ord
+		e.open[ord.ID] = &open
+	}
+	copied := ord
+	e.sent <- &copied
+	return &ord, nil
+}
+
+func (e *ocoExchange) CancelOrderRaw(ctx context.Context, orderID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.open, orderID)
+	e.canceled <- orderID
+	return nil
+}
+
+func (e *ocoExchange) rejections() []error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rejected
+}
+
+// newOCOGateway は ocoExchange に発注するゲートウェイを作ります
+func newOCOGateway(t *testing.T, holds map[string]float64) (*MarketGateway, *ocoExchange) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	exchange := newOCOExchange(holds)
+	gateway := NewMarketGateway(nil, nil)
+	gateway.client = &MockKabuClient{}
+	gateway.dispatcher = NewOrderDispatcher(exchange)
+	gateway.dispatcher.Start(ctx)
+	return gateway, exchange
+}
+
+func receiveSent(t *testing.T, e *ocoExchange) *order.Order {
+	t.Helper()
+	select {
+	case o := <-e.sent:
+		return o
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for an order to be sent")
+		return nil
+	}
+}
+
+func receiveCanceled(t *testing.T, e *ocoExchange) string {
+	t.Helper()
+	select {
+	case id := <-e.canceled:
+		return id
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for a cancel")
+		return ""
+	}
+}
+
+// waitOCOGroup は組の状態が cond を満たすまで待ち、その時点の状態のコピーを返します
+func waitOCOGroup(t *testing.T, g *MarketGateway, id string, cond func(ocoGroup) bool) ocoGroup {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		g.ifdMu.Lock()
+		var snapshot ocoGroup
+		if group := g.ocoGroups[id]; group != nil {
+			snapshot = *group
+			held := *group.held
+			snapshot.held = &held
+		}
+		g.ifdMu.Unlock()
+		if snapshot.held != nil && cond(snapshot) {
+			return snapshot
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for OCO group %s, last state: %+v", id, snapshot)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func ocoReport(orders ...*order.Order) order.Orders {
+	var r order.Orders
+	for _, o := range orders {
+		r.Orders = append(r.Orders, *o)
+	}
+	return r
+}
+
+var ocoRequest = &order.OrderRequest{
+	Exchange:        order.EXCHANGE_TOSHO,
+	SecurityType:    order.SECURITY_TYPE_STOCK,
+	MarginTradeType: order.TRADE_TYPE_GENERAL_DAY,
+	AccountType:     order.ACCOUNT_SPECIAL,
+}
+
+func TestMarketGateway_OCO_HoldsStopUntilTriggered(t *testing.T) {
+	gateway, exchange := newOCOGateway(t, map[string]float64{"exec-1": 100})
+
+	// 同じ建玉を返済する注文を2つ出すと、取引所は後の注文を拒否する
+	probe := order.NewOrder("probe", "7203", order.ACTION_SELL, 2050, 100, order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT),
+		order.WithRequest(&order.OrderRequest{ClosePositions: []order.ClosePosition{{HoldID: "exec-1", Qty: 100}}}))
+	first, _ := exchange.SendOrderRaw(context.Background(), order.SendOrderInput{Order: probe})
+	<-exchange.sent
+	if _, err := exchange.SendOrderRaw(context.Background(), order.SendOrderInput{Order: probe}); err == nil {
+		t.Fatal("expected the exchange to reject a second close of the same hold")
+	}
+	exchange.CancelOrderRaw(context.Background(), first.ID)
+	<-exchange.canceled
+	exchange.mu.Lock()
+	exchange.rejected = nil
+	exchange.mu.Unlock()
+
+	// 買いの親注文に、利確の指値と損切りの逆指値を OCO で組んだ IFD
+	takeProfit := order.NewOrder("tp-local", "7203", order.ACTION_SELL, 2050, 100,
+		order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT), order.WithRequest(ocoRequest), order.WithReason("TakeProfit"))
+	takeProfit.OCO = order.NewOrder("sl-local", "7203", order.ACTION_SELL, 0, 100, order.WithType(order.ORDER_TYPE_STOP), order.WithTriggerPrice(1980),
+		order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT), order.WithRequest(ocoRequest), order.WithReason("StopLoss"))
+	gateway.ifdTracker["parent-1"] = takeProfit
+
+	parent := order.NewOrder("parent-1", "7203", order.ACTION_BUY, 2000, 100)
+	parent.AddExecution(order.Execution{ID: "exec-1", Price: 2000, Qty: 100})
+	parent.BypassTransition(order.ORDER_STATUS_FILLED, order.STATE_CLOSED)
+
+	// 1. 親の約定で、取引所には利確だけを出し、損切りは保持する
+	gateway.checkAndFireIFD(context.Background(), ocoReport(parent))
+	tp := receiveSent(t, exchange)
+	if tp.Reason != "TakeProfit" || tp.OCOGroupID == "" {
+		t.Fatalf("expected only the take profit to be sent, got %+v", tp)
+	}
+	group := waitOCOGroup(t, gateway, tp.OCOGroupID, func(g ocoGroup) bool { return g.restingID == tp.ID })
+	if sl := group.held; sl.Type != order.ORDER_TYPE_STOP || sl.TriggerPrice != 1980 || sl.Status() != order.ORDER_STATUS_WAITING ||
+		sl.Request.ClosePositions[0].HoldID != "exec-1" {
+		t.Errorf("unexpected held stop: %+v", sl)
+	}
+
+	// 保持している損切りは注文一覧に載る
+	ords, err := gateway.GetOrders(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ords.Orders) != 1 || ords.Orders[0].ID != group.held.ID || ords.Orders[0].OCOGroupID != tp.OCOGroupID || ords.Orders[0].Reason != "StopLoss" {
+		t.Fatalf("expected the held stop to be reported, got %+v", ords.Orders)
+	}
+
+	// 2. 利確が40株約定しても、取消や出し直しはせず損切りの残数量が減るだけ
+	tp.AddExecution(order.Execution{ID: "exec-tp-1", Price: 2050, Qty: 40})
+	tp.BypassTransition(order.ORDER_STATUS_IN_PROGRESS, order.STATE_ACTIVE)
+	gateway.checkOCO(ocoReport(parent, tp))
+	if group = waitOCOGroup(t, gateway, tp.OCOGroupID, func(ocoGroup) bool { return true }); group.filled != 40 || group.triggered {
+		t.Fatalf("expected the fill to be recorded without triggering, got %+v", group)
+	}
+
+	// 3. トリガー価格に達するまでは発動しない。達したら利確を取り消す
+	gateway.checkOCOTriggers(tick.Tick{Symbol: "7203", Price: 1990})
+	gateway.checkOCOTriggers(tick.Tick{Symbol: "7203", Price: 1980})
+	if canceled := receiveCanceled(t, exchange); canceled != tp.ID {
+		t.Fatalf("expected the take profit to be cancelled, got %s", canceled)
+	}
+
+	// 4. 取消が完了したら、損切りを残りの60株で成行で発注する
+	tp.BypassTransition(order.ORDER_STATUS_CANCELED, order.STATE_CLOSED)
+	gateway.checkOCO(ocoReport(parent, tp))
+	sl := receiveSent(t, exchange)
+	if sl.Reason != "StopLoss" || sl.Type != order.ORDER_TYPE_MARKET || sl.OrderQty != 60 || sl.OCOGroupID != tp.OCOGroupID {
+		t.Fatalf("expected the stop to be sent at market for 60 shares, got %+v", sl)
+	}
+	if cp := sl.Request.ClosePositions; len(cp) != 1 || cp[0].HoldID != "exec-1" || cp[0].Qty != 60 {
+		t.Errorf("unexpected close positions for the stop: %+v", cp)
+	}
+	if errs := exchange.rejections(); len(errs) != 0 {
+		t.Errorf("expected no close order to be rejected, got %v", errs)
+	}
+
+	// 5. 発注した損切りは逆指値の内容で注文一覧に載り、保持していた注文は取消済みになる
+	group = waitOCOGroup(t, gateway, tp.OCOGroupID, func(g ocoGroup) bool { return g.dissolved && !g.firing })
+	gateway.client = &MockKabuClient{Orders: []api.Order{{ID: sl.ID, Symbol: "7203", Side: api.SIDE_SELL, OrderQty: 60, State: api.STATE_WAITING, CashMargin: 3}}}
+	if ords, err = gateway.GetOrders(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, got := range ords.Orders {
+		switch got.ID {
+		case sl.ID:
+			if got.OCOGroupID != tp.OCOGroupID || got.Type != order.ORDER_TYPE_STOP || got.TriggerPrice != 1980 || got.Reason != "StopLoss" {
+				t.Errorf("expected the fired stop to be restored, got %+v", got)
+			}
+		case group.held.ID:
+			if got.Status() != order.ORDER_STATUS_CANCELED {
+				t.Errorf("expected the held stop to be reported as cancelled, got %v", got.Status())
+			}
+		default:
+			t.Errorf("unexpected order in the report: %+v", got)
+		}
+	}
+}
+
+func TestMarketGateway_OCO_FilledRestingOrderDropsHeldLeg(t *testing.T) {
+	gateway, exchange := newOCOGateway(t, map[string]float64{"exec-1": 100})
+
+	req := *ocoRequest
+	req.ClosePositions = []order.ClosePosition{{HoldID: "exec-1", Qty: 100}}
+	primary := order.NewOrder("order-tp", "7203", order.ACTION_SELL, 2050, 100,
+		order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT), order.WithRequest(&req), order.WithReason("TakeProfit"))
+	primary.OCO = order.NewOrder("sl-local", "7203", order.ACTION_SELL, 0, 100, order.WithType(order.ORDER_TYPE_STOP_LIMIT), order.WithTriggerPrice(1980),
+		order.WithCashMargin(order.CASH_MARGIN_MARGIN_EXIT), order.WithRequest(&req), order.WithReason("StopLoss"))
+	gateway.holdOCOSibling(primary)
+
+	// 利確がすべて約定したら組を解散し、その後トリガー価格に達しても損切りは出さない
+	filled := *primary
+	filled.AddExecution(order.Execution{ID: "exec-tp-1", Price: 2050, Qty: 100})
+	filled.BypassTransition(order.ORDER_STATUS_FILLED, order.STATE_CLOSED)
+	gateway.checkOCO(ocoReport(&filled))
+	gateway.checkOCOTriggers(tick.Tick{Symbol: "7203", Price: 1970})
+	gateway.checkOCO(ocoReport(&filled))
+
+	group := waitOCOGroup(t, gateway, primary.OCOGroupID, func(ocoGroup) bool { return true })
+	if !group.dissolved || group.triggered || group.held.Status() != order.ORDER_STATUS_CANCELED {
+		t.Errorf("expected the group to be dissolved without firing, got %+v", group)
+	}
+	select {
+	case o := <-exchange.sent:
+		t.Errorf("expected no order to be sent, got %+v", o)
+	case id := <-exchange.canceled:
+		t.Errorf("expected no cancel, got %s", id)
+	default:
+	}
+}
+
+func TestMarketGateway_CancelOrder_CancelsOCOGroup(t *testing.T) {
+	gateway, exchange := newOCOGateway(t, nil)
+
+	newPrimary := func(id string) *order.Order {
+		primary := order.NewOrder(id, "7203", order.ACTION_SELL, 2050, 100, order.WithRequest(ocoRequest), order.WithReason("TakeProfit"))
+		primary.OCO = order.NewOrder(order.GenerateLocalID(), "7203", order.ACTION_SELL, 0, 100, order.WithType(order.ORDER_TYPE_STOP), order.WithTriggerPrice(1980),
+			order.WithRequest(ocoRequest), order.WithReason("StopLoss"))
+		gateway.holdOCOSibling(primary)
+		return primary
+	}
+
+	// Bot が保持している損切りを取り消したら、取引所には板の利確の取消だけを送る
+	first := newPrimary("order-tp-1")
+	held := waitOCOGroup(t, gateway, first.OCOGroupID, func(ocoGroup) bool { return true }).held
+	if err := gateway.CancelOrder(context.Background(), held.ID); err != nil {
+		t.Fatalf("expected the held leg to be cancelled locally, got %v", err)
+	}
+	if canceled := receiveCanceled(t, exchange); canceled != first.ID {
+		t.Errorf("expected the resting order to be cancelled, got %s", canceled)
+	}
+	if group := waitOCOGroup(t, gateway, first.OCOGroupID, func(ocoGroup) bool { return true }); !group.dissolved || group.held.Status() != order.ORDER_STATUS_CANCELED {
+		t.Errorf("expected the OCO group to be dissolved, got %+v", group)
+	}
+
+	// Bot が板の利確を取り消したら、保持している損切りは破棄する
+	second := newPrimary("order-tp-2")
+	if err := gateway.CancelOrder(context.Background(), second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if canceled := receiveCanceled(t, exchange); canceled != second.ID {
+		t.Errorf("expected the resting order to be cancelled, got %s", canceled)
+	}
+	gateway.checkOCOTriggers(tick.Tick{Symbol: "7203", Price: 1970})
+	if group := waitOCOGroup(t, gateway, second.OCOGroupID, func(ocoGroup) bool { return true }); !group.dissolved || group.triggered {
+		t.Errorf("expected the held stop to be dropped, got %+v", group)
+	}
+}
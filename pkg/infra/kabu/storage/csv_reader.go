@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+)
+
+// minTickColumns は旧フォーマット（最良気配のみ）の最低列数です
+const minTickColumns = 9
+
+// fullBoardStatusIdx は新フォーマット（板10本）における CurrentPriceStatus 列の位置です
+const fullBoardStatusIdx = 45
+
+// ReadTicks は CSVLogger が出力した CSV を先頭から順に読み込み、1行ごとに fn を呼び出します。
+// 時刻列には日付が含まれないため、ファイル名の日付（YYYYMMDD、無ければ実行当日）と合成します。
+// 書き込み途中で途切れた行など解釈できない行は読み飛ばします。
+func ReadTicks(path string, fn func(tick.Tick)) error {
+	baseDate := DateFromFileName(path, time.Now())
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // 旧フォーマットとの混在や途切れた最終行でも読み進める
+
+	// ヘッダー行
+	if _, err := reader.Read(); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		t, err := ParseTickRecord(record, baseDate)
+		if err != nil {
+			slog.Warn("⚠️ 解釈できないTick行を読み飛ばしました", slog.String("path", path), slog.Any("error", err))
+			continue
+		}
+		fn(t)
+	}
+
+	return nil
+}
+
+// CSVTickReplayer は記録済みの CSV から Tick を時系列順に再生します（起動時のウォームアップ用）
+type CSVTickReplayer struct {
+	path string
+}
+
+// NewCSVTickReplayer は path の CSV を再生する CSVTickReplayer を作成します
+func NewCSVTickReplayer(path string) *CSVTickReplayer {
+	return &CSVTickReplayer{path: path}
+}
+
+// Path は再生対象の CSV のパスを返します
+func (r *CSVTickReplayer) Path() string {
+	return r.path
+}
+
+// Replay は CSV の全 Tick を記録順に fn へ渡します。ファイルが無い場合は os.ErrNotExist を返します
+func (r *CSVTickReplayer) Replay(fn func(tick.Tick)) error {
+	return ReadTicks(r.path, fn)
+}
+
+// DateFromFileName はファイル名に含まれる日付 (YYYYMMDD) を返します。見つからない場合は fallback を返します
+func DateFromFileName(path string, fallback time.Time) time.Time {
+	baseName := filepath.Base(path)
+	for i := 0; i <= len(baseName)-8; i++ {
+		sub := baseName[i : i+8]
+		if _, err := strconv.Atoi(sub); err == nil {
+			if d, err := time.Parse("20060102", sub); err == nil {
+				return d
+			}
+		}
+	}
+	return fallback
+}
+
+// ParseTickRecord は CSVLogger の1行を Tick に変換します。
+// 旧フォーマット（最良気配 + ステータス）と新フォーマット（板10本 + 前値比較・四本値・板外数量）の両方に対応します。
+func ParseTickRecord(record []string, baseDate time.Time) (tick.Tick, error) {
+	if len(record) < minTickColumns {
+		return tick.Tick{}, fmt.Errorf("too few columns: %d", len(record))
+	}
+
+	parsedTime, err := time.Parse("15:04:05.000", record[0])
+	if err != nil {
+		return tick.Tick{}, fmt.Errorf("invalid time %q: %w", record[0], err)
+	}
+	// ファイル名の日付と時刻をマージして完全な time.Time を生成
+	tickTime := time.Date(
+		baseDate.Year(), baseDate.Month(), baseDate.Day(),
+		parsedTime.Hour(), parsedTime.Minute(), parsedTime.Second(), parsedTime.Nanosecond(),
+		time.Local,
+	)
+
+	var sellBoard []tick.Quote
+	var buyBoard []tick.Quote
+	statusIdx := minTickColumns // デフォルト（旧フォーマット）
+
+	// フル板情報がある場合 (新フォーマット)
+	if len(record) > fullBoardStatusIdx {
+		statusIdx = fullBoardStatusIdx
+		for i := 0; i < 9; i++ {
+			base := minTickColumns + (i * 4)
+			askP := parseFloatAt(record, base)
+			askQ := parseFloatAt(record, base+1)
+			bidP := parseFloatAt(record, base+2)
+			bidQ := parseFloatAt(record, base+3)
+
+			if askP > 0 {
+				sellBoard = append(sellBoard, tick.Quote{Price: askP, Qty: askQ})
+			}
+			if bidP > 0 {
+				buyBoard = append(buyBoard, tick.Quote{Price: bidP, Qty: bidQ})
+			}
+		}
+	}
+
+	status := 1
+	if len(record) > statusIdx {
+		if s, err := strconv.Atoi(record[statusIdx]); err == nil {
+			status = s
+		}
+	}
+
+	// 現値前値比較・四本値・板外数量 (新フォーマットのみ、ステータス列の後ろに続く)
+	var changeStatus tick.PriceChangeStatus
+	var extra []string
+	if statusIdx == fullBoardStatusIdx && len(record) > statusIdx+1 {
+		changeStatus = tick.PriceChangeStatus(record[statusIdx+1])
+		extra = record[statusIdx+2:]
+	}
+
+	return tick.Tick{
+		Symbol:        record[1],
+		Price:         parseFloatAt(record, 2),
+		TradingVolume: parseFloatAt(record, 3),
+		VWAP:          parseFloatAt(record, 4),
+		BestAsk: tick.FirstQuote{
+			Price: parseFloatAt(record, 5),
+			Qty:   parseFloatAt(record, 6),
+		},
+		BestBid: tick.FirstQuote{
+			Price: parseFloatAt(record, 7),
+			Qty:   parseFloatAt(record, 8),
+		},
+		SellBoard:                sellBoard,
+		BuyBoard:                 buyBoard,
+		CurrentPriceTime:         tickTime,
+		CurrentPriceStatus:       tick.PriceStatus(status),
+		CurrentPriceChangeStatus: changeStatus,
+		OpeningPrice:             parseFloatAt(extra, 0),
+		TradingValue:             parseFloatAt(extra, 1),
+		MarketOrderSellQty:       parseFloatAt(extra, 2),
+		MarketOrderBuyQty:        parseFloatAt(extra, 3),
+		OverSellQty:              parseFloatAt(extra, 4),
+		UnderBuyQty:              parseFloatAt(extra, 5),
+	}, nil
+}
+
+// parseFloatAt は CSV の列スライスの idx 番目を数値として読み取ります（列が無い・不正な場合は0）
+func parseFloatAt(record []string, idx int) float64 {
+	if idx >= len(record) {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(record[idx], 64)
+	return v
+}
@@ -0,0 +1,87 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/storage"
+)
+
+func TestReadTicks_RoundTripWithCSVLogger(t *testing.T) {
+	tempDir := t.TempDir()
+	date := "20260610"
+
+	written := tick.Tick{
+		Symbol:                   "7203",
+		Price:                    2500.5,
+		TradingVolume:            100000,
+		VWAP:                     2499.8,
+		CurrentPriceTime:         time.Date(2026, 6, 10, 9, 0, 5, 123000000, time.Local),
+		BestAsk:                  tick.FirstQuote{Price: 2501.0, Qty: 500},
+		BestBid:                  tick.FirstQuote{Price: 2500.0, Qty: 800},
+		SellBoard:                []tick.Quote{{Price: 2501.0, Qty: 500}, {Price: 2502.0, Qty: 300}},
+		BuyBoard:                 []tick.Quote{{Price: 2500.0, Qty: 800}, {Price: 2499.0, Qty: 400}},
+		CurrentPriceStatus:       tick.PRICE_STATUS_CURRENT,
+		CurrentPriceChangeStatus: tick.PRICE_CHANGE_UP,
+		OpeningPrice:             2480.0,
+		TradingValue:             250000000.0,
+		MarketOrderSellQty:       1000,
+		MarketOrderBuyQty:        1200,
+		OverSellQty:              5000,
+		UnderBuyQty:              6000,
+	}
+
+	logger, err := storage.NewCSVLogger("all", date, tempDir)
+	if err != nil {
+		t.Fatalf("failed to create CSVLogger: %v", err)
+	}
+	logger.Log(written)
+	time.Sleep(50 * time.Millisecond)
+	logger.Close()
+
+	var got []tick.Tick
+	replayer := storage.NewCSVTickReplayer(filepath.Join(tempDir, "all_"+date+".csv"))
+	if err := replayer.Replay(func(t tick.Tick) { got = append(got, t) }); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 tick, got %d", len(got))
+	}
+	if !reflect.DeepEqual(got[0], written) {
+		t.Errorf("expected replayed tick to equal the logged tick\nwant: %+v\ngot:  %+v", written, got[0])
+	}
+}
+
+func TestReadTicks_SkipsTruncatedRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "all_20260610.csv")
+	content := "Time,Symbol,Price,TradingVolume,VWAP,BestAskPrice,BestAskQty,BestBidPrice,BestBidQty,CurrentPriceStatus\n" +
+		"09:00:00.000,7203,100,1000,100,101,10,99,10,1\n" +
+		"09:00:01.000,7203,10" // 書き込み途中でプロセスが落ちた最終行
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write csv: %v", err)
+	}
+
+	var got []tick.Tick
+	if err := storage.ReadTicks(path, func(t tick.Tick) { got = append(got, t) }); err != nil {
+		t.Fatalf("ReadTicks failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Price != 100 || got[0].CurrentPriceStatus != tick.PriceStatus(1) {
+		t.Errorf("expected only the complete record, got %+v", got)
+	}
+	if want := time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local); !got[0].CurrentPriceTime.Equal(want) {
+		t.Errorf("expected date from file name %v, got %v", want, got[0].CurrentPriceTime)
+	}
+}
+
+func TestCSVTickReplayer_MissingFile(t *testing.T) {
+	replayer := storage.NewCSVTickReplayer(filepath.Join(t.TempDir(), "all_20260610.csv"))
+	err := replayer.Replay(func(tick.Tick) {})
+	if !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
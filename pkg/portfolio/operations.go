@@ -1,8 +1,12 @@
 package portfolio
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"os"
+	"reflect"
+	"time"
 )
 
 // OperationTarget は operations.json の各作戦設定を表す構造体です。
@@ -14,16 +18,120 @@ type OperationTarget struct {
 
 // LoadOperationsFromJSON は指定されたJSONファイルから作戦設定リストを読み込みます。
 func LoadOperationsFromJSON(path string) ([]OperationTarget, error) {
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	return parseOperations(data)
+}
 
+func parseOperations(data []byte) ([]OperationTarget, error) {
 	var targets []OperationTarget
-	if err := json.NewDecoder(file).Decode(&targets); err != nil {
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&targets); err != nil {
 		return nil, err
 	}
-
 	return targets, nil
 }
+
+// OperationDiff は稼働中の作戦設定と新しい作戦設定の差分です。
+// 作戦の同一性（作戦タイプ・銘柄・戦略の構成）が変わった作戦は、古い設定を Removed、新しい設定を Added に含めます。
+// default 作戦の strategy_params・sizing の変更は、建玉を手仕舞わずに稼働中の作戦へ差し替えられるため Updated に含めます。
+type OperationDiff struct {
+	Added   []OperationTarget // 新たに配備する作戦
+	Removed []OperationTarget // 撤収させる作戦
+	Updated []OperationTarget // 同一性を保ったまま設定（strategy_params・sizing）が変わった作戦の新しい設定
+}
+
+// Empty は差分が無いかを返します
+func (d OperationDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Updated) == 0
+}
+
+// DiffOperations は作戦IDをキーに current と next を比較します
+func DiffOperations(current, next []OperationTarget) OperationDiff {
+	var diff OperationDiff
+	nextByID := make(map[string]OperationTarget, len(next))
+	for _, t := range next {
+		nextByID[t.ID] = t
+	}
+	currentByID := make(map[string]OperationTarget, len(current))
+	for _, t := range current {
+		currentByID[t.ID] = t
+		n, ok := nextByID[t.ID]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, t)
+		case reflect.DeepEqual(t, n):
+		case t.Type == n.Type && reflect.DeepEqual(identity(t), identity(n)):
+			diff.Updated = append(diff.Updated, n)
+		default:
+			diff.Removed = append(diff.Removed, t)
+			diff.Added = append(diff.Added, n)
+		}
+	}
+	for _, t := range next {
+		if _, ok := currentByID[t.ID]; !ok {
+			diff.Added = append(diff.Added, t)
+		}
+	}
+	return diff
+}
+
+// inPlaceParams は作戦タイプごとに稼働中に差し替えられる設定のキーです
+var inPlaceParams = map[string]map[string]bool{
+	"default":      {"strategy_params": true, "sizing": true},
+	"pair_trading": {"threshold": true, "qty": true},
+}
+
+// identity は作戦の同一性を表す設定として、差し替えられる設定を除いたもの（銘柄・戦略の構成など）を返します。
+// 差し替えに対応しない作戦タイプは params 全体です。
+func identity(t OperationTarget) map[string]interface{} {
+	keys, ok := inPlaceParams[t.Type]
+	if !ok {
+		return t.Params
+	}
+	out := make(map[string]interface{}, len(t.Params))
+	for k, v := range t.Params {
+		if !keys[k] {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// WatchOperations は operations.json を interval ごとに読み直し、内容が変わっていれば onChange に新しい作戦設定を渡します。
+// 起動時点の内容は基準として扱い、通知しません。書きかけなどで読み込めない内容は onError に渡し、同じ内容では再通知しません。
+// ctx がキャンセルされるまでブロックします。
+func WatchOperations(ctx context.Context, path string, interval time.Duration, onChange func([]OperationTarget), onError func(error)) {
+	last, _ := os.ReadFile(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if last != nil {
+				onError(err)
+				last = nil
+			}
+			continue
+		}
+		if last != nil && bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+
+		targets, err := parseOperations(data)
+		if err != nil {
+			onError(err)
+			continue
+		}
+		onChange(targets)
+	}
+}
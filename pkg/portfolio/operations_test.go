@@ -0,0 +1,121 @@
+package portfolio_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/portfolio"
+)
+
+func TestDiffOperations(t *testing.T) {
+	op := func(id, symbol string, params map[string]interface{}) portfolio.OperationTarget {
+		p := map[string]interface{}{"symbol": symbol, "strategies": []interface{}{"sample"}}
+		if params != nil {
+			p["strategy_params"] = params
+		}
+		return portfolio.OperationTarget{Type: "default", ID: id, Params: p}
+	}
+	current := []portfolio.OperationTarget{
+		op("Keep", "7203", nil),
+		op("Tune", "6758", map[string]interface{}{"sample": map[string]interface{}{"rising_bars": 3.0}}),
+		op("Move", "8306", nil),
+		op("Drop", "9984", nil),
+		op("Size", "4502", nil),
+	}
+	next := []portfolio.OperationTarget{
+		op("Keep", "7203", nil),
+		op("Tune", "6758", map[string]interface{}{"sample": map[string]interface{}{"rising_bars": 5.0}}),
+		op("Move", "8316", nil),
+		op("New", "4063", nil),
+		op("Size", "4502", nil),
+	}
+	next[4].Params["sizing"] = map[string]interface{}{"model": "fixed_notional", "notional": 1e6}
+
+	diff := portfolio.DiffOperations(current, next)
+	ids := func(targets []portfolio.OperationTarget) []string {
+		var out []string
+		for _, t := range targets {
+			out = append(out, t.ID+":"+t.Params["symbol"].(string))
+		}
+		return out
+	}
+	// 戦略パラメータ・サイジングの変更は稼働中の作戦への差し替え
+	if got := ids(diff.Updated); len(got) != 2 || got[0] != "Tune:6758" || got[1] != "Size:4502" {
+		t.Errorf("unexpected updated: %v", got)
+	}
+	// 銘柄が変わった作戦は入れ替え（古い設定の撤収と新しい設定の追加）
+	if got := ids(diff.Removed); len(got) != 2 || got[0] != "Move:8306" || got[1] != "Drop:9984" {
+		t.Errorf("unexpected removed: %v", got)
+	}
+	if got := ids(diff.Added); len(got) != 2 || got[0] != "Move:8316" || got[1] != "New:4063" {
+		t.Errorf("unexpected added: %v", got)
+	}
+	if !portfolio.DiffOperations(next, next).Empty() {
+		t.Errorf("expected no diff for the same config")
+	}
+
+	// ペアトレードの閾値・数量の変更は稼働中の作戦への差し替え、銘柄の変更は入れ替え
+	pair := func(symbolB string, threshold float64) []portfolio.OperationTarget {
+		return []portfolio.OperationTarget{{Type: "pair_trading", ID: "Pair", Params: map[string]interface{}{"symbol_a": "7201", "symbol_b": symbolB, "threshold": threshold, "qty": 100.0}}}
+	}
+	if d := portfolio.DiffOperations(pair("7267", 2), pair("7267", 3)); len(d.Updated) != 1 || len(d.Removed) != 0 || len(d.Added) != 0 {
+		t.Errorf("expected pair threshold changes to update the operation in place, got %+v", d)
+	}
+	if d := portfolio.DiffOperations(pair("7267", 2), pair("7203", 2)); len(d.Removed) != 1 || len(d.Added) != 1 || len(d.Updated) != 0 {
+		t.Errorf("expected pair symbol changes to replace the operation, got %+v", d)
+	}
+}
+
+func TestWatchOperations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "operations.json")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`[{"type": "default", "id": "Op1", "params": {"symbol": "7203"}}]`)
+
+	changes := make(chan []portfolio.OperationTarget, 10)
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		portfolio.WatchOperations(ctx, path, 5*time.Millisecond,
+			func(targets []portfolio.OperationTarget) { changes <- targets },
+			func(err error) { errs <- err },
+		)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// 起動時点の内容は通知しない
+	select {
+	case got := <-changes:
+		t.Fatalf("unexpected notification for the initial content: %v", got)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	// 書きかけの内容はエラーとして通知する
+	write(`[{"type": "default",`)
+	select {
+	case <-errs:
+	case <-time.After(time.Second):
+		t.Fatal("expected a parse error")
+	}
+
+	write(`[{"type": "default", "id": "Op2", "params": {"symbol": "6758"}}]`)
+	select {
+	case got := <-changes:
+		if len(got) != 1 || got[0].ID != "Op2" {
+			t.Errorf("unexpected targets: %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a change notification")
+	}
+}
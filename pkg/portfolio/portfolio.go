@@ -12,3 +12,16 @@ type SymbolTarget struct {
 	Sector   string               `json:"sector"`
 	Enabled  bool                 `json:"enabled"`
 }
+
+// SectorMembers は有効な銘柄をセクターごとにまとめて返します（セクター未設定の銘柄は含みません）。
+// 複数銘柄にまたがるセクター指数の構成銘柄として利用されます。
+func SectorMembers(targets []SymbolTarget) map[string][]string {
+	members := make(map[string][]string)
+	for _, t := range targets {
+		if !t.Enabled || t.Sector == "" {
+			continue
+		}
+		members[t.Sector] = append(members[t.Sector], t.Symbol)
+	}
+	return members
+}
@@ -139,3 +139,23 @@ func TestLoadOperationsFromJSON_InvalidJSON(t *testing.T) {
 		t.Fatal("expected LoadOperationsFromJSON to fail with invalid JSON")
 	}
 }
+
+func TestSectorMembers(t *testing.T) {
+	members := portfolio.SectorMembers([]portfolio.SymbolTarget{
+		{Symbol: "8306", Sector: "銀行業", Enabled: true},
+		{Symbol: "8316", Sector: "銀行業", Enabled: true},
+		{Symbol: "8411", Sector: "銀行業", Enabled: false},
+		{Symbol: "7203", Sector: "輸送用機器", Enabled: true},
+		{Symbol: "9999", Enabled: true},
+	})
+
+	if len(members) != 2 {
+		t.Fatalf("expected 2 sectors, got %v", members)
+	}
+	if got := members["銀行業"]; len(got) != 2 || got[0] != "8306" || got[1] != "8316" {
+		t.Errorf("unexpected bank members: %v", got)
+	}
+	if got := members["輸送用機器"]; len(got) != 1 || got[0] != "7203" {
+		t.Errorf("unexpected auto members: %v", got)
+	}
+}
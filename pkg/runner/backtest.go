@@ -2,23 +2,27 @@ package runner
 
 import (
 	"context"
-	"encoding/csv"
 	"flag"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"strconv"
 	"time"
 
+	"github.com/r-umemoto/trading-bot/pkg/config"
+	"github.com/r-umemoto/trading-bot/pkg/domain/market"
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
 	"github.com/r-umemoto/trading-bot/pkg/domain/service"
+	"github.com/r-umemoto/trading-bot/pkg/domain/session"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/sizing"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
 	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick/composite"
 	"github.com/r-umemoto/trading-bot/pkg/infra/backtest"
+	"github.com/r-umemoto/trading-bot/pkg/infra/daily"
+	"github.com/r-umemoto/trading-bot/pkg/infra/kabu/storage"
 	"github.com/r-umemoto/trading-bot/pkg/portfolio"
 	"github.com/r-umemoto/trading-bot/pkg/usecase"
 )
@@ -35,6 +39,12 @@ func RunBacktest() error {
 	flag.StringVar(&execModelStr, "execution-model", "pessimistic", "約定モデル (touch, pessimistic, volume)")
 	var latencyMs int
 	flag.IntVar(&latencyMs, "latency", 0, "発注・キャンセル遅延時間 (ミリ秒)")
+	var dailyDir string
+	flag.StringVar(&dailyDir, "daily", daily.DefaultDir, "日足ストアのディレクトリ")
+	var symbolMasterPath string
+	flag.StringVar(&symbolMasterPath, "symbols", backtest.DefaultSymbolMasterPath, "銘柄マスタCSVのパス（売買単位・信用区分の可否・値幅制限）")
+	var calendarPath string
+	flag.StringVar(&calendarPath, "calendar", session.DefaultCalendarPath, "JPX 休場日カレンダーのパス")
 	flag.Parse()
 
 	// csvPath がディレクトリの場合は、その中の tick データ (all_*.csv または all.csv) を探索して解決します
@@ -58,6 +68,13 @@ func RunBacktest() error {
 
 	fmt.Printf("戦略のバックテストを開始します... (データ: %s, 約定モデル: %s, 遅延: %v)\n", csvPath, execModel, latency)
 
+	// 立会カレンダーは本番と同じものを使い、作戦の時間帯判定や時間足の区切りを揃える
+	if market, err := session.LoadMarket(calendarPath); err != nil {
+		slog.Warn("⚠️ 休場日カレンダーを読み込めません。土日のみを休場として扱います", slog.String("path", calendarPath), slog.Any("error", err))
+	} else {
+		session.SetDefault(market)
+	}
+
 	// 2. ポートフォリオおよび作戦のセットアップ
 	targets, err := portfolio.LoadFromJSON(portfolioPath)
 	if err != nil {
@@ -75,6 +92,13 @@ func RunBacktest() error {
 	if err := gateway.LoadPreviousCloses(csvPath); err != nil {
 		slog.Error("前日終値CSVのロードに失敗しました (デフォルト値を使用します)", slog.Any("error", err))
 	}
+	// 日足はバックテスト対象日より前のものだけを参照する（本番で当日に起動した場合と同じ値になる）
+	gateway.UseDailyStore(daily.NewStore(dailyDir), storage.DateFromFileName(csvPath, time.Now()))
+	if master, err := backtest.LoadSymbolMaster(symbolMasterPath); err != nil {
+		slog.Warn("⚠️ 銘柄マスタを読み込めません。売買単位・信用区分の可否は既定値で扱います", slog.String("path", symbolMasterPath), slog.Any("error", err))
+	} else {
+		gateway.UseSymbolMaster(master)
+	}
 	dataPool := gateway.DataPool()
 	if _, err := gateway.Listen(context.Background()); err != nil {
 		return fmt.Errorf("バックテスト用ゲートウェイのListen開始に失敗: %w", err)
@@ -93,12 +117,18 @@ func RunBacktest() error {
 	// 3. 監視リスト (watchList) の自動構築
 	var watchList []symbol.WatchTarget
 
-	for _, op := range opTargets {
+	for i, op := range opTargets {
 		switch op.Type {
 		case "default":
 			symbolCode, _ := op.Params["symbol"].(string)
-			strategiesRaw, _ := op.Params["strategies"].([]interface{})
-			strategyParams, _ := op.Params["strategy_params"].(map[string]interface{})
+			// 戦略パラメータは銘柄の有無に関わらず起動時に検証し、設定ミスを設定ファイル上の位置付きで報告する
+			specs, err := strategy.ResolveStrategies(op.Params, fmt.Sprintf("operations[%d].params", i))
+			if err != nil {
+				return fmt.Errorf("作戦 '%s' の戦略パラメータが不正です: %w", op.ID, err)
+			}
+			if _, err := sizing.Resolve(op.Params, fmt.Sprintf("operations[%d].params", i)); err != nil {
+				return fmt.Errorf("作戦 '%s' のサイジングの設定が不正です: %w", op.ID, err)
+			}
 
 			asset, ok := enabledAssets[symbolCode]
 			if !ok {
@@ -111,22 +141,19 @@ func RunBacktest() error {
 				return err
 			}
 
-			for _, stratRaw := range strategiesRaw {
-				stratName, _ := stratRaw.(string)
-				var params interface{}
-				if strategyParams != nil {
-					params = strategyParams[stratName]
-				}
-
+			for _, spec := range specs {
 				watchList = append(watchList, symbol.WatchTarget{
 					Detail:       detail,
-					StrategyName: stratName,
+					StrategyName: spec.Name,
 					Exchange:     asset.Exchange,
-					Params:       params,
+					Params:       spec.Params,
 				})
 			}
 
 		case "pair_trading":
+			if err := sizing.Forbid(op.Type, op.Params, fmt.Sprintf("operations[%d].params", i)); err != nil {
+				return fmt.Errorf("作戦 '%s' のサイジングの設定が不正です: %w", op.ID, err)
+			}
 			symbolA, _ := op.Params["symbol_a"].(string)
 			symbolB, _ := op.Params["symbol_b"].(string)
 
@@ -161,13 +188,24 @@ func RunBacktest() error {
 		}
 	}
 
+	// 監視銘柄の登録（本番の SystemUseCase.Initialize と同じくゲートウェイへ通知する）
+	var registerReqs []market.ResisterSymbolRequest
+	for _, sym := range watchList {
+		registerReqs = append(registerReqs, market.ResisterSymbolRequest{Symbol: sym.Detail.Code, Exchange: sym.Exchange})
+	}
+	if err := gateway.RegisterSymbols(context.Background(), registerReqs); err != nil {
+		return fmt.Errorf("バックテスト用ゲートウェイへの銘柄登録に失敗: %w", err)
+	}
+
 	// バックテストログディレクトリの準備
 	logDir := filepath.Join("backtest_logs", time.Now().Format("20060102_150405"))
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return fmt.Errorf("バックテストログディレクトリの作成に失敗: %w", err)
 	}
 
-	// 4. スナイパーの配備
+	// 4. スナイパーの配備（セクター指数は戦略から参照できるよう先に登録しておく）
+	composite.RegisterSectorIndices(dataPool, portfolio.SectorMembers(targets))
+
 	var snipers []*sniper.Sniper
 	snipersBySymbol := make(map[string][]*sniper.Sniper)
 	pairSnipersBySymbol := make(map[string]*sniper.Sniper)
@@ -190,7 +228,7 @@ func RunBacktest() error {
 			slog.Error("バックテストログファイルの作成に失敗", slog.String("path", logPath), slog.Any("error", err))
 		}
 
-		sniperID := fmt.Sprintf("%s_%s", sym.StrategyName, sym.Detail.Code)
+		sniperID := sniper.SniperID(sym.StrategyName, sym.Detail.Code)
 		s := sniper.NewSniper(sniperID, sym.Detail, st, policy, sym.Exchange, analysisLogger)
 		snipers = append(snipers, s)
 		if s.Strategy.Name() == "InstructionStrategy" {
@@ -211,6 +249,10 @@ func RunBacktest() error {
 			symSnipers, ok := snipersBySymbol[symbolCode]
 			if ok && len(symSnipers) > 0 {
 				nest := sniper.NewSniperNest(symbolCode, symSnipers[0].Detail, symSnipers, symSnipers[0].Logger)
+				// sizing の設定は監視リストの構築時に検証済み
+				if p, _ := sizing.Resolve(op.Params, ""); p != nil {
+					nest.UseSizing(*p, dataPool)
+				}
 				operations = append(operations, sniper.NewDefaultOperation(op.ID, nest))
 				delete(snipersBySymbol, symbolCode)
 			}
@@ -260,9 +302,19 @@ func RunBacktest() error {
 	// 5. Feederの準備
 	csvTickChan := make(chan tick.Tick, 1000)
 
+	// Tick の品質検証ステージ（ルールは本番と同じ環境変数から読み込む）
+	validationCfg, err := config.LoadTickValidation()
+	if err != nil {
+		return fmt.Errorf("Tick検証ルールの設定エラー: %w", err)
+	}
+	validator, err := tick.NewValidator(validationCfg.Rules())
+	if err != nil {
+		return fmt.Errorf("Tick検証ルールの設定エラー: %w", err)
+	}
+
 	// Feederを別ゴルーチンで起動し、CSVの読み込みを開始
 	go func() {
-		if err := runCustomCSVFeeder(csvPath, csvTickChan); err != nil {
+		if err := runCustomCSVFeeder(csvPath, csvTickChan, validator); err != nil {
 			fmt.Printf("Feeder実行エラー: %v\n", err)
 		}
 	}()
@@ -359,6 +411,7 @@ func RunBacktest() error {
 	time.Sleep(100 * time.Millisecond)
 
 	fmt.Printf("バックテスト完了: 総処理Tick数 %d件\n", tickCount)
+	validator.LogSummary()
 
 	// 結果の出力
 	positions, err := gateway.GetPositions(context.Background(), order.PRODICT_CASH)
@@ -412,112 +465,18 @@ func (p *backtestPerformanceProvider) GetUnrealizedPnL(sniperID string, currentP
 	return 0
 }
 
-func runCustomCSVFeeder(csvPath string, tickChan chan<- tick.Tick) error {
-	// 🌟 CSVファイル名から日付 (YYYYMMDD) を抽出。デフォルトは実行当日の日付
-	baseDate := time.Now()
-	baseName := filepath.Base(csvPath)
-	for i := 0; i <= len(baseName)-8; i++ {
-		sub := baseName[i : i+8]
-		if _, err := strconv.Atoi(sub); err == nil {
-			if d, err := time.Parse("20060102", sub); err == nil {
-				baseDate = d
-				break
-			}
+func runCustomCSVFeeder(csvPath string, tickChan chan<- tick.Tick, validator *tick.Validator) error {
+	// 🌟 ライブ記録と同じ CSV リーダーで読み込む（日付はファイル名の YYYYMMDD から補完される）
+	err := storage.ReadTicks(csvPath, func(t tick.Tick) {
+		// 本番の MarketGateway と同じ検証ステージを通し、隔離された Tick は流さない
+		if t, ok := validator.Validate(t); ok {
+			tickChan <- t
 		}
-	}
-
-	file, err := os.Open(csvPath)
+	})
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-
-	if _, err := reader.Read(); err != nil {
-		return err
-	}
-
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		parsedTime, _ := time.Parse("15:04:05.000", record[0])
-		// 🌟 抽出した日付と時刻をマージして完全な time.Time を生成
-		tickTime := time.Date(
-			baseDate.Year(), baseDate.Month(), baseDate.Day(),
-			parsedTime.Hour(), parsedTime.Minute(), parsedTime.Second(), parsedTime.Nanosecond(),
-			time.Local,
-		)
-		price, _ := strconv.ParseFloat(record[2], 64)
-		volume, _ := strconv.ParseFloat(record[3], 64)
-		vwap, _ := strconv.ParseFloat(record[4], 64)
-
-		// 板情報のパース (最良気配)
-		askPrice, _ := strconv.ParseFloat(record[5], 64)
-		askQty, _ := strconv.ParseFloat(record[6], 64)
-		bidPrice, _ := strconv.ParseFloat(record[7], 64)
-		bidQty, _ := strconv.ParseFloat(record[8], 64)
-
-		var sellBoard []tick.Quote
-		var buyBoard []tick.Quote
-		statusIdx := 9 // デフォルト（旧フォーマット）
-
-		// フル板情報がある場合 (新フォーマット)
-		if len(record) >= 46 {
-			statusIdx = 45
-			for i := 0; i < 9; i++ {
-				base := 9 + (i * 4)
-				askP, _ := strconv.ParseFloat(record[base], 64)
-				askQ, _ := strconv.ParseFloat(record[base+1], 64)
-				bidP, _ := strconv.ParseFloat(record[base+2], 64)
-				bidQ, _ := strconv.ParseFloat(record[base+3], 64)
-
-				if askP > 0 {
-					sellBoard = append(sellBoard, tick.Quote{Price: askP, Qty: askQ})
-				}
-				if bidP > 0 {
-					buyBoard = append(buyBoard, tick.Quote{Price: bidP, Qty: bidQ})
-				}
-			}
-		}
-
-		status := 1
-		if len(record) > statusIdx {
-			if s, err := strconv.Atoi(record[statusIdx]); err == nil {
-				status = s
-			}
-		}
-
-		tick := tick.Tick{
-			Symbol:        record[1],
-			Price:         price,
-			TradingVolume: volume,
-			VWAP:          vwap,
-			BestAsk: tick.FirstQuote{
-				Price: askPrice,
-				Qty:   askQty,
-			},
-			BestBid: tick.FirstQuote{
-				Price: bidPrice,
-				Qty:   bidQty,
-			},
-			SellBoard:          sellBoard,
-			BuyBoard:           buyBoard,
-			CurrentPriceTime:   tickTime,
-			CurrentPriceStatus: tick.PriceStatus(status),
-		}
-
-		tickChan <- tick
-	}
 
 	close(tickChan)
 	return nil
 }
-
-
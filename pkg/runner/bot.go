@@ -36,11 +36,7 @@ func RunBot() error {
 		return fmt.Errorf("ポートフォリオの読み込みに失敗しました: %w", err)
 	}
 
-	operationsPath := "configs/operations.json"
-	if p := os.Getenv("OPERATIONS_PATH"); p != "" {
-		operationsPath = p
-	}
-	opTargets, err := portfolio.LoadOperationsFromJSON(operationsPath)
+	opTargets, err := portfolio.LoadOperationsFromJSON(cfg.OperationsPath)
 	if err != nil {
 		// operations.json が存在しない場合は空としてフォールバック（下位互換性）
 		opTargets = nil
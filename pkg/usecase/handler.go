@@ -32,6 +32,9 @@ func (h *UseCaseHandler) Start(ctx context.Context) error {
 
 	// 3. 取引処理の起動
 	h.trade.Start(ctx, chs)
+
+	// 4. 指標スナップショットの定期保存の開始
+	h.system.StartIndicatorSnapshot(ctx)
 	return nil
 }
 
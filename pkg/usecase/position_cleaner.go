@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/market"
@@ -17,6 +18,7 @@ type CleanableTarget interface {
 
 // PositionCleaner はシステムの起動・終了時に、不要な建玉を強制決済してお掃除するアプリケーションサービスです。
 type PositionCleaner struct {
+	mu            sync.Mutex
 	targets       []CleanableTarget
 	marketGateway market.MarketGateway
 }
@@ -28,6 +30,13 @@ func NewPositionCleaner(targets []CleanableTarget, marketGateway market.MarketGa
 	}
 }
 
+// AddTarget は稼働中に追加された作戦を終了時の撤収対象に加えます
+func (c *PositionCleaner) AddTarget(target CleanableTarget) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.targets = append(c.targets, target)
+}
+
 // CleanupOnStartup は起動時に残存している「注文」と「建玉」をすべてクリーンアップします
 func (c *PositionCleaner) CleanupOnStartup(ctx context.Context) error {
 	fmt.Println("🧹 起動時のシステム状態チェックを開始します...")
@@ -110,7 +119,11 @@ func (c *PositionCleaner) CleanAllPositions(ctx context.Context) error {
 
 	fmt.Println("\n🚨 全スナイパーに緊急撤退命令を出します...")
 
-	for _, s := range c.targets {
+	c.mu.Lock()
+	targets := append([]CleanableTarget(nil), c.targets...)
+	c.mu.Unlock()
+
+	for _, s := range targets {
 		s.ForceExit()
 		activeOrders := s.GetActiveOrders()
 		symbolCode := s.GetSymbolCode()
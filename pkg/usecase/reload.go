@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/sizing"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/portfolio"
+)
+
+// OperationBuilder は作戦設定から稼働用の Operation を組み立てます（スナイパーの配備を含む）
+type OperationBuilder func(ctx context.Context, targets []portfolio.OperationTarget) ([]sniper.Operation, error)
+
+// ReloadUseCase は作戦設定（operations.json）の変更を、再起動せずに稼働中の作戦へ反映するユースケースです。
+// 変更の無い作戦の建玉・注文には一切触れません。
+type ReloadUseCase struct {
+	trade   *TradeUseCase
+	system  *SystemUseCase
+	build   OperationBuilder
+	mu      sync.Mutex
+	current []portfolio.OperationTarget
+}
+
+func NewReloadUseCase(trade *TradeUseCase, system *SystemUseCase, build OperationBuilder, current []portfolio.OperationTarget) *ReloadUseCase {
+	return &ReloadUseCase{
+		trade:   trade,
+		system:  system,
+		build:   build,
+		current: current,
+	}
+}
+
+// reconfiguration は稼働中のスナイパー1体へのパラメータ差し替えです
+type reconfiguration struct {
+	op       sniper.Operation
+	sniperID string
+	params   interface{}
+	policy   strategy.ExecutionPolicy // 新しいパラメータから作り直した執行ポリシー
+}
+
+// sizingUpdate は稼働中の作戦へのサイジングの差し替えです
+type sizingUpdate struct {
+	op     sniper.Operation
+	params *sizing.Params // nil ならサイジングを外す
+}
+
+// Apply は新しい作戦設定を稼働中の作戦と突き合わせて反映します。
+//   - 戦略パラメータが変わった作戦: strategy.Reconfigurable を実装した戦略にパラメータを差し替え、執行ポリシーを作り直す
+//   - サイジングが変わった作戦: スナイパーのサイジングを差し替える（建てている途中の数量は維持する）
+//   - ペアトレードの閾値・数量が変わった作戦: 建玉を維持したまま差し替える
+//   - 新しい作戦: スナイパーを配備して稼働させる
+//   - 無くなった作戦、銘柄・戦略の構成が変わった作戦: Sniper.OrderlyExit で建玉を手仕舞わせて撤収させる（入れ替え先は撤収する作戦の建玉がなくなってから稼働する）
+//
+// 検証や作戦の組み立てに失敗した場合は何も反映せずにエラーを返し、稼働中の設定を維持します。
+// 戦略がパラメータの差し替えを受け付けなかった作戦は、その作戦の戦略パラメータを稼働中のものとして記録し、次回の再読み込みで再度差し替えを試みます。
+func (r *ReloadUseCase) Apply(ctx context.Context, next []portfolio.OperationTarget) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 1. 新しい設定全体を検証する（エラーの位置は新しいファイル上の位置で報告する）
+	seen := make(map[string]bool, len(next))
+	for i, t := range next {
+		if seen[t.ID] {
+			return fmt.Errorf("operations[%d].id: 作戦ID '%s' が重複しています", i, t.ID)
+		}
+		seen[t.ID] = true
+		if t.Type == "default" {
+			if _, err := strategy.ResolveStrategies(t.Params, fmt.Sprintf("operations[%d].params", i)); err != nil {
+				return fmt.Errorf("作戦 '%s' の戦略パラメータが不正です: %w", t.ID, err)
+			}
+			if _, err := sizing.Resolve(t.Params, fmt.Sprintf("operations[%d].params", i)); err != nil {
+				return fmt.Errorf("作戦 '%s' のサイジングの設定が不正です: %w", t.ID, err)
+			}
+		} else if err := sizing.Forbid(t.Type, t.Params, fmt.Sprintf("operations[%d].params", i)); err != nil {
+			return fmt.Errorf("作戦 '%s' のサイジングの設定が不正です: %w", t.ID, err)
+		}
+	}
+
+	diff := portfolio.DiffOperations(r.current, next)
+	if diff.Empty() {
+		r.current = next
+		return nil
+	}
+
+	// 2. 差し替えの対象を確定する（起動時に配備されなかった作戦は新規として扱う）
+	previous := make(map[string]portfolio.OperationTarget, len(r.current))
+	for _, t := range r.current {
+		previous[t.ID] = t
+	}
+	var reconfigs []reconfiguration
+	var sizings []sizingUpdate
+	added := diff.Added
+	for _, t := range diff.Updated {
+		op := r.trade.Operation(t.ID)
+		if op == nil {
+			added = append(added, t)
+			continue
+		}
+		prev := previous[t.ID]
+		if t.Type == "pair_trading" {
+			threshold, _ := t.Params["threshold"].(float64)
+			qty, _ := t.Params["qty"].(float64)
+			reconfigs = append(reconfigs, reconfiguration{op: op, params: sniper.PairParams{Threshold: threshold, Qty: qty}})
+			continue
+		}
+		prevSpecs, _ := strategy.ResolveStrategies(prev.Params, "")
+		prevParams := make(map[string]interface{}, len(prevSpecs))
+		for _, spec := range prevSpecs {
+			prevParams[spec.Name] = spec.Params
+		}
+		specs, _ := strategy.ResolveStrategies(t.Params, "")
+		symbolCode, _ := t.Params["symbol"].(string)
+		for _, spec := range specs {
+			if reflect.DeepEqual(prevParams[spec.Name], spec.Params) {
+				continue
+			}
+			var policy strategy.ExecutionPolicy
+			if factory, err := strategy.GetFactory(spec.Name); err == nil {
+				policy = factory.CreateExecutionPolicy(spec.Params)
+			}
+			reconfigs = append(reconfigs, reconfiguration{op: op, sniperID: sniper.SniperID(spec.Name, symbolCode), params: spec.Params, policy: policy})
+		}
+		if !reflect.DeepEqual(prev.Params["sizing"], t.Params["sizing"]) {
+			params, _ := sizing.Resolve(t.Params, "")
+			sizings = append(sizings, sizingUpdate{op: op, params: params})
+		}
+	}
+
+	// 3. 新しい作戦を組み立てる（ここで失敗した場合は稼働中の作戦に何も変更を加えない）
+	var built []sniper.Operation
+	if len(added) > 0 {
+		ops, err := r.build(ctx, added)
+		if err != nil {
+			return fmt.Errorf("追加する作戦の組み立てに失敗しました: %w", err)
+		}
+		built = ops
+	}
+
+	// 4. 反映
+	rejected := make(map[string]bool)
+	for _, rc := range reconfigs {
+		if err := rc.op.Reconfigure(rc.sniperID, rc.params, rc.policy); err != nil {
+			rejected[rc.op.GetID()] = true
+			if errors.Is(err, sniper.ErrNotReconfigurable) {
+				slog.Warn("⚠️ [RELOAD] 戦略が稼働中のパラメータ変更に対応していません。次回起動時に反映されます", slog.String("opID", rc.op.GetID()), slog.String("sniper", rc.sniperID))
+			} else {
+				slog.Error("❌ [RELOAD] 戦略パラメータの差し替えに失敗しました。稼働中のパラメータのまま続行します", slog.String("opID", rc.op.GetID()), slog.String("sniper", rc.sniperID), slog.Any("error", err))
+			}
+			continue
+		}
+		slog.Info("🔧 [RELOAD] 戦略パラメータを差し替えました", slog.String("opID", rc.op.GetID()), slog.String("sniper", rc.sniperID))
+	}
+	for _, su := range sizings {
+		if err := su.op.UpdateSizing(su.params, r.trade.gateway.DataPool()); err != nil {
+			slog.Error("❌ [RELOAD] サイジングの差し替えに失敗しました", slog.String("opID", su.op.GetID()), slog.Any("error", err))
+			continue
+		}
+		slog.Info("📐 [RELOAD] サイジングを差し替えました（建てている途中の数量は維持します）", slog.String("opID", su.op.GetID()), slog.Bool("enabled", su.params != nil))
+	}
+	for _, t := range diff.Removed {
+		if r.trade.RetireOperation(t.ID) {
+			slog.Warn("🏳️ [RELOAD] 作戦を撤収させます（保有建玉は成行で手仕舞います）", slog.String("opID", t.ID))
+		}
+	}
+	for _, op := range built {
+		r.trade.AddOperation(op)
+		r.system.AddOperation(op)
+		slog.Info("🚀 [RELOAD] 作戦を追加しました", slog.String("opID", op.GetID()), slog.Any("symbols", op.GetSymbolCodes()))
+	}
+
+	r.current = withRejectedParams(next, previous, rejected)
+	return nil
+}
+
+// rejectableParams はパラメータの差し替えを受け付けなかった作戦について、稼働中のものに戻す設定のキーです
+var rejectableParams = map[string][]string{
+	"default":      {"strategy_params"},
+	"pair_trading": {"threshold", "qty"},
+}
+
+// withRejectedParams はパラメータの差し替えを受け付けなかった作戦について、戦略パラメータ（ペアトレードは閾値・数量）を稼働中のものに戻した設定を返します。
+// 稼働中の作戦と記録を一致させておくことで、次回の再読み込みでも差分として検出されます。
+func withRejectedParams(next []portfolio.OperationTarget, previous map[string]portfolio.OperationTarget, rejected map[string]bool) []portfolio.OperationTarget {
+	if len(rejected) == 0 {
+		return next
+	}
+	out := make([]portfolio.OperationTarget, len(next))
+	for i, t := range next {
+		out[i] = t
+		if !rejected[t.ID] {
+			continue
+		}
+		params := make(map[string]interface{}, len(t.Params))
+		for k, v := range t.Params {
+			params[k] = v
+		}
+		for _, key := range rejectableParams[t.Type] {
+			if prev, ok := previous[t.ID].Params[key]; ok {
+				params[key] = prev
+			} else {
+				delete(params, key)
+			}
+		}
+		out[i].Params = params
+	}
+	return out
+}
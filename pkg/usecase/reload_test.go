@@ -0,0 +1,276 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/r-umemoto/trading-bot/pkg/domain/market"
+	"github.com/r-umemoto/trading-bot/pkg/domain/order"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper"
+	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
+	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
+	"github.com/r-umemoto/trading-bot/pkg/infra/backtest"
+	"github.com/r-umemoto/trading-bot/pkg/portfolio"
+	"github.com/r-umemoto/trading-bot/pkg/usecase"
+)
+
+// recordingStrategy は評価回数と差し替えられたパラメータを記録する戦略です
+type recordingStrategy struct {
+	mu        sync.Mutex
+	evaluated int
+	params    interface{}
+}
+
+func (s *recordingStrategy) Name() string                 { return "recording" }
+func (s *recordingStrategy) AnalysisLogger() *slog.Logger { return nil }
+func (s *recordingStrategy) Evaluate(input strategy.StrategyInput) strategy.TargetPosition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evaluated++
+	return strategy.TargetPosition{Qty: input.HoldQty()}
+}
+func (s *recordingStrategy) Reconfigure(params interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.params = params
+	return nil
+}
+func (s *recordingStrategy) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.evaluated
+}
+
+func newRecordingOperation(opID, strategyName, code string) (*sniper.DefaultOperation, *sniper.Sniper, *recordingStrategy) {
+	detail := symbol.Symbol{Code: code}
+	st := &recordingStrategy{}
+	s := sniper.NewSniper(sniper.SniperID(strategyName, code), detail, st, &strategy.NoopPolicy{}, order.EXCHANGE_TOSHO, nil)
+	nest := sniper.NewSniperNest(code, detail, []*sniper.Sniper{s}, nil)
+	return sniper.NewDefaultOperation(opID, nest), s, st
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTradeUseCase_FanOutAndAddOperation(t *testing.T) {
+	gateway := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	op1, _, st1 := newRecordingOperation("Op1", "sample", "7203")
+	op2, _, st2 := newRecordingOperation("Op2", "other", "7203")
+	tradeUC := usecase.NewTradeUseCase([]sniper.Operation{op1, op2}, gateway, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tickCh := make(chan tick.Tick, 10)
+	tradeUC.Start(ctx, &market.MarketChannels{Ticks: map[string]<-chan tick.Tick{"7203": tickCh}})
+
+	// 同じ銘柄を扱う作戦すべてに配信される
+	tickCh <- tick.Tick{Symbol: "7203", Price: 2500, CurrentPriceTime: time.Now()}
+	waitFor(t, func() bool { return st1.count() == 1 && st2.count() == 1 })
+
+	// 稼働中に追加した作戦にも以降の Tick が届く
+	op3, _, st3 := newRecordingOperation("Op3", "third", "7203")
+	tradeUC.AddOperation(op3)
+	tickCh <- tick.Tick{Symbol: "7203", Price: 2501, CurrentPriceTime: time.Now()}
+	waitFor(t, func() bool { return st3.count() == 1 && st1.count() == 2 })
+
+	// 撤収させた作戦は ID で引けなくなり、建玉・注文が無ければその場で配信先から外れる
+	if !tradeUC.RetireOperation("Op1") || tradeUC.Operation("Op1") != nil {
+		t.Errorf("expected Op1 to be retired")
+	}
+	if len(tradeUC.Operations()) != 3 {
+		t.Errorf("expected retired operations to remain listed, got %d", len(tradeUC.Operations()))
+	}
+	tickCh <- tick.Tick{Symbol: "7203", Price: 2502, CurrentPriceTime: time.Now()}
+	waitFor(t, func() bool { return st2.count() == 3 && st3.count() == 2 })
+	if st1.count() != 2 {
+		t.Errorf("expected a flat retired operation to stop receiving ticks, got %d evaluations", st1.count())
+	}
+}
+
+// holdingOperation は建玉の有無をテストから切り替えられる作戦です
+type holdingOperation struct {
+	*sniper.DefaultOperation
+	flat atomic.Bool
+}
+
+func (o *holdingOperation) IsFlat() bool { return o.flat.Load() }
+
+func TestTradeUseCase_ReplacementWaitsForRetiredOperation(t *testing.T) {
+	gateway := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	inner, _, stOld := newRecordingOperation("Op1", "sample", "7203")
+	old := &holdingOperation{DefaultOperation: inner}
+	tradeUC := usecase.NewTradeUseCase([]sniper.Operation{old}, gateway, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tickCh := make(chan tick.Tick, 10)
+	tradeUC.Start(ctx, &market.MarketChannels{Ticks: map[string]<-chan tick.Tick{"7203": tickCh}})
+
+	// 建玉が残っている間は撤収中の作戦に配信が続き、同じIDの入れ替え先は稼働を待つ
+	tradeUC.RetireOperation("Op1")
+	replacement, _, stNew := newRecordingOperation("Op1", "sample", "7203")
+	tradeUC.AddOperation(replacement)
+	if tradeUC.Operation("Op1") != replacement {
+		t.Errorf("expected the replacement to be looked up by ID while waiting")
+	}
+	tickCh <- tick.Tick{Symbol: "7203", Price: 2500, CurrentPriceTime: time.Now()}
+	waitFor(t, func() bool { return stOld.count() == 1 })
+	if stNew.count() != 0 {
+		t.Errorf("expected the replacement to wait while the retired operation holds a position")
+	}
+
+	// 手仕舞いが終わると撤収中の作戦が外れ、入れ替え先に配信が始まる
+	old.flat.Store(true)
+	waitFor(t, func() bool {
+		tickCh <- tick.Tick{Symbol: "7203", Price: 2501, CurrentPriceTime: time.Now()}
+		return stNew.count() > 0
+	})
+	evaluated := stOld.count()
+	tickCh <- tick.Tick{Symbol: "7203", Price: 2502, CurrentPriceTime: time.Now()}
+	waitFor(t, func() bool { return stNew.count() > 1 })
+	if stOld.count() != evaluated {
+		t.Errorf("expected the retired operation to stop receiving ticks once flat, got %d evaluations", stOld.count())
+	}
+}
+
+func TestReloadUseCase_Apply(t *testing.T) {
+	gateway := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	opA, sniperA, stA := newRecordingOperation("OpA", "sample", "7203")
+	opB, sniperB, _ := newRecordingOperation("OpB", "sample", "6758")
+	tradeUC := usecase.NewTradeUseCase([]sniper.Operation{opA, opB}, gateway, nil)
+	systemUC := usecase.NewSystemUseCase(nil, []sniper.Operation{opA, opB}, gateway)
+
+	defaultOp := func(id, code string, risingBars float64) portfolio.OperationTarget {
+		return portfolio.OperationTarget{Type: "default", ID: id, Params: map[string]interface{}{
+			"symbol":          code,
+			"strategies":      []interface{}{"sample"},
+			"strategy_params": map[string]interface{}{"sample": map[string]interface{}{"rising_bars": risingBars}},
+		}}
+	}
+	current := []portfolio.OperationTarget{defaultOp("OpA", "7203", 3), defaultOp("OpB", "6758", 3)}
+
+	var builtIDs []string
+	build := func(ctx context.Context, targets []portfolio.OperationTarget) ([]sniper.Operation, error) {
+		var ops []sniper.Operation
+		for _, target := range targets {
+			builtIDs = append(builtIDs, target.ID)
+			op, _, _ := newRecordingOperation(target.ID, "sample", target.Params["symbol"].(string))
+			ops = append(ops, op)
+		}
+		return ops, nil
+	}
+	reload := usecase.NewReloadUseCase(tradeUC, systemUC, build, current)
+	sniperA.ExecutionPolicy = &strategy.TouchTTLPolicy{TTL: time.Second}
+
+	// 1. 不正なパラメータを含む設定は何も反映しない
+	invalid := []portfolio.OperationTarget{defaultOp("OpA", "7203", 1), defaultOp("OpC", "8306", 3)}
+	if err := reload.Apply(context.Background(), invalid); err == nil {
+		t.Fatal("expected an invalid config to be rejected")
+	}
+	if stA.params != nil || len(builtIDs) != 0 || sniperB.GetLifecycle() != sniper.LifecycleActive {
+		t.Fatalf("expected nothing to be applied for an invalid config")
+	}
+
+	// 2. OpA はパラメータの差し替え、OpB は撤収、OpC は追加
+	next := []portfolio.OperationTarget{defaultOp("OpA", "7203", 5), defaultOp("OpC", "8306", 3)}
+	if err := reload.Apply(context.Background(), next); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if p, ok := stA.params.(*strategy.SampleParams); !ok || p.RisingBars != 5 {
+		t.Errorf("expected OpA to be reconfigured, got %#v", stA.params)
+	}
+	if _, ok := sniperA.ExecutionPolicy.(*strategy.NoopPolicy); !ok {
+		t.Errorf("expected the execution policy to be rebuilt from the new params, got %T", sniperA.ExecutionPolicy)
+	}
+	if sniperA.GetLifecycle() != sniper.LifecycleActive {
+		t.Errorf("expected OpA to keep running")
+	}
+	if sniperB.GetLifecycle() != sniper.LifecycleExiting {
+		t.Errorf("expected OpB to be exiting, got %v", sniperB.GetLifecycle())
+	}
+	if len(builtIDs) != 1 || builtIDs[0] != "OpC" || tradeUC.Operation("OpC") == nil {
+		t.Errorf("expected OpC to be added, built %v", builtIDs)
+	}
+
+	// 3. 同じ内容の再適用は何もしない
+	if err := reload.Apply(context.Background(), next); err != nil || len(builtIDs) != 1 {
+		t.Errorf("expected no changes on the same config (built %v, err %v)", builtIDs, err)
+	}
+
+	// 4. サイジングの変更は建玉を手仕舞わずに差し替える
+	sized := []portfolio.OperationTarget{defaultOp("OpA", "7203", 5), next[1]}
+	sized[0].Params["sizing"] = map[string]interface{}{"model": "fixed_notional", "notional": 1e6}
+	if err := reload.Apply(context.Background(), sized); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if sniperA.Sizer == nil || sniperA.GetLifecycle() != sniper.LifecycleActive || tradeUC.Operation("OpA") != opA || len(builtIDs) != 1 {
+		t.Errorf("expected OpA to keep running with sizing (lifecycle %v, built %v)", sniperA.GetLifecycle(), builtIDs)
+	}
+	if err := reload.Apply(context.Background(), next); err != nil || sniperA.Sizer != nil {
+		t.Errorf("expected sizing to be removed in place (err %v)", err)
+	}
+}
+
+// flakyStrategy は最初の fails 回のパラメータ差し替えを拒否する戦略です
+type flakyStrategy struct {
+	recordingStrategy
+	fails int
+}
+
+func (s *flakyStrategy) Reconfigure(params interface{}) error {
+	if s.fails > 0 {
+		s.fails--
+		return errors.New("reconfigure rejected")
+	}
+	return s.recordingStrategy.Reconfigure(params)
+}
+
+func TestReloadUseCase_RetriesRejectedReconfiguration(t *testing.T) {
+	gateway := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	st := &flakyStrategy{fails: 1}
+	s := sniper.NewSniper(sniper.SniperID("sample", "7203"), symbol.Symbol{Code: "7203"}, st, &strategy.NoopPolicy{}, order.EXCHANGE_TOSHO, nil)
+	op := sniper.NewDefaultOperation("OpA", sniper.NewSniperNest("7203", symbol.Symbol{Code: "7203"}, []*sniper.Sniper{s}, nil))
+	tradeUC := usecase.NewTradeUseCase([]sniper.Operation{op}, gateway, nil)
+	systemUC := usecase.NewSystemUseCase(nil, []sniper.Operation{op}, gateway)
+
+	target := func(risingBars float64) []portfolio.OperationTarget {
+		return []portfolio.OperationTarget{{Type: "default", ID: "OpA", Params: map[string]interface{}{
+			"symbol":          "7203",
+			"strategies":      []interface{}{"sample"},
+			"strategy_params": map[string]interface{}{"sample": map[string]interface{}{"rising_bars": risingBars}},
+		}}}
+	}
+	build := func(ctx context.Context, targets []portfolio.OperationTarget) ([]sniper.Operation, error) {
+		t.Fatalf("expected no operation to be rebuilt, got %v", targets)
+		return nil, nil
+	}
+	reload := usecase.NewReloadUseCase(tradeUC, systemUC, build, target(3))
+
+	// 差し替えを拒否された変更は稼働中の設定として記録せず、同じ内容の再読み込みで再度試みる
+	if err := reload.Apply(context.Background(), target(5)); err != nil || st.params != nil {
+		t.Fatalf("expected the first reconfiguration to be rejected (params %#v, err %v)", st.params, err)
+	}
+	if err := reload.Apply(context.Background(), target(5)); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if p, ok := st.params.(*strategy.SampleParams); !ok || p.RisingBars != 5 {
+		t.Errorf("expected the rejected change to be retried, got %#v", st.params)
+	}
+	if s.GetLifecycle() != sniper.LifecycleActive {
+		t.Errorf("expected the operation to keep running, got %v", s.GetLifecycle())
+	}
+}
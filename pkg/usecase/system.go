@@ -2,19 +2,48 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/market"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
 )
 
 // SystemUseCase はシステムの起動時・終了時のライフサイクル処理を行うユースケースです
 type SystemUseCase struct {
 	watchTargets []symbol.WatchTarget
-	operations   []sniper.Operation
+	opsMu        sync.Mutex
+	operations   []sniper.Operation // 終了時に戦略を閉じる作戦（稼働中に追加された作戦を含む）
 	cleaner      *PositionCleaner
 	gateway      market.MarketGateway
+
+	// 途中再起動に備えた指標スナップショットの保存先と保存間隔（空の場合は無効）
+	snapshotPath     string
+	snapshotInterval time.Duration
+
+	// 起動時に当日の記録済みTickを DataPool へ再生するウォームアップ元（nil の場合は無効）
+	warmUp TickReplayer
+
+	// 終了時に当日の日足をヒストリカルデータへ追記する保存先（nil の場合は無効）
+	archiver SessionArchiver
+}
+
+// SessionArchiver は当日の取引記録をヒストリカルデータ（日足）へ追記する保存先です。
+// 同じ日を複数回追記した場合は上書きされる（冪等である）必要があります。
+type SessionArchiver interface {
+	ArchiveSession() error
+}
+
+// TickReplayer は記録済みの Tick を時系列順に再生するデータ源です。
+// 記録が存在しない場合、Replay は os.ErrNotExist を返します。
+type TickReplayer interface {
+	Replay(fn func(tick.Tick)) error
 }
 
 func NewSystemUseCase(watchTargets []symbol.WatchTarget, operations []sniper.Operation, gateway market.MarketGateway) *SystemUseCase {
@@ -30,6 +59,30 @@ func NewSystemUseCase(watchTargets []symbol.WatchTarget, operations []sniper.Ope
 	}
 }
 
+// AddOperation は稼働中に追加された作戦を、終了時のポジションクローズと戦略のクローズの対象に加えます
+func (s *SystemUseCase) AddOperation(op sniper.Operation) {
+	s.cleaner.AddTarget(op)
+	s.opsMu.Lock()
+	s.operations = append(s.operations, op)
+	s.opsMu.Unlock()
+}
+
+// EnableIndicatorSnapshot は DataPool 上の指標状態を path へ定期保存し、起動時に復元するよう設定します
+func (s *SystemUseCase) EnableIndicatorSnapshot(path string, interval time.Duration) {
+	s.snapshotPath = path
+	s.snapshotInterval = interval
+}
+
+// EnableTickWarmUp は起動時に replayer の記録済み Tick を DataPool へ再生し、指標を当日分から再構築するよう設定します
+func (s *SystemUseCase) EnableTickWarmUp(replayer TickReplayer) {
+	s.warmUp = replayer
+}
+
+// EnableSessionArchive は終了時に当日の日足を archiver へ追記するよう設定します
+func (s *SystemUseCase) EnableSessionArchive(archiver SessionArchiver) {
+	s.archiver = archiver
+}
+
 // Initialize はシステム起動時の初期クリーンアップと銘柄登録を行います
 func (s *SystemUseCase) Initialize(ctx context.Context) error {
 	// 1. 起動時のクリーンアップ（残存注文・建玉の強制決済）
@@ -58,9 +111,115 @@ func (s *SystemUseCase) Initialize(ctx context.Context) error {
 		return fmt.Errorf("監視銘柄の登録に失敗: %w", err)
 	}
 
+	// 3. 途中再起動時の指標状態の復元（戦略による指標登録が済んだ後、ライブTickの配信前に行う）
+	// 記録済みTickを再生できた場合はスナップショットを重ねて復元しない（出来高などの二重計上を防ぐ）
+	if !s.warmUpFromRecordedTicks(reqs) {
+		s.restoreIndicatorSnapshot()
+	}
+
 	return nil
 }
 
+// warmUpFromRecordedTicks は当日の記録済みTickを監視銘柄分だけ DataPool へ再生します。
+// 戦略の評価や発注は行わず、指標の更新のみを行います。1件でも再生できた場合は true を返します。
+func (s *SystemUseCase) warmUpFromRecordedTicks(reqs []market.ResisterSymbolRequest) bool {
+	if s.warmUp == nil {
+		return false
+	}
+
+	watched := make(map[string]bool, len(reqs))
+	for _, req := range reqs {
+		watched[req.Symbol] = true
+	}
+
+	pool := s.gateway.DataPool()
+	start := time.Now()
+	replayed := 0
+	err := s.warmUp.Replay(func(t tick.Tick) {
+		if !watched[t.Symbol] {
+			return
+		}
+		pool.PushTick(t)
+		replayed++
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return false // 当日初回の起動
+	}
+	if err != nil {
+		slog.Warn("⚠️ 記録済みTickの再生を途中で中断しました", slog.Int("replayed", replayed), slog.Any("error", err))
+	}
+	if replayed == 0 {
+		return false
+	}
+
+	slog.Info("🔥 記録済みTickで指標をウォームアップしました",
+		slog.Int("replayed", replayed),
+		slog.Duration("elapsed", time.Since(start)),
+	)
+	return true
+}
+
+// snapshotPool は指標スナップショットに対応した DataPool を返します（未設定・非対応の場合は nil）
+func (s *SystemUseCase) snapshotPool() tick.SnapshotPool {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	pool, ok := s.gateway.DataPool().(tick.SnapshotPool)
+	if !ok {
+		return nil
+	}
+	return pool
+}
+
+func (s *SystemUseCase) restoreIndicatorSnapshot() {
+	pool := s.snapshotPool()
+	if pool == nil {
+		return
+	}
+
+	result, err := pool.RestoreSnapshot(s.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return // 当日初回の起動
+	}
+	if err != nil {
+		slog.Warn("⚠️ 指標スナップショットを破棄しました", slog.String("path", s.snapshotPath), slog.Any("error", err))
+		return
+	}
+	slog.Info("♻️ 指標スナップショットを復元しました",
+		slog.String("path", s.snapshotPath),
+		slog.Time("saved_at", result.SavedAt),
+		slog.Int("restored", result.Restored),
+		slog.Int("discarded", result.Discarded),
+	)
+}
+
+// StartIndicatorSnapshot は指標状態の定期保存をバックグラウンドで開始します
+func (s *SystemUseCase) StartIndicatorSnapshot(ctx context.Context) {
+	pool := s.snapshotPool()
+	if pool == nil || s.snapshotInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.snapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.saveIndicatorSnapshot(pool)
+			}
+		}
+	}()
+}
+
+func (s *SystemUseCase) saveIndicatorSnapshot(pool tick.SnapshotPool) {
+	if err := pool.SaveSnapshot(s.snapshotPath); err != nil {
+		slog.Error("❌ 指標スナップショットの保存に失敗", slog.String("path", s.snapshotPath), slog.Any("error", err))
+	}
+}
+
 // Listen は市場ゲートウェイのストリーミングを開始します
 func (s *SystemUseCase) Listen(ctx context.Context) (*market.MarketChannels, error) {
 	return s.gateway.Listen(ctx)
@@ -68,12 +227,27 @@ func (s *SystemUseCase) Listen(ctx context.Context) (*market.MarketChannels, err
 
 // Shutdown はシステム終了時のポジション全決済と銘柄の全解除を行います
 func (s *SystemUseCase) Shutdown(ctx context.Context) error {
+	// 0. 終了直前の指標状態を保存（同日中の再起動に備える）
+	if pool := s.snapshotPool(); pool != nil {
+		s.saveIndicatorSnapshot(pool)
+	}
+
 	// 1. 撤収・強制終了＆全ポジションのクローズ
 	if err := s.cleaner.CleanAllPositions(ctx); err != nil {
 		fmt.Printf("⚠️ ポジションクローズ失敗: %v\n", err)
 	}
 
-	// 2. 監視銘柄の全解除を保証
+	// 戦略が持つ子プロセスなどの資源を解放する（手仕舞いの発注が済んでから行う）
+	s.closeOperations()
+
+	// 2. 当日の日足をヒストリカルデータへ追記（翌日以降の日足系指標に使われる）
+	if s.archiver != nil {
+		if err := s.archiver.ArchiveSession(); err != nil {
+			slog.Error("❌ 当日の日足の追記に失敗", slog.Any("error", err))
+		}
+	}
+
+	// 3. 監視銘柄の全解除を保証
 	fmt.Println("\n🧹 監視銘柄の登録を解除中...")
 	if err := s.gateway.UnregisterSymbolAll(ctx); err != nil {
 		return fmt.Errorf("銘柄登録解除に失敗: %w", err)
@@ -82,5 +256,17 @@ func (s *SystemUseCase) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// closeOperations は全作戦の戦略（strategy.Closer を実装したもの）を閉じます
+func (s *SystemUseCase) closeOperations() {
+	s.opsMu.Lock()
+	ops := append([]sniper.Operation(nil), s.operations...)
+	s.opsMu.Unlock()
+	for _, op := range ops {
+		if err := op.Close(); err != nil {
+			slog.Warn("⚠️ 戦略の終了処理に失敗", slog.String("operation", op.GetID()), slog.Any("error", err))
+		}
+	}
+}
+
 
 
@@ -3,13 +3,17 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/market"
 	"github.com/r-umemoto/trading-bot/pkg/domain/order"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper"
 	"github.com/r-umemoto/trading-bot/pkg/domain/sniper/strategy"
 	"github.com/r-umemoto/trading-bot/pkg/domain/symbol"
+	"github.com/r-umemoto/trading-bot/pkg/domain/tick"
 	"github.com/r-umemoto/trading-bot/pkg/infra/backtest"
 	"github.com/r-umemoto/trading-bot/pkg/usecase"
 )
@@ -120,3 +124,135 @@ func TestSystemUseCase_Initialize_DuplicateFilter(t *testing.T) {
 		t.Fatalf("Initialize failed: %v", err)
 	}
 }
+
+func TestSystemUseCase_IndicatorSnapshot_SaveOnShutdownAndRestoreOnInitialize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "indicators_snapshot.json")
+	detail := symbol.Symbol{Code: "7203"}
+	watchTargets := []symbol.WatchTarget{{Detail: detail, Exchange: order.EXCHANGE_TOSHO, StrategyName: "test_strategy"}}
+
+	newSystem := func(bg *backtest.SyncBacktestGateway) *usecase.SystemUseCase {
+		s := sniper.NewSniper("test_sniper", detail, sniper.NewInstructionStrategy(), &strategy.NoopPolicy{}, order.EXCHANGE_TOSHO, nil)
+		nest := sniper.NewSniperNest("7203", detail, []*sniper.Sniper{s}, nil)
+		su := usecase.NewSystemUseCase(watchTargets, []sniper.Operation{sniper.NewDefaultOperation("Op_7203", nest)}, bg)
+		su.EnableIndicatorSnapshot(path, time.Minute)
+		return su
+	}
+
+	// 1. 再起動前: 指標を育ててからシャットダウン（終了時に保存される）
+	bg1 := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	bars1 := tick.GetOrCreateBarIndicator(bg1.DataPool(), "7203", tick.TimeBar(time.Minute))
+	bars1.Update(tick.Tick{Symbol: "7203", Price: 100, CurrentPriceTime: time.Date(2026, 4, 19, 10, 29, 0, 0, time.Local)})
+	if err := newSystem(bg1).Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	// 2. 再起動後: 戦略が指標を登録した状態で Initialize すると復元される
+	bg2 := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	bars2 := tick.GetOrCreateBarIndicator(bg2.DataPool(), "7203", tick.TimeBar(time.Minute))
+	if err := newSystem(bg2).Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if got := bars2.Bars(); len(got) != 1 || got[0].Close != 100 {
+		t.Errorf("expected the bar to be restored, got %+v", got)
+	}
+}
+
+// fakeReplayer は記録済みTickの再生元のテスト用実装です
+type fakeReplayer struct {
+	ticks []tick.Tick
+	err   error
+}
+
+func (r *fakeReplayer) Replay(fn func(tick.Tick)) error {
+	for _, t := range r.ticks {
+		fn(t)
+	}
+	return r.err
+}
+
+func TestSystemUseCase_TickWarmUp_ReplaysWatchedSymbolsIntoDataPool(t *testing.T) {
+	detail := symbol.Symbol{Code: "7203"}
+	watchTargets := []symbol.WatchTarget{{Detail: detail, Exchange: order.EXCHANGE_TOSHO, StrategyName: "test_strategy"}}
+	base := time.Date(2026, 4, 19, 9, 0, 0, 0, time.Local)
+
+	// 同日のスナップショットも存在するが、Tickを再生できた場合は重ねて復元しない
+	path := filepath.Join(t.TempDir(), "indicators_snapshot.json")
+	stale := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	tick.GetOrCreateBarIndicator(stale.DataPool(), "7203", tick.TimeBar(time.Minute)).
+		Update(tick.Tick{Symbol: "7203", Price: 999, CurrentPriceTime: base.Add(-time.Hour)})
+	if err := stale.DataPool().(tick.SnapshotPool).SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	bg := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	bars := tick.GetOrCreateBarIndicator(bg.DataPool(), "7203", tick.TimeBar(time.Minute))
+	su := usecase.NewSystemUseCase(watchTargets, nil, bg)
+	su.EnableIndicatorSnapshot(path, time.Minute)
+	su.EnableTickWarmUp(&fakeReplayer{ticks: []tick.Tick{
+		{Symbol: "7203", Price: 100, TradingVolume: 1000, CurrentPriceTime: base},
+		{Symbol: "9984", Price: 5000, TradingVolume: 10, CurrentPriceTime: base.Add(time.Second)},
+		{Symbol: "7203", Price: 102, TradingVolume: 1300, CurrentPriceTime: base.Add(time.Minute)},
+	}})
+
+	if err := su.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	got := bars.Bars()
+	if len(got) != 2 || got[0].Close != 100 || got[1].Close != 102 || got[1].Volume != 300 {
+		t.Errorf("expected bars rebuilt from the replayed ticks only, got %+v", got)
+	}
+	if latest := bg.DataPool().GetState("9984").LatestTick; latest.Price != 0 {
+		t.Errorf("expected unwatched symbol not to be replayed, got %+v", latest)
+	}
+}
+
+func TestSystemUseCase_TickWarmUp_FallsBackToSnapshotWithoutRecording(t *testing.T) {
+	detail := symbol.Symbol{Code: "7203"}
+	watchTargets := []symbol.WatchTarget{{Detail: detail, Exchange: order.EXCHANGE_TOSHO, StrategyName: "test_strategy"}}
+	path := filepath.Join(t.TempDir(), "indicators_snapshot.json")
+
+	bg1 := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	tick.GetOrCreateBarIndicator(bg1.DataPool(), "7203", tick.TimeBar(time.Minute)).
+		Update(tick.Tick{Symbol: "7203", Price: 100, CurrentPriceTime: time.Date(2026, 4, 19, 10, 29, 0, 0, time.Local)})
+	if err := bg1.DataPool().(tick.SnapshotPool).SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	bg2 := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	bars := tick.GetOrCreateBarIndicator(bg2.DataPool(), "7203", tick.TimeBar(time.Minute))
+	su := usecase.NewSystemUseCase(watchTargets, nil, bg2)
+	su.EnableIndicatorSnapshot(path, time.Minute)
+	su.EnableTickWarmUp(&fakeReplayer{err: os.ErrNotExist})
+
+	if err := su.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if got := bars.Bars(); len(got) != 1 || got[0].Close != 100 {
+		t.Errorf("expected the snapshot to be restored, got %+v", got)
+	}
+}
+
+type fakeArchiver struct {
+	calls int
+}
+
+func (a *fakeArchiver) ArchiveSession() error {
+	a.calls++
+	return nil
+}
+
+func TestSystemUseCase_Shutdown_ArchivesSession(t *testing.T) {
+	bg := backtest.NewSyncBacktestGateway(backtest.ExecutionModelTouch, 0)
+	su := usecase.NewSystemUseCase(nil, nil, bg)
+	archiver := &fakeArchiver{}
+	su.EnableSessionArchive(archiver)
+
+	if err := su.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if archiver.calls != 1 {
+		t.Errorf("expected the session to be archived once, got %d", archiver.calls)
+	}
+}
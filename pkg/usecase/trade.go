@@ -6,6 +6,7 @@ import (
 	"errors"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/r-umemoto/trading-bot/pkg/domain/market"
@@ -23,6 +24,73 @@ type TradeUseCase struct {
 	lastZombieReconcile map[string]time.Time
 	zombieMu            sync.Mutex
 	reportRepo          report.Repository
+
+	// 稼働中の作戦の入れ替え（設定の再読み込み）に備え、銘柄ごとの配信先を Start 後も変更できるようにする
+	opsMu       sync.RWMutex
+	runCtx      context.Context                      // Start で受け取ったコンテキスト（nil の場合は未起動）
+	subscribers map[string][]*operationInbox         // 銘柄コード → 配信先の作戦
+	attached    map[sniper.Operation]*operationInbox // イベントループを起動した作戦
+	retired     map[sniper.Operation]bool            // 撤収を命じた作戦（同じIDで入れ替えた新しい作戦と区別する）
+	waiting     []sniper.Operation                   // 同じIDの作戦の手仕舞いを待っている入れ替え先の作戦
+}
+
+// operationInbox は作戦ごとのイベントループへの入力です。
+// 配信ループは作戦の処理を待たずに渡し、1つの作戦の遅れが同じ銘柄を扱う他の作戦の配信を止めないようにします。
+type operationInbox struct {
+	op     sniper.Operation
+	ticks  chan tick.Tick // 満杯の場合は最も古い Tick を捨てて最新の Tick を入れる
+	wake   chan struct{}  // 注文レポートの到着通知
+	done   chan struct{}  // 配信先から外した時に閉じる
+	mu     sync.Mutex
+	orders []order.Orders // 未処理の注文レポート（約定を取りこぼさないよう捨てない）
+
+	droppedTicks atomic.Int64
+}
+
+func newOperationInbox(op sniper.Operation) *operationInbox {
+	return &operationInbox{
+		op:    op,
+		ticks: make(chan tick.Tick, 100),
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+// pushTick は Tick を渡します。作戦の処理が追いつかず満杯の場合は、最も古い Tick を捨てて最新の Tick を渡し、true を返します
+func (b *operationInbox) pushTick(t tick.Tick) bool {
+	dropped := false
+	for {
+		select {
+		case b.ticks <- t:
+			return dropped
+		default:
+		}
+		select {
+		case <-b.ticks:
+			dropped = true
+		default:
+		}
+	}
+}
+
+// pushOrders は注文レポートを未処理の列に積み、イベントループに到着を知らせます
+func (b *operationInbox) pushOrders(o order.Orders) {
+	b.mu.Lock()
+	b.orders = append(b.orders, o)
+	b.mu.Unlock()
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// takeOrders は未処理の注文レポートを届いた順に取り出します
+func (b *operationInbox) takeOrders() []order.Orders {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := b.orders
+	b.orders = nil
+	return out
 }
 
 func NewTradeUseCase(operations []sniper.Operation, gateway market.MarketGateway, reportRepo report.Repository) *TradeUseCase {
@@ -31,82 +99,205 @@ func NewTradeUseCase(operations []sniper.Operation, gateway market.MarketGateway
 		gateway:             gateway,
 		lastZombieReconcile: make(map[string]time.Time),
 		reportRepo:          reportRepo,
+		subscribers:         make(map[string][]*operationInbox),
+		attached:            make(map[sniper.Operation]*operationInbox),
+		retired:             make(map[sniper.Operation]bool),
 	}
 }
 
 // Start は市場データ受信を開始し、各作戦ごとのイベントループを起動します
 func (u *TradeUseCase) Start(ctx context.Context, chs *market.MarketChannels) {
-	activeSymbols := make(map[string]bool)
+	u.opsMu.Lock()
+	u.runCtx = ctx
+	for _, op := range u.operations {
+		u.attachLocked(ctx, op)
+	}
+	u.opsMu.Unlock()
+
+	if chs == nil {
+		return
+	}
+	// 銘柄ごとのチャネルは1つの配信ループだけが読み出し、その銘柄を扱う全作戦へ配る。
+	// 作戦に使われていない（観測用のみの）銘柄は捨てることで詰まりを防ぐ。
+	for sym, tickCh := range chs.Ticks {
+		go u.dispatchTicks(ctx, sym, tickCh)
+	}
+	for sym, orderCh := range chs.Orders {
+		go u.dispatchOrders(ctx, sym, orderCh)
+	}
+}
+
+// AddOperation は稼働中に作戦を追加します。Start 前に呼ばれた場合は Start 時に起動します。
+// 同じIDの作戦が撤収中（建玉・注文が残っている）の場合は、同じスナイパーIDで建玉が重ならないよう、手仕舞いが終わってから配信を始めます。
+func (u *TradeUseCase) AddOperation(op sniper.Operation) {
+	u.opsMu.Lock()
+	defer u.opsMu.Unlock()
+	u.operations = append(u.operations, op)
+	if u.runCtx == nil {
+		return
+	}
+	if u.retiringLocked(op.GetID()) {
+		u.waiting = append(u.waiting, op)
+		slog.Info("⏳ 同じIDの作戦の手仕舞いが終わるまで、入れ替え先の作戦の稼働を待ちます", slog.String("opID", op.GetID()))
+		return
+	}
+	u.attachLocked(u.runCtx, op)
+}
+
+// RetireOperation は作戦に撤収を命じます。保有建玉と注文が無くなるまで Tick と注文レポートは引き続き配信され、
+// 無くなった時点で配信先から外します。該当する稼働中の作戦が無い場合は false を返します。
+func (u *TradeUseCase) RetireOperation(id string) bool {
+	op := u.Operation(id)
+	if op == nil {
+		return false
+	}
+	u.opsMu.Lock()
+	u.retired[op] = true
+	for i, w := range u.waiting {
+		if w == op {
+			// 稼働前の入れ替え先はそのまま取りやめる
+			u.waiting = append(u.waiting[:i], u.waiting[i+1:]...)
+			break
+		}
+	}
+	u.opsMu.Unlock()
+	op.OrderlyExit()
+	u.detachIfFlat(op)
+	return true
+}
 
+// Operation は指定したIDの稼働中（撤収中を除く）の作戦を返します（無い場合は nil）
+func (u *TradeUseCase) Operation(id string) sniper.Operation {
+	u.opsMu.RLock()
+	defer u.opsMu.RUnlock()
 	for _, op := range u.operations {
-		symbols := op.GetSymbolCodes()
-		for _, sym := range symbols {
-			activeSymbols[sym] = true
+		if op.GetID() == id && !u.retired[op] {
+			return op
 		}
+	}
+	return nil
+}
 
-		mergedTickCh := make(chan tick.Tick, 100)
-		mergedOrderCh := make(chan order.Orders, 100)
-
-		for _, sym := range symbols {
-			tickCh := chs.Ticks[sym]
-			orderCh := chs.Orders[sym]
-			if tickCh != nil {
-				go func(c <-chan tick.Tick) {
-					for t := range c {
-						select {
-						case <-ctx.Done():
-							return
-						case mergedTickCh <- t:
-						}
-					}
-				}(tickCh)
-			}
-			if orderCh != nil {
-				go func(c <-chan order.Orders) {
-					for o := range c {
-						select {
-						case <-ctx.Done():
-							return
-						case mergedOrderCh <- o:
-						}
-					}
-				}(orderCh)
-			}
+// Operations は撤収中・撤収済みのものも含む全作戦を返します
+func (u *TradeUseCase) Operations() []sniper.Operation {
+	u.opsMu.RLock()
+	defer u.opsMu.RUnlock()
+	return append([]sniper.Operation(nil), u.operations...)
+}
+
+// attachLocked は作戦のイベントループを起動し、作戦が扱う銘柄の配信先に登録します（opsMu を保持して呼ぶこと）
+func (u *TradeUseCase) attachLocked(ctx context.Context, op sniper.Operation) {
+	inbox := newOperationInbox(op)
+	for _, sym := range op.GetSymbolCodes() {
+		u.subscribers[sym] = append(u.subscribers[sym], inbox)
+	}
+	u.attached[op] = inbox
+	go u.runOperationEventLoop(ctx, inbox)
+}
+
+// retiringLocked は指定したIDの作戦が撤収中で、まだ配信先に残っているかを返します（opsMu を保持して呼ぶこと）
+func (u *TradeUseCase) retiringLocked(id string) bool {
+	for op := range u.attached {
+		if u.retired[op] && op.GetID() == id {
+			return true
 		}
+	}
+	return false
+}
 
-		go u.runOperationEventLoop(ctx, op, mergedTickCh, mergedOrderCh)
+// detachIfFlat は撤収中の作戦の建玉と注文が無くなっていれば、配信先から外してイベントループを止め、
+// 手仕舞いを待っていた同じIDの入れ替え先の作戦を稼働させます。外した場合は true を返します。
+func (u *TradeUseCase) detachIfFlat(op sniper.Operation) bool {
+	u.opsMu.RLock()
+	retired := u.retired[op]
+	u.opsMu.RUnlock()
+	if !retired || !op.IsFlat() {
+		return false
 	}
 
-	// 実取引で使用されていない（観測用のみの）銘柄チャネルをドレイン（吸い出し）して詰まりを防ぐ
-	if chs != nil {
-		for sym, tickCh := range chs.Ticks {
-			if !activeSymbols[sym] {
-				go func(c <-chan tick.Tick) {
-					for range c {
-						// 観測用なので捨てる
-					}
-				}(tickCh)
+	u.opsMu.Lock()
+	defer u.opsMu.Unlock()
+	inbox, ok := u.attached[op]
+	if !ok {
+		return false
+	}
+	for _, sym := range op.GetSymbolCodes() {
+		subs := u.subscribers[sym]
+		for i, b := range subs {
+			if b == inbox {
+				u.subscribers[sym] = append(subs[:i:i], subs[i+1:]...)
+				break
 			}
 		}
-		for sym, orderCh := range chs.Orders {
-			if !activeSymbols[sym] {
-				go func(c <-chan order.Orders) {
-					for range c {
-						// 観測用なので捨てる
-					}
-				}(orderCh)
+	}
+	delete(u.attached, op)
+	close(inbox.done)
+	slog.Info("🏁 撤収した作戦の手仕舞いが完了したため、配信を止めます", slog.String("opID", op.GetID()))
+
+	if u.runCtx == nil || u.retiringLocked(op.GetID()) {
+		return true
+	}
+	var rest []sniper.Operation
+	for _, w := range u.waiting {
+		if w.GetID() != op.GetID() {
+			rest = append(rest, w)
+			continue
+		}
+		u.attachLocked(u.runCtx, w)
+		slog.Info("🚀 入れ替え先の作戦の稼働を始めます", slog.String("opID", w.GetID()))
+	}
+	u.waiting = rest
+	return true
+}
+
+func (u *TradeUseCase) inboxes(sym string) []*operationInbox {
+	u.opsMu.RLock()
+	defer u.opsMu.RUnlock()
+	return u.subscribers[sym]
+}
+
+// dispatchTicks は銘柄の Tick をその銘柄を扱う全作戦へ配信します。
+// 処理が追いつかない作戦には古い Tick を捨てて最新の Tick を渡し、他の作戦への配信を止めません。
+func (u *TradeUseCase) dispatchTicks(ctx context.Context, sym string, c <-chan tick.Tick) {
+	for t := range c {
+		if ctx.Err() != nil {
+			return
+		}
+		for _, inbox := range u.inboxes(sym) {
+			if !inbox.pushTick(t) {
+				continue
+			}
+			if n := inbox.droppedTicks.Add(1); n == 1 || n%1000 == 0 {
+				slog.Warn("⚠️ 作戦の処理が遅れているため、古い Tick を捨てて最新の Tick を渡します",
+					slog.String("opID", inbox.op.GetID()), slog.String("symbol", sym), slog.Int64("dropped", n))
 			}
 		}
 	}
 }
 
-// runOperationEventLoop は特定の作戦のイベントループを非同期に監視します
-func (u *TradeUseCase) runOperationEventLoop(ctx context.Context, op sniper.Operation, tickCh <-chan tick.Tick, orderCh <-chan order.Orders) {
+// dispatchOrders は銘柄の注文レポートをその銘柄を扱う全作戦へ配信します（各作戦は自身の注文だけを取り込みます）
+func (u *TradeUseCase) dispatchOrders(ctx context.Context, sym string, c <-chan order.Orders) {
+	for o := range c {
+		if ctx.Err() != nil {
+			return
+		}
+		for _, inbox := range u.inboxes(sym) {
+			inbox.pushOrders(o)
+		}
+	}
+}
+
+// runOperationEventLoop は特定の作戦のイベントループを非同期に監視します。
+// 撤収中の作戦は、建玉と注文が無くなった時点で配信先から外れて終了します。
+func (u *TradeUseCase) runOperationEventLoop(ctx context.Context, inbox *operationInbox) {
+	op := inbox.op
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case t := <-tickCh:
+		case <-inbox.done:
+			return
+		case t := <-inbox.ticks:
 			// ドメイン集約にビジネスロジックの評価を委譲 (純粋関数)
 			actions := op.HandleTick(t)
 			for _, act := range actions {
@@ -114,8 +305,13 @@ func (u *TradeUseCase) runOperationEventLoop(ctx context.Context, op sniper.Oper
 			}
 			// ゾンビ注文（キャンセル応答なしで膠着状態の注文）の自動監視と自己修復
 			u.checkZombieOrders(ctx, op)
-		case ords := <-orderCh:
-			op.UpdateOrders(ords)
+		case <-inbox.wake:
+			for _, ords := range inbox.takeOrders() {
+				op.UpdateOrders(ords)
+			}
+		}
+		if u.detachIfFlat(op) {
+			return
 		}
 	}
 }
@@ -222,9 +418,17 @@ func (u *TradeUseCase) fire(ctx context.Context, op sniper.Operation, sniperID s
 }
 
 func (u *TradeUseCase) PrintPerformanceReport(enableCSV bool) {
+	// 入れ替えた作戦は同じスナイパーIDを引き継ぐため、成績は GetPerformance で合算して1つの対象として集計する
 	var targets []sniper.ReportableTarget
-	for _, op := range u.operations {
-		targets = append(targets, op.GetReportableTargets()...)
+	seen := make(map[string]bool)
+	for _, op := range u.Operations() {
+		for _, target := range op.GetReportableTargets() {
+			if seen[target.GetID()] {
+				continue
+			}
+			seen[target.GetID()] = true
+			targets = append(targets, target)
+		}
 	}
 	reportData := service.GeneratePerformanceReport(u, targets, u.gateway.DataPool())
 	presenter := NewReportPresenter()
@@ -292,20 +496,30 @@ func (u *TradeUseCase) PrintPerformanceReport(enableCSV bool) {
 	}
 }
 
+// GetPerformance はスナイパーの成績を返します。作戦を入れ替えた場合は、撤収した作戦と入れ替え先の作戦の成績を合算します
 func (u *TradeUseCase) GetPerformance(sniperID string) sniper.Performance {
-	for _, op := range u.operations {
-		if op.HasSniper(sniperID) {
-			return op.GetPerformance(sniperID)
+	var total sniper.Performance
+	for _, op := range u.Operations() {
+		if !op.HasSniper(sniperID) {
+			continue
 		}
+		p := op.GetPerformance(sniperID)
+		total.Trades += p.Trades
+		total.Wins += p.Wins
+		total.Losses += p.Losses
+		total.RealizedPnL += p.RealizedPnL
+		total.UnrealizedPnL += p.UnrealizedPnL
 	}
-	return sniper.Performance{}
+	return total
 }
 
+// GetUnrealizedPnL はスナイパーの含み損益を返します。作戦を入れ替えた場合は、撤収中の作戦の建玉も含めます
 func (u *TradeUseCase) GetUnrealizedPnL(sniperID string, currentPrice float64) float64 {
-	for _, op := range u.operations {
+	var total float64
+	for _, op := range u.Operations() {
 		if op.HasSniper(sniperID) {
-			return op.GetUnrealizedPnL(sniperID, currentPrice)
+			total += op.GetUnrealizedPnL(sniperID, currentPrice)
 		}
 	}
-	return 0
+	return total
 }